@@ -16,19 +16,117 @@
 package auklib
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 )
 
-const (
-	// ServiceName defines the name of Aukera Windows service.
-	ServiceName = "Aukera"
+// PortEnv is the environment variable that overrides the configured port.
+const PortEnv = "AUKERA_PORT"
 
-	// ServicePort is the default port the Aukera HTTP service is listening on.
-	ServicePort = 9119
+// SettingsFile holds service settings, such as {"Port": 9119}.
+var SettingsFile = filepath.Join(DataDir, "settings.json")
+
+// Settings are the service settings read from SettingsFile.
+type Settings struct {
+	Port int
+	// DisableActiveHours omits the built-in active hours windows, such as on
+	// servers and kiosks where they are meaningless.
+	DisableActiveHours bool
+	// OSUpdates adds the built-in os_updates window, open while the
+	// operating system installs updates automatically.
+	OSUpdates bool
+	// ConfigPermissions selects how configuration that unprivileged users
+	// could modify is treated: off, warn or enforce.
+	ConfigPermissions string
+}
+
+// LoadSettings reads SettingsFile.
+func LoadSettings() (Settings, error) {
+	var s Settings
+	b, err := os.ReadFile(SettingsFile)
+	if err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal(b, &s); err != nil {
+		return s, fmt.Errorf("LoadSettings: unable to parse %q: %w", SettingsFile, err)
+	}
+	return s, nil
+}
+
+func validPort(p int) bool {
+	return p > 0 && p <= 65535
+}
+
+// settingsPort returns the port set in SettingsFile.
+func settingsPort() (int, error) {
+	s, err := LoadSettings()
+	return s.Port, err
+}
+
+// Platform port storage (the registry on Windows), replaced in tests.
+var (
+	fnStorePort  = storePort
+	fnLoadPort   = loadPort
+	fnPolicyPort = policyPort
 )
 
+// ConfiguredPort returns the port the service is configured to listen on.
+// override, typically a flag, is used if zero or greater. Otherwise the first
+// valid port from PortEnv, SettingsFile, platform policy (the registry on
+// Windows) and Defaults.ServicePort is returned.
+func ConfiguredPort(override int) int {
+	if override >= 0 {
+		return override
+	}
+	if p, err := strconv.Atoi(os.Getenv(PortEnv)); err == nil && validPort(p) {
+		return p
+	}
+	if p, err := settingsPort(); err == nil && validPort(p) {
+		return p
+	}
+	if p, err := fnPolicyPort(); err == nil && validPort(p) {
+		return p
+	}
+	return Defaults.ServicePort
+}
+
+// PortFile is the discovery file the service records its bound port in.
+var PortFile = filepath.Join(DataDir, "port")
+
+// WritePort records the port the service is listening on so that clients
+// can discover it without relying on Defaults.ServicePort.
+func WritePort(port int) error {
+	if err := os.MkdirAll(filepath.Dir(PortFile), 0755); err != nil {
+		return fmt.Errorf("WritePort: unable to create %q: %w", filepath.Dir(PortFile), err)
+	}
+	if err := os.WriteFile(PortFile, []byte(strconv.Itoa(port)), 0644); err != nil {
+		return fmt.Errorf("WritePort: unable to write %q: %w", PortFile, err)
+	}
+	return fnStorePort(port)
+}
+
+// DiscoverPort returns the port recorded by the running service. Platform
+// storage (the registry on Windows) takes precedence over PortFile.
+func DiscoverPort() (int, error) {
+	if port, err := fnLoadPort(); err == nil {
+		return port, nil
+	}
+	b, err := os.ReadFile(PortFile)
+	if err != nil {
+		return 0, fmt.Errorf("DiscoverPort: %w", err)
+	}
+	port, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil {
+		return 0, fmt.Errorf("DiscoverPort: invalid port in %q: %w", PortFile, err)
+	}
+	return port, nil
+}
+
 // PathExists used for determining if path exists already.
 func PathExists(path string) (bool, error) {
 	if path == "" {
@@ -58,3 +156,24 @@ func UniqueStrings(slice []string) []string {
 	}
 	return unique
 }
+
+// activeHoursSpan returns the active hours between the hours start and end
+// that are open at now, or else open next. Hours are wall-clock hours in the
+// location of now, so the span is computed by calendar day rather than by
+// adding fixed durations, and keeps its wall-clock bounds on days when
+// daylight saving time begins or ends. When end is not after start the span
+// ends the following day; after midnight, the span that began the previous
+// evening is returned until it ends.
+func activeHoursSpan(start, end int, now time.Time) (time.Time, time.Time) {
+	y, m, d := now.Date()
+	at := func(day, hour int) time.Time {
+		return time.Date(y, m, day, hour, 0, 0, 0, now.Location())
+	}
+	if end > start {
+		return at(d, start), at(d, end)
+	}
+	if now.Before(at(d, end)) {
+		return at(d-1, start), at(d, end)
+	}
+	return at(d, start), at(d+1, end)
+}
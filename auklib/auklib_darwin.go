@@ -30,16 +30,37 @@ var (
 	ConfDir = "/var/lib/aukera/conf.d"
 	// LogPath defines active log file filesystem location.
 	LogPath = "/var/log/aukera.log"
-
-	// MetricRoot sets metric path for all aukera metrics
-	MetricRoot = `/aukera/metrics`
-	// MetricSvc sets platform source for metrics.
-	MetricSvc = "darwin"
 )
 
+// defaultMetrics discards metrics, since the cabbie backend is only
+// available on Windows.
+var defaultMetrics MetricSink = NopMetrics{}
+
 // ActiveHours retrieves the user/auto-set active hours times.
 // Stubbed out on darwin.
 func ActiveHours() (time.Time, time.Time, error) {
 	var t time.Time
 	return t, t, fmt.Errorf("ActiveHours: unsupported operating system: %s", runtime.GOOS)
 }
+
+// OSUpdateHours retrieves the times of automatic operating system updates.
+// Stubbed out on darwin.
+func OSUpdateHours() (time.Time, time.Time, error) {
+	var t time.Time
+	return t, t, fmt.Errorf("OSUpdateHours: unsupported operating system: %s", runtime.GOOS)
+}
+
+// storePort is a no-op on darwin; PortFile is the only discovery mechanism.
+func storePort(port int) error {
+	return nil
+}
+
+// loadPort is stubbed out on darwin.
+func loadPort() (int, error) {
+	return 0, fmt.Errorf("loadPort: unsupported operating system: %s", runtime.GOOS)
+}
+
+// policyPort is stubbed out on darwin.
+func policyPort() (int, error) {
+	return 0, fmt.Errorf("policyPort: unsupported operating system: %s", runtime.GOOS)
+}
@@ -30,16 +30,30 @@ var (
 	ConfDir = "/etc/aukera"
 	// LogPath defines active log file filesystem location.
 	LogPath = "/var/log/aukera.log"
-
-	// MetricSvc sets platform source for metrics.
-	MetricSvc = "aukera"
-	// MetricRoot sets metric path for all aukera metrics
-	MetricRoot = `/aukera/metrics`
 )
 
-// ActiveHours retrieves the user/auto-set active hours times.
-// Stubbed out on linux.
+// defaultMetrics discards metrics, since the cabbie backend is only
+// available on Windows.
+var defaultMetrics MetricSink = NopMetrics{}
+
+// ActiveHours derives active hours from the presence of a user at the active
+// desktop session, as reported by GNOME or KDE. Returns the start and end
+// times of the active hours window, respectively.
 func ActiveHours() (time.Time, time.Time, error) {
-	var t time.Time
-	return t, t, fmt.Errorf("ActiveHours: unsupported operating system: %s", runtime.GOOS)
+	return presenceHours(time.Now())
+}
+
+// storePort is a no-op on linux; PortFile is the only discovery mechanism.
+func storePort(port int) error {
+	return nil
+}
+
+// loadPort is stubbed out on linux.
+func loadPort() (int, error) {
+	return 0, fmt.Errorf("loadPort: unsupported operating system: %s", runtime.GOOS)
+}
+
+// policyPort is stubbed out on linux.
+func policyPort() (int, error) {
+	return 0, fmt.Errorf("policyPort: unsupported operating system: %s", runtime.GOOS)
 }
@@ -15,9 +15,12 @@
 package auklib
 
 import (
+	"errors"
 	"os"
+	"path/filepath"
 	"runtime"
 	"testing"
+	"time"
 )
 
 type pathTest struct {
@@ -60,3 +63,178 @@ func TestEmptyPath(t *testing.T) {
 		t.Errorf("TestEmptyPath(%q) returned %t", empty.desc, b)
 	}
 }
+
+// fakePortStorage replaces platform port storage, such as the registry,
+// with stored, and platform policy with policy if it is nonzero. It returns
+// a function restoring the originals.
+func fakePortStorage(stored *int, policy int) func() {
+	origStore, origLoad, origPolicy := fnStorePort, fnLoadPort, fnPolicyPort
+	fnStorePort = func(port int) error {
+		*stored = port
+		return nil
+	}
+	fnLoadPort = func() (int, error) {
+		if *stored == 0 {
+			return 0, errors.New("no port stored")
+		}
+		return *stored, nil
+	}
+	fnPolicyPort = func() (int, error) {
+		if policy == 0 {
+			return 0, errors.New("no port policy")
+		}
+		return policy, nil
+	}
+	return func() { fnStorePort, fnLoadPort, fnPolicyPort = origStore, origLoad, origPolicy }
+}
+
+func TestDiscoverPort(t *testing.T) {
+	var stored int
+	defer fakePortStorage(&stored, 0)()
+	orig := PortFile
+	defer func() { PortFile = orig }()
+	PortFile = filepath.Join(t.TempDir(), "data", "port")
+
+	if _, err := DiscoverPort(); err == nil {
+		t.Errorf("DiscoverPort() without port file did not return an error")
+	}
+	if err := WritePort(12345); err != nil {
+		t.Fatalf("WritePort(12345) returned error: %v", err)
+	}
+	if stored != 12345 {
+		t.Errorf("WritePort(12345) stored port %d in platform storage, want 12345", stored)
+	}
+	port, err := DiscoverPort()
+	if err != nil {
+		t.Errorf("DiscoverPort() returned error: %v", err)
+	}
+	if port != 12345 {
+		t.Errorf("DiscoverPort() = %d, want 12345", port)
+	}
+
+	// Platform storage takes precedence over the port file.
+	stored = 23456
+	if port, err := DiscoverPort(); err != nil || port != 23456 {
+		t.Errorf("DiscoverPort() with stored port = %d, %v, want 23456", port, err)
+	}
+}
+
+func TestLoadSettings(t *testing.T) {
+	orig := SettingsFile
+	defer func() { SettingsFile = orig }()
+	SettingsFile = filepath.Join(t.TempDir(), "settings.json")
+
+	if _, err := LoadSettings(); !os.IsNotExist(err) {
+		t.Errorf("LoadSettings() without settings file returned %v, want not exist", err)
+	}
+	if err := os.WriteFile(SettingsFile, []byte(`{"Port": 9200, "DisableActiveHours": true}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("LoadSettings() returned error: %v", err)
+	}
+	if want := (Settings{Port: 9200, DisableActiveHours: true}); got != want {
+		t.Errorf("LoadSettings() = %+v, want %+v", got, want)
+	}
+	if err := os.WriteFile(SettingsFile, []byte(`{`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSettings(); err == nil {
+		t.Errorf("LoadSettings() with malformed settings returned nil error")
+	}
+}
+
+func TestConfiguredPort(t *testing.T) {
+	var stored int
+	restore := fakePortStorage(&stored, 0)
+	defer func() { restore() }()
+	orig := SettingsFile
+	defer func() { SettingsFile = orig }()
+	SettingsFile = filepath.Join(t.TempDir(), "settings.json")
+	t.Setenv(PortEnv, "")
+
+	if got := ConfiguredPort(-1); got != Defaults.ServicePort {
+		t.Errorf("ConfiguredPort(-1) without settings = %d, want %d", got, Defaults.ServicePort)
+	}
+	if err := os.WriteFile(SettingsFile, []byte(`{"Port": 9200}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := ConfiguredPort(-1); got != 9200 {
+		t.Errorf("ConfiguredPort(-1) with settings file = %d, want 9200", got)
+	}
+	t.Setenv(PortEnv, "9300")
+	if got := ConfiguredPort(-1); got != 9300 {
+		t.Errorf("ConfiguredPort(-1) with %s = %d, want 9300", PortEnv, got)
+	}
+	if got := ConfiguredPort(9400); got != 9400 {
+		t.Errorf("ConfiguredPort(9400) = %d, want 9400", got)
+	}
+	if got := ConfiguredPort(0); got != 0 {
+		t.Errorf("ConfiguredPort(0) = %d, want 0", got)
+	}
+	t.Setenv(PortEnv, "99999")
+	if got := ConfiguredPort(-1); got != 9200 {
+		t.Errorf("ConfiguredPort(-1) with invalid %s = %d, want 9200", PortEnv, got)
+	}
+	t.Setenv(PortEnv, "")
+	os.Remove(SettingsFile)
+	restore()
+	restore = fakePortStorage(&stored, 9500)
+	if got := ConfiguredPort(-1); got != 9500 {
+		t.Errorf("ConfiguredPort(-1) with port policy = %d, want 9500", got)
+	}
+}
+
+func TestActiveHoursSpan(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	at := func(loc *time.Location, month time.Month, day, hour int) time.Time {
+		return time.Date(2026, month, day, hour, 0, 0, 0, loc)
+	}
+	tests := []struct {
+		desc                 string
+		start, end           int
+		now                  time.Time
+		wantOpens, wantClose time.Time
+		wantDuration         time.Duration
+	}{
+		{"same day", 8, 17, at(time.UTC, time.October, 31, 12), at(time.UTC, time.October, 31, 8), at(time.UTC, time.October, 31, 17), 9 * time.Hour},
+		{"overnight across month", 20, 2, at(time.UTC, time.October, 31, 12), at(time.UTC, time.October, 31, 20), at(time.UTC, time.November, 1, 2), 6 * time.Hour},
+		{"overnight after midnight", 20, 2, at(time.UTC, time.November, 1, 1), at(time.UTC, time.October, 31, 20), at(time.UTC, time.November, 1, 2), 6 * time.Hour},
+		{"overnight just ended", 20, 2, at(time.UTC, time.November, 1, 2), at(time.UTC, time.November, 1, 20), at(time.UTC, time.November, 2, 2), 6 * time.Hour},
+		{"overnight into DST", 22, 6, at(ny, time.March, 7, 23), at(ny, time.March, 7, 22), at(ny, time.March, 8, 6), 7 * time.Hour},
+		{"overnight out of DST", 22, 6, at(ny, time.November, 1, 3), at(ny, time.October, 31, 22), at(ny, time.November, 1, 6), 9 * time.Hour},
+		{"same day on DST change", 1, 12, at(ny, time.November, 1, 9), at(ny, time.November, 1, 1), at(ny, time.November, 1, 12), 12 * time.Hour},
+	}
+	for _, tt := range tests {
+		opens, closes := activeHoursSpan(tt.start, tt.end, tt.now)
+		if !opens.Equal(tt.wantOpens) || !closes.Equal(tt.wantClose) {
+			t.Errorf("activeHoursSpan(%s) = (%v, %v), want (%v, %v)", tt.desc, opens, closes, tt.wantOpens, tt.wantClose)
+		}
+		if got := closes.Sub(opens); got != tt.wantDuration {
+			t.Errorf("activeHoursSpan(%s) lasts %v, want %v", tt.desc, got, tt.wantDuration)
+		}
+	}
+}
+
+func TestOverride(t *testing.T) {
+	got, err := override(upstreamDefaults, "Contoso", "9200", "", "contoso")
+	if err != nil {
+		t.Fatalf("override() returned error: %v", err)
+	}
+	want := BuildDefaults{ServiceName: "Contoso", ServicePort: 9200, MetricRoot: upstreamDefaults.MetricRoot, MetricSvc: "contoso"}
+	if got != want {
+		t.Errorf("override() = %+v, want %+v", got, want)
+	}
+	if got, _ := override(upstreamDefaults, "", "", "", ""); got != upstreamDefaults {
+		t.Errorf("override() without overrides = %+v, want %+v", got, upstreamDefaults)
+	}
+	for _, port := range []string{"http", "0", "70000"} {
+		if _, err := override(upstreamDefaults, "", port, "", ""); err == nil {
+			t.Errorf("override() with port %q returned nil error", port)
+		}
+	}
+}
@@ -33,50 +33,73 @@ var (
 	ConfDir = filepath.Join(DataDir, "conf")
 	// LogPath defines active log file filesystem location.
 	LogPath = filepath.Join(DataDir, "aukera.log")
-
-	// MetricRoot sets metric path for all aukera metrics
-	MetricRoot = `/aukera/metrics`
-	// MetricSvc sets platform source for metrics.
-	MetricSvc = "aukera"
-
-	activeHoursStart, activeHoursEnd uint64
-	activeStartTime, activeEndTime   time.Time
 )
 
+// defaultMetrics emits metrics through the cabbie backend.
+var defaultMetrics MetricSink = CabbieMetrics{}
+
 const (
 	activeHoursPath = `SOFTWARE\Microsoft\WindowsUpdate\UX\Settings\`
+	servicePath     = `SOFTWARE\Aukera`
+	policyPath      = `SOFTWARE\Policies\Aukera`
 )
 
 // ActiveHours retrieves the user/auto-set active hours times from the registry.
 // Returns the start and end times of the active hours window, respectively.
 func ActiveHours() (time.Time, time.Time, error) {
-
+	var t time.Time
 	k, err := registry.OpenKey(registry.LOCAL_MACHINE, activeHoursPath, registry.READ)
 	if err != nil {
-		return activeStartTime, activeEndTime, err
+		return t, t, err
 	}
 	defer k.Close()
 
-	activeHoursStart, _, err = k.GetIntegerValue("ActiveHoursStart")
+	activeHoursStart, _, err := k.GetIntegerValue("ActiveHoursStart")
 	if err != nil {
-		return activeStartTime, activeEndTime, fmt.Errorf("unable to get active hours start time: %v", err)
+		return t, t, fmt.Errorf("unable to get active hours start time: %w", err)
 	}
+	activeHoursEnd, _, err := k.GetIntegerValue("ActiveHoursEnd")
+	if err != nil {
+		return t, t, fmt.Errorf("unable to get active hours end time: %w", err)
+	}
+	start, end := activeHoursSpan(int(activeHoursStart), int(activeHoursEnd), time.Now())
+	return start, end, nil
+}
 
-	now := time.Now()
-	activeStartTime = time.Date(now.Year(), now.Month(), now.Day(), int(activeHoursStart), 0, 0, 0, now.Location())
-
-	activeHoursEnd, _, err = k.GetIntegerValue("ActiveHoursEnd")
+// storePort records the bound service port in the registry.
+func storePort(port int) error {
+	k, _, err := registry.CreateKey(registry.LOCAL_MACHINE, servicePath, registry.SET_VALUE)
 	if err != nil {
-		return activeStartTime, activeEndTime, fmt.Errorf("unable to get active hours end time: %v", err)
+		return fmt.Errorf("storePort: unable to open %q: %w", servicePath, err)
 	}
+	defer k.Close()
+	return k.SetDWordValue("Port", uint32(port))
+}
 
-	var day int
-	if int(activeHoursEnd) < activeStartTime.Hour() {
-		day = now.Day() + 1
-	} else {
-		day = now.Day()
+// loadPort retrieves the bound service port from the registry.
+func loadPort() (int, error) {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, servicePath, registry.QUERY_VALUE)
+	if err != nil {
+		return 0, err
 	}
-	activeEndTime = time.Date(now.Year(), now.Month(), day, int(activeHoursEnd), 0, 0, 0, now.Location())
+	defer k.Close()
+	port, _, err := k.GetIntegerValue("Port")
+	if err != nil {
+		return 0, fmt.Errorf("loadPort: unable to get port: %w", err)
+	}
+	return int(port), nil
+}
 
-	return activeStartTime, activeEndTime, nil
+// policyPort retrieves the service port set by group policy.
+func policyPort() (int, error) {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, policyPath, registry.QUERY_VALUE)
+	if err != nil {
+		return 0, err
+	}
+	defer k.Close()
+	port, _, err := k.GetIntegerValue("Port")
+	if err != nil {
+		return 0, fmt.Errorf("policyPort: unable to get port: %w", err)
+	}
+	return int(port), nil
 }
@@ -0,0 +1,41 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auklib
+
+import (
+	"time"
+)
+
+// ClockWatch detects the system clock being stepped backwards, such as by
+// an NTP correction, between successive observations. The zero ClockWatch
+// is ready to use.
+type ClockWatch struct {
+	last time.Time
+}
+
+// SteppedBack records now and reports how far the wall clock moved
+// backwards since the previous observation, or zero if it did not.
+func (c *ClockWatch) SteppedBack(now time.Time) time.Duration {
+	// Steps only affect wall clock readings, so monotonic readings, which
+	// would hide them, are discarded.
+	now = now.Round(0)
+	var d time.Duration
+	if !c.last.IsZero() && now.Before(c.last) {
+		d = c.last.Sub(now)
+		ReportDuration("clock_stepped_back", d, nil)
+	}
+	c.last = now
+	return d
+}
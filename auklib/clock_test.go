@@ -0,0 +1,47 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auklib
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClockWatch(t *testing.T) {
+	now := time.Date(2026, time.March, 1, 2, 0, 0, 0, time.UTC)
+	var c ClockWatch
+	tests := []struct {
+		desc string
+		now  time.Time
+		want time.Duration
+	}{
+		{"first observation", now, 0},
+		{"forward", now.Add(10 * time.Second), 0},
+		{"same time", now.Add(10 * time.Second), 0},
+		{"stepped back", now.Add(-2 * time.Hour), 2*time.Hour + 10*time.Second},
+		{"resumed", now.Add(-2*time.Hour + 10*time.Second), 0},
+	}
+	for _, tt := range tests {
+		if got := c.SteppedBack(tt.now); got != tt.want {
+			t.Errorf("SteppedBack(%s) = %v, want %v", tt.desc, got, tt.want)
+		}
+	}
+	// Monotonic readings do not hide steps of the wall clock.
+	c = ClockWatch{}
+	c.SteppedBack(time.Now())
+	if got := c.SteppedBack(time.Now().Round(0).Add(-time.Hour)); got < 59*time.Minute {
+		t.Errorf("SteppedBack(an hour earlier) = %v, want about 1h", got)
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auklib
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Build-time overrides of Defaults, for forks and internal branding. They
+// are set with the linker, such as:
+//
+//	go build -ldflags "-X github.com/google/aukera/auklib.buildServiceName=Contoso -X github.com/google/aukera/auklib.buildServicePort=9200"
+//
+// The linker only sets strings, so the port is parsed when the package is
+// initialized. Empty values keep the upstream default.
+var (
+	buildServiceName string
+	buildServicePort string
+	buildMetricRoot  string
+	buildMetricSvc   string
+)
+
+// BuildDefaults holds the defaults identifying the service.
+type BuildDefaults struct {
+	// ServiceName is the name of the Windows service.
+	ServiceName string
+	// ServicePort is the default port the HTTP service listens on.
+	ServicePort int
+	// MetricRoot is the path all metrics are reported under.
+	MetricRoot string
+	// MetricSvc is the source all metrics are reported from.
+	MetricSvc string
+}
+
+// Upstream defaults identifying the service. Builds may override them; see
+// Defaults for the values in effect.
+const (
+	// ServiceName defines the name of Aukera Windows service.
+	ServiceName = "Aukera"
+	// ServicePort is the default port the Aukera HTTP service is listening on.
+	ServicePort = 9119
+	// MetricRoot is the path all metrics are reported under.
+	MetricRoot = "/aukera/metrics"
+	// MetricSvc is the source all metrics are reported from.
+	MetricSvc = "aukera"
+)
+
+// upstreamDefaults are the defaults of upstream builds.
+var upstreamDefaults = BuildDefaults{
+	ServiceName: ServiceName,
+	ServicePort: ServicePort,
+	MetricRoot:  MetricRoot,
+	MetricSvc:   MetricSvc,
+}
+
+// Defaults are the defaults in effect: upstream defaults with any build-time
+// overrides applied.
+var Defaults = mustOverride(upstreamDefaults, buildServiceName, buildServicePort, buildMetricRoot, buildMetricSvc)
+
+// override returns d with the non-empty build-time overrides applied.
+func override(d BuildDefaults, name, port, root, svc string) (BuildDefaults, error) {
+	if name != "" {
+		d.ServiceName = name
+	}
+	if port != "" {
+		p, err := strconv.Atoi(port)
+		if err != nil || !validPort(p) {
+			return d, fmt.Errorf("invalid build-time service port %q", port)
+		}
+		d.ServicePort = p
+	}
+	if root != "" {
+		d.MetricRoot = root
+	}
+	if svc != "" {
+		d.MetricSvc = svc
+	}
+	return d, nil
+}
+
+// mustOverride is override, panicking on invalid overrides, which can only
+// come from a misconfigured build.
+func mustOverride(d BuildDefaults, name, port, root, svc string) BuildDefaults {
+	d, err := override(d, name, port, root, svc)
+	if err != nil {
+		panic(fmt.Sprintf("auklib: %v", err))
+	}
+	return d
+}
@@ -0,0 +1,78 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auklib
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLockFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "approvals.json")
+	l, err := LockFile(path)
+	if err != nil {
+		t.Fatalf("LockFile() returned error: %v", err)
+	}
+	acquired := make(chan *FileLock)
+	go func() {
+		l2, err := LockFile(path)
+		if err != nil {
+			t.Errorf("second LockFile() returned error: %v", err)
+		}
+		acquired <- l2
+	}()
+	select {
+	case <-acquired:
+		t.Fatalf("second LockFile() acquired a held lock")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if err := l.Unlock(); err != nil {
+		t.Fatalf("Unlock() returned error: %v", err)
+	}
+	select {
+	case l2 := <-acquired:
+		if l2 != nil {
+			l2.Unlock()
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("second LockFile() did not acquire a released lock")
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+	for _, content := range []string{"first", "second"} {
+		if err := WriteFileAtomic(path, []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFileAtomic(%q) returned error: %v", content, err)
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != content {
+			t.Errorf("WriteFileAtomic(%q) wrote %q", content, b)
+		}
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("WriteFileAtomic() left %d files in %s, want 1", len(files), dir)
+	}
+}
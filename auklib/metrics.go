@@ -0,0 +1,214 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auklib
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/deck"
+	"github.com/google/cabbie/metrics"
+)
+
+// MetricSink emits metric samples. name is the full metric name, including
+// Defaults.MetricRoot, and fields are attached to the sample as string
+// fields.
+type MetricSink interface {
+	SetInt(name string, v int64, fields map[string]string) error
+	SetString(name, v string, fields map[string]string) error
+	SetDistribution(name string, d Distribution, fields map[string]string) error
+}
+
+// Distribution summarizes every sample reported to a timing metric since
+// the service started.
+type Distribution struct {
+	// Count is the number of samples, and Sum their total.
+	Count, Sum int64
+	// Bounds are the inclusive upper bounds of the buckets. Buckets[i]
+	// counts the samples above Bounds[i-1] and no greater than Bounds[i],
+	// and the final bucket counts those above every bound.
+	Bounds  []int64
+	Buckets []int64
+}
+
+// add records a sample of v.
+func (d *Distribution) add(v int64) {
+	d.Count++
+	d.Sum += v
+	i := sort.Search(len(d.Bounds), func(i int) bool { return v <= d.Bounds[i] })
+	d.Buckets[i]++
+}
+
+// copy returns a copy of d that does not share its buckets.
+func (d *Distribution) copy() Distribution {
+	c := *d
+	c.Buckets = append([]int64(nil), d.Buckets...)
+	return c
+}
+
+// DurationBounds are the bucket bounds of duration metrics, in microseconds.
+var DurationBounds = []int64{
+	1000, 5000, 10000, 25000, 50000, 100000, 250000, 500000,
+	1000000, 2500000, 5000000, 10000000,
+}
+
+// Metrics receives all metrics reported by Aukera. It defaults to the cabbie
+// metrics backend on Windows and to NopMetrics elsewhere. A nil Metrics
+// discards samples.
+var Metrics MetricSink = defaultMetrics
+
+// NopMetrics discards all samples.
+type NopMetrics struct{}
+
+// SetInt discards v.
+func (NopMetrics) SetInt(string, int64, map[string]string) error { return nil }
+
+// SetString discards v.
+func (NopMetrics) SetString(string, string, map[string]string) error { return nil }
+
+// SetDistribution discards d.
+func (NopMetrics) SetDistribution(string, Distribution, map[string]string) error { return nil }
+
+// CabbieMetrics emits samples through the cabbie metrics backend.
+type CabbieMetrics struct{}
+
+// SetInt emits v as a sample of the named integer metric.
+func (CabbieMetrics) SetInt(name string, v int64, fields map[string]string) error {
+	m, err := metrics.NewInt(name, Defaults.MetricSvc)
+	if err != nil {
+		return fmt.Errorf("could not create metric: %w", err)
+	}
+	for _, k := range sortedKeys(fields) {
+		m.Data.AddStringField(k, fields[k])
+	}
+	return m.Set(v)
+}
+
+// SetString emits v as a sample of the named string metric.
+func (CabbieMetrics) SetString(name, v string, fields map[string]string) error {
+	m, err := metrics.NewString(name, Defaults.MetricSvc)
+	if err != nil {
+		return fmt.Errorf("could not create metric: %w", err)
+	}
+	for _, k := range sortedKeys(fields) {
+		m.Data.AddStringField(k, fields[k])
+	}
+	return m.Set(v)
+}
+
+// SetDistribution emits d as the integer metrics name/count and name/sum,
+// and name/bucket once per bucket, with an "le" field holding the bucket's
+// upper bound, or "+Inf", and the cumulative count of samples within it.
+func (c CabbieMetrics) SetDistribution(name string, d Distribution, fields map[string]string) error {
+	if err := c.SetInt(name+"/count", d.Count, fields); err != nil {
+		return err
+	}
+	if err := c.SetInt(name+"/sum", d.Sum, fields); err != nil {
+		return err
+	}
+	var cum int64
+	for i, n := range d.Buckets {
+		cum += n
+		le := "+Inf"
+		if i < len(d.Bounds) {
+			le = strconv.FormatInt(d.Bounds[i], 10)
+		}
+		f := map[string]string{"le": le}
+		for k, v := range fields {
+			f[k] = v
+		}
+		if err := c.SetInt(name+"/bucket", cum, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sortedKeys(fields map[string]string) []string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// report emits a sample through Metrics. Metric failures, including panics
+// in the backend, are logged and otherwise ignored so that callers never
+// depend on the availability of a metrics backend.
+func report(name string, set func(MetricSink, string) error) {
+	sink := Metrics
+	if sink == nil {
+		return
+	}
+	full := fmt.Sprintf("%s/%s", Defaults.MetricRoot, name)
+	defer func() {
+		if r := recover(); r != nil {
+			deck.Warningf("metric %q: backend panicked: %v", full, r)
+		}
+	}()
+	if err := set(sink, full); err != nil {
+		deck.Warningf("metric %q: %v", full, err)
+	}
+}
+
+// ReportInt emits v as a sample of the named integer metric.
+func ReportInt(name string, v int64, fields map[string]string) {
+	report(name, func(s MetricSink, full string) error { return s.SetInt(full, v, fields) })
+}
+
+// ReportString emits v as a sample of the named string metric.
+func ReportString(name, v string, fields map[string]string) {
+	report(name, func(s MetricSink, full string) error { return s.SetString(full, v, fields) })
+}
+
+// distributions holds the Distribution of each duration metric series,
+// keyed by metric name and fields.
+var distributions = struct {
+	sync.Mutex
+	series map[string]*Distribution
+}{series: make(map[string]*Distribution)}
+
+// seriesKey identifies the series of the named metric with fields.
+func seriesKey(name string, fields map[string]string) string {
+	var b strings.Builder
+	b.WriteString(name)
+	for _, k := range sortedKeys(fields) {
+		fmt.Fprintf(&b, "|%s=%s", k, fields[k])
+	}
+	return b.String()
+}
+
+// ReportDuration adds d, in microseconds, to the distribution of the named
+// timing metric, bucketed by DurationBounds, and emits the distribution.
+// Each combination of fields is a separate series, so fields should take
+// few values.
+func ReportDuration(name string, d time.Duration, fields map[string]string) {
+	key := seriesKey(name, fields)
+	distributions.Lock()
+	dist, ok := distributions.series[key]
+	if !ok {
+		dist = &Distribution{Bounds: DurationBounds, Buckets: make([]int64, len(DurationBounds)+1)}
+		distributions.series[key] = dist
+	}
+	dist.add(d.Microseconds())
+	snapshot := dist.copy()
+	distributions.Unlock()
+	report(name, func(s MetricSink, full string) error { return s.SetDistribution(full, snapshot, fields) })
+}
@@ -0,0 +1,105 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auklib
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type sample struct {
+	Name, Value string
+	Fields      map[string]string
+}
+
+type fakeMetrics struct {
+	samples []sample
+	err     error
+	panic   bool
+}
+
+func (f *fakeMetrics) SetInt(name string, v int64, fields map[string]string) error {
+	return f.SetString(name, strconv.FormatInt(v, 10), fields)
+}
+
+func (f *fakeMetrics) SetDistribution(name string, d Distribution, fields map[string]string) error {
+	return f.SetString(name, fmt.Sprintf("count=%d sum=%d buckets=%v", d.Count, d.Sum, d.Buckets), fields)
+}
+
+func (f *fakeMetrics) SetString(name, v string, fields map[string]string) error {
+	if f.panic {
+		panic("backend unavailable")
+	}
+	f.samples = append(f.samples, sample{name, v, fields})
+	return f.err
+}
+
+func TestReport(t *testing.T) {
+	defer func(m MetricSink) { Metrics = m }(Metrics)
+	distributions.series = make(map[string]*Distribution)
+	root := Defaults.MetricRoot
+
+	f := &fakeMetrics{}
+	Metrics = f
+	ReportString("config_loader", "ok", map[string]string{"file_path": "a.json"})
+	ReportDuration("latency", 3*time.Millisecond, nil)
+	want := []sample{
+		{root + "/config_loader", "ok", map[string]string{"file_path": "a.json"}},
+		{root + "/latency", "count=1 sum=3000 buckets=[0 1 0 0 0 0 0 0 0 0 0 0 0]", nil},
+	}
+	if diff := cmp.Diff(want, f.samples); diff != "" {
+		t.Errorf("reported samples mismatch (-want +got):\n%s", diff)
+	}
+
+	// Backend failures must not reach callers.
+	Metrics = &fakeMetrics{err: errors.New("write failed")}
+	ReportInt("failing", 1, nil)
+	Metrics = &fakeMetrics{panic: true}
+	ReportInt("panicking", 1, nil)
+	Metrics = nil
+	ReportInt("discarded", 1, nil)
+	Metrics = NopMetrics{}
+	ReportString("discarded", "x", nil)
+}
+
+func TestReportDurationDistribution(t *testing.T) {
+	defer func(m MetricSink) { Metrics = m }(Metrics)
+	distributions.series = make(map[string]*Distribution)
+	f := &fakeMetrics{}
+	Metrics = f
+
+	for _, d := range []time.Duration{time.Millisecond, 2 * time.Millisecond, time.Minute} {
+		ReportDuration("dist", d, map[string]string{"route": "/a"})
+	}
+	ReportDuration("dist", time.Millisecond, map[string]string{"route": "/b"})
+	want := []string{
+		"count=1 sum=1000 buckets=[1 0 0 0 0 0 0 0 0 0 0 0 0]",
+		"count=2 sum=3000 buckets=[1 1 0 0 0 0 0 0 0 0 0 0 0]",
+		"count=3 sum=60003000 buckets=[1 1 0 0 0 0 0 0 0 0 0 0 1]",
+		"count=1 sum=1000 buckets=[1 0 0 0 0 0 0 0 0 0 0 0 0]",
+	}
+	var got []string
+	for _, s := range f.samples {
+		got = append(got, s.Value)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ReportDuration() distributions mismatch (-want +got):\n%s", diff)
+	}
+}
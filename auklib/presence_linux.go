@@ -0,0 +1,175 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package auklib
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// PresenceHold is how long a user present at a desktop session is assumed
+// to remain active.
+var PresenceHold = 30 * time.Minute
+
+// presenceTTL is how long a presence lookup is reused, so that schedule
+// requests do not each query logind and the desktop session.
+const presenceTTL = 30 * time.Second
+
+// command runs an external command and returns its standard output.
+var command = func(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).Output()
+}
+
+// desktopSession describes a login session.
+type desktopSession struct {
+	id, user, typ string
+	uid           int
+	active, idle  bool
+	idleSince     time.Time
+}
+
+const (
+	logindName = "org.freedesktop.login1"
+	logindPath = "/org/freedesktop/login1"
+)
+
+// listSessions returns the login sessions known to systemd-logind, to which
+// GNOME and KDE both report session idleness.
+var listSessions = func() ([]desktopSession, error) {
+	conn, err := dbus.SystemBus()
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to the system bus: %w", err)
+	}
+	var list []struct {
+		ID   string
+		UID  uint32
+		User string
+		Seat string
+		Path dbus.ObjectPath
+	}
+	if err := conn.Object(logindName, logindPath).Call(logindName+".Manager.ListSessions", 0).Store(&list); err != nil {
+		return nil, fmt.Errorf("unable to list sessions: %w", err)
+	}
+	var out []desktopSession
+	for _, l := range list {
+		var props map[string]dbus.Variant
+		if err := conn.Object(logindName, l.Path).Call("org.freedesktop.DBus.Properties.GetAll", 0, logindName+".Session").Store(&props); err != nil {
+			continue
+		}
+		s := desktopSession{id: l.ID, user: l.User, uid: int(l.UID)}
+		s.typ, _ = props["Type"].Value().(string)
+		s.active, _ = props["Active"].Value().(bool)
+		s.idle, _ = props["IdleHint"].Value().(bool)
+		if us, ok := props["IdleSinceHint"].Value().(uint64); ok && us > 0 {
+			s.idleSince = time.UnixMicro(int64(us))
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// activeDesktopSession finds the active graphical session.
+func activeDesktopSession() (desktopSession, error) {
+	sessions, err := listSessions()
+	if err != nil {
+		return desktopSession{}, err
+	}
+	for _, s := range sessions {
+		if s.active && (s.typ == "x11" || s.typ == "wayland") {
+			return s, nil
+		}
+	}
+	return desktopSession{}, fmt.Errorf("no active graphical session")
+}
+
+// presence holds the result of the last presence lookup.
+var presence struct {
+	sync.Mutex
+	checked time.Time
+	session desktopSession
+	dnd     bool
+	err     error
+}
+
+// desktopPresence returns the active graphical session and whether Do Not
+// Disturb is enabled in it, reusing a lookup made within presenceTTL of now.
+func desktopPresence(now time.Time) (desktopSession, bool, error) {
+	presence.Lock()
+	defer presence.Unlock()
+	if !presence.checked.IsZero() && !now.Before(presence.checked) && now.Sub(presence.checked) < presenceTTL {
+		return presence.session, presence.dnd, presence.err
+	}
+	s, err := activeDesktopSession()
+	var dnd bool
+	if err == nil && s.idle {
+		dnd = doNotDisturb(s)
+	}
+	presence.checked, presence.session, presence.dnd, presence.err = now, s, dnd, err
+	return s, dnd, err
+}
+
+// sessionCommand runs a command against the session bus of s's user.
+func sessionCommand(s desktopSession, args ...string) ([]byte, error) {
+	env := fmt.Sprintf("DBUS_SESSION_BUS_ADDRESS=unix:path=/run/user/%d/bus", s.uid)
+	if os.Geteuid() == s.uid {
+		return command("env", append([]string{env}, args...)...)
+	}
+	return command("runuser", append([]string{"-u", s.user, "--", "env", env}, args...)...)
+}
+
+// doNotDisturb reports whether notifications are suppressed in s, using the
+// GNOME notification settings or the freedesktop notification service
+// inhibition used by KDE.
+func doNotDisturb(s desktopSession) bool {
+	if out, err := sessionCommand(s, "gsettings", "get", "org.gnome.desktop.notifications", "show-banners"); err == nil {
+		return strings.TrimSpace(string(out)) == "false"
+	}
+	out, err := sessionCommand(s, "gdbus", "call", "--session",
+		"--dest", "org.freedesktop.Notifications",
+		"--object-path", "/org/freedesktop/Notifications",
+		"--method", "org.freedesktop.DBus.Properties.Get",
+		"org.freedesktop.Notifications", "Inhibited")
+	return err == nil && strings.Contains(string(out), "true")
+}
+
+// presenceHours derives active hours from desktop presence at now, looked up
+// at most once every presenceTTL. A user who
+// is present, or has enabled Do Not Disturb, is active for PresenceHold. Once
+// the session goes idle, active hours are considered to have ended when it
+// did.
+func presenceHours(now time.Time) (time.Time, time.Time, error) {
+	s, dnd, err := desktopPresence(now)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("ActiveHours: %w", err)
+	}
+	if !s.idle || dnd {
+		start := now.Truncate(time.Minute)
+		return start, start.Add(PresenceHold), nil
+	}
+	since := s.idleSince
+	if since.IsZero() || since.After(now) {
+		since = now
+	}
+	return since, since, nil
+}
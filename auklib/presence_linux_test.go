@@ -0,0 +1,116 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package auklib
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// resetPresence discards the cached presence lookup.
+func resetPresence() {
+	presence.Lock()
+	defer presence.Unlock()
+	presence.checked = time.Time{}
+}
+
+func TestPresenceHours(t *testing.T) {
+	origCommand, origList := command, listSessions
+	defer func() { command, listSessions = origCommand, origList }()
+	now := time.Date(2020, time.January, 1, 12, 0, 30, 0, time.UTC)
+	idleSince := now.Add(-20 * time.Minute)
+
+	tests := []struct {
+		desc        string
+		sessionType string
+		idle        bool
+		showBanners string
+		start, end  time.Time
+		wantErr     bool
+	}{
+		{"present", "wayland", false, "true", now.Truncate(time.Minute), now.Truncate(time.Minute).Add(PresenceHold), false},
+		{"idle", "x11", true, "true", idleSince, idleSince, false},
+		{"idle with do not disturb", "x11", true, "false", now.Truncate(time.Minute), now.Truncate(time.Minute).Add(PresenceHold), false},
+		{"no graphical session", "tty", false, "true", time.Time{}, time.Time{}, true},
+	}
+	for _, tt := range tests {
+		resetPresence()
+		listSessions = func() ([]desktopSession, error) {
+			return []desktopSession{
+				{id: "1", user: "gdm", typ: "wayland", uid: 120},
+				{id: "2", user: "alice", typ: tt.sessionType, uid: 1000, active: true, idle: tt.idle, idleSince: idleSince},
+			}, nil
+		}
+		command = func(name string, args ...string) ([]byte, error) {
+			cmd := name + " " + strings.Join(args, " ")
+			if strings.Contains(cmd, "gsettings get org.gnome.desktop.notifications show-banners") {
+				return []byte(tt.showBanners + "\n"), nil
+			}
+			return nil, errors.New("unexpected command: " + cmd)
+		}
+		start, end, err := presenceHours(now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("presenceHours(%s) returned error %v, want error %t", tt.desc, err, tt.wantErr)
+			continue
+		}
+		if !start.Equal(tt.start) || !end.Equal(tt.end) {
+			t.Errorf("presenceHours(%s) = [%s, %s], want [%s, %s]", tt.desc, start, end, tt.start, tt.end)
+		}
+	}
+}
+
+func TestPresenceCached(t *testing.T) {
+	origList := listSessions
+	defer func() { listSessions = origList }()
+	resetPresence()
+	var lookups int
+	listSessions = func() ([]desktopSession, error) {
+		lookups++
+		return nil, errors.New("no logind")
+	}
+	now := time.Date(2020, time.January, 1, 12, 0, 0, 0, time.UTC)
+	for _, at := range []time.Time{now, now.Add(presenceTTL / 2), now.Add(presenceTTL), now.Add(-time.Minute)} {
+		if _, _, err := presenceHours(at); err == nil {
+			t.Errorf("presenceHours(%s) returned no error without logind", at)
+		}
+	}
+	// Lookups are reused within presenceTTL, and repeated once it elapses or
+	// the clock steps back.
+	if lookups != 3 {
+		t.Errorf("presenceHours() looked up sessions %d times, want 3", lookups)
+	}
+}
+
+func TestDoNotDisturbKDE(t *testing.T) {
+	orig := command
+	defer func() { command = orig }()
+	for _, inhibited := range []bool{true, false} {
+		command = func(name string, args ...string) ([]byte, error) {
+			if strings.Contains(strings.Join(args, " "), "org.freedesktop.Notifications Inhibited") {
+				return []byte(fmt.Sprintf("(<%t>,)\n", inhibited)), nil
+			}
+			return nil, errors.New("not found")
+		}
+		if got := doNotDisturb(desktopSession{user: "alice", uid: 1000}); got != inhibited {
+			t.Errorf("doNotDisturb() with Inhibited=%t = %t", inhibited, got)
+		}
+	}
+}
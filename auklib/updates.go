@@ -0,0 +1,41 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auklib
+
+import (
+	"time"
+)
+
+// OSUpdateHold is how long an automatic operating system update run is
+// assumed to take once it starts.
+var OSUpdateHold = time.Hour
+
+// scheduledInstall returns the run of a weekly automatic update schedule that
+// is in progress at now, or else the next one. Runs start at hour on day,
+// numbered 1 for Sunday through 7 for Saturday, or every day if day is 0.
+func scheduledInstall(day, hour int, now time.Time) (time.Time, time.Time) {
+	// Start from yesterday in case its run is still in progress.
+	for d := -1; d <= 7; d++ {
+		date := now.AddDate(0, 0, d)
+		t := time.Date(date.Year(), date.Month(), date.Day(), hour, 0, 0, 0, now.Location())
+		if day != 0 && int(t.Weekday())+1 != day {
+			continue
+		}
+		if end := t.Add(OSUpdateHold); now.Before(end) {
+			return t, end
+		}
+	}
+	return time.Time{}, time.Time{}
+}
@@ -0,0 +1,124 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package auklib
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// updateTimers are the systemd timers that install updates automatically,
+// for unattended-upgrades on Debian and dnf-automatic on Fedora and RHEL.
+var updateTimers = []string{"apt-daily-upgrade.timer", "dnf-automatic-install.timer"}
+
+// systemdTimestamp is the layout of timestamps shown by systemctl.
+const systemdTimestamp = "Mon 2006-01-02 15:04:05 MST"
+
+// systemdUnits maps systemd time span units to Go duration units.
+var systemdUnits = map[string]time.Duration{
+	"us": time.Microsecond, "usec": time.Microsecond,
+	"ms": time.Millisecond, "msec": time.Millisecond,
+	"s": time.Second, "sec": time.Second,
+	"m": time.Minute, "min": time.Minute,
+	"h": time.Hour, "hr": time.Hour,
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+}
+
+// parseSpan parses a systemd time span, such as "1h 30min".
+func parseSpan(s string) (time.Duration, error) {
+	var d time.Duration
+	for _, f := range strings.Fields(s) {
+		i := strings.IndexFunc(f, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+		if i < 0 {
+			i = len(f)
+		}
+		n, err := strconv.ParseFloat(f[:i], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid time span %q", s)
+		}
+		unit := time.Second
+		if i < len(f) {
+			var ok bool
+			if unit, ok = systemdUnits[f[i:]]; !ok {
+				return 0, fmt.Errorf("invalid time span %q", s)
+			}
+		}
+		d += time.Duration(n * float64(unit))
+	}
+	return d, nil
+}
+
+// properties parses the key=value output of systemctl show.
+func properties(b []byte) map[string]string {
+	props := make(map[string]string)
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	for sc.Scan() {
+		if k, v, ok := strings.Cut(sc.Text(), "="); ok {
+			props[k] = v
+		}
+	}
+	return props
+}
+
+// timerHours returns the run of the systemd timer unit in progress at now,
+// or else the next one. The next run covers the timer's randomized delay.
+func timerHours(unit string, now time.Time) (time.Time, time.Time, error) {
+	var t time.Time
+	b, err := command("systemctl", "show", unit,
+		"-p", "LoadState", "-p", "ActiveState", "-p", "LastTriggerUSec", "-p", "NextElapseUSecRealtime", "-p", "RandomizedDelayUSec")
+	if err != nil {
+		return t, t, fmt.Errorf("unable to query %s: %w", unit, err)
+	}
+	p := properties(b)
+	if p["LoadState"] != "loaded" || p["ActiveState"] != "active" {
+		return t, t, fmt.Errorf("%s is not active", unit)
+	}
+	if last, err := time.ParseInLocation(systemdTimestamp, p["LastTriggerUSec"], time.Local); err == nil && now.Before(last.Add(OSUpdateHold)) {
+		return last, last.Add(OSUpdateHold), nil
+	}
+	next, err := time.ParseInLocation(systemdTimestamp, p["NextElapseUSecRealtime"], time.Local)
+	if err != nil {
+		return t, t, fmt.Errorf("%s has no next run: %w", unit, err)
+	}
+	delay, err := parseSpan(p["RandomizedDelayUSec"])
+	if err != nil {
+		return t, t, fmt.Errorf("%s: %w", unit, err)
+	}
+	return next, next.Add(delay + OSUpdateHold), nil
+}
+
+// OSUpdateHours returns the start and end times of the automatic update run
+// in progress, or else the next one, from the first active update timer.
+func OSUpdateHours() (time.Time, time.Time, error) {
+	now := time.Now()
+	var errs []string
+	for _, u := range updateTimers {
+		opens, closes, err := timerHours(u, now)
+		if err == nil {
+			return opens, closes, nil
+		}
+		errs = append(errs, err.Error())
+	}
+	var t time.Time
+	return t, t, fmt.Errorf("OSUpdateHours: no automatic updates: %s", strings.Join(errs, "; "))
+}
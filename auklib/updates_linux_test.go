@@ -0,0 +1,89 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package auklib
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSpan(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"0", 0, false},
+		{"1h", time.Hour, false},
+		{"1h 30min", 90 * time.Minute, false},
+		{"2d 500ms", 48*time.Hour + 500*time.Millisecond, false},
+		{"", 0, false},
+		{"1 fortnight", 0, true},
+		{"min", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseSpan(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseSpan(%q) = (%v, %v), want (%v, error: %t)", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestTimerHours(t *testing.T) {
+	orig := command
+	defer func() { command = orig }()
+	now := time.Date(2026, time.October, 15, 12, 0, 0, 0, time.Local)
+	format := func(t time.Time) string { return t.Format(systemdTimestamp) }
+	next := now.Add(18 * time.Hour)
+
+	tests := []struct {
+		desc         string
+		load, active string
+		last         string
+		want         time.Time
+		wantDuration time.Duration
+		wantErr      bool
+	}{
+		{"next run", "loaded", "active", format(now.Add(-6 * time.Hour)), next, time.Hour + OSUpdateHold, false},
+		{"never run", "loaded", "active", "n/a", next, time.Hour + OSUpdateHold, false},
+		{"in progress", "loaded", "active", format(now.Add(-10 * time.Minute)), now.Add(-10 * time.Minute), OSUpdateHold, false},
+		{"inactive", "loaded", "inactive", "n/a", time.Time{}, 0, true},
+		{"not found", "not-found", "inactive", "n/a", time.Time{}, 0, true},
+	}
+	for _, tt := range tests {
+		command = func(name string, args ...string) ([]byte, error) {
+			cmd := name + " " + strings.Join(args, " ")
+			if !strings.HasPrefix(cmd, "systemctl show apt-daily-upgrade.timer") {
+				return nil, errors.New("unexpected command: " + cmd)
+			}
+			return []byte(fmt.Sprintf("LoadState=%s\nActiveState=%s\nNextElapseUSecRealtime=%s\nLastTriggerUSec=%s\nRandomizedDelayUSec=1h\n",
+				tt.load, tt.active, format(next), tt.last)), nil
+		}
+		opens, closes, err := timerHours("apt-daily-upgrade.timer", now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("timerHours(%s) returned error %v, want error: %t", tt.desc, err, tt.wantErr)
+			continue
+		}
+		if !opens.Equal(tt.want) || closes.Sub(opens) != tt.wantDuration {
+			t.Errorf("timerHours(%s) = (%v, %v), want (%v, %v)", tt.desc, opens, closes, tt.want, tt.want.Add(tt.wantDuration))
+		}
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auklib
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduledInstall(t *testing.T) {
+	// Thursday.
+	now := time.Date(2026, time.October, 15, 12, 0, 0, 0, time.UTC)
+	at := func(day, hour int) time.Time {
+		return time.Date(2026, time.October, day, hour, 0, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		desc      string
+		day, hour int
+		now       time.Time
+		want      time.Time
+	}{
+		{"every day, later today", 0, 15, now, at(15, 15)},
+		{"every day, tomorrow", 0, 3, now, at(16, 3)},
+		{"every day, in progress", 0, 12, now.Add(30 * time.Minute), at(15, 12)},
+		{"every day, just ended", 0, 11, now, at(16, 11)},
+		{"sunday", 1, 3, now, at(18, 3)},
+		{"thursday, next week", 5, 3, now, at(22, 3)},
+		{"thursday, later today", 5, 15, now, at(15, 15)},
+	}
+	for _, tt := range tests {
+		opens, closes := scheduledInstall(tt.day, tt.hour, tt.now)
+		if !opens.Equal(tt.want) || closes.Sub(opens) != OSUpdateHold {
+			t.Errorf("scheduledInstall(%s) = (%v, %v), want (%v, %v)", tt.desc, opens, closes, tt.want, tt.want.Add(OSUpdateHold))
+		}
+	}
+}
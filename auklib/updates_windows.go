@@ -0,0 +1,65 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+// +build windows
+
+package auklib
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+const (
+	updatePolicyPath = `SOFTWARE\Policies\Microsoft\Windows\WindowsUpdate\AU`
+	// auScheduledInstall is the AUOptions value for automatically
+	// downloading updates and installing them on a schedule.
+	auScheduledInstall = 4
+	// defaultInstallTime is the hour updates are installed at when the
+	// policy does not set ScheduledInstallTime.
+	defaultInstallTime = 3
+)
+
+// OSUpdateHours returns the start and end times of the Windows Update
+// scheduled installation in progress, or else the next one, as configured
+// by policy.
+func OSUpdateHours() (time.Time, time.Time, error) {
+	var t time.Time
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, updatePolicyPath, registry.READ)
+	if err != nil {
+		return t, t, fmt.Errorf("OSUpdateHours: no Windows Update policy: %w", err)
+	}
+	defer k.Close()
+
+	if v, _, err := k.GetIntegerValue("NoAutoUpdate"); err == nil && v == 1 {
+		return t, t, fmt.Errorf("OSUpdateHours: automatic updates are disabled")
+	}
+	opt, _, err := k.GetIntegerValue("AUOptions")
+	if err != nil || opt != auScheduledInstall {
+		return t, t, fmt.Errorf("OSUpdateHours: updates are not installed on a schedule (AUOptions: %d)", opt)
+	}
+	day, _, err := k.GetIntegerValue("ScheduledInstallDay")
+	if err != nil || day > 7 {
+		day = 0
+	}
+	hour, _, err := k.GetIntegerValue("ScheduledInstallTime")
+	if err != nil || hour > 23 {
+		hour = defaultInstallTime
+	}
+	opens, closes := scheduledInstall(int(day), int(hour), time.Now())
+	return opens, closes, nil
+}
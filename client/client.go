@@ -16,11 +16,22 @@
 package client
 
 import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
+	"time"
 
+	"github.com/google/deck"
+	"github.com/google/aukera/auklib"
+	"github.com/google/aukera/schedule"
+	"github.com/google/aukera/signing"
 	"github.com/google/aukera/window"
 )
 
@@ -28,9 +39,86 @@ const (
 	urlBase = "http://localhost"
 )
 
-// Test validates service is available and responding locally.
+// ErrUnavailable is returned when the Aukera service is not responding.
+var ErrUnavailable = errors.New("service not available")
+
+// BypassProxy controls whether requests to loopback addresses ignore proxies
+// configured through HTTP_PROXY and related environment variables. Proxies
+// are rarely able to reach the local service, so they are bypassed unless
+// this is set to false, which defers to http.ProxyFromEnvironment.
+var BypassProxy = true
+
+// deprecationHeader carries the deprecation warnings of a response; see
+// server.DeprecationHeader.
+const deprecationHeader = "X-Aukera-Deprecation"
+
+// OnDeprecation is called with each deprecation warning the service sends in
+// response to a request for url, so that callers relying on deprecated parts
+// of the API are found before support is removed. By default warnings are
+// logged.
+var OnDeprecation = func(url, warning string) {
+	deck.Warningf("aukera: request for %s: %s", url, warning)
+}
+
+// checkDeprecations reports the deprecation warnings of response to
+// OnDeprecation.
+func checkDeprecations(url string, response *http.Response) {
+	if OnDeprecation == nil {
+		return
+	}
+	for _, w := range response.Header.Values(deprecationHeader) {
+		OnDeprecation(url, w)
+	}
+}
+
+// httpClient is used for all requests to the service.
+var httpClient = &http.Client{Transport: newTransport()}
+
+func newTransport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = proxy
+	return t
+}
+
+// proxy selects the proxy for req, bypassing proxies for loopback hosts
+// when BypassProxy is set.
+func proxy(req *http.Request) (*url.URL, error) {
+	if BypassProxy && isLoopback(req.URL.Hostname()) {
+		return nil, nil
+	}
+	return http.ProxyFromEnvironment(req)
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// DefaultSkewTolerance absorbs typical clock drift between a client and the
+// host serving schedules, such as a container querying its VM host.
+const DefaultSkewTolerance = 30 * time.Second
+
+// IsOpen reports whether schedule s is open by the local clock, treating a
+// schedule that opens within skew of now, inclusive, as already open.
+func IsOpen(s window.Schedule, skew time.Duration) bool {
+	return isOpenAt(s, time.Now(), skew)
+}
+
+func isOpenAt(s window.Schedule, now time.Time, skew time.Duration) bool {
+	return !s.Opens.After(now.Add(skew)) && now.Before(s.Closes)
+}
+
+// Test validates service is available and responding locally. The service
+// is probed once, without retrying refusals.
 func Test(url string) bool {
-	response, err := http.Get(fmt.Sprintf("%s/status", url))
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/status", url), nil)
+	if err != nil {
+		return false
+	}
+	response, err := httpClient.Do(req)
 	if err != nil {
 		return false
 	}
@@ -51,23 +139,74 @@ func makeURL(port int, names []string) []string {
 	return urls
 }
 
-// Label gets a window schedule by label name(s).
+// LabelVerified gets a window schedule by label name(s) like Label, and
+// rejects responses not signed by pub, the host signing key.
+func LabelVerified(port int, pub ed25519.PublicKey, names ...string) ([]window.Schedule, error) {
+	port = resolvePort(port)
+	if !Test(fmt.Sprintf("%s:%d", urlBase, port)) {
+		return nil, ErrUnavailable
+	}
+	return readSchedulesVerified(makeURL(port, names), pub)
+}
+
+// Port discovery, replaced in tests to avoid reading platform storage.
+var (
+	fnDiscoverPort   = auklib.DiscoverPort
+	fnConfiguredPort = auklib.ConfiguredPort
+)
+
+// resolvePort returns port, or the port discovered from the running service
+// when port is zero or negative. The configured port, as resolved by
+// auklib.ConfiguredPort, is used if discovery fails.
+func resolvePort(port int) int {
+	if port > 0 {
+		return port
+	}
+	p, err := fnDiscoverPort()
+	if err != nil {
+		return fnConfiguredPort(-1)
+	}
+	return p
+}
+
+// Label gets a window schedule by label name(s). A port of 0 or -1
+// discovers the port of the running service.
 func Label(port int, names ...string) ([]window.Schedule, error) {
+	port = resolvePort(port)
 	if !Test(fmt.Sprintf("%s:%d", urlBase, port)) {
-		return nil, fmt.Errorf("service not available")
+		return nil, ErrUnavailable
 	}
 	urls := makeURL(port, names)
 	return readSchedules(urls)
 }
 
+// readSchedules retrieves schedules from urls. The default transport requests
+// gzip compressed responses and decompresses them transparently.
 func readSchedules(urls []string) ([]window.Schedule, error) {
+	return readSchedulesVerified(urls, nil)
+}
+
+// readSchedulesVerified retrieves schedules from urls, verifying response
+// signatures against pub if it is set.
+func readSchedulesVerified(urls []string, pub ed25519.PublicKey) ([]window.Schedule, error) {
 	var sched []window.Schedule
 	for _, url := range urls {
-		response, err := http.Get(url)
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		response, err := do(req)
 		if err != nil {
 			return nil, err
 		}
 		defer response.Body.Close()
+		checkDeprecations(url, response)
+		if response.StatusCode == http.StatusNotFound {
+			return sched, fmt.Errorf("schedule request failed for url %s: %w", url, window.ErrNoWindows)
+		}
+		if response.StatusCode == http.StatusServiceUnavailable {
+			return sched, fmt.Errorf("schedule request failed for url %s: %w", url, ErrUnavailable)
+		}
 		if response.StatusCode != http.StatusOK {
 			return sched, fmt.Errorf(
 				"schedule request failed for url %s (%d)", url, response.StatusCode)
@@ -76,12 +215,168 @@ func readSchedules(urls []string) ([]window.Schedule, error) {
 		if err != nil {
 			return nil, err
 		}
+		if pub != nil {
+			if err := signing.Verify(pub, j, response.Header.Get(signing.Header)); err != nil {
+				return nil, fmt.Errorf("schedule response from %s: %w", url, err)
+			}
+		}
 
+		if err := checkContentType(response); err != nil {
+			return nil, fmt.Errorf("schedule response from %s: %w", url, err)
+		}
 		var s []window.Schedule
-		if err := json.Unmarshal(j, &s); err != nil {
+		if err := unmarshal(j, &s); err != nil {
 			return nil, err
 		}
 		sched = append(sched, s...)
 	}
 	return sched, nil
 }
+
+// getJSON decodes the JSON response of a GET request for path into v.
+func getJSON(ctx context.Context, port int, path string, v any) error {
+	port = resolvePort(port)
+	url := fmt.Sprintf("%s:%d%s", urlBase, port, path)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	response, err := do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", url, ErrUnavailable)
+	}
+	defer response.Body.Close()
+	checkDeprecations(url, response)
+	if response.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("request failed for url %s: %w", url, window.ErrNoWindows)
+	}
+	if response.StatusCode == http.StatusServiceUnavailable {
+		return fmt.Errorf("request failed for url %s: %w", url, ErrUnavailable)
+	}
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("request failed for url %s (%d)", url, response.StatusCode)
+	}
+	if err := decodeResponse(response, v); err != nil {
+		return fmt.Errorf("response from %s: %w", url, err)
+	}
+	return nil
+}
+
+// getSchedules gets the schedules of the named labels, or of all labels if
+// none are named, with the query string q.
+func getSchedules(ctx context.Context, port int, q string, names []string) ([]window.Schedule, error) {
+	paths := []string{"/schedule" + q}
+	if len(names) > 0 {
+		paths = nil
+		for _, n := range names {
+			paths = append(paths, "/schedule/"+url.PathEscape(n)+q)
+		}
+	}
+	var sched []window.Schedule
+	for _, p := range paths {
+		var s []window.Schedule
+		if err := getJSON(ctx, port, p, &s); err != nil {
+			return sched, err
+		}
+		sched = append(sched, s...)
+	}
+	return sched, nil
+}
+
+// LabelAt gets the window schedule by label name(s) as of t, which may be in
+// the future: the schedule open at t, or else the next to open. Returned
+// schedules are pinned to t: their State and Reason are as of t, which they
+// carry as EvaluatedAt. A port of 0 or -1 discovers the port of the running
+// service.
+func LabelAt(ctx context.Context, port int, t time.Time, names ...string) ([]window.Schedule, error) {
+	q := "?" + url.Values{"at": {t.Format(time.RFC3339)}}.Encode()
+	return getSchedules(ctx, port, q, names)
+}
+
+// Range gets every span of the named labels, or of all labels if none are
+// named, open within [from, to), which may cover at most schedule.MaxRange.
+// A port of 0 or -1 discovers the port of the running service.
+func Range(ctx context.Context, port int, from, to time.Time, names ...string) ([]window.Schedule, error) {
+	q := "?" + url.Values{"from": {from.Format(time.RFC3339)}, "to": {to.Format(time.RFC3339)}}.Encode()
+	return getSchedules(ctx, port, q, names)
+}
+
+// Evaluate evaluates each label at each time of evals in a single request,
+// returning the results in the same order. At most schedule.MaxBatch
+// evaluations may be sent at once. Requests refused with 503 Service
+// Unavailable are retried as for GET requests. A port of 0 or -1 discovers
+// the port of the running service.
+func Evaluate(ctx context.Context, port int, evals []schedule.Evaluation) ([]schedule.Evaluated, error) {
+	body, err := json.Marshal(evals)
+	if err != nil {
+		return nil, err
+	}
+	u := fmt.Sprintf("%s:%d/evaluate", urlBase, resolvePort(port))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// Evaluation has no side effects, so refused requests are replayed.
+	req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+	response, err := doSafe(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", u, ErrUnavailable)
+	}
+	defer response.Body.Close()
+	checkDeprecations(u, response)
+	if response.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		return nil, fmt.Errorf("evaluate request failed for url %s (%d): %s", u, response.StatusCode, bytes.TrimSpace(msg))
+	}
+	var res []schedule.Evaluated
+	if err := decodeResponse(response, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Shared gets the schedules of all labels, with labels whose windows open
+// over the same span, such as a window carrying several labels, sharing one
+// entry. A port of 0 or -1 discovers the port of the running service.
+func Shared(ctx context.Context, port int) ([]schedule.Shared, error) {
+	var s []schedule.Shared
+	if err := getJSON(ctx, port, "/schedule?dedup=true", &s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Labels lists the labels configured on the local host along with the
+// windows that make them up.
+func Labels(ctx context.Context, port int) ([]schedule.Label, error) {
+	var l []schedule.Label
+	if err := getJSON(ctx, port, "/labels", &l); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Windows lists the window definitions configured on the local host.
+func Windows(ctx context.Context, port int) ([]window.Window, error) {
+	return WindowsTagged(ctx, port)
+}
+
+// WindowsTagged lists the window definitions configured on the local host
+// that carry every given tag, each formatted as name=value, or name to match
+// any value. Windows carry the Source reported by the service.
+func WindowsTagged(ctx context.Context, port int, tags ...string) ([]window.Window, error) {
+	path := "/windows"
+	if len(tags) > 0 {
+		path += "?" + url.Values{"tag": tags}.Encode()
+	}
+	var l []window.Listing
+	if err := getJSON(ctx, port, path, &l); err != nil {
+		return nil, err
+	}
+	w := make([]window.Window, 0, len(l))
+	for _, li := range l {
+		w = append(w, li.Window)
+	}
+	return w, nil
+}
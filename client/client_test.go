@@ -15,12 +15,20 @@
 package client
 
 import (
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/google/aukera/auklib"
+	"github.com/google/aukera/signing"
 	"github.com/google/aukera/window"
 	"github.com/google/go-cmp/cmp"
 )
@@ -171,3 +179,224 @@ func TestReadSchedules(t *testing.T) {
 		}
 	}
 }
+
+func TestResolvePort(t *testing.T) {
+	origDiscover, origConfigured := fnDiscoverPort, fnConfiguredPort
+	defer func() { fnDiscoverPort, fnConfiguredPort = origDiscover, origConfigured }()
+	discovered := 0
+	fnDiscoverPort = func() (int, error) {
+		if discovered == 0 {
+			return 0, errors.New("no port discovered")
+		}
+		return discovered, nil
+	}
+	fnConfiguredPort = func(override int) int { return 9119 }
+
+	if got := resolvePort(8080); got != 8080 {
+		t.Errorf("resolvePort(8080) = %d, want 8080", got)
+	}
+	if got := resolvePort(0); got != 9119 {
+		t.Errorf("resolvePort(0) without discovered port = %d, want 9119", got)
+	}
+	discovered = 4242
+	if got := resolvePort(-1); got != 4242 {
+		t.Errorf("resolvePort(-1) = %d, want 4242", got)
+	}
+}
+
+func TestReadSchedulesNotFound(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(dummyServer))
+	defer ts.Close()
+	_, err := readSchedules([]string{ts.URL + "/schedule/missing"})
+	if !errors.Is(err, window.ErrNoWindows) {
+		t.Errorf("readSchedules(missing) returned %v, want %v", err, window.ErrNoWindows)
+	}
+}
+
+func TestReadSchedulesCompressed(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			t.Errorf("request did not accept gzip encoding: %q", r.Header.Get("Accept-Encoding"))
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		defer gz.Close()
+		json.NewEncoder(gz).Encode(&[]window.Schedule{{Name: "Schedule Z"}})
+	}))
+	defer ts.Close()
+
+	s, err := readSchedules([]string{ts.URL + "/schedule"})
+	if err != nil {
+		t.Fatalf("readSchedules() returned error: %v", err)
+	}
+	if len(s) != 1 || s[0].Name != "Schedule Z" {
+		t.Errorf("readSchedules() = %v, want [Schedule Z]", s)
+	}
+}
+
+func TestReadSchedulesDeprecated(t *testing.T) {
+	defer func(fn func(string, string)) { OnDeprecation = fn }(OnDeprecation)
+	var warnings []string
+	OnDeprecation = func(url, warning string) { warnings = append(warnings, warning) }
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add(deprecationHeader, "first")
+		w.Header().Add(deprecationHeader, "second")
+		json.NewEncoder(w).Encode(&[]window.Schedule{{Name: "Schedule Z"}})
+	}))
+	defer ts.Close()
+
+	if _, err := readSchedules([]string{ts.URL + "/schedule"}); err != nil {
+		t.Fatalf("readSchedules() returned error: %v", err)
+	}
+	if want := []string{"first", "second"}; !cmp.Equal(warnings, want) {
+		t.Errorf("readSchedules() reported deprecations %q, want %q", warnings, want)
+	}
+}
+
+func TestReadSchedulesVerified(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := []byte(`[{"Name": "Schedule S", "Duration": "1h"}]`)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sig := signing.Sign(priv, body)
+		if r.URL.Path == "/schedule/forged" {
+			sig = signing.Sign(priv, []byte("something else"))
+		}
+		w.Header().Set(signing.Header, sig)
+		w.Write(body)
+	}))
+	defer ts.Close()
+
+	if _, err := readSchedulesVerified([]string{ts.URL + "/schedule/signed"}, pub); err != nil {
+		t.Errorf("readSchedulesVerified(signed) returned error: %v", err)
+	}
+	if _, err := readSchedulesVerified([]string{ts.URL + "/schedule/forged"}, pub); !errors.Is(err, signing.ErrInvalidSignature) {
+		t.Errorf("readSchedulesVerified(forged) returned %v, want %v", err, signing.ErrInvalidSignature)
+	}
+}
+
+func TestLabelsAndWindows(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/labels":
+			fmt.Fprint(w, `[{"Name":"patch","Windows":["nightly"],"LastQueried":"0001-01-01T00:00:00Z"}]`)
+		case "/windows":
+			fmt.Fprint(w, `[{"Name":"nightly","Format":1,"Schedule":"0 0 2 * * *","Duration":"1h","Labels":["patch"]}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	var port int
+	if _, err := fmt.Sscanf(ts.URL[strings.LastIndex(ts.URL, ":")+1:], "%d", &port); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	l, err := Labels(ctx, port)
+	if err != nil {
+		t.Fatalf("Labels() returned error: %v", err)
+	}
+	if len(l) != 1 || l[0].Name != "patch" || !cmp.Equal(l[0].Windows, []string{"nightly"}) {
+		t.Errorf("Labels() = %+v", l)
+	}
+
+	w, err := Windows(ctx, port)
+	if err != nil {
+		t.Fatalf("Windows() returned error: %v", err)
+	}
+	if len(w) != 1 || w[0].Name != "nightly" || w[0].Duration != time.Hour {
+		t.Errorf("Windows() = %+v", w)
+	}
+
+	ts.Close()
+	if _, err := Labels(ctx, port); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Labels() on stopped server returned %v, want %v", err, ErrUnavailable)
+	}
+}
+
+func TestLabelAt(t *testing.T) {
+	at := time.Date(2030, time.January, 2, 3, 4, 5, 0, time.UTC)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("at"); got != at.Format(time.RFC3339) {
+			t.Errorf("request for %s has at %q, want %q", r.URL.Path, got, at.Format(time.RFC3339))
+		}
+		switch r.URL.Path {
+		case "/schedule/patch", "/schedule/reboot":
+			name := strings.TrimPrefix(r.URL.Path, "/schedule/")
+			fmt.Fprintf(w, `[{"Name":%q,"Duration":"1h","Opens":"2030-01-02T03:00:00Z","Closes":"2030-01-02T04:00:00Z"}]`, name)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	var port int
+	if _, err := fmt.Sscanf(ts.URL[strings.LastIndex(ts.URL, ":")+1:], "%d", &port); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	s, err := LabelAt(ctx, port, at, "patch", "reboot")
+	if err != nil {
+		t.Fatalf("LabelAt() returned error: %v", err)
+	}
+	if len(s) != 2 || s[0].Name != "patch" || s[1].Name != "reboot" || !s[0].Contains(at) {
+		t.Errorf("LabelAt() = %+v", s)
+	}
+	if _, err := LabelAt(ctx, port, at, "unknown"); !errors.Is(err, window.ErrNoWindows) {
+		t.Errorf("LabelAt(unknown) returned %v, want %v", err, window.ErrNoWindows)
+	}
+}
+
+func TestIsOpenSkew(t *testing.T) {
+	now := time.Date(2020, time.January, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		desc  string
+		opens time.Duration
+		skew  time.Duration
+		want  bool
+	}{
+		{"open", -time.Minute, 0, true},
+		{"opens soon without tolerance", 20 * time.Second, 0, false},
+		{"opens now", 0, 0, true},
+		{"opens within tolerance", 20 * time.Second, DefaultSkewTolerance, true},
+		{"opens at tolerance", DefaultSkewTolerance, DefaultSkewTolerance, true},
+		{"opens beyond tolerance", time.Minute, DefaultSkewTolerance, false},
+	}
+	for _, tt := range tests {
+		s := window.Schedule{Opens: now.Add(tt.opens), Closes: now.Add(time.Hour)}
+		if got := isOpenAt(s, now, tt.skew); got != tt.want {
+			t.Errorf("isOpenAt(%s) = %t, want %t", tt.desc, got, tt.want)
+		}
+	}
+	closed := window.Schedule{Opens: now.Add(-2 * time.Hour), Closes: now.Add(-time.Hour)}
+	if IsOpen(closed, DefaultSkewTolerance) {
+		t.Errorf("IsOpen(closed schedule) = true, want false")
+	}
+}
+
+func TestProxyBypass(t *testing.T) {
+	orig := BypassProxy
+	defer func() { BypassProxy = orig }()
+	BypassProxy = true
+	for _, host := range []string{"localhost", "127.0.0.1", "[::1]"} {
+		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("http://%s:%d/status", host, auklib.Defaults.ServicePort), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		u, err := proxy(req)
+		if err != nil || u != nil {
+			t.Errorf("proxy(%s) = (%v, %v), want no proxy", host, u, err)
+		}
+	}
+	for host, want := range map[string]bool{"localhost": true, "127.0.0.2": true, "::1": true, "10.0.0.1": false, "example.com": false} {
+		if got := isLoopback(host); got != want {
+			t.Errorf("isLoopback(%q) = %t, want %t", host, got, want)
+		}
+	}
+	if tr, ok := httpClient.Transport.(*http.Transport); !ok || tr.Proxy == nil {
+		t.Errorf("httpClient does not use the proxy selection transport")
+	}
+}
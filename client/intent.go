@@ -0,0 +1,77 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/aukera/schedule"
+	"github.com/google/aukera/window"
+)
+
+// DeclareIntent registers the intent of agent to perform action, taking about
+// est, in the upcoming window of label, returning the registered intent. A
+// port of 0 or -1 discovers the port of the running service.
+func DeclareIntent(ctx context.Context, port int, label, agent, action string, est time.Duration) (schedule.Intent, error) {
+	body, err := json.Marshal(schedule.Intent{Agent: agent, Action: action, EstimatedDuration: est})
+	if err != nil {
+		return schedule.Intent{}, err
+	}
+	u := fmt.Sprintf("%s:%d/intent/%s", urlBase, resolvePort(port), url.PathEscape(label))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return schedule.Intent{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	response, err := httpClient.Do(req)
+	if err != nil {
+		return schedule.Intent{}, fmt.Errorf("%s: %w", u, ErrUnavailable)
+	}
+	defer response.Body.Close()
+	checkDeprecations(u, response)
+	if response.StatusCode == http.StatusNotFound {
+		return schedule.Intent{}, fmt.Errorf("intent request failed for url %s: %w", u, window.ErrNoWindows)
+	}
+	if response.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		return schedule.Intent{}, fmt.Errorf("intent request failed for url %s (%d): %s", u, response.StatusCode, bytes.TrimSpace(msg))
+	}
+	var i schedule.Intent
+	if err := decodeResponse(response, &i); err != nil {
+		return schedule.Intent{}, err
+	}
+	return i, nil
+}
+
+// Intents lists the intents registered for label, or for all labels if label
+// is empty.
+func Intents(ctx context.Context, port int, label string) ([]schedule.Intent, error) {
+	path := "/intent"
+	if label != "" {
+		path += "?" + url.Values{"label": {label}}.Encode()
+	}
+	var l []schedule.Intent
+	if err := getJSON(ctx, port, path, &l); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
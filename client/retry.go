@@ -0,0 +1,120 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	// MaxRetries is how many times a request refused with 503 Service
+	// Unavailable, such as while the service is overloaded or cannot load
+	// its configuration, is retried. Zero disables retries.
+	MaxRetries = 3
+	// MaxRetryWait bounds the wait before each retry, whatever the service
+	// advises.
+	MaxRetryWait = time.Minute
+	// MaxUnboundedRetryTime bounds the total wait before retries of requests
+	// whose context is never cancelled, such as those of Label, so that
+	// callers without a deadline of their own are not held for minutes.
+	// Requests with a cancellable context are bounded by it instead.
+	MaxUnboundedRetryTime = 5 * time.Second
+)
+
+// defaultRetryWait is the wait before retrying responses without a usable
+// Retry-After header.
+const defaultRetryWait = time.Second
+
+// retryWait returns how long to wait before retrying the request refused
+// with response at now, as advised by its Retry-After header in either delay
+// seconds or HTTP date form, bounded by MaxRetryWait. Up to a fifth of the
+// wait is added at random, so that the retries of many clients refused at
+// once are spread out.
+func retryWait(response *http.Response, now time.Time) time.Duration {
+	d := defaultRetryWait
+	if v := response.Header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+			d = time.Duration(secs) * time.Second
+		} else if t, err := http.ParseTime(v); err == nil {
+			d = t.Sub(now)
+		}
+	}
+	if d < 0 {
+		d = 0
+	}
+	d += time.Duration(rand.Int63n(int64(d)/5 + 1))
+	if d > MaxRetryWait {
+		d = MaxRetryWait
+	}
+	return d
+}
+
+// do sends req, retrying GET and HEAD requests up to MaxRetries times while
+// the service responds 503 Service Unavailable, waiting as advised by
+// retryWait. Other requests are sent once, since the service may have acted
+// on them. The response of the final attempt is returned.
+func do(req *http.Request) (*http.Response, error) {
+	return send(req, req.Method == http.MethodGet || req.Method == http.MethodHead)
+}
+
+// doSafe is like do, but retries req whatever its method, for requests the
+// service never acts on, such as POST /evaluate. Bodies are replayed through
+// req.GetBody; requests with a body that cannot be replayed are sent once.
+func doSafe(req *http.Request) (*http.Response, error) {
+	return send(req, req.Body == nil || req.Body == http.NoBody || req.GetBody != nil)
+}
+
+// send sends req, retrying it as described by do if retry is set. Retries
+// that would wait beyond MaxUnboundedRetryTime in total are given up if the
+// context of req cannot be cancelled.
+func send(req *http.Request, retry bool) (*http.Response, error) {
+	var budget time.Duration = -1
+	if req.Context().Done() == nil {
+		budget = MaxUnboundedRetryTime
+	}
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+		response, err := httpClient.Do(req)
+		if err != nil || response.StatusCode != http.StatusServiceUnavailable || !retry || attempt >= MaxRetries {
+			return response, err
+		}
+		wait := retryWait(response, time.Now())
+		if budget >= 0 {
+			if wait > budget {
+				return response, nil
+			}
+			budget -= wait
+		}
+		io.Copy(io.Discard, response.Body)
+		response.Body.Close()
+		t := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			t.Stop()
+			return nil, req.Context().Err()
+		case <-t.C:
+		}
+	}
+}
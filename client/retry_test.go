@@ -0,0 +1,162 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryWait(t *testing.T) {
+	now := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		retryAfter string
+		min, max   time.Duration
+	}{
+		{"", defaultRetryWait, defaultRetryWait * 6 / 5},
+		{"10", 10 * time.Second, 12 * time.Second},
+		{"0", 0, 0},
+		{now.Add(20 * time.Second).Format(http.TimeFormat), 20 * time.Second, 24 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0, 0},
+		{"3600", MaxRetryWait, MaxRetryWait},
+		{"soon", defaultRetryWait, defaultRetryWait * 6 / 5},
+	}
+	for _, tt := range tests {
+		res := &http.Response{Header: http.Header{}}
+		if tt.retryAfter != "" {
+			res.Header.Set("Retry-After", tt.retryAfter)
+		}
+		if got := retryWait(res, now); got < tt.min || got > tt.max {
+			t.Errorf("retryWait(Retry-After: %q) = %v, want within [%v, %v]", tt.retryAfter, got, tt.min, tt.max)
+		}
+	}
+}
+
+func TestDoRetries(t *testing.T) {
+	var requests, refusals int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if atomic.AddInt32(&refusals, -1) >= 0 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("[]"))
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		desc         string
+		method       string
+		refusals     int32
+		wantCode     int
+		wantRequests int32
+	}{
+		{"recovers", http.MethodGet, 2, http.StatusOK, 3},
+		{"gives up", http.MethodGet, int32(MaxRetries) + 1, http.StatusServiceUnavailable, int32(MaxRetries) + 1},
+		{"not idempotent", http.MethodPost, 1, http.StatusServiceUnavailable, 1},
+	}
+	for _, tt := range tests {
+		atomic.StoreInt32(&requests, 0)
+		atomic.StoreInt32(&refusals, tt.refusals)
+		req, err := http.NewRequest(tt.method, ts.URL+"/schedule", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := do(req)
+		if err != nil {
+			t.Fatalf("%s: do() returned error: %v", tt.desc, err)
+		}
+		res.Body.Close()
+		if n := atomic.LoadInt32(&requests); res.StatusCode != tt.wantCode || n != tt.wantRequests {
+			t.Errorf("%s: do() = %d after %d requests, want %d after %d", tt.desc, res.StatusCode, n, tt.wantCode, tt.wantRequests)
+		}
+	}
+
+	atomic.StoreInt32(&refusals, int32(MaxRetries)+1)
+	if _, err := readSchedules([]string{ts.URL + "/schedule"}); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("readSchedules() while refused returned %v, want %v", err, ErrUnavailable)
+	}
+	atomic.StoreInt32(&refusals, 1)
+	if s, err := readSchedules([]string{ts.URL + "/schedule"}); err != nil || len(s) != 0 {
+		t.Errorf("readSchedules() after one refusal = %v, %v; want no schedules", s, err)
+	}
+}
+
+func TestDoRetryBudget(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	req, err := http.NewRequest(http.MethodGet, ts.URL+"/schedule", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := do(req)
+	if err != nil {
+		t.Fatalf("do() returned error: %v", err)
+	}
+	res.Body.Close()
+	if n := atomic.LoadInt32(&requests); res.StatusCode != http.StatusServiceUnavailable || n != 1 {
+		t.Errorf("do() without a context = %d after %d requests, want 503 after 1", res.StatusCode, n)
+	}
+
+	if Test(ts.URL) {
+		t.Error("Test() of a refusing service = true, want false")
+	}
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Errorf("Test() sent %d requests, want 1", n-1)
+	}
+}
+
+func TestDoSafeReplaysBody(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		if string(b) != "[]" {
+			t.Errorf("request %d carried body %q, want %q", atomic.LoadInt32(&requests)+1, b, "[]")
+		}
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("[]"))
+	}))
+	defer ts.Close()
+
+	req, err := http.NewRequest(http.MethodPost, ts.URL+"/evaluate", strings.NewReader("[]"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := doSafe(req)
+	if err != nil {
+		t.Fatalf("doSafe() returned error: %v", err)
+	}
+	res.Body.Close()
+	if n := atomic.LoadInt32(&requests); res.StatusCode != http.StatusOK || n != 2 {
+		t.Errorf("doSafe() = %d after %d requests, want 200 after 2", res.StatusCode, n)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+)
+
+// StrictDecoding makes the client reject successful responses that do not
+// decode exactly into the expected type: bodies that are not JSON, carry
+// fields the client does not know or data after the JSON value. Such
+// responses otherwise decode as empty or partial results, hiding protocol
+// mismatches between client and service.
+var StrictDecoding = false
+
+// ErrUnexpectedResponse is wrapped by errors returned for responses rejected
+// by StrictDecoding.
+var ErrUnexpectedResponse = errors.New("unexpected response")
+
+// decodeResponse decodes the JSON body of response into v.
+func decodeResponse(response *http.Response, v any) error {
+	if err := checkContentType(response); err != nil {
+		return err
+	}
+	return decodeJSON(response.Body, v)
+}
+
+// checkContentType returns an error wrapping ErrUnexpectedResponse if
+// StrictDecoding is set and response is not JSON.
+func checkContentType(response *http.Response) error {
+	if !StrictDecoding {
+		return nil
+	}
+	ct := response.Header.Get("Content-Type")
+	if mt, _, err := mime.ParseMediaType(ct); err != nil || mt != "application/json" {
+		return fmt.Errorf("%w: content type %q", ErrUnexpectedResponse, ct)
+	}
+	return nil
+}
+
+// decodeJSON decodes the JSON value read from r into v, enforcing
+// StrictDecoding.
+func decodeJSON(r io.Reader, v any) error {
+	d := json.NewDecoder(r)
+	if !StrictDecoding {
+		return d.Decode(v)
+	}
+	d.DisallowUnknownFields()
+	if err := d.Decode(v); err != nil {
+		return fmt.Errorf("%w: %v", ErrUnexpectedResponse, err)
+	}
+	if _, err := d.Token(); err != io.EOF {
+		return fmt.Errorf("%w: data after JSON value", ErrUnexpectedResponse)
+	}
+	return nil
+}
+
+// unmarshal is like json.Unmarshal, enforcing StrictDecoding.
+func unmarshal(b []byte, v any) error {
+	if !StrictDecoding {
+		return json.Unmarshal(b, v)
+	}
+	return decodeJSON(bytes.NewReader(b), v)
+}
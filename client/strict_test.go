@@ -0,0 +1,63 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/aukera/schedule"
+)
+
+func TestStrictDecoding(t *testing.T) {
+	defer func() { StrictDecoding = false }()
+	tests := []struct {
+		desc, contentType, body string
+		// looseErr and strictErr report whether decoding fails without and
+		// with StrictDecoding.
+		looseErr, strictErr bool
+	}{
+		{"valid", "application/json", `[{"Name":"patch","Windows":["nightly"]}]`, false, false},
+		{"charset", "application/json; charset=utf-8", `[]`, false, false},
+		{"text", "text/plain; charset=utf-8", `[]`, false, true},
+		{"trailing error", "application/json", "[]\nno windows found", false, true},
+		{"unknown field", "application/json", `[{"Name":"patch","Owner":"ops"}]`, false, true},
+		{"not JSON", "application/json", `no windows found`, true, true},
+	}
+	for _, strict := range []bool{false, true} {
+		StrictDecoding = strict
+		for _, tt := range tests {
+			res := &http.Response{
+				Header: http.Header{"Content-Type": {tt.contentType}},
+				Body:   io.NopCloser(strings.NewReader(tt.body)),
+			}
+			var l []schedule.Label
+			err := decodeResponse(res, &l)
+			wantErr := tt.looseErr
+			if strict {
+				wantErr = tt.strictErr
+			}
+			if (err != nil) != wantErr {
+				t.Errorf("decodeResponse(%s) with StrictDecoding=%t returned %v, want error: %t", tt.desc, strict, err, wantErr)
+			}
+			if strict && wantErr && !errors.Is(err, ErrUnexpectedResponse) {
+				t.Errorf("decodeResponse(%s) with StrictDecoding returned %v, want %v", tt.desc, err, ErrUnexpectedResponse)
+			}
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/aukera/window"
+)
+
+// Reconnection delays used by Watch after a stream fails.
+var (
+	watchMinRetry = time.Second
+	watchMaxRetry = 30 * time.Second
+)
+
+// Watch streams schedule updates for label, or all labels if label is empty,
+// from the service's /events endpoint. The current schedule is delivered
+// first, followed by each change. Failed or interrupted streams are
+// reconnected with backoff, and only schedules that changed while
+// disconnected are delivered on resync. The channel is closed once ctx is done.
+func Watch(ctx context.Context, port int, label string) <-chan window.Schedule {
+	out := make(chan window.Schedule)
+	go func() {
+		defer close(out)
+		last := make(map[string]window.Schedule)
+		retry := watchMinRetry
+		for {
+			delivered, _ := watchOnce(ctx, resolvePort(port), label, last, out)
+			if delivered {
+				retry = watchMinRetry
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(retry):
+			}
+			if retry *= 2; retry > watchMaxRetry {
+				retry = watchMaxRetry
+			}
+		}
+	}()
+	return out
+}
+
+// watchOnce consumes a single event stream until it ends, sending schedules
+// that differ from last to out. It reports whether the stream was established.
+func watchOnce(ctx context.Context, port int, label string, last map[string]window.Schedule, out chan<- window.Schedule) (bool, error) {
+	u := fmt.Sprintf("%s:%d/events", urlBase, port)
+	if label != "" {
+		u += "?label=" + url.QueryEscape(label)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	response, err := do(req)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", u, ErrUnavailable)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("event request failed for url %s (%d)", u, response.StatusCode)
+	}
+	return true, readEvents(ctx, response.Body, func(event string, data []byte) {
+		if event != "schedule" {
+			return
+		}
+		var s window.Schedule
+		if err := json.Unmarshal(data, &s); err != nil {
+			return
+		}
+		if old, ok := last[s.Name]; ok && old.State == s.State && old.Opens.Equal(s.Opens) && old.Closes.Equal(s.Closes) {
+			return
+		}
+		select {
+		case out <- s:
+			last[s.Name] = s
+		case <-ctx.Done():
+		}
+	})
+}
+
+// readEvents parses a server-sent event stream from r, calling fn with the
+// type and data of each event.
+func readEvents(ctx context.Context, r io.Reader, fn func(event string, data []byte)) error {
+	var (
+		event string
+		data  []string
+	)
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		line := sc.Text()
+		switch {
+		case line == "":
+			if len(data) > 0 {
+				if event == "" {
+					event = "message"
+				}
+				fn(event, []byte(strings.Join(data, "\n")))
+			}
+			event, data = "", nil
+		case strings.HasPrefix(line, ":"):
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	return sc.Err()
+}
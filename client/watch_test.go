@@ -0,0 +1,89 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReadEvents(t *testing.T) {
+	stream := ": keepalive\n\nevent: schedule\ndata: {\"a\":1}\n\ndata: one\ndata: two\n\nevent: empty\n\n"
+	var got []string
+	err := readEvents(context.Background(), strings.NewReader(stream), func(event string, data []byte) {
+		got = append(got, event+"="+string(data))
+	})
+	if err != nil {
+		t.Fatalf("readEvents() returned error: %v", err)
+	}
+	want := []string{`schedule={"a":1}`, "message=one\ntwo"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("readEvents() = %q, want %q", got, want)
+	}
+}
+
+func TestWatchReconnects(t *testing.T) {
+	origMin := watchMinRetry
+	defer func() { watchMinRetry = origMin }()
+	watchMinRetry = 10 * time.Millisecond
+
+	closed := `{"Name":"patch","State":"closed","Duration":"1h0m0s","Opens":"2020-01-01T02:00:00Z","Closes":"2020-01-01T03:00:00Z"}`
+	open := `{"Name":"patch","State":"open","Duration":"1h0m0s","Opens":"2020-01-01T02:00:00Z","Closes":"2020-01-01T03:00:00Z"}`
+	var conns int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/events" || r.URL.Query().Get("label") != "patch" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		// The first stream ends after the initial schedule. The reconnected
+		// stream resends it before the change.
+		fmt.Fprintf(w, "event: schedule\ndata: %s\n\n", closed)
+		if atomic.AddInt32(&conns, 1) > 1 {
+			fmt.Fprintf(w, "event: schedule\ndata: %s\n\n", open)
+		}
+	}))
+	defer ts.Close()
+	var port int
+	if _, err := fmt.Sscanf(ts.URL[strings.LastIndex(ts.URL, ":")+1:], "%d", &port); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ch := Watch(ctx, port, "patch")
+	for _, want := range []string{"closed", "open"} {
+		select {
+		case s := <-ch:
+			if s.Name != "patch" || s.State != want {
+				t.Errorf("Watch() delivered %v, want state %s", s, want)
+			}
+		case <-ctx.Done():
+			t.Fatalf("Watch() timed out waiting for %s schedule", want)
+		}
+	}
+	if n := atomic.LoadInt32(&conns); n < 2 {
+		t.Errorf("Watch() connected %d times, want at least 2", n)
+	}
+	cancel()
+	for range ch {
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The aukera-osquery binary is an osquery extension exposing the
+// aukera_labels and aukera_windows tables, backed by the local Aukera
+// service. osquery starts it with the flags below; for example, list the
+// binary, named with a .ext suffix, in osquery's extensions.load file.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/google/deck/backends/logger"
+	"github.com/google/deck"
+	"github.com/google/aukera/logsink"
+	"github.com/google/aukera/osquery"
+)
+
+var (
+	socket   = flag.String("socket", "", `Path of the osquery extension manager socket, or on Windows its named pipe, such as \\.\pipe\shell.em.`)
+	timeout  = flag.Int("timeout", 3, "Seconds to wait for the extension manager to become available.")
+	interval = flag.Int("interval", 3, "Seconds between checks that osquery is still running.")
+	verbose  = flag.Bool("verbose", false, "Log informational messages.")
+	port     = flag.Int("port", 0, "Port of the Aukera service. 0 discovers the port of the running service.")
+)
+
+func main() {
+	flag.Parse()
+	level := deck.WARNING
+	if *verbose {
+		level = deck.INFO
+	}
+	deck.Add(logsink.Filter(logger.Init(os.Stderr, 0), level))
+	defer deck.Close()
+	if *socket == "" {
+		fmt.Fprintln(os.Stderr, "-socket is required")
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ext := &osquery.Extension{Name: "aukera", Version: "1.0.0", Tables: osquery.Tables(*port)}
+	err := ext.Serve(ctx, *socket, time.Duration(*timeout)*time.Second, time.Duration(*interval)*time.Second)
+	if err != nil && ctx.Err() == nil {
+		deck.Error(err)
+		deck.Close()
+		os.Exit(1)
+	}
+}
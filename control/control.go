@@ -0,0 +1,270 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package control provides a local control channel for administrative
+// commands, such as reloading configuration, separate from the HTTP query
+// API used by unprivileged agents. Commands are exchanged as JSON over a
+// Unix domain socket that, on Linux, only the service's own user, or root,
+// can use.
+package control
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/deck"
+	"github.com/google/aukera/auklib"
+)
+
+// SocketPath is the location of the control socket.
+var SocketPath = filepath.Join(auklib.DataDir, "control.sock")
+
+// DefaultSocketPath returns the control socket to serve unless configured
+// otherwise: SocketPath on platforms where the credentials of peers are
+// checked, and none elsewhere, where the socket would admit any local user.
+func DefaultSocketPath() string {
+	if !peerCredentials {
+		return ""
+	}
+	return SocketPath
+}
+
+// ErrNoPeerCredentials is returned by Check on platforms where the
+// credentials of peers cannot be checked.
+var ErrNoPeerCredentials = errors.New("the control socket cannot check the credentials of its peers on this platform")
+
+// Check returns ErrNoPeerCredentials unless the control socket can be served
+// safely on this platform, admitting only root and the service's own user.
+func Check() error {
+	if !peerCredentials {
+		return ErrNoPeerCredentials
+	}
+	return nil
+}
+
+// ErrUnknownCommand is returned for commands without a handler.
+var ErrUnknownCommand = errors.New("unknown command")
+
+// maxRequestBytes bounds the size of a single request.
+const maxRequestBytes = 1 << 20
+
+// requestTimeout bounds the time taken to read a request and send its
+// response.
+const requestTimeout = 30 * time.Second
+
+// Request is a command sent over the control socket.
+type Request struct {
+	Command string
+	Args    json.RawMessage `json:",omitempty"`
+}
+
+// Response is the outcome of a Request.
+type Response struct {
+	OK     bool
+	Error  string          `json:",omitempty"`
+	Result json.RawMessage `json:",omitempty"`
+}
+
+// Handler executes a command with the given arguments, which are empty if
+// none were sent, returning a result marshalled into the Response.
+type Handler func(ctx context.Context, args json.RawMessage) (any, error)
+
+// Server dispatches requests received over the control socket to handlers.
+type Server struct {
+	mu       sync.RWMutex
+	handlers map[string]Handler
+}
+
+// Handle registers h as the handler of command.
+func (s *Server) Handle(command string, h Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.handlers == nil {
+		s.handlers = make(map[string]Handler)
+	}
+	s.handlers[command] = h
+}
+
+// Commands returns the registered commands in order.
+func (s *Server) Commands() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []string
+	for c := range s.handlers {
+		out = append(out, c)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Listen creates the control socket at path, replacing a socket left behind
+// by a previous run, with permissions restricting it to the current user.
+func Listen(path string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
+
+// Serve handles connections accepted from ln until it is closed. Each
+// connection carries a single request and its response.
+func (s *Server) Serve(ln net.Listener) error {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go s.serveConn(conn)
+	}
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(requestTimeout))
+	if err := peerAllowed(conn); err != nil {
+		deck.Warningf("control: rejected connection: %v", err)
+		writeResponse(conn, Response{Error: err.Error()})
+		return
+	}
+	var req Request
+	line, err := bufio.NewReader(&limitedReader{conn, maxRequestBytes}).ReadBytes('\n')
+	if err == nil || len(line) > 0 {
+		err = json.Unmarshal(line, &req)
+	}
+	if err != nil {
+		writeResponse(conn, Response{Error: fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	writeResponse(conn, s.dispatch(ctx, req))
+}
+
+// dispatch executes req, logging it for audit.
+func (s *Server) dispatch(ctx context.Context, req Request) Response {
+	s.mu.RLock()
+	h, ok := s.handlers[req.Command]
+	s.mu.RUnlock()
+	if !ok {
+		return Response{Error: fmt.Sprintf("%v %q: available commands are %s", ErrUnknownCommand, req.Command, strings.Join(s.Commands(), ", "))}
+	}
+	deck.Infof("control: %s %s", req.Command, req.Args)
+	v, err := h(ctx, req.Args)
+	if err != nil {
+		deck.Warningf("control: %s failed: %v", req.Command, err)
+		return Response{Error: err.Error()}
+	}
+	res := Response{OK: true}
+	if v != nil {
+		if res.Result, err = json.Marshal(v); err != nil {
+			return Response{Error: err.Error()}
+		}
+	}
+	return res
+}
+
+func writeResponse(conn net.Conn, r Response) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		deck.Errorf("control: unable to marshal response: %v", err)
+		return
+	}
+	if _, err := conn.Write(append(b, '\n')); err != nil {
+		deck.Warningf("control: unable to write response: %v", err)
+	}
+}
+
+// limitedReader fails reads past n bytes, rather than silently truncating
+// oversized requests.
+type limitedReader struct {
+	r net.Conn
+	n int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		return 0, fmt.Errorf("request exceeds %d bytes", maxRequestBytes)
+	}
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	return n, err
+}
+
+// Call sends command with args, which may be nil, to the control socket at
+// path, decoding the result into result if it is not nil. Commands that
+// fail return an error with the reason given by the service.
+func Call(ctx context.Context, path, command string, args, result any) error {
+	req := Request{Command: command}
+	if args != nil {
+		b, err := json.Marshal(args)
+		if err != nil {
+			return err
+		}
+		req.Args = b
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", path)
+	if err != nil {
+		return fmt.Errorf("control socket %s: %w", path, err)
+	}
+	defer conn.Close()
+	if dl, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(dl)
+	}
+	b, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	if _, err := conn.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	var res Response
+	if err := json.NewDecoder(conn).Decode(&res); err != nil {
+		return fmt.Errorf("control socket %s: %w", path, err)
+	}
+	if !res.OK {
+		return fmt.Errorf("%s: %s", command, res.Error)
+	}
+	if result != nil && len(res.Result) > 0 {
+		return json.Unmarshal(res.Result, result)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package control
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCall(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "control.sock")
+	ln, err := Listen(path)
+	if err != nil {
+		t.Fatalf("Listen(%q) returned error: %v", path, err)
+	}
+	defer ln.Close()
+	s := &Server{}
+	s.Handle("echo", func(ctx context.Context, args json.RawMessage) (any, error) {
+		var v map[string]string
+		if err := json.Unmarshal(args, &v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	})
+	s.Handle("fail", func(ctx context.Context, args json.RawMessage) (any, error) {
+		return nil, errors.New("no can do")
+	})
+	go s.Serve(ln)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var got map[string]string
+	if err := Call(ctx, path, "echo", map[string]string{"k": "v"}, &got); err != nil {
+		t.Fatalf("Call(echo) returned error: %v", err)
+	}
+	if got["k"] != "v" {
+		t.Errorf("Call(echo) = %v, want map[k:v]", got)
+	}
+
+	tests := []struct {
+		command, wantErr string
+	}{
+		{"fail", "fail: no can do"},
+		{"missing", `unknown command "missing": available commands are echo, fail`},
+	}
+	for _, tt := range tests {
+		err := Call(ctx, path, tt.command, nil, nil)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("Call(%s) = %v, want error containing %q", tt.command, err, tt.wantErr)
+		}
+	}
+}
+
+func TestListenReplacesStaleSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "control.sock")
+	ln, err := Listen(path)
+	if err != nil {
+		t.Fatalf("Listen(%q) returned error: %v", path, err)
+	}
+	// Closing a Unix listener removes its socket; leave it behind instead,
+	// as a crashed process would.
+	if ul, ok := ln.(interface{ SetUnlinkOnClose(bool) }); ok {
+		ul.SetUnlinkOnClose(false)
+	}
+	ln.Close()
+	ln, err = Listen(path)
+	if err != nil {
+		t.Fatalf("Listen(%q) over a stale socket returned error: %v", path, err)
+	}
+	ln.Close()
+}
+
+func TestCheck(t *testing.T) {
+	err := Check()
+	if peerCredentials && err != nil {
+		t.Errorf("Check() = %v, want nil", err)
+	}
+	if !peerCredentials && !errors.Is(err, ErrNoPeerCredentials) {
+		t.Errorf("Check() = %v without peer credentials, want %v", err, ErrNoPeerCredentials)
+	}
+}
+
+func TestDefaultSocketPath(t *testing.T) {
+	got := DefaultSocketPath()
+	if peerCredentials && got != SocketPath {
+		t.Errorf("DefaultSocketPath() = %q, want %q", got, SocketPath)
+	}
+	if !peerCredentials && got != "" {
+		t.Errorf("DefaultSocketPath() = %q without peer credentials, want it disabled", got)
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !linux
+// +build !linux
+
+package control
+
+import (
+	"net"
+)
+
+// peerCredentials reports whether peerAllowed can identify the peer. It
+// cannot here, so the control socket is disabled by default.
+const peerCredentials = false
+
+// peerAllowed admits every peer: access is restricted only by the
+// permissions of the socket file and of the directory holding it, which
+// are not enforced on every platform.
+func peerAllowed(conn net.Conn) error {
+	return nil
+}
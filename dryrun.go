@@ -0,0 +1,61 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/deck"
+	"github.com/google/aukera/schedule"
+)
+
+// runDryRun loads the configuration as by loadConfig, then logs and writes
+// to w the next schedule of every label along with any configuration errors.
+// It returns an error if no valid windows are configured, so that
+// provisioning pipelines can fail early.
+func runDryRun(w io.Writer) error {
+	cfg, err := loadConfig()
+	for _, c := range cfg.Checks {
+		for _, e := range c.Errors {
+			deck.Errorf("%s: %s", c.Path, e)
+			fmt.Fprintf(w, "error: %s: %s\n", c.Path, e)
+		}
+		for _, e := range c.Warnings {
+			deck.Warningf("%s: %s", c.Path, e)
+			fmt.Fprintf(w, "warning: %s: %s\n", c.Path, e)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("dry run: %v", err)
+	}
+	s, err := schedule.Query(schedule.Options{})
+	if err != nil {
+		return fmt.Errorf("dry run: %v", err)
+	}
+	sort.Slice(s, func(i, j int) bool { return s[i].Name < s[j].Name })
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "LABEL\tSTATE\tOPENS\tCLOSES")
+	for _, sch := range s {
+		opens, closes := sch.Opens.Format(time.RFC3339), sch.Closes.Format(time.RFC3339)
+		deck.Infof("Label %q is %s, opening %s and closing %s.", sch.Name, sch.CurrentState(), opens, closes)
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", sch.Name, sch.CurrentState(), opens, closes)
+	}
+	return tw.Flush()
+}
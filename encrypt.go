@@ -0,0 +1,85 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/google/aukera/window"
+)
+
+// ensureConfigKey creates a random window.ConfigKeyPath if none exists.
+func ensureConfigKey() error {
+	if _, err := os.Stat(window.ConfigKeyPath); !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(window.ConfigKeyPath), 0755); err != nil {
+		return err
+	}
+	fmt.Printf("Generated configuration key %s\n", window.ConfigKeyPath)
+	return os.WriteFile(window.ConfigKeyPath, key, 0600)
+}
+
+// runEncrypt implements the encrypt-config subcommand, replacing a plaintext
+// configuration file with its encrypted form.
+func runEncrypt(args []string) error {
+	fs := flag.NewFlagSet("encrypt-config", flag.ContinueOnError)
+	scheme := fs.String("scheme", defaultScheme, "Encryption scheme: aes256-gcm or dpapi")
+	out := fs.String("out", "", "Output file path (default: overwrite the input file)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("encrypt-config: expected a single configuration file")
+	}
+	in := fs.Arg(0)
+	b, err := os.ReadFile(in)
+	if err != nil {
+		return fmt.Errorf("encrypt-config: %v", err)
+	}
+	// Refuse to encrypt anything the service would not be able to load.
+	var conf struct{ Windows []window.Window }
+	if err := json.Unmarshal(b, &conf); err != nil {
+		return fmt.Errorf("encrypt-config: %s: %v", in, err)
+	}
+	if *scheme == window.SchemeAESGCM {
+		if err := ensureConfigKey(); err != nil {
+			return fmt.Errorf("encrypt-config: %v", err)
+		}
+	}
+	enc, err := window.EncryptConfig(b, *scheme)
+	if err != nil {
+		return fmt.Errorf("encrypt-config: %v", err)
+	}
+	path := *out
+	if path == "" {
+		path = in
+	}
+	if err := os.WriteFile(path, append(enc, '\n'), 0644); err != nil {
+		return fmt.Errorf("encrypt-config: %v", err)
+	}
+	fmt.Printf("Wrote encrypted configuration to %s\n", path)
+	return nil
+}
@@ -0,0 +1,73 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/aukera/export"
+	"github.com/google/aukera/schedule"
+	"github.com/google/aukera/window"
+)
+
+// runExport implements the export subcommand, writing the schedules of all
+// labels over the coming days without involving the HTTP service.
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := fs.String("format", "json", "Export format: json, csv or ics")
+	days := fs.Int("days", 7, "Number of days of schedules to export")
+	out := fs.String("out", "", "Output file path (default: stdout)")
+	mode := fs.String("mode", "", "Aggregation of overlapping windows: merge, or a comma-separated list of conservative and adjacent")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *days <= 0 {
+		return fmt.Errorf("export: days must be positive (found: %d)", *days)
+	}
+	// The format is checked before -out is truncated, so that a typo does
+	// not destroy a previous export.
+	if err := export.CheckFormat(*format); err != nil {
+		return fmt.Errorf("export: %v", err)
+	}
+	a, err := window.ParseAggregation(*mode)
+	if err != nil {
+		return fmt.Errorf("export: %v", err)
+	}
+
+	now := time.Now()
+	s, err := schedule.Occurrences(now, now.AddDate(0, 0, *days), schedule.Options{Aggregation: a})
+	if err != nil {
+		return fmt.Errorf("export: %v", err)
+	}
+
+	if *out == "" {
+		return export.Write(os.Stdout, *format, s)
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return fmt.Errorf("export: %v", err)
+	}
+	if err := export.Write(f, *format, s); err != nil {
+		f.Close()
+		return fmt.Errorf("export: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("export: %v", err)
+	}
+	return nil
+}
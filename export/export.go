@@ -0,0 +1,116 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package export renders computed schedules to offline file formats.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/aukera/window"
+)
+
+// Formats lists the supported export formats.
+var Formats = []string{"json", "csv", "ics"}
+
+// CheckFormat returns an error if format is not one of Formats.
+func CheckFormat(format string) error {
+	for _, f := range Formats {
+		if strings.EqualFold(f, format) {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported export format %q (supported: %s)", format, strings.Join(Formats, ", "))
+}
+
+// Write renders schedules to w in the given format.
+func Write(w io.Writer, format string, schedules []window.Schedule) error {
+	switch strings.ToLower(format) {
+	case "json":
+		return writeJSON(w, schedules)
+	case "csv":
+		return writeCSV(w, schedules)
+	case "ics":
+		return writeICS(w, schedules, time.Now())
+	}
+	return CheckFormat(format)
+}
+
+func writeJSON(w io.Writer, schedules []window.Schedule) error {
+	if schedules == nil {
+		schedules = []window.Schedule{}
+	}
+	b, err := json.MarshalIndent(&schedules, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+func writeCSV(w io.Writer, schedules []window.Schedule) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"Name", "Opens", "Closes", "Duration"}); err != nil {
+		return err
+	}
+	for _, s := range schedules {
+		if err := cw.Write([]string{
+			s.Name,
+			s.Opens.Format(time.RFC3339),
+			s.Closes.Format(time.RFC3339),
+			s.Duration.String(),
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+const icsTime = "20060102T150405Z"
+
+// icsEscape escapes text values per RFC 5545.
+func icsEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+func writeICS(w io.Writer, schedules []window.Schedule, now time.Time) error {
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Google//Aukera//EN",
+	}
+	for _, s := range schedules {
+		h := fnv.New64a()
+		fmt.Fprintf(h, "%s|%d", s.Name, s.Opens.Unix())
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			fmt.Sprintf("UID:%x@aukera", h.Sum64()),
+			"DTSTAMP:"+now.UTC().Format(icsTime),
+			"DTSTART:"+s.Opens.UTC().Format(icsTime),
+			"DTEND:"+s.Closes.UTC().Format(icsTime),
+			"SUMMARY:"+icsEscape(fmt.Sprintf("Maintenance window: %s", s.Name)),
+			"END:VEVENT",
+		)
+	}
+	lines = append(lines, "END:VCALENDAR")
+	_, err := io.WriteString(w, strings.Join(lines, "\r\n")+"\r\n")
+	return err
+}
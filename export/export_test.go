@@ -0,0 +1,143 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/google/aukera/window"
+)
+
+func TestWrite(t *testing.T) {
+	opens := time.Date(2020, time.January, 1, 2, 0, 0, 0, time.UTC)
+	schedules := []window.Schedule{{
+		Name:     "patch, weekly",
+		State:    "closed",
+		Opens:    opens,
+		Closes:   opens.Add(time.Hour),
+		Duration: time.Hour,
+	}}
+	tests := []struct {
+		format  string
+		want    []string
+		wantErr bool
+	}{
+		{"json", []string{`"Name": "patch, weekly"`, `"Duration": "1h0m0s"`}, false},
+		{"CSV", []string{"Name,Opens,Closes,Duration", `"patch, weekly",2020-01-01T02:00:00Z,2020-01-01T03:00:00Z,1h0m0s`}, false},
+		{"ics", []string{"BEGIN:VEVENT", "DTSTART:20200101T020000Z", "DTEND:20200101T030000Z", `SUMMARY:Maintenance window: patch\, weekly`}, false},
+		{"yaml", nil, true},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		err := Write(&buf, tt.format, schedules)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Write(%s) returned error %v, want error: %t", tt.format, err, tt.wantErr)
+		}
+		for _, w := range tt.want {
+			if !strings.Contains(buf.String(), w) {
+				t.Errorf("Write(%s) output missing %q:\n%s", tt.format, w, buf.String())
+			}
+		}
+	}
+}
+
+// decodeUTF16 decodes little-endian UTF-16 with a byte order mark.
+func decodeUTF16(t *testing.T, b []byte) string {
+	t.Helper()
+	if len(b) < 2 || b[0] != 0xFF || b[1] != 0xFE || len(b)%2 != 0 {
+		t.Fatalf("output is not UTF-16LE with a byte order mark: % x", b[:4])
+	}
+	units := make([]uint16, 0, len(b)/2-1)
+	for i := 2; i < len(b); i += 2 {
+		units = append(units, binary.LittleEndian.Uint16(b[i:]))
+	}
+	return string(utf16.Decode(units))
+}
+
+func TestCheckFormat(t *testing.T) {
+	for _, f := range []string{"json", "CSV", "ics"} {
+		if err := CheckFormat(f); err != nil {
+			t.Errorf("CheckFormat(%q) = %v, want nil", f, err)
+		}
+	}
+	if err := CheckFormat("jsno"); err == nil {
+		t.Error("CheckFormat(jsno) = nil, want error")
+	}
+}
+
+func TestWriteTask(t *testing.T) {
+	opens := time.Date(2020, time.January, 1, 2, 0, 0, 0, time.UTC)
+	task := Task{
+		Description: "Patch & reboot",
+		Command:     `C:\Program Files\Patch\patch.exe`,
+		Arguments:   "/quiet",
+		Schedules: []window.Schedule{
+			{Name: "patch", Opens: opens, Closes: opens.Add(90 * time.Minute)},
+			{Name: "patch", Opens: opens.AddDate(0, 0, 1), Closes: opens.AddDate(0, 0, 1).Add(time.Hour)},
+		},
+	}
+	var buf bytes.Buffer
+	if err := WriteTask(&buf, task); err != nil {
+		t.Fatalf("WriteTask() returned error: %v", err)
+	}
+	got := decodeUTF16(t, buf.Bytes())
+	for _, want := range []string{
+		`<?xml version="1.0" encoding="UTF-16"?>`,
+		`<Description>Patch &amp; reboot</Description>`,
+		"<StartBoundary>2020-01-01T02:00:00Z</StartBoundary>",
+		"<EndBoundary>2020-01-01T03:30:00Z</EndBoundary>",
+		"<ExecutionTimeLimit>PT1H30M</ExecutionTimeLimit>",
+		"<StartBoundary>2020-01-02T02:00:00Z</StartBoundary>",
+		"<ExecutionTimeLimit>PT1H</ExecutionTimeLimit>",
+		`<Command>C:\Program Files\Patch\patch.exe</Command>`,
+		"<Arguments>/quiet</Arguments>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("WriteTask() output missing %q:\n%s", want, got)
+		}
+	}
+	if err := xml.Unmarshal([]byte(strings.Replace(got, `encoding="UTF-16"`, `encoding="UTF-8"`, 1)), new(struct{})); err != nil {
+		t.Errorf("WriteTask() output is not well-formed XML: %v", err)
+	}
+
+	task.Command = ""
+	if err := WriteTask(&buf, task); err == nil {
+		t.Errorf("WriteTask() without a command returned nil error")
+	}
+	task.Command = "patch.exe"
+	task.Schedules = make([]window.Schedule, MaxTaskTriggers+1)
+	if err := WriteTask(&buf, task); err == nil {
+		t.Errorf("WriteTask() with %d schedules returned nil error", len(task.Schedules))
+	}
+}
+
+func TestISODuration(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		0:                                "PT0S",
+		45 * time.Second:                 "PT45S",
+		time.Hour:                        "PT1H",
+		26*time.Hour + 5*time.Minute + 1: "PT26H5M",
+	} {
+		if got := isoDuration(d); got != want {
+			t.Errorf("isoDuration(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+	"unicode/utf16"
+
+	"github.com/google/aukera/window"
+)
+
+// MaxTaskTriggers is the number of triggers Task Scheduler accepts per task.
+const MaxTaskTriggers = 48
+
+// Task describes a Windows Task Scheduler task running Command whenever the
+// windows of a label open.
+type Task struct {
+	Description string
+	// Command and Arguments are the program run and its command line.
+	Command, Arguments string
+	// Schedules are the occurrences of the label, each becoming a trigger
+	// that starts the task when the window opens and stops it when the
+	// window closes.
+	Schedules []window.Schedule
+}
+
+type taskXML struct {
+	XMLName     xml.Name      `xml:"Task"`
+	Version     string        `xml:"version,attr"`
+	Namespace   string        `xml:"xmlns,attr"`
+	Description string        `xml:"RegistrationInfo>Description,omitempty"`
+	Source      string        `xml:"RegistrationInfo>Source"`
+	Triggers    []timeTrigger `xml:"Triggers>TimeTrigger"`
+	Principal   principal     `xml:"Principals>Principal"`
+	Settings    taskSettings  `xml:"Settings"`
+	Actions     taskActions   `xml:"Actions"`
+}
+
+type timeTrigger struct {
+	StartBoundary      string
+	EndBoundary        string
+	ExecutionTimeLimit string
+	Enabled            bool
+}
+
+type principal struct {
+	ID       string `xml:"id,attr"`
+	UserID   string `xml:"UserId"`
+	RunLevel string
+}
+
+type taskSettings struct {
+	MultipleInstancesPolicy    string
+	DisallowStartIfOnBatteries bool
+	StopIfGoingOnBatteries     bool
+	StartWhenAvailable         bool
+	Enabled                    bool
+}
+
+type taskActions struct {
+	Context string `xml:",attr"`
+	Exec    taskExec
+}
+
+type taskExec struct {
+	Command   string
+	Arguments string `xml:",omitempty"`
+}
+
+// isoDuration formats d as an XML Schema duration, such as PT1H30M.
+func isoDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	h, m, s := int64(d/time.Hour), int64(d%time.Hour/time.Minute), int64(d%time.Minute/time.Second)
+	out := "PT"
+	if h > 0 {
+		out += fmt.Sprintf("%dH", h)
+	}
+	if m > 0 {
+		out += fmt.Sprintf("%dM", m)
+	}
+	if s > 0 || out == "PT" {
+		out += fmt.Sprintf("%dS", s)
+	}
+	return out
+}
+
+// WriteTask renders t to w as Task Scheduler XML, as accepted by
+// schtasks /Create /XML, encoded in UTF-16 like the files Task Scheduler
+// exports. The task runs as SYSTEM and only while a window is open; a
+// window missed while the host was off is not made up. At most
+// MaxTaskTriggers schedules are accepted.
+func WriteTask(w io.Writer, t Task) error {
+	if t.Command == "" {
+		return fmt.Errorf("WriteTask: no command")
+	}
+	if len(t.Schedules) > MaxTaskTriggers {
+		return fmt.Errorf("WriteTask: %d schedules exceed the limit of %d triggers per task", len(t.Schedules), MaxTaskTriggers)
+	}
+	x := taskXML{
+		Version:     "1.2",
+		Namespace:   "http://schemas.microsoft.com/windows/2004/02/mit/task",
+		Description: t.Description,
+		Source:      "Aukera",
+		Principal:   principal{ID: "Author", UserID: "S-1-5-18", RunLevel: "HighestAvailable"},
+		Settings:    taskSettings{MultipleInstancesPolicy: "IgnoreNew", Enabled: true},
+		Actions:     taskActions{Context: "Author", Exec: taskExec{Command: t.Command, Arguments: t.Arguments}},
+	}
+	for _, s := range t.Schedules {
+		x.Triggers = append(x.Triggers, timeTrigger{
+			StartBoundary:      s.Opens.Format(time.RFC3339),
+			EndBoundary:        s.Closes.Format(time.RFC3339),
+			ExecutionTimeLimit: isoDuration(s.Closes.Sub(s.Opens)),
+			Enabled:            true,
+		})
+	}
+	b, err := xml.MarshalIndent(&x, "", "  ")
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-16"?>` + "\r\n")
+	buf.Write(bytes.ReplaceAll(b, []byte("\n"), []byte("\r\n")))
+	buf.WriteString("\r\n")
+	units := utf16.Encode([]rune(buf.String()))
+	out := make([]byte, 2+2*len(units))
+	binary.LittleEndian.PutUint16(out, 0xFEFF)
+	for i, u := range units {
+		binary.LittleEndian.PutUint16(out[2+2*i:], u)
+	}
+	_, err = w.Write(out)
+	return err
+}
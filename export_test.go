@@ -0,0 +1,34 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunExportUnknownFormat(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "export.json")
+	if err := os.WriteFile(out, []byte("previous"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := runExport([]string{"-format", "jsno", "-out", out}); err == nil {
+		t.Error("runExport() with an unknown format returned nil, want error")
+	}
+	if b, err := os.ReadFile(out); err != nil || string(b) != "previous" {
+		t.Errorf("runExport() with an unknown format left %q, %v; want the previous export untouched", b, err)
+	}
+}
@@ -0,0 +1,448 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gcal publishes upcoming maintenance windows as Google Calendar
+// events, authenticating with a service account.
+package gcal
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/deck"
+	"github.com/google/aukera/auklib"
+	"github.com/google/aukera/window"
+)
+
+// ConfigPath is the location of the publisher configuration. Publishing is
+// disabled unless it exists.
+var ConfigPath = filepath.Join(auklib.DataDir, "gcal.json")
+
+// apiBase is the Google Calendar API endpoint.
+var apiBase = "https://www.googleapis.com/calendar/v3"
+
+const scope = "https://www.googleapis.com/auth/calendar"
+
+// Config selects what is published and where.
+type Config struct {
+	// Credentials is the path of a service account JSON key.
+	Credentials string
+	// CalendarID identifies the calendar to publish to, which must be shared
+	// with the service account.
+	CalendarID string
+	// Labels to publish. All labels are published if empty.
+	Labels []string `json:",omitempty"`
+	// Count is the number of upcoming occurrences published per label.
+	Count int `json:",omitempty"`
+	// Days bounds how far ahead occurrences are searched for.
+	Days int `json:",omitempty"`
+}
+
+// LoadConfig reads the publisher configuration from ConfigPath, applying
+// defaults for unset values.
+func LoadConfig() (Config, error) {
+	var c Config
+	b, err := os.ReadFile(ConfigPath)
+	if err != nil {
+		return c, err
+	}
+	if err := json.Unmarshal(b, &c); err != nil {
+		return c, fmt.Errorf("gcal: unable to parse %q: %w", ConfigPath, err)
+	}
+	if c.Credentials == "" || c.CalendarID == "" {
+		return c, fmt.Errorf("gcal: %q must specify Credentials and CalendarID", ConfigPath)
+	}
+	if c.Count <= 0 {
+		c.Count = 5
+	}
+	if c.Days <= 0 {
+		c.Days = 30
+	}
+	for i := range c.Labels {
+		c.Labels[i] = strings.ToLower(c.Labels[i])
+	}
+	return c, nil
+}
+
+// credentials holds the fields of a service account key used for signing.
+type credentials struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// Publisher syncs occurrences into a calendar.
+type Publisher struct {
+	cfg    Config
+	host   string
+	email  string
+	key    *rsa.PrivateKey
+	tokURI string
+	client *http.Client
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// New creates a Publisher for cfg, reading the service account key.
+func New(cfg Config) (*Publisher, error) {
+	b, err := os.ReadFile(cfg.Credentials)
+	if err != nil {
+		return nil, fmt.Errorf("gcal: %w", err)
+	}
+	var c credentials
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("gcal: unable to parse credentials: %w", err)
+	}
+	block, _ := pem.Decode([]byte(c.PrivateKey))
+	if block == nil {
+		return nil, errors.New("gcal: credentials contain no private key")
+	}
+	k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("gcal: unable to parse private key: %w", err)
+	}
+	key, ok := k.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("gcal: private key is not an RSA key")
+	}
+	if c.TokenURI == "" {
+		c.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		return nil, fmt.Errorf("gcal: %w", err)
+	}
+	return &Publisher{
+		cfg:    cfg,
+		host:   strings.ToLower(host),
+		email:  c.ClientEmail,
+		key:    key,
+		tokURI: c.TokenURI,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// accessToken returns a cached OAuth token, exchanging a signed assertion
+// for a new one when needed.
+func (p *Publisher) accessToken(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.token != "" && time.Now().Before(p.expiry) {
+		return p.token, nil
+	}
+	now := time.Now()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]any{
+		"iss":   p.email,
+		"scope": scope,
+		"aud":   p.tokURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	signed := b64(header) + "." + b64(claims)
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {signed + "." + b64(sig)},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.tokURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	var tok struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := p.do(req, &tok); err != nil {
+		return "", fmt.Errorf("gcal: token exchange: %w", err)
+	}
+	p.token = tok.AccessToken
+	// Refresh a minute early to avoid using a token as it expires.
+	p.expiry = now.Add(time.Duration(tok.ExpiresIn)*time.Second - time.Minute)
+	return p.token, nil
+}
+
+// apiError is a failed Calendar API response.
+type apiError struct {
+	status int
+	body   string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("status %d: %s", e.status, e.body)
+}
+
+// do sends req, decoding a JSON response into v if set.
+func (p *Publisher) do(req *http.Request, v any) error {
+	res, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return &apiError{status: res.StatusCode, body: strings.TrimSpace(string(b))}
+	}
+	if v == nil || len(b) == 0 {
+		return nil
+	}
+	return json.Unmarshal(b, v)
+}
+
+// call makes an authenticated Calendar API request.
+func (p *Publisher) call(ctx context.Context, method, path string, body, v any) error {
+	tok, err := p.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, apiBase+path, r)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+tok)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return p.do(req, v)
+}
+
+type eventTime struct {
+	DateTime string `json:"dateTime"`
+}
+
+type extendedProperties struct {
+	Private map[string]string `json:"private"`
+}
+
+// event is the subset of a Calendar event managed by the publisher.
+type event struct {
+	ID                 string             `json:"id"`
+	Status             string             `json:"status,omitempty"`
+	Summary            string             `json:"summary"`
+	Description        string             `json:"description,omitempty"`
+	Start              eventTime          `json:"start"`
+	End                eventTime          `json:"end"`
+	ExtendedProperties extendedProperties `json:"extendedProperties"`
+}
+
+// same reports whether e and o have the same managed content.
+func (e event) same(o event) bool {
+	times := func(a, b string) bool {
+		ta, errA := time.Parse(time.RFC3339, a)
+		tb, errB := time.Parse(time.RFC3339, b)
+		return errA == nil && errB == nil && ta.Equal(tb)
+	}
+	return e.Summary == o.Summary && times(e.Start.DateTime, o.Start.DateTime) && times(e.End.DateTime, o.End.DateTime)
+}
+
+// eventID derives a stable event ID for an occurrence of label on this host.
+// Calendar IDs are restricted to base32hex characters, which hex satisfies.
+func (p *Publisher) eventID(label string, opens time.Time) string {
+	h := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%d", p.host, label, opens.Unix())))
+	return "aukera" + hex.EncodeToString(h[:16])
+}
+
+// desired returns the events that should exist for schedules, keyed by ID.
+func (p *Publisher) desired(schedules []window.Schedule) map[string]event {
+	selected := make(map[string]bool)
+	for _, l := range p.cfg.Labels {
+		selected[l] = true
+	}
+	sorted := append([]window.Schedule(nil), schedules...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Opens.Before(sorted[j].Opens) })
+	count := make(map[string]int)
+	out := make(map[string]event)
+	for _, s := range sorted {
+		if (len(selected) > 0 && !selected[s.Name]) || count[s.Name] >= p.cfg.Count {
+			continue
+		}
+		count[s.Name]++
+		id := p.eventID(s.Name, s.Opens)
+		out[id] = event{
+			ID:          id,
+			Summary:     fmt.Sprintf("%s: %s maintenance", p.host, s.Name),
+			Description: fmt.Sprintf("Maintenance window for label %q on %s, published by Aukera.", s.Name, p.host),
+			Start:       eventTime{s.Opens.UTC().Format(time.RFC3339)},
+			End:         eventTime{s.Closes.UTC().Format(time.RFC3339)},
+			ExtendedProperties: extendedProperties{Private: map[string]string{
+				"aukera": "true",
+				"host":   p.host,
+				"label":  s.Name,
+			}},
+		}
+	}
+	return out
+}
+
+func (p *Publisher) eventsPath() string {
+	return "/calendars/" + url.PathEscape(p.cfg.CalendarID) + "/events"
+}
+
+// existing lists the upcoming events previously published by this host.
+func (p *Publisher) existing(ctx context.Context, now time.Time) (map[string]event, error) {
+	out := make(map[string]event)
+	q := url.Values{
+		"privateExtendedProperty": {"aukera=true", "host=" + p.host},
+		"timeMin":                 {now.UTC().Format(time.RFC3339)},
+		"maxResults":              {"2500"},
+	}
+	for {
+		var page struct {
+			Items         []event `json:"items"`
+			NextPageToken string  `json:"nextPageToken"`
+		}
+		if err := p.call(ctx, http.MethodGet, p.eventsPath()+"?"+q.Encode(), nil, &page); err != nil {
+			return nil, err
+		}
+		for _, e := range page.Items {
+			out[e.ID] = e
+		}
+		if page.NextPageToken == "" {
+			return out, nil
+		}
+		q.Set("pageToken", page.NextPageToken)
+	}
+}
+
+// Sync publishes the upcoming occurrences among schedules, updating events
+// whose occurrence changed and deleting events for occurrences that no
+// longer exist.
+func (p *Publisher) Sync(ctx context.Context, schedules []window.Schedule) error {
+	want := p.desired(schedules)
+	have, err := p.existing(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("gcal: listing events: %w", err)
+	}
+	var errs []string
+	for id, e := range want {
+		h, ok := have[id]
+		switch {
+		case !ok:
+			err = p.call(ctx, http.MethodPost, p.eventsPath(), e, nil)
+			// Events deleted by an earlier sync keep their ID and must be
+			// restored by updating them.
+			var ae *apiError
+			if errors.As(err, &ae) && ae.status == http.StatusConflict {
+				e.Status = "confirmed"
+				err = p.call(ctx, http.MethodPut, p.eventsPath()+"/"+id, e, nil)
+			}
+		case !h.same(e):
+			err = p.call(ctx, http.MethodPut, p.eventsPath()+"/"+id, e, nil)
+		default:
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("event %s: %v", id, err))
+		}
+	}
+	for id := range have {
+		if _, ok := want[id]; ok {
+			continue
+		}
+		if err := p.call(ctx, http.MethodDelete, p.eventsPath()+"/"+id, nil, nil); err != nil {
+			errs = append(errs, fmt.Sprintf("deleting event %s: %v", id, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("gcal: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Occurrences returns the schedules of the named labels, or of all labels if
+// none are named, within [from, to), as schedule.Range does.
+type Occurrences func(ctx context.Context, from, to time.Time, labels ...string) ([]window.Schedule, error)
+
+// upcoming returns the schedules of the configured Labels within [from, to).
+// Labels without any window are skipped, so that their events are removed
+// rather than failing the sync of every other label.
+func (p *Publisher) upcoming(ctx context.Context, from, to time.Time, occurrences Occurrences) ([]window.Schedule, error) {
+	if len(p.cfg.Labels) == 0 {
+		return occurrences(ctx, from, to)
+	}
+	var out []window.Schedule
+	for _, l := range p.cfg.Labels {
+		s, err := occurrences(ctx, from, to, l)
+		if errors.Is(err, window.ErrNoWindows) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s...)
+	}
+	return out, nil
+}
+
+// Run syncs the schedules returned by occurrences every interval until ctx
+// is done.
+func (p *Publisher) Run(ctx context.Context, interval time.Duration, occurrences Occurrences) {
+	for {
+		now := time.Now()
+		s, err := p.upcoming(ctx, now, now.AddDate(0, 0, p.cfg.Days), occurrences)
+		if err == nil {
+			err = p.Sync(ctx, s)
+		}
+		if err != nil {
+			deck.Warningf("calendar publishing failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
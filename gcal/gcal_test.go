@@ -0,0 +1,245 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcal
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/aukera/window"
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeCalendar serves the token and event endpoints used by Publisher.
+type fakeCalendar struct {
+	t     *testing.T
+	pub   *rsa.PublicKey
+	mu    sync.Mutex
+	items map[string]event
+	calls []string
+}
+
+func (f *fakeCalendar) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.URL.Path == "/token" {
+		parts := strings.Split(r.FormValue("assertion"), ".")
+		if len(parts) != 3 {
+			http.Error(w, "malformed assertion", http.StatusBadRequest)
+			return
+		}
+		sig, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		if err := rsa.VerifyPKCS1v15(f.pub, crypto.SHA256, digest[:], sig); err != nil {
+			http.Error(w, "bad signature", http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"access_token": "tok", "expires_in": 3600})
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer tok" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	const prefix = "/calendars/maint@example.com/events"
+	id := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, prefix), "/")
+	f.calls = append(f.calls, r.Method+" "+id)
+	switch r.Method {
+	case http.MethodGet:
+		var items []event
+		for _, e := range f.items {
+			items = append(items, e)
+		}
+		json.NewEncoder(w).Encode(map[string]any{"items": items})
+	case http.MethodPost, http.MethodPut:
+		var e event
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.items[e.ID] = e
+		json.NewEncoder(w).Encode(e)
+	case http.MethodDelete:
+		delete(f.items, id)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestSync(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeCalendar{t: t, pub: &key.PublicKey, items: make(map[string]event)}
+	ts := httptest.NewServer(f)
+	defer ts.Close()
+	origBase := apiBase
+	defer func() { apiBase = origBase }()
+	apiBase = ts.URL
+
+	creds, err := json.Marshal(credentials{
+		ClientEmail: "aukera@example.iam.gserviceaccount.com",
+		PrivateKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		TokenURI:    ts.URL + "/token",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "sa.json")
+	if err := os.WriteFile(path, creds, 0600); err != nil {
+		t.Fatal(err)
+	}
+	p, err := New(Config{Credentials: path, CalendarID: "maint@example.com", Labels: []string{"patch"}, Count: 2, Days: 30})
+	if err != nil {
+		t.Fatalf("New() returned error: %v", err)
+	}
+
+	now := time.Now().Truncate(time.Hour)
+	day := func(d int) time.Time { return now.AddDate(0, 0, d) }
+	schedules := []window.Schedule{
+		{Name: "patch", Opens: day(3), Closes: day(3).Add(time.Hour)},
+		{Name: "patch", Opens: day(1), Closes: day(1).Add(time.Hour)},
+		{Name: "patch", Opens: day(2), Closes: day(2).Add(time.Hour)},
+		{Name: "reboot", Opens: day(1), Closes: day(1).Add(time.Hour)},
+	}
+	first, second := p.eventID("patch", day(1)), p.eventID("patch", day(2))
+	// The first occurrence was published before its close time changed, and
+	// a stale occurrence no longer exists.
+	outdated := p.desired(schedules)[first]
+	outdated.End.DateTime = day(1).Add(2 * time.Hour).UTC().Format(time.RFC3339)
+	f.items[first] = outdated
+	f.items["aukerastale"] = event{ID: "aukerastale", Summary: "stale"}
+
+	if err := p.Sync(context.Background(), schedules); err != nil {
+		t.Fatalf("Sync() returned error: %v", err)
+	}
+	sort.Strings(f.calls)
+	want := []string{"DELETE aukerastale", "GET ", "POST ", "PUT " + first}
+	if diff := cmp.Diff(want, f.calls); diff != "" {
+		t.Errorf("Sync() made unexpected calls (-want +got):\n%s", diff)
+	}
+	var ids []string
+	for id := range f.items {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	wantIDs := []string{first, second}
+	sort.Strings(wantIDs)
+	if diff := cmp.Diff(wantIDs, ids); diff != "" {
+		t.Errorf("Sync() left unexpected events (-want +got):\n%s", diff)
+	}
+
+	// A second sync with unchanged schedules only lists events.
+	f.calls = nil
+	if err := p.Sync(context.Background(), schedules); err != nil {
+		t.Fatalf("Sync() returned error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"GET "}, f.calls); diff != "" {
+		t.Errorf("repeated Sync() made unexpected calls (-want +got):\n%s", diff)
+	}
+}
+
+func TestUpcoming(t *testing.T) {
+	from := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 30)
+	occurrences := func(ctx context.Context, gotFrom, gotTo time.Time, labels ...string) ([]window.Schedule, error) {
+		if !gotFrom.Equal(from) || !gotTo.Equal(to) {
+			t.Errorf("occurrences(%s, %s), want (%s, %s)", gotFrom, gotTo, from, to)
+		}
+		if len(labels) == 0 {
+			return []window.Schedule{{Name: "patch"}, {Name: "reboot"}}, nil
+		}
+		var out []window.Schedule
+		for _, l := range labels {
+			if l == "missing" {
+				return nil, fmt.Errorf("label(s) %s: %w", l, window.ErrNoWindows)
+			}
+			out = append(out, window.Schedule{Name: l})
+		}
+		return out, nil
+	}
+
+	tests := []struct {
+		labels []string
+		want   []string
+	}{
+		{nil, []string{"patch", "reboot"}},
+		{[]string{"patch"}, []string{"patch"}},
+		{[]string{"missing", "reboot"}, []string{"reboot"}},
+	}
+	for _, tt := range tests {
+		p := &Publisher{cfg: Config{Labels: tt.labels}}
+		s, err := p.upcoming(context.Background(), from, to, occurrences)
+		if err != nil {
+			t.Errorf("upcoming(%v) returned error: %v", tt.labels, err)
+			continue
+		}
+		var got []string
+		for _, o := range s {
+			got = append(got, o.Name)
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("upcoming(%v) returned diff (-want +got):\n%s", tt.labels, diff)
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	orig := ConfigPath
+	defer func() { ConfigPath = orig }()
+	ConfigPath = filepath.Join(t.TempDir(), "gcal.json")
+
+	if _, err := LoadConfig(); !os.IsNotExist(err) {
+		t.Errorf("LoadConfig() without file returned %v, want not exist", err)
+	}
+	if err := os.WriteFile(ConfigPath, []byte(`{"CalendarID": "maint@example.com"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(); err == nil {
+		t.Errorf("LoadConfig() without credentials returned nil error")
+	}
+	if err := os.WriteFile(ConfigPath, []byte(`{"Credentials": "sa.json", "CalendarID": "maint@example.com", "Labels": ["OS_Patch"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() returned error: %v", err)
+	}
+	want := Config{Credentials: "sa.json", CalendarID: "maint@example.com", Labels: []string{"os_patch"}, Count: 5, Days: 30}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("LoadConfig() returned diff (-want +got):\n%s", diff)
+	}
+}
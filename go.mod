@@ -3,7 +3,9 @@ module github.com/google/aukera
 go 1.18
 
 require (
+        github.com/Microsoft/go-winio v0.6.0
         github.com/go-chi/chi/v5 v5.0.8
+        github.com/godbus/dbus/v5 v5.1.0
         github.com/google/cabbie v1.0.3-0.20210720165919-9cf1b44a02bb
         github.com/google/deck v0.0.0-20221206151953-9363e9de5515
         github.com/google/go-cmp v0.5.4
@@ -11,4 +13,8 @@ require (
         golang.org/x/sys v0.2.0
 )
 
-require golang.org/x/xerrors v0.0.0-20191204190536-9bdfabe68543 // indirect
+require (
+	golang.org/x/mod v0.6.0-dev.0.20220419223038-86c51ed26bb4 // indirect
+	golang.org/x/tools v0.1.12 // indirect
+	golang.org/x/xerrors v0.0.0-20191204190536-9bdfabe68543 // indirect
+)
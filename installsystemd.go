@@ -0,0 +1,166 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/google/aukera/auklib"
+)
+
+// defaultUnitPath is where install-systemd writes the unit file by default.
+const defaultUnitPath = "/etc/systemd/system/aukera.service"
+
+// systemdUnit describes the unit file written by install-systemd.
+type systemdUnit struct {
+	// Exec is the absolute path of the aukera binary.
+	Exec string
+	// Args are additional command line arguments of the service.
+	Args []string
+	// User runs the service as a dedicated user. Empty runs it as a
+	// dynamic user allocated by systemd.
+	User string
+	// DataDir and LogPath are the only paths the service may write.
+	DataDir, LogPath string
+}
+
+// quoteArg quotes a command line argument of ExecStart if it needs quoting.
+func quoteArg(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\"'\\;$%") {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", "$$", "%", "%%").Replace(s) + `"`
+}
+
+// quotePath quotes a path of a setting such as ReadWritePaths if it needs
+// quoting. Unlike ExecStart, such settings do not expand variables.
+func quotePath(s string) string {
+	if !strings.ContainsAny(s, " \t\"'\\%") {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%").Replace(s) + `"`
+}
+
+// write writes u as a hardened systemd unit file to w.
+func (u systemdUnit) write(w io.Writer) error {
+	exec := []string{quoteArg(u.Exec)}
+	for _, a := range u.Args {
+		exec = append(exec, quoteArg(a))
+	}
+	lines := []string{
+		"# Generated by aukera install-systemd.",
+		"[Unit]",
+		"Description=Aukera maintenance window service",
+		"Documentation=https://github.com/google/aukera",
+		"After=network.target",
+		"",
+		"[Service]",
+		"Type=simple",
+		"ExecStart=" + strings.Join(exec, " "),
+		"Restart=on-failure",
+		"RestartSec=5s",
+	}
+	if u.User == "" {
+		lines = append(lines, "DynamicUser=yes")
+	} else {
+		lines = append(lines, "User="+u.User, "Group="+u.User)
+	}
+	// StateDirectory creates the data directory owned by the service user,
+	// which a dynamic user otherwise could not write to.
+	if rel, err := filepath.Rel("/var/lib", u.DataDir); err == nil && !strings.HasPrefix(rel, "..") && rel != "." {
+		lines = append(lines, "StateDirectory="+quotePath(rel))
+	}
+	lines = append(lines,
+		"ReadWritePaths="+quotePath(u.DataDir),
+		// The log file may not exist until the service first starts.
+		"ReadWritePaths="+quotePath("-"+u.LogPath),
+		"ProtectSystem=strict",
+		"ProtectHome=yes",
+		"PrivateTmp=yes",
+		"PrivateDevices=yes",
+		"NoNewPrivileges=yes",
+		"CapabilityBoundingSet=",
+		"ProtectKernelTunables=yes",
+		"ProtectKernelModules=yes",
+		"ProtectKernelLogs=yes",
+		"ProtectControlGroups=yes",
+		"ProtectClock=yes",
+		"ProtectHostname=yes",
+		"RestrictAddressFamilies=AF_UNIX AF_INET AF_INET6",
+		"RestrictNamespaces=yes",
+		"RestrictRealtime=yes",
+		"RestrictSUIDSGID=yes",
+		"LockPersonality=yes",
+		"MemoryDenyWriteExecute=yes",
+		"SystemCallArchitectures=native",
+		"",
+		"[Install]",
+		"WantedBy=multi-user.target",
+	)
+	_, err := fmt.Fprintln(w, strings.Join(lines, "\n"))
+	return err
+}
+
+// runInstallSystemd implements the install-systemd subcommand, writing a
+// hardened systemd unit file for the service so that deployments need not
+// each write their own.
+func runInstallSystemd(args []string) error {
+	fs := flag.NewFlagSet("install-systemd", flag.ContinueOnError)
+	user := fs.String("user", "", "Dedicated user, and group of the same name, the service runs as (default: a dynamic user allocated by systemd)")
+	binary := fs.String("exec", "", "Absolute path of the aukera binary the service runs (default: this binary)")
+	extra := fs.String("args", "", "Space-separated command line arguments of the service, such as -log_backend=journald")
+	out := fs.String("out", defaultUnitPath, "Output file path for the unit file, or - for stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if runtime.GOOS != "linux" && *out != "-" {
+		return fmt.Errorf("install-systemd: installing units is only supported on Linux; use -out - to print the unit")
+	}
+	u := systemdUnit{User: *user, Exec: *binary, Args: strings.Fields(*extra), DataDir: auklib.DataDir, LogPath: auklib.LogPath}
+	if u.Exec == "" {
+		exe, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("install-systemd: %v", err)
+		}
+		u.Exec = exe
+	}
+	if !filepath.IsAbs(u.Exec) {
+		return fmt.Errorf("install-systemd: -exec must be an absolute path (found: %q)", u.Exec)
+	}
+
+	if *out == "-" {
+		return u.write(os.Stdout)
+	}
+	f, err := os.OpenFile(*out, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("install-systemd: %v", err)
+	}
+	if err := u.write(f); err != nil {
+		f.Close()
+		return fmt.Errorf("install-systemd: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("install-systemd: %v", err)
+	}
+	name := filepath.Base(*out)
+	fmt.Fprintf(os.Stderr, "Wrote %s. Start the service with:\n  systemctl daemon-reload && systemctl enable --now %s\n", *out, name)
+	return nil
+}
@@ -0,0 +1,123 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestQuoteArg(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"/usr/bin/aukera", "/usr/bin/aukera"},
+		{"-log_backend=journald", "-log_backend=journald"},
+		{"", `""`},
+		{"/opt/my app/aukera", `"/opt/my app/aukera"`},
+		{"-note=100%", `"-note=100%%"`},
+		{`-note=say "hi"`, `"-note=say \"hi\""`},
+		{"-note=it's", `"-note=it's"`},
+		{`C:\aukera`, `"C:\\aukera"`},
+		{"$HOME", `"$$HOME"`},
+		{"a;b", `"a;b"`},
+	}
+	for _, tt := range tests {
+		if got := quoteArg(tt.in); got != tt.want {
+			t.Errorf("quoteArg(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSystemdUnitWrite(t *testing.T) {
+	tests := []struct {
+		desc    string
+		u       systemdUnit
+		want    []string
+		notWant []string
+	}{
+		{
+			desc: "dynamic user",
+			u:    systemdUnit{Exec: "/usr/bin/aukera", DataDir: "/var/lib/aukera", LogPath: "/var/log/aukera.log"},
+			want: []string{
+				"ExecStart=/usr/bin/aukera\n",
+				"DynamicUser=yes\n",
+				"StateDirectory=aukera\n",
+				"ReadWritePaths=/var/lib/aukera\n",
+				"ReadWritePaths=-/var/log/aukera.log\n",
+			},
+			notWant: []string{"\nUser=", "\nGroup="},
+		},
+		{
+			desc: "dedicated user",
+			u:    systemdUnit{Exec: "/usr/bin/aukera", User: "aukera", DataDir: "/var/lib/aukera", LogPath: "/var/log/aukera.log"},
+			want: []string{
+				"User=aukera\n",
+				"Group=aukera\n",
+			},
+			notWant: []string{"\nDynamicUser="},
+		},
+		{
+			desc: "arguments needing quotes",
+			u: systemdUnit{Exec: "/opt/my app/aukera", Args: []string{"-log_backend=journald", "-note=100%", `-label="patch"`},
+				DataDir: "/var/lib/aukera", LogPath: "/var/log/aukera.log"},
+			want: []string{
+				`ExecStart="/opt/my app/aukera" -log_backend=journald "-note=100%%" "-label=\"patch\""` + "\n",
+			},
+		},
+		{
+			desc: "data directory outside /var/lib",
+			u:    systemdUnit{Exec: "/usr/bin/aukera", DataDir: "/srv/aukera", LogPath: "/srv/aukera/aukera.log"},
+			want: []string{
+				"ReadWritePaths=/srv/aukera\n",
+				"ReadWritePaths=-/srv/aukera/aukera.log\n",
+			},
+			notWant: []string{"\nStateDirectory="},
+		},
+		{
+			desc: "log outside data directory",
+			u:    systemdUnit{Exec: "/usr/bin/aukera", DataDir: "/var/lib/aukera", LogPath: "/var/log/my logs/aukera 100%.log"},
+			want: []string{
+				"ReadWritePaths=/var/lib/aukera\n",
+				`ReadWritePaths="-/var/log/my logs/aukera 100%%.log"` + "\n",
+			},
+		},
+		{
+			desc: "paths needing quotes",
+			u:    systemdUnit{Exec: "/usr/bin/aukera", DataDir: `/var/lib/my "aukera"`, LogPath: "/var/log/aukera.log"},
+			want: []string{
+				`StateDirectory="my \"aukera\""` + "\n",
+				`ReadWritePaths="/var/lib/my \"aukera\""` + "\n",
+			},
+		},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		if err := tt.u.write(&b); err != nil {
+			t.Fatalf("%s: write() returned error: %v", tt.desc, err)
+		}
+		got := b.String()
+		for _, w := range append([]string{"[Service]\n", "ProtectSystem=strict\n", "WantedBy=multi-user.target\n"}, tt.want...) {
+			if !strings.Contains(got, w) {
+				t.Errorf("%s: write() = %s, want it to contain %q", tt.desc, got, w)
+			}
+		}
+		for _, w := range tt.notWant {
+			if strings.Contains(got, w) {
+				t.Errorf("%s: write() = %s, want it not to contain %q", tt.desc, got, w)
+			}
+		}
+	}
+}
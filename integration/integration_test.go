@@ -0,0 +1,525 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/aukera/auklib"
+	"github.com/google/aukera/client"
+	"github.com/google/aukera/schedule"
+	"github.com/google/aukera/server"
+	"github.com/google/aukera/window"
+	"github.com/google/go-cmp/cmp"
+)
+
+// config is installed in the temporary ConfDir. The always window opens
+// hourly for two hours, so it is open whenever the tests run.
+const config = `{"Windows": [
+	{"Name": "nightly", "Format": 1, "Schedule": "0 0 2 * * *", "Duration": "1h", "Labels": ["patch"], "Tags": {"ring": "prod"}},
+	{"Name": "always", "Format": 1, "Schedule": "0 0 * * * *", "Duration": "2h", "Labels": ["always", "patch"], "Tags": {"ring": "canary"}},
+	{"Name": "gated", "Format": 1, "Schedule": "0 0 * * * *", "Duration": "2h", "Labels": ["gated"], "RequiresApproval": true}
+]}`
+
+// gated names the window and label requiring approval. Other tests ignore
+// it, since whether it is included depends on whether TestApprove has run.
+const gated = "gated"
+
+// port is the port the server under test is listening on.
+var port int
+
+func TestMain(m *testing.M) {
+	os.Exit(run(m))
+}
+
+// run starts the server against a temporary data and configuration
+// directory and runs the tests once its port is recorded.
+func run(m *testing.M) int {
+	dir, err := os.MkdirTemp("", "aukera-integration")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer os.RemoveAll(dir)
+
+	auklib.ConfDir = filepath.Join(dir, "conf.d")
+	auklib.PortFile = filepath.Join(dir, "port")
+	auklib.SettingsFile = filepath.Join(dir, "settings.json")
+	schedule.QueryHistoryFile = filepath.Join(dir, "label_queries.json")
+	window.ApprovalsFile = filepath.Join(dir, "approvals.json")
+	window.EphemeralFile = filepath.Join(dir, "ephemeral.json")
+	server.TokenPath = filepath.Join(dir, "admin.token")
+	schedule.DisableActiveHours = true
+
+	if err := os.MkdirAll(auklib.ConfDir, 0755); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := os.WriteFile(filepath.Join(auklib.ConfDir, "windows.json"), []byte(config), 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	errch := make(chan error, 1)
+	go func() { errch <- server.RunWithConfig(0, server.DefaultConfig) }()
+	if port, err = waitForPort(errch, 10*time.Second); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return m.Run()
+}
+
+// waitForPort waits for the server to record its port in auklib.PortFile and
+// to start answering requests.
+func waitForPort(errch <-chan error, timeout time.Duration) (int, error) {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		select {
+		case err := <-errch:
+			return 0, fmt.Errorf("server exited: %v", err)
+		default:
+		}
+		// PortFile is read directly, since DiscoverPort prefers platform
+		// storage that may hold the port of an installed service.
+		if b, err := os.ReadFile(auklib.PortFile); err == nil {
+			if p, err := strconv.Atoi(strings.TrimSpace(string(b))); err == nil && client.Test(fmt.Sprintf("http://localhost:%d", p)) {
+				return p, nil
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return 0, fmt.Errorf("server did not start within %v", timeout)
+}
+
+// get requests path from the server under test, returning the status code,
+// headers and body.
+func get(t *testing.T, path string) (int, http.Header, []byte) {
+	t.Helper()
+	return do(t, http.MethodGet, path, "", nil)
+}
+
+func do(t *testing.T, method, path, token string, body io.Reader) (int, http.Header, []byte) {
+	t.Helper()
+	req, err := http.NewRequest(method, fmt.Sprintf("http://localhost:%d%s", port, path), body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("%s %s: reading body: %v", method, path, err)
+	}
+	return resp.StatusCode, resp.Header, b
+}
+
+// keys returns the sorted field names of a JSON object.
+func keys(t *testing.T, raw json.RawMessage) []string {
+	t.Helper()
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &m); err != nil {
+		t.Fatalf("not a JSON object: %s: %v", raw, err)
+	}
+	var k []string
+	for n := range m {
+		k = append(k, n)
+	}
+	sort.Strings(k)
+	return k
+}
+
+// checkShape verifies that body is a JSON array of n objects, each having
+// exactly the fields want, and returns the elements.
+func checkShape(t *testing.T, body []byte, n int, want ...string) []json.RawMessage {
+	t.Helper()
+	var l []json.RawMessage
+	if err := json.Unmarshal(body, &l); err != nil {
+		t.Fatalf("not a JSON array: %s: %v", body, err)
+	}
+	if n >= 0 && len(l) != n {
+		t.Fatalf("got %d elements, want %d: %s", len(l), n, body)
+	}
+	want = append([]string(nil), want...)
+	sort.Strings(want)
+	for _, e := range l {
+		if diff := cmp.Diff(want, keys(t, e)); diff != "" {
+			t.Errorf("fields of %s mismatch (-want +got):\n%s", e, diff)
+		}
+	}
+	return l
+}
+
+// scheduleFields are the sorted fields of a marshaled window.Schedule.
+var scheduleFields = []string{"Closes", "Duration", "EvaluatedAt", "Name", "Opens", "State"}
+
+func TestStatus(t *testing.T) {
+	if code, _, body := get(t, "/status"); code != http.StatusOK || string(body) != "OK" {
+		t.Errorf("/status = %d %q, want 200 \"OK\"", code, body)
+	}
+	if code, _, body := get(t, "/healthz"); code != http.StatusNoContent || len(body) != 0 {
+		t.Errorf("/healthz = %d %q, want 204 and no body", code, body)
+	}
+	if code, _, body := get(t, "/"); code != http.StatusOK || !strings.Contains(string(body), "always") {
+		t.Errorf("/ = %d %q, want 200 listing the always label", code, body)
+	}
+	if !client.Test(fmt.Sprintf("http://localhost:%d", port)) {
+		t.Error("client.Test reported the service unavailable")
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	code, h, body := get(t, "/schedule")
+	if code != http.StatusOK {
+		t.Fatalf("/schedule = %d %s", code, body)
+	}
+	l := checkShape(t, body, -1, scheduleFields...)
+	var names []string
+	for _, e := range l {
+		var s window.Schedule
+		if err := json.Unmarshal(e, &s); err != nil {
+			t.Fatal(err)
+		}
+		if s.Name != gated {
+			names = append(names, s.Name)
+		}
+	}
+	if diff := cmp.Diff([]string{"always", "patch"}, names); diff != "" {
+		t.Errorf("/schedule names mismatch (-want +got):\n%s", diff)
+	}
+	if h.Get(server.LabelStatusHeader) == "" {
+		t.Errorf("/schedule missing %s header", server.LabelStatusHeader)
+	}
+
+	sched, err := client.Label(port, "always")
+	if err != nil {
+		t.Fatalf("client.Label(always): %v", err)
+	}
+	if len(sched) != 1 || sched[0].Name != "always" || sched[0].State != window.StateOpen || sched[0].Duration != 2*time.Hour {
+		t.Errorf("client.Label(always) = %+v, want one open 2h schedule", sched)
+	}
+
+	// The patch label combines the open always window with nightly.
+	sched, err = client.Label(port, "patch")
+	if err != nil {
+		t.Fatalf("client.Label(patch): %v", err)
+	}
+	if len(sched) != 1 || sched[0].State != window.StateOpen {
+		t.Errorf("client.Label(patch) = %+v, want one open schedule", sched)
+	}
+
+	if _, err := client.Label(port, "missing"); !errors.Is(err, window.ErrNoWindows) {
+		t.Errorf("client.Label(missing) = %v, want %v", err, window.ErrNoWindows)
+	}
+}
+
+func TestScheduleAt(t *testing.T) {
+	ctx := context.Background()
+	at := time.Now().Add(48 * time.Hour)
+	sched, err := client.LabelAt(ctx, port, at, "always")
+	if err != nil {
+		t.Fatalf("client.LabelAt: %v", err)
+	}
+	if len(sched) != 1 || !sched[0].Contains(at) {
+		t.Errorf("client.LabelAt(%v) = %+v, want one schedule containing it", at, sched)
+	}
+	if _, err := client.LabelAt(ctx, port, at, "missing"); !errors.Is(err, window.ErrNoWindows) {
+		t.Errorf("client.LabelAt(missing) = %v, want %v", err, window.ErrNoWindows)
+	}
+	if code, _, _ := get(t, "/schedule/always?at=yesterday"); code != http.StatusBadRequest {
+		t.Errorf("/schedule/always?at=yesterday = %d, want %d", code, http.StatusBadRequest)
+	}
+}
+
+func TestLabels(t *testing.T) {
+	code, _, body := get(t, "/labels")
+	if code != http.StatusOK {
+		t.Fatalf("/labels = %d %s", code, body)
+	}
+	var raw []json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		t.Fatalf("/labels: %s: %v", body, err)
+	}
+	for _, e := range raw {
+		k := keys(t, e)
+		for _, f := range []string{"Name", "Windows", "LastQueried"} {
+			if i := sort.SearchStrings(k, f); i == len(k) || k[i] != f {
+				t.Errorf("/labels element %s missing field %s", e, f)
+			}
+		}
+	}
+
+	l, err := client.Labels(context.Background(), port)
+	if err != nil {
+		t.Fatalf("client.Labels: %v", err)
+	}
+	got := make(map[string][]string)
+	for _, label := range l {
+		if label.Name != gated {
+			got[label.Name] = label.Windows
+		}
+	}
+	want := map[string][]string{
+		"always": {"always"},
+		"patch":  {"nightly", "always"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("client.Labels mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWindows(t *testing.T) {
+	ctx := context.Background()
+	w, err := client.Windows(ctx, port)
+	if err != nil {
+		t.Fatalf("client.Windows: %v", err)
+	}
+	var names []string
+	for _, win := range w {
+		if win.Name != gated {
+			names = append(names, win.Name)
+		}
+	}
+	if diff := cmp.Diff([]string{"always", "nightly"}, names); diff != "" {
+		t.Errorf("client.Windows names mismatch (-want +got):\n%s", diff)
+	}
+	code, _, body := get(t, "/windows")
+	if code != http.StatusOK {
+		t.Fatalf("/windows = %d %s", code, body)
+	}
+	var listed []window.Listing
+	if err := json.Unmarshal(body, &listed); err != nil {
+		t.Fatal(err)
+	}
+	// The window requiring approval is listed whether or not TestApprove
+	// has run yet; only it may be pending.
+	var found bool
+	for _, l := range listed {
+		found = found || l.Name == gated
+		if l.Pending && l.Name != gated {
+			t.Errorf("/windows lists %s as pending approval", l.Name)
+		}
+	}
+	if !found {
+		t.Errorf("/windows omits window %s", gated)
+	}
+	w, err = client.WindowsTagged(ctx, port, "ring=canary")
+	if err != nil {
+		t.Fatalf("client.WindowsTagged: %v", err)
+	}
+	if len(w) != 1 || w[0].Name != "always" {
+		t.Errorf("client.WindowsTagged(ring=canary) = %+v, want the always window", w)
+	}
+	w, err = client.WindowsTagged(ctx, port, "ring=none")
+	if err != nil || len(w) != 0 {
+		t.Errorf("client.WindowsTagged(ring=none) = %+v, %v, want none", w, err)
+	}
+	if code, _, body := get(t, "/windows?tag=ring=none"); code != http.StatusOK || strings.TrimSpace(string(body)) != "[]" {
+		t.Errorf("/windows?tag=ring=none = %d %s, want 200 []", code, body)
+	}
+}
+
+func TestCalendar(t *testing.T) {
+	code, _, body := get(t, "/calendar?days=1")
+	if code != http.StatusOK {
+		t.Fatalf("/calendar = %d %s", code, body)
+	}
+	l := checkShape(t, body, -1, "Opens", "Closes", "Labels")
+	if len(l) == 0 {
+		t.Fatal("/calendar returned no spans")
+	}
+	var spans []schedule.Span
+	if err := json.Unmarshal(body, &spans); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < len(spans); i++ {
+		if spans[i].Opens.Before(spans[i-1].Opens) {
+			t.Errorf("/calendar spans out of order at %d: %v before %v", i, spans[i].Opens, spans[i-1].Opens)
+		}
+	}
+}
+
+func TestConfig(t *testing.T) {
+	code, _, body := get(t, "/configcheck")
+	if code != http.StatusOK {
+		t.Fatalf("/configcheck = %d %s", code, body)
+	}
+	// The label of the window pending approval is never queried.
+	checkShape(t, body, 1, "Path", "Status", "Warnings", "Windows")
+	var c []window.FileCheck
+	if err := json.Unmarshal(body, &c); err != nil {
+		t.Fatal(err)
+	}
+	if c[0].Windows != 3 {
+		t.Errorf("/configcheck found %d windows, want 3", c[0].Windows)
+	}
+	want := fmt.Sprintf("label %q has never been queried", gated)
+	var found bool
+	for _, w := range c[0].Warnings {
+		found = found || w == want
+	}
+	if !found {
+		t.Errorf("/configcheck warnings = %q, want %q", c[0].Warnings, want)
+	}
+
+	code, h, body := get(t, "/schema")
+	if code != http.StatusOK || h.Get("Content-Type") != "application/schema+json" || !json.Valid(body) {
+		t.Errorf("/schema = %d %q, want 200 with a JSON schema", code, h.Get("Content-Type"))
+	}
+
+	code, _, body = do(t, http.MethodPost, "/validate?name=bad.json", "", strings.NewReader(`{"Windows": [{"Name": "x"}]}`))
+	if code != http.StatusOK {
+		t.Fatalf("/validate = %d %s", code, body)
+	}
+	var fc window.FileCheck
+	if err := json.Unmarshal(body, &fc); err != nil {
+		t.Fatal(err)
+	}
+	if fc.Path != "bad.json" || len(fc.Errors) == 0 {
+		t.Errorf("/validate = %+v, want errors for bad.json", fc)
+	}
+}
+
+func TestSelfTest(t *testing.T) {
+	code, _, body := get(t, "/selftest")
+	if code != http.StatusOK {
+		t.Fatalf("/selftest = %d %s", code, body)
+	}
+	var res schedule.SelfTestResult
+	if err := json.Unmarshal(body, &res); err != nil {
+		t.Fatal(err)
+	}
+	if !res.Passed || len(res.Steps) == 0 {
+		t.Errorf("/selftest = %+v, want passed", res)
+	}
+}
+
+func TestApprove(t *testing.T) {
+	_, err := client.Label(port, gated)
+	if err == nil {
+		t.Skip("gated window already approved by an earlier run")
+	}
+	if !errors.Is(err, window.ErrNoWindows) {
+		t.Fatalf("client.Label(gated) before approval = %v, want %v", err, window.ErrNoWindows)
+	}
+	if code, _, _ := do(t, http.MethodPost, "/approve/gated", "", nil); code != http.StatusUnauthorized {
+		t.Errorf("/approve without token = %d, want %d", code, http.StatusUnauthorized)
+	}
+	b, err := os.ReadFile(server.TokenPath)
+	if err != nil {
+		t.Fatalf("reading admin token: %v", err)
+	}
+	token := strings.TrimSpace(string(b))
+	if code, _, _ := do(t, http.MethodPost, "/approve/nightly", token, nil); code != http.StatusNotFound {
+		t.Errorf("/approve/nightly = %d, want %d", code, http.StatusNotFound)
+	}
+	if code, _, body := do(t, http.MethodPost, "/approve/gated", token, nil); code != http.StatusOK {
+		t.Fatalf("/approve/gated = %d %s", code, body)
+	}
+	sched, err := client.Label(port, "gated")
+	if err != nil || len(sched) != 1 || sched[0].State != window.StateOpen {
+		t.Errorf("client.Label(gated) after approval = %+v, %v, want one open schedule", sched, err)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://localhost:%d/events?label=always", port), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("/events: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("/events Content-Type = %q, want text/event-stream", ct)
+	}
+	// Intents registered by TestIntent may precede the schedule.
+	var event, data string
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() && (event != "schedule" || data == "") {
+		line := sc.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			event, data = strings.TrimPrefix(line, "event: "), ""
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+	if event != "schedule" {
+		t.Fatalf("/events ended before a schedule event, last %q", event)
+	}
+	if diff := cmp.Diff(scheduleFields, keys(t, json.RawMessage(data))); diff != "" {
+		t.Errorf("/events data fields mismatch (-want +got):\n%s", diff)
+	}
+
+	s, ok := <-client.Watch(ctx, port, "always")
+	if !ok {
+		t.Fatal("client.Watch closed without a schedule")
+	}
+	if s.Name != "always" || s.State != window.StateOpen {
+		t.Errorf("client.Watch delivered %+v, want the open always schedule", s)
+	}
+}
+
+func TestIntent(t *testing.T) {
+	ctx := context.Background()
+	i, err := client.DeclareIntent(ctx, port, "always", "patcher", "install updates", 30*time.Minute)
+	if err != nil {
+		t.Fatalf("client.DeclareIntent: %v", err)
+	}
+	if i.Label != "always" || i.Expires.IsZero() || i.Seq == 0 {
+		t.Errorf("client.DeclareIntent = %+v, want a registered intent expiring with the window", i)
+	}
+	if _, err := client.DeclareIntent(ctx, port, "missing", "patcher", "install updates", time.Minute); !errors.Is(err, window.ErrNoWindows) {
+		t.Errorf("client.DeclareIntent(missing) = %v, want %v", err, window.ErrNoWindows)
+	}
+
+	code, _, body := get(t, "/intent")
+	if code != http.StatusOK {
+		t.Fatalf("/intent = %d %s", code, body)
+	}
+	checkShape(t, body, 1, "Label", "Agent", "Action", "EstimatedDuration", "Registered", "Expires", "Seq")
+
+	l, err := client.Intents(ctx, port, "always")
+	if err != nil {
+		t.Fatalf("client.Intents: %v", err)
+	}
+	if len(l) != 1 || l[0].Agent != "patcher" || l[0].EstimatedDuration != 30*time.Minute {
+		t.Errorf("client.Intents(always) = %+v, want the patcher intent", l)
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package journald provides a deck backend writing to the systemd journal
+// using its native protocol, preserving structured fields.
+package journald
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/google/deck"
+)
+
+// SocketPath is the default location of the journal's native socket.
+const SocketPath = "/run/systemd/journal/socket"
+
+// fieldPrefix namespaces journal fields within a deck.AttribStore.
+const fieldPrefix = "journald:"
+
+// Init initializes the journald backend for use in a deck. Messages are
+// tagged with identifier as their SYSLOG_IDENTIFIER.
+func Init(identifier string) (*Journal, error) {
+	return InitWithSocket(identifier, SocketPath)
+}
+
+// InitWithSocket initializes the journald backend using the journal socket at path.
+func InitWithSocket(identifier, path string) (*Journal, error) {
+	conn, err := net.Dial("unixgram", path)
+	if err != nil {
+		return nil, fmt.Errorf("journald: %w", err)
+	}
+	return &Journal{conn: conn, identifier: identifier}, nil
+}
+
+// Field attaches a structured field to a message. Keys are upper-cased as
+// required by the journal.
+func Field(key, value string) deck.Attrib {
+	return func(a *deck.AttribStore) {
+		a.Store(fieldPrefix+strings.ToUpper(key), value)
+	}
+}
+
+// Journal is a deck backend writing messages to the systemd journal.
+type Journal struct {
+	conn       net.Conn
+	identifier string
+}
+
+// Close closes the journald backend.
+func (j *Journal) Close() error {
+	return j.conn.Close()
+}
+
+type message struct {
+	parent  *Journal
+	level   deck.Level
+	message string
+	fields  map[string]string
+}
+
+// New creates a new journald message.
+func (j *Journal) New(lvl deck.Level, msg string) deck.Composer {
+	return &message{parent: j, level: lvl, message: msg, fields: make(map[string]string)}
+}
+
+// priority maps deck levels to syslog priorities.
+func priority(lvl deck.Level) int {
+	switch lvl {
+	case deck.DEBUG:
+		return 7
+	case deck.WARNING:
+		return 4
+	case deck.ERROR:
+		return 3
+	case deck.FATAL:
+		return 2
+	}
+	return 6
+}
+
+// writeField appends a field in the journal native format. Values containing
+// newlines use the length-prefixed binary encoding.
+func writeField(b *bytes.Buffer, key, value string) {
+	if !strings.Contains(value, "\n") {
+		fmt.Fprintf(b, "%s=%s\n", key, value)
+		return
+	}
+	b.WriteString(key)
+	b.WriteByte('\n')
+	binary.Write(b, binary.LittleEndian, uint64(len(value)))
+	b.WriteString(value)
+	b.WriteByte('\n')
+}
+
+// Write flushes a stored message to the journal.
+func (m *message) Write() error {
+	var b bytes.Buffer
+	writeField(&b, "MESSAGE", m.message)
+	writeField(&b, "PRIORITY", fmt.Sprint(priority(m.level)))
+	writeField(&b, "SYSLOG_IDENTIFIER", m.parent.identifier)
+	for k, v := range m.fields {
+		writeField(&b, k, v)
+	}
+	_, err := m.parent.conn.Write(b.Bytes())
+	return err
+}
+
+// Compose collects structured fields attached to the message.
+func (m *message) Compose(s *deck.AttribStore) error {
+	s.Range(func(k, v any) bool {
+		key, ok := k.(string)
+		if !ok || !strings.HasPrefix(key, fieldPrefix) {
+			return true
+		}
+		m.fields[strings.TrimPrefix(key, fieldPrefix)] = fmt.Sprint(v)
+		return true
+	})
+	return nil
+}
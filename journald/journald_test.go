@@ -0,0 +1,59 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package journald
+
+import (
+	"net"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/google/deck"
+)
+
+func TestJournal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix datagram sockets are unsupported on windows")
+	}
+	path := filepath.Join(t.TempDir(), "socket")
+	ln, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("unable to listen on %s: %v", path, err)
+	}
+	defer ln.Close()
+
+	j, err := InitWithSocket("aukera", path)
+	if err != nil {
+		t.Fatalf("InitWithSocket() returned error: %v", err)
+	}
+	defer j.Close()
+
+	d := deck.New()
+	d.Add(j)
+	d.WarningA("window\nclosed").With(Field("label", "patch")).Go()
+
+	buf := make([]byte, 4096)
+	n, err := ln.Read(buf)
+	if err != nil {
+		t.Fatalf("unable to read message: %v", err)
+	}
+	got := string(buf[:n])
+	for _, want := range []string{"MESSAGE\n", "window\nclosed\n", "PRIORITY=4\n", "SYSLOG_IDENTIFIER=aukera\n", "LABEL=patch\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("journal message missing %q: %q", want, got)
+		}
+	}
+}
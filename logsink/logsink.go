@@ -0,0 +1,107 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package logsink composes several deck backends, each logging at its own
+// minimum level.
+package logsink
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/deck"
+)
+
+var levels = map[string]deck.Level{
+	"debug":   deck.DEBUG,
+	"info":    deck.INFO,
+	"warning": deck.WARNING,
+	"error":   deck.ERROR,
+	"fatal":   deck.FATAL,
+}
+
+// ParseLevel returns the deck level with the given name.
+func ParseLevel(name string) (deck.Level, error) {
+	l, ok := levels[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return 0, fmt.Errorf("unknown log level %q", name)
+	}
+	return l, nil
+}
+
+// Spec names a log sink and the minimum level of messages it receives.
+type Spec struct {
+	Name  string
+	Level deck.Level
+}
+
+// ParseSpecs parses a comma-separated list of sinks, each optionally
+// followed by a colon and minimum level, such as "file:debug,eventlog:warning".
+// Sinks without a level receive messages at def and above.
+func ParseSpecs(s string, def deck.Level) ([]Spec, error) {
+	var out []Spec
+	seen := make(map[string]bool)
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		spec := Spec{Name: f, Level: def}
+		if i := strings.Index(f, ":"); i >= 0 {
+			l, err := ParseLevel(f[i+1:])
+			if err != nil {
+				return nil, fmt.Errorf("log sink %q: %w", f, err)
+			}
+			spec.Name, spec.Level = f[:i], l
+		}
+		spec.Name = strings.ToLower(spec.Name)
+		if seen[spec.Name] {
+			return nil, fmt.Errorf("log sink %q specified more than once", spec.Name)
+		}
+		seen[spec.Name] = true
+		out = append(out, spec)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no log sinks specified")
+	}
+	return out, nil
+}
+
+// filter is a deck backend discarding messages below a minimum level.
+type filter struct {
+	deck.Backend
+	min deck.Level
+}
+
+type discard struct{}
+
+func (discard) Compose(*deck.AttribStore) error { return nil }
+func (discard) Write() error                    { return nil }
+
+// New creates a message on the underlying backend if lvl is at or above the
+// filter's minimum.
+func (f *filter) New(lvl deck.Level, msg string) deck.Composer {
+	if lvl < f.min {
+		return discard{}
+	}
+	return f.Backend.New(lvl, msg)
+}
+
+// Filter wraps b so that it only receives messages at min or above.
+func Filter(b deck.Backend, min deck.Level) deck.Backend {
+	if min == deck.DEBUG {
+		return b
+	}
+	return &filter{Backend: b, min: min}
+}
@@ -0,0 +1,67 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logsink
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/deck"
+	"github.com/google/deck/backends/logger"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseSpecs(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []Spec
+		wantErr bool
+	}{
+		{"file", []Spec{{"file", deck.INFO}}, false},
+		{"file:debug, EventLog:warning", []Spec{{"file", deck.DEBUG}, {"eventlog", deck.WARNING}}, false},
+		{"file,stderr:Error", []Spec{{"file", deck.INFO}, {"stderr", deck.ERROR}}, false},
+		{"file:loud", nil, true},
+		{"file,file:debug", nil, true},
+		{" , ", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSpecs(tt.in, deck.INFO)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSpecs(%q) returned error %v, want error %t", tt.in, err, tt.wantErr)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("ParseSpecs(%q) returned diff (-want +got):\n%s", tt.in, diff)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	var verbose, quiet bytes.Buffer
+	d := deck.New()
+	d.Add(Filter(logger.Init(&verbose, 0), deck.DEBUG))
+	d.Add(Filter(logger.Init(&quiet, 0), deck.WARNING))
+
+	d.Info("schedule loaded")
+	d.Warning("window expired")
+
+	if !strings.Contains(verbose.String(), "schedule loaded") || !strings.Contains(verbose.String(), "window expired") {
+		t.Errorf("debug sink output = %q, want both messages", verbose.String())
+	}
+	if strings.Contains(quiet.String(), "schedule loaded") || !strings.Contains(quiet.String(), "window expired") {
+		t.Errorf("warning sink output = %q, want only the warning", quiet.String())
+	}
+}
@@ -16,26 +16,329 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"flag"
 	"github.com/google/deck/backends/logger"
 	"github.com/google/deck"
 	"github.com/google/aukera/auklib"
+	"github.com/google/aukera/control"
+	"github.com/google/aukera/gcal"
+	"github.com/google/aukera/logsink"
+	"github.com/google/aukera/notify"
+	"github.com/google/aukera/regstate"
+	"github.com/google/aukera/schedule"
+	"github.com/google/aukera/server"
+	"github.com/google/aukera/signing"
+	"github.com/google/aukera/snapshot"
+	"github.com/google/aukera/window"
 )
 
 var (
-	runInDebug = flag.Bool("debug", false, "Run in debug mode")
-	port       = flag.Int("port", auklib.ServicePort, "Define listening port")
+	runInDebug     = flag.Bool("debug", false, "Run in debug mode, which also serves pprof and expvar on -debug_port")
+	debugPort      = flag.Int("debug_port", server.DebugPort, "Localhost-only port for pprof and expvar in debug mode")
+	port           = flag.Int("port", -1, "Define listening port (default: from the AUKERA_PORT environment variable, settings file or policy, else 9119)")
+	readTimeout    = flag.Duration("read_timeout", server.DefaultConfig.ReadTimeout, "Maximum duration for reading a request")
+	writeTimeout   = flag.Duration("write_timeout", server.DefaultConfig.WriteTimeout, "Maximum duration before timing out writes of a response")
+	idleTimeout    = flag.Duration("idle_timeout", server.DefaultConfig.IdleTimeout, "Maximum duration to wait for the next request on keep-alive connections")
+	handlerTimeout = flag.Duration("handler_timeout", server.DefaultConfig.HandlerTimeout, "Maximum duration for handling a request before responding 503 Service Unavailable; 0 disables")
+	sign           = flag.Bool("sign", false, "Sign schedule responses with the host signing key")
+	logBackend     = flag.String("log_backend", defaultLogSinks, "Comma-separated log sinks, each optionally suffixed with a minimum level such as file:info. Sinks are file and stderr, plus journald or syslog on Linux, unified or syslog on macOS and eventlog on Windows")
+	noActiveHours  = flag.Bool("disable_active_hours", false, "Omit the built-in active_hours and outside_active_hours windows (also set by DisableActiveHours in the settings file)")
+	osUpdates      = flag.Bool("os_updates", false, "Add the built-in os_updates window, open while Windows Update or unattended-upgrades/dnf-automatic install updates on their schedule (also set by OSUpdates in the settings file)")
+	maxDuration    = flag.Duration("max_window_duration", window.MaxDuration, "Reject windows configured to stay open longer than this; 0 removes the bound")
+	configPerms    = flag.String("config_permissions", "", "How configuration files and directories that are not owned by root/Administrators or are world-writable are treated: off, warn or enforce (default: ConfigPermissions in the settings file, else off)")
+	snapInterval   = flag.Duration("snapshot_interval", 10*time.Minute, "How often computed schedules are recorded for postmortems; 0 disables snapshots")
+	snapRetention  = flag.Duration("snapshot_retention", 14*24*time.Hour, "How long schedule snapshots are kept")
+	snapCompress   = flag.Duration("snapshot_compress_after", snapshot.CompressAfter, "Age at which schedule snapshots are gzip compressed; 0 disables compression")
+	snapMaxBytes   = flag.Int64("snapshot_max_bytes", snapshot.MaxBytes, "Disk space schedule snapshots may use before the oldest are removed; 0 removes the bound")
+	notifyLead     = flag.Duration("notify_lead", 0, "On Windows, notify the console user this long before windows tagged notify=true open; 0 disables notifications")
+	registryState  = flag.Bool("registry_state", false, "On Windows, mirror each label's state and next open time to HKLM\\"+regstate.KeyPath+" for legacy tooling")
+	requireValid   = flag.Bool("require_valid_config", false, "Exit with an error at startup if no valid windows are configured, for deployments where running without a schedule is worse than not running")
+	dryRun         = flag.Bool("dry_run", false, "Load the configuration, print the next schedule of every label and any errors, then exit; exits non-zero if no valid windows are configured")
+	maxHeaderBytes = flag.Int("max_header_bytes", server.DefaultConfig.MaxHeaderBytes, "Maximum size of request headers in bytes")
+	maxInFlight    = flag.Int("max_in_flight", server.DefaultConfig.MaxInFlight, "Maximum number of requests handled at once; further requests receive 503 Service Unavailable with Retry-After. 0 removes the limit")
+	controlSocket  = flag.String("control_socket", control.DefaultSocketPath(), "Unix socket accepting local administrative commands (reload, dump-state, set-override, drain); empty disables it. Only Linux restricts it to root or the service user, so it is unavailable elsewhere")
+	inline         stringList
 )
 
+func init() {
+	flag.Var(&inline, "window", "Inline window JSON, loaded in addition to the configuration directory; repeatable. Also accepts an array of windows or a whole configuration file, as does the "+window.InlineEnv+" environment variable")
+}
+
+// stringList is a repeatable string flag.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, " ")
+}
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+// inlineConfig parses the configuration given in window.InlineEnv and by
+// -window flags.
+func inlineConfig() ([][]byte, error) {
+	var out [][]byte
+	if env := os.Getenv(window.InlineEnv); env != "" {
+		b, err := window.ParseInline(env)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", window.InlineEnv, err)
+		}
+		out = append(out, b)
+	}
+	for i, s := range inline {
+		b, err := window.ParseInline(s)
+		if err != nil {
+			return nil, fmt.Errorf("-window #%d: %w", i+1, err)
+		}
+		out = append(out, b)
+	}
+	return out, nil
+}
+
+// serverConfig returns the schedule server configuration set by flags.
+func serverConfig() server.Config {
+	cfg := server.Config{
+		ReadTimeout:    *readTimeout,
+		WriteTimeout:   *writeTimeout,
+		IdleTimeout:    *idleTimeout,
+		MaxHeaderBytes: *maxHeaderBytes,
+		HandlerTimeout: *handlerTimeout,
+		MaxInFlight:    *maxInFlight,
+	}
+	if *sign {
+		k, err := signing.LoadOrCreateKey()
+		if err != nil {
+			deck.Errorf("Unable to load signing key, responses will not be signed: %v", err)
+		} else {
+			cfg.SigningKey = k
+		}
+	}
+	return cfg
+}
+
+// initLogging adds the log sinks selected by flags to the default deck. Debug
+// mode additionally logs everything to stderr. The returned func closes any
+// opened log files.
+func initLogging() (func(), error) {
+	specs, err := logsink.ParseSpecs(*logBackend, deck.DEBUG)
+	if err != nil {
+		return nil, err
+	}
+	if *runInDebug {
+		stderr := false
+		for _, s := range specs {
+			stderr = stderr || s.Name == "stderr"
+		}
+		if !stderr {
+			specs = append(specs, logsink.Spec{Name: "stderr", Level: deck.DEBUG})
+		}
+	}
+	var files []*os.File
+	cleanup := func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}
+	for _, s := range specs {
+		var b deck.Backend
+		switch s.Name {
+		case "file":
+			lf, err := os.OpenFile(auklib.LogPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0664)
+			if err != nil {
+				cleanup()
+				return nil, fmt.Errorf("failed to open log file: %v", err)
+			}
+			files = append(files, lf)
+			b = logger.Init(lf, 0)
+		case "stderr":
+			b = logger.Init(os.Stderr, 0)
+		default:
+			b, err = platformLogBackend(s.Name)
+			if err != nil {
+				cleanup()
+				return nil, err
+			}
+		}
+		deck.Add(logsink.Filter(b, s.Level))
+	}
+	return cleanup, nil
+}
+
+// calendarSyncInterval is how often upcoming windows are published to
+// Google Calendar when publishing is configured.
+const calendarSyncInterval = 15 * time.Minute
+
+// startCalendarSync publishes upcoming windows to Google Calendar in the
+// background if gcal.ConfigPath exists.
+func startCalendarSync() {
+	cfg, err := gcal.LoadConfig()
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		deck.Warningf("Calendar publishing disabled: %v", err)
+		return
+	}
+	p, err := gcal.New(cfg)
+	if err != nil {
+		deck.Warningf("Calendar publishing disabled: %v", err)
+		return
+	}
+	deck.Infof("Publishing upcoming windows to calendar %q.", cfg.CalendarID)
+	go p.Run(context.Background(), calendarSyncInterval, func(ctx context.Context, from, to time.Time, labels ...string) ([]window.Schedule, error) {
+		return schedule.Range(ctx, from, to, schedule.Options{}, labels...)
+	})
+}
+
+// notifyInterval is how often upcoming windows are checked for user
+// notifications.
+const notifyInterval = time.Minute
+
+// startNotifier notifies the console user of upcoming notify windows in the
+// background if -notify_lead is set.
+func startNotifier() {
+	if *notifyLead <= 0 {
+		return
+	}
+	n := &notify.Notifier{Lead: *notifyLead, Windows: schedule.Windows}
+	go n.Run(context.Background(), notifyInterval)
+}
+
+// registryStateInterval is how often label state is published to the
+// registry.
+const registryStateInterval = time.Minute
+
+// startRegistryState publishes label state to the registry in the background
+// if -registry_state is set.
+func startRegistryState() {
+	if !*registryState {
+		return
+	}
+	p := &regstate.Publisher{Schedules: func() ([]window.Schedule, error) {
+		return schedule.Query(schedule.Options{})
+	}}
+	go p.Run(context.Background(), registryStateInterval)
+}
+
+// Besides the resume signals of logind on Linux, resume from sleep, or the
+// wall clock jumping forward, is detected by comparing wall-clock and
+// monotonic time every resumeCheckInterval; jumps beyond resumeTolerance
+// invalidate computed schedules.
+const (
+	resumeCheckInterval = time.Minute
+	resumeTolerance     = 30 * time.Second
+)
+
+// startResumeWatch recomputes schedules in the background whenever the host
+// resumes from sleep, as signalled by logind on Linux, or appears to have
+// been suspended. This complements the power events delivered to the Windows
+// service.
+func startResumeWatch() {
+	go schedule.WatchResume(resumeCheckInterval, resumeTolerance, nil)
+}
+
+// activeHoursDisabled reports whether the built-in active hours windows are
+// disabled by flag or in the settings file.
+func activeHoursDisabled() bool {
+	if *noActiveHours {
+		return true
+	}
+	s, err := auklib.LoadSettings()
+	return err == nil && s.DisableActiveHours
+}
+
+// osUpdatesEnabled reports whether the built-in os_updates window is enabled
+// by flag or in the settings file.
+func osUpdatesEnabled() bool {
+	if *osUpdates {
+		return true
+	}
+	s, err := auklib.LoadSettings()
+	return err == nil && s.OSUpdates
+}
+
+// configPermissions returns the configuration permission mode set by flag or
+// in the settings file.
+func configPermissions() (window.PermissionMode, error) {
+	if *configPerms != "" {
+		return window.ParsePermissionMode(*configPerms)
+	}
+	s, err := auklib.LoadSettings()
+	if err != nil {
+		return window.PermissionOff, nil
+	}
+	return window.ParsePermissionMode(s.ConfigPermissions)
+}
+
 func main() {
+	flag.Parse()
+	schedule.DisableActiveHours = activeHoursDisabled()
+	schedule.EnableOSUpdates = osUpdatesEnabled()
+	window.MaxDuration = *maxDuration
+	var err error
+	if window.FilePermissions, err = configPermissions(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if window.Inline, err = inlineConfig(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	switch flag.Arg(0) {
+	case "export":
+		if err := runExport(flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	case "encrypt-config":
+		if err := runEncrypt(flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	case "snapshot":
+		if err := runSnapshot(flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	case "new-window":
+		if err := runNewWindow(flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	case "schtasks":
+		if err := runSchtasks(flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	case "install-systemd":
+		if err := runInstallSystemd(flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Initialize configuration directory
 	exist, err := auklib.PathExists(auklib.ConfDir)
 	if err != nil {
 		deck.Errorf("unexpected error finding path %s: %v", auklib.ConfDir, err)
 	}
-	if exist == false {
+	if exist == false && !*dryRun && len(window.Inline) == 0 {
 		deck.Warning("Configuration directory does not exist. Attempting creation.")
 		if err := os.MkdirAll(auklib.ConfDir, 0664); err != nil {
 			deck.Warningf("Unable to create configuration directory: %v", err)
@@ -43,21 +346,68 @@ func main() {
 	}
 
 	// Initialize logger
-	lf, err := os.OpenFile(auklib.LogPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0664)
+	closeLogs, err := initLogging()
 	if err != nil {
-		deck.Fatalln("Failed to open log file: ", err)
+		deck.Fatalln("Failed to initialize logging: ", err)
 		os.Exit(1)
 	}
-	defer lf.Close()
-	deck.Add(logger.Init(lf, 0))
+	defer closeLogs()
 	defer deck.Close()
 
+	if *dryRun {
+		if err := runDryRun(os.Stdout); err != nil {
+			deck.Error(err)
+			closeLogs()
+			deck.Close()
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if err := setup(); err != nil {
 		deck.Fatalln("Setup exited with error: ", err)
 		os.Exit(1)
 	}
 
+	if err := checkStartupConfig(); err != nil {
+		deck.Errorf("Refusing to start: %v", err)
+		closeLogs()
+		deck.Close()
+		fmt.Fprintln(os.Stderr, "Refusing to start:", err)
+		os.Exit(1)
+	}
+
+	if *runInDebug {
+		go func() {
+			if err := server.RunDebug(*debugPort); err != nil {
+				deck.Errorf("Debug server exited with error: %v", err)
+			}
+		}()
+	}
+
+	if *controlSocket != "" {
+		go func() {
+			if err := server.RunControl(*controlSocket); err != nil {
+				deck.Errorf("Control socket exited with error: %v", err)
+			}
+		}()
+	}
+
+	if *snapInterval > 0 {
+		snapshot.CompressAfter, snapshot.MaxBytes = *snapCompress, *snapMaxBytes
+		go snapshot.Run(context.Background(), snapshot.Dir, *snapInterval, *snapRetention, func() ([]window.Schedule, error) {
+			return schedule.Query(schedule.Options{})
+		})
+	}
+
+	startCalendarSync()
+	startNotifier()
+	startRegistryState()
+	startResumeWatch()
+
 	err = run()
+	schedule.FlushQueryHistory()
 	if err != nil {
 		deck.Fatalln("Run exited with error: ", err)
 		os.Exit(1)
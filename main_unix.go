@@ -16,11 +16,61 @@
 
 package main
 
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
+
+	"github.com/google/deck/backends/syslog"
+	"github.com/google/deck"
+	"github.com/google/aukera/auklib"
+	"github.com/google/aukera/journald"
+	"github.com/google/aukera/server"
+	"github.com/google/aukera/window"
+)
+
+// defaultScheme is the encryption scheme used by encrypt-config unless
+// overridden by flag.
+const defaultScheme = window.SchemeAESGCM
+
+// defaultLogSinks are the log sinks used unless overridden by flag.
+const defaultLogSinks = "file"
+
+// platformLogBackend initializes the named system logging backend. macOS
+// routes syslog messages into unified logging.
+func platformLogBackend(name string) (deck.Backend, error) {
+	switch {
+	case name == "journald" && runtime.GOOS == "linux":
+		return journald.Init("aukera")
+	case name == "syslog", name == "unified" && runtime.GOOS == "darwin":
+		return syslog.Init("aukera", syslog.LOG_DAEMON)
+	}
+	return nil, fmt.Errorf("unsupported log backend on %s: %q", runtime.GOOS, name)
+}
+
 func setup() error {
 	return nil
 }
 
-// Stub for running Aukera on Linux.
+// run serves schedules until the server fails or the process is asked to
+// stop with SIGTERM, as by systemd, or SIGINT.
 func run() error {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGTERM, syscall.SIGINT)
+	defer signal.Stop(sig)
+	errch := make(chan error, 1)
+	go func() {
+		errch <- server.RunWithConfig(auklib.ConfiguredPort(*port), serverConfig())
+	}()
+	deck.Infof("Service started.")
+
+	select {
+	case err := <-errch:
+		return fmt.Errorf("%s server failed: %v", auklib.Defaults.ServiceName, err)
+	case s := <-sig:
+		deck.Infof("Received %v; stopping %s service.", s, auklib.Defaults.ServiceName)
+	}
 	return nil
 }
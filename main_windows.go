@@ -18,38 +18,145 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"time"
+	"unsafe"
 
 	"github.com/google/deck/backends/eventlog"
 	"github.com/google/deck"
 	"github.com/google/aukera/auklib"
+	"github.com/google/aukera/schedule"
 	"github.com/google/aukera/server"
+	"github.com/google/aukera/window"
+	"golang.org/x/sys/windows"
 	"golang.org/x/sys/windows/svc/debug"
 	"golang.org/x/sys/windows/svc"
 )
 
-// Type winSvc implements svc.Handler.
-type winSvc struct{}
+// Power broadcast event types delivered with svc.PowerEvent.
+const (
+	pbtAPMResumeSuspend   = 0x7
+	pbtAPMResumeAutomatic = 0x12
+)
 
-func setup() error {
-	evt, err := eventlog.InitWithDefaultInstall("aukera")
+// svcControlUpdate is the user-defined service control code (sc control
+// Aukera 128) an updater sends to request that the service stop to be
+// updated. Unlike Stop, the service keeps running until the self-update
+// window opens, bounded by selfUpdateMaxDelay, and then stops itself.
+//
+// A stop carrying a reason code (sc stop Aukera <reason>) cannot serve
+// instead: the service control manager expects a stop to complete within
+// its wait hint, and a service left stop pending for hours blocks other
+// control requests and is reported as hung.
+//
+// Service controls do not identify their sender, so the service restricts
+// who may send user-defined controls with restrictServiceControl at startup,
+// and ignores svcControlUpdate if it cannot.
+const svcControlUpdate = svc.Cmd(128)
+
+// aclHeader mirrors the header of an ACL, whose fields windows.ACL does not
+// export.
+type aclHeader struct {
+	revision, sbz1 byte
+	size, count    uint16
+	sbz2           uint16
+}
+
+// accessAllowedACE mirrors ACCESS_ALLOWED_ACE, up to the start of its SID.
+type accessAllowedACE struct {
+	typ, flags byte
+	size       uint16
+	mask       windows.ACCESS_MASK
+	sidStart   uint32
+}
+
+// accessAllowedACEType is the type of an ACCESS_ALLOWED_ACE.
+const accessAllowedACEType = 0
+
+// restrictServiceControl revokes user-defined controls (CR) from every
+// entry of the named service's DACL that grants them to anyone but SYSTEM
+// and Administrators. Other rights, including those granted by installers
+// or administrators, are kept, and the DACL is only written if it changes,
+// which is normally on the first start after installation.
+func restrictServiceControl(name string) error {
+	sd, err := windows.GetNamedSecurityInfo(name, windows.SE_SERVICE, windows.DACL_SECURITY_INFORMATION)
+	if err != nil {
+		return err
+	}
+	dacl, _, err := sd.DACL()
 	if err != nil {
 		return err
 	}
-	deck.Add(evt)
+	if dacl == nil {
+		return fmt.Errorf("service %s has a null DACL, granting everyone full control", name)
+	}
+	var changed bool
+	hdr := (*aclHeader)(unsafe.Pointer(dacl))
+	p := unsafe.Add(unsafe.Pointer(dacl), unsafe.Sizeof(*hdr))
+	for i := 0; i < int(hdr.count); i++ {
+		ace := (*accessAllowedACE)(p)
+		if ace.typ == accessAllowedACEType && ace.mask&windows.SERVICE_USER_DEFINED_CONTROL != 0 {
+			sid := (*windows.SID)(unsafe.Pointer(&ace.sidStart))
+			if !sid.IsWellKnown(windows.WinLocalSystemSid) && !sid.IsWellKnown(windows.WinBuiltinAdministratorsSid) {
+				ace.mask &^= windows.SERVICE_USER_DEFINED_CONTROL
+				changed = true
+			}
+		}
+		p = unsafe.Add(p, ace.size)
+	}
+	if !changed {
+		return nil
+	}
+	deck.Infof("Restricting user-defined controls of the %s service to SYSTEM and Administrators.", name)
+	return windows.SetNamedSecurityInfo(name, windows.SE_SERVICE, windows.DACL_SECURITY_INFORMATION, nil, nil, dacl, nil)
+}
+
+// selfUpdateMaxDelay bounds how long a stop for update is delayed.
+const selfUpdateMaxDelay = 24 * time.Hour
+
+// Type winSvc implements svc.Handler.
+type winSvc struct {
+	// updateControl reports whether svcControlUpdate is honored, which it
+	// is only once user-defined controls are restricted to administrators.
+	updateControl bool
+}
+
+// defaultScheme is the encryption scheme used by encrypt-config unless
+// overridden by flag.
+const defaultScheme = window.SchemeDPAPI
+
+// defaultLogSinks are the log sinks used unless overridden by flag.
+const defaultLogSinks = "file,eventlog"
+
+// platformLogBackend initializes the named system logging backend.
+func platformLogBackend(name string) (deck.Backend, error) {
+	if name == "eventlog" {
+		return eventlog.InitWithDefaultInstall("aukera")
+	}
+	return nil, fmt.Errorf("unsupported log backend on windows: %q", name)
+}
+
+func setup() error {
 	return nil
 }
 
 func startService(isDebug bool) error {
-	deck.Infof("Starting %s service.", auklib.ServiceName)
+	deck.Infof("Starting %s service.", auklib.Defaults.ServiceName)
 	run := svc.Run
 	if isDebug {
 		run = debug.Run
 	}
-	if err := run(auklib.ServiceName, winSvc{}); err != nil {
-		return fmt.Errorf("%s service failed: %v", auklib.ServiceName, err)
+	var s winSvc
+	if err := restrictServiceControl(auklib.Defaults.ServiceName); err != nil {
+		deck.Warningf("Unable to restrict %s service controls, ignoring update requests: %v", auklib.Defaults.ServiceName, err)
+	} else {
+		s.updateControl = true
+	}
+	if err := run(auklib.Defaults.ServiceName, s); err != nil {
+		return fmt.Errorf("%s service failed: %v", auklib.Defaults.ServiceName, err)
 	}
-	deck.Infof("%s service stopped.", auklib.ServiceName)
+	deck.Infof("%s service stopped.", auklib.Defaults.ServiceName)
 	return nil
 }
 
@@ -60,16 +167,18 @@ func startService(isDebug bool) error {
 // we break out of the loop and send a StopPending status to
 // Windows, which will stop the service process and all child processes.
 func (m winSvc) Execute(args []string, r <-chan svc.ChangeRequest, changes chan<- svc.Status) (bool, uint32) {
-	const cmdsAccepted = svc.AcceptStop | svc.AcceptShutdown | svc.AcceptPauseAndContinue
+	const cmdsAccepted = svc.AcceptStop | svc.AcceptShutdown | svc.AcceptPauseAndContinue | svc.AcceptPowerEvent
 	var (
 		ssec  bool
 		errno uint32
 	)
 	errch := make(chan error)
+	updatech := make(chan error, 1)
+	var updating bool
 
 	changes <- svc.Status{State: svc.StartPending}
 	go func() {
-		errch <- server.Run(*port)
+		errch <- server.RunWithConfig(auklib.ConfiguredPort(*port), serverConfig())
 	}()
 	deck.Infof("Service started.")
 
@@ -79,7 +188,14 @@ loop:
 		select {
 		// Watch for the aukera goroutine to fail for some reason.
 		case err := <-errch:
-			deck.Errorf("%s goroutine has failed: %v", auklib.ServiceName, err)
+			deck.Errorf("%s goroutine has failed: %v", auklib.Defaults.ServiceName, err)
+			break loop
+		// Watch for a pending stop for update to be allowed.
+		case err := <-updatech:
+			if err != nil {
+				deck.Warningf("Stopping for update without a self-update window: %v", err)
+			}
+			deck.Infof("Stopping %s service for update.", auklib.Defaults.ServiceName)
 			break loop
 		// Watch for service signals.
 		case c := <-r:
@@ -88,10 +204,28 @@ loop:
 				changes <- c.CurrentStatus
 			case svc.Stop, svc.Shutdown:
 				break loop
+			case svcControlUpdate:
+				if !m.updateControl {
+					deck.Warningf("Ignoring update request: service controls are not restricted to administrators.")
+					continue
+				}
+				if updating {
+					continue
+				}
+				updating = true
+				deck.Infof("Update requested; stopping when label %q opens.", window.SelfUpdateLabel)
+				go func() {
+					updatech <- schedule.AwaitSelfUpdate(context.Background(), selfUpdateMaxDelay)
+				}()
 			case svc.Pause:
 				changes <- svc.Status{State: svc.Paused, Accepts: cmdsAccepted}
 			case svc.Continue:
 				changes <- svc.Status{State: svc.Running, Accepts: cmdsAccepted}
+			case svc.PowerEvent:
+				switch c.EventType {
+				case pbtAPMResumeSuspend, pbtAPMResumeAutomatic:
+					go schedule.Resume()
+				}
 			default:
 				deck.Errorf("unexpected control request #%d", c)
 			}
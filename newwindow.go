@@ -0,0 +1,181 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/aukera/auklib"
+	"github.com/google/aukera/window"
+)
+
+var weekdays = map[string]string{
+	"sun": "SUN", "mon": "MON", "tue": "TUE", "wed": "WED",
+	"thu": "THU", "fri": "FRI", "sat": "SAT",
+}
+
+// cronSpec builds a cron schedule opening at the given HH:MM time on a
+// comma-separated list of weekdays, or every day if days is empty.
+func cronSpec(days, at string) (string, error) {
+	t, err := time.Parse("15:04", at)
+	if err != nil {
+		return "", fmt.Errorf("time must be formatted as HH:MM (found: %q)", at)
+	}
+	dow := "*"
+	if days != "" {
+		var names []string
+		for _, d := range strings.Split(days, ",") {
+			d = strings.ToLower(strings.TrimSpace(d))
+			if len(d) > 3 {
+				d = d[:3]
+			}
+			n, ok := weekdays[d]
+			if !ok {
+				return "", fmt.Errorf("unknown day %q", d)
+			}
+			names = append(names, n)
+		}
+		dow = strings.Join(names, ",")
+	}
+	return fmt.Sprintf("0 %d %d * * %s", t.Minute(), t.Hour(), dow), nil
+}
+
+// checkName rejects window names that cannot be used as the base name of a
+// file in the configuration directory.
+func checkName(name string) error {
+	if strings.ContainsAny(name, `/\`) || strings.Contains(name, "..") {
+		return fmt.Errorf("window name %q must not contain path separators or \"..\"", name)
+	}
+	return nil
+}
+
+// prompt asks for a value on stdin when v was not set by flag.
+func prompt(in *bufio.Reader, out io.Writer, v *string, question string) error {
+	for *v == "" {
+		fmt.Fprintf(out, "%s: ", question)
+		line, err := in.ReadString('\n')
+		*v = strings.TrimSpace(line)
+		if err != nil {
+			if *v == "" {
+				return fmt.Errorf("no value for %q", question)
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// runNewWindow implements the new-window subcommand, generating a validated
+// configuration file for a single window.
+func runNewWindow(args []string) error {
+	fs := flag.NewFlagSet("new-window", flag.ContinueOnError)
+	name := fs.String("name", "", "Name of the window")
+	days := fs.String("days", "", "Comma-separated days the window opens, such as Tue,Thu (default: every day)")
+	at := fs.String("time", "", "Time of day the window opens, as HH:MM")
+	duration := fs.String("duration", "", "How long the window stays open, such as 4h")
+	labels := fs.String("label", "", "Comma-separated labels the window applies to")
+	out := fs.String("out", "", "Output file path (default: <name>.json in the configuration directory)")
+	count := fs.Int("count", 5, "Number of upcoming occurrences to print")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	in := bufio.NewReader(os.Stdin)
+	for _, p := range []struct {
+		v *string
+		q string
+	}{
+		{name, "Window name"},
+		{at, "Opening time (HH:MM)"},
+		{duration, "Duration (e.g. 4h)"},
+		{labels, "Labels (comma-separated)"},
+	} {
+		if err := prompt(in, os.Stdout, p.v, p.q); err != nil {
+			return fmt.Errorf("new-window: %v", err)
+		}
+	}
+
+	if err := checkName(*name); err != nil {
+		return fmt.Errorf("new-window: %v", err)
+	}
+	spec, err := cronSpec(*days, *at)
+	if err != nil {
+		return fmt.Errorf("new-window: %v", err)
+	}
+	d, err := time.ParseDuration(*duration)
+	if err != nil {
+		return fmt.Errorf("new-window: %v", err)
+	}
+	var l []string
+	for _, s := range strings.Split(*labels, ",") {
+		l = append(l, strings.ToLower(strings.TrimSpace(s)))
+	}
+	conf := struct {
+		Windows []window.Window
+	}{[]window.Window{{
+		Name:       *name,
+		Format:     window.FormatCron,
+		CronString: spec,
+		Duration:   d,
+		Labels:     l,
+	}}}
+	b, err := json.MarshalIndent(conf, "", "  ")
+	if err != nil {
+		return fmt.Errorf("new-window: %v", err)
+	}
+	// Validate the generated file exactly as the service would load it.
+	if err := json.Unmarshal(b, &conf); err != nil {
+		return fmt.Errorf("new-window: generated invalid configuration: %v", err)
+	}
+
+	path := *out
+	if path == "" {
+		path = filepath.Join(auklib.ConfDir, *name+".json")
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("new-window: %v", err)
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("new-window: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("new-window: %v", err)
+	}
+	fmt.Printf("Wrote window %q (schedule %q) to %s\n", *name, spec, path)
+
+	w := conf.Windows[0]
+	now := time.Now()
+	// Only the first few occurrences are shown, so frequent windows whose
+	// occurrences are truncated need no error.
+	occ, _ := w.Occurrences(now, now.AddDate(0, 1, 0))
+	if len(occ) > *count {
+		occ = occ[:*count]
+	}
+	fmt.Println("Upcoming occurrences:")
+	for _, s := range occ {
+		fmt.Printf("  %s - %s\n", s.Opens.Format(time.RFC1123), s.Closes.Format(time.RFC1123))
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import "testing"
+
+func TestCronSpec(t *testing.T) {
+	tests := []struct {
+		desc    string
+		days    string
+		at      string
+		want    string
+		wantErr bool
+	}{
+		{desc: "every day", at: "02:30", want: "0 30 2 * * *"},
+		{desc: "abbreviations", days: "Tue,Thu", at: "22:00", want: "0 0 22 * * TUE,THU"},
+		{desc: "full names and spaces", days: "monday, Wednesday ,FRIDAY", at: "09:05", want: "0 5 9 * * MON,WED,FRI"},
+		{desc: "unknown day", days: "Tue,Tux", at: "02:00", wantErr: true},
+		{desc: "empty day", days: "Tue,", at: "02:00", wantErr: true},
+		{desc: "hour out of range", at: "24:00", wantErr: true},
+		{desc: "minute out of range", at: "02:60", wantErr: true},
+		{desc: "not HH:MM", at: "2am", wantErr: true},
+		{desc: "no time", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := cronSpec(tt.days, tt.at)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: cronSpec(%q, %q) returned error %v, want error %t", tt.desc, tt.days, tt.at, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: cronSpec(%q, %q) = %q, want %q", tt.desc, tt.days, tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestCheckName(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{"weekly-patch", false},
+		{"patch.window", false},
+		{"../weekly", true},
+		{"..", true},
+		{"conf/weekly", true},
+		{`conf\weekly`, true},
+		{"/etc/weekly", true},
+	}
+	for _, tt := range tests {
+		if err := checkName(tt.name); (err != nil) != tt.wantErr {
+			t.Errorf("checkName(%q) returned error %v, want error %t", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package notify warns the interactive user shortly before user-visible
+// maintenance windows open.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/deck"
+	"github.com/google/aukera/auklib"
+	"github.com/google/aukera/window"
+)
+
+// Tag marks windows users are notified about, when set to "true".
+const Tag = "notify"
+
+// ErrUnsupported is returned by Show on platforms without desktop notifications.
+var ErrUnsupported = errors.New("desktop notifications are unsupported on this platform")
+
+// notifiedRetention is how long openings are remembered after they pass, so
+// that a clock stepped backwards, such as by an NTP correction, does not
+// repeat their notifications.
+const notifiedRetention = 24 * time.Hour
+
+// occurrence identifies a single opening of a window.
+type occurrence struct {
+	name  string
+	opens time.Time
+}
+
+// Notifier shows a notification Lead before each opening of a window tagged
+// with Tag.
+type Notifier struct {
+	Lead time.Duration
+	// Windows returns the configured windows.
+	Windows func() ([]window.Window, error)
+	// Show displays a notification; Show is used if nil.
+	Show func(title, message string) error
+
+	notified map[occurrence]bool
+}
+
+// due returns the openings of notify windows within (now, now+Lead] that have
+// not yet been notified, marking them notified.
+func (n *Notifier) due(windows []window.Window, now time.Time) []window.Schedule {
+	if n.notified == nil {
+		n.notified = make(map[occurrence]bool)
+	}
+	for o := range n.notified {
+		if o.opens.Before(now.Add(-notifiedRetention)) {
+			delete(n.notified, o)
+		}
+	}
+	var out []window.Schedule
+	for _, w := range windows {
+		if w.Tags[Tag] != "true" {
+			continue
+		}
+		occ, err := w.Occurrences(now, now.Add(n.Lead).Add(time.Nanosecond))
+		if err != nil {
+			deck.Warningf("notify: %v", err)
+		}
+		for _, s := range occ {
+			o := occurrence{w.Name, s.Opens}
+			if !s.Opens.After(now) || n.notified[o] {
+				continue
+			}
+			n.notified[o] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func message(s window.Schedule, now time.Time) (string, string) {
+	mins := int(s.Opens.Sub(now).Round(time.Minute) / time.Minute)
+	return "Maintenance window starting soon",
+		fmt.Sprintf("Maintenance window %q opens in %d minute(s), at %s, and may restart this device. Please save your work.", s.Name, mins, s.Opens.Format("15:04"))
+}
+
+// Run checks for upcoming windows every interval until ctx is done.
+func (n *Notifier) Run(ctx context.Context, interval time.Duration) {
+	show := n.Show
+	if show == nil {
+		show = Show
+	}
+	var clock auklib.ClockWatch
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		now := time.Now()
+		if d := clock.SteppedBack(now); d > 0 {
+			deck.Warningf("notify: system clock stepped back by %v", d)
+		}
+		windows, err := n.Windows()
+		if err != nil {
+			deck.Warningf("notify: unable to load windows: %v", err)
+		}
+		for _, s := range n.due(windows, now) {
+			title, msg := message(s, now)
+			if err := show(title, msg); err != nil {
+				deck.Warningf("notify: unable to show notification for window %q: %v", s.Name, err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
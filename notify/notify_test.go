@@ -0,0 +1,69 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/aukera/window"
+	"github.com/robfig/cron/v3"
+)
+
+func TestDue(t *testing.T) {
+	cr, err := cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.DowOptional).Parse("0 0 2 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	notified := window.Window{Name: "restart", Format: window.FormatCron, Cron: cr, Duration: time.Hour, Tags: map[string]string{Tag: "true"}}
+	silent := notified
+	silent.Name = "silent"
+	silent.Tags = nil
+	windows := []window.Window{notified, silent}
+
+	day := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.Local)
+	n := &Notifier{Lead: 15 * time.Minute}
+	tests := []struct {
+		desc string
+		now  time.Time
+		want int
+	}{
+		{"too early", day.Add(time.Hour), 0},
+		{"within lead", day.Add(time.Hour + 50*time.Minute), 1},
+		{"already notified", day.Add(time.Hour + 55*time.Minute), 0},
+		{"open", day.Add(2*time.Hour + time.Minute), 0},
+		{"clock stepped back", day.Add(time.Hour + 50*time.Minute), 0},
+		{"next day", day.Add(25*time.Hour + 45*time.Minute), 1},
+	}
+	for _, tt := range tests {
+		got := n.due(windows, tt.now)
+		if len(got) != tt.want {
+			t.Errorf("due(%s) returned %d schedules, want %d: %v", tt.desc, len(got), tt.want, got)
+			continue
+		}
+		for _, s := range got {
+			if s.Name != "restart" || !s.Opens.After(tt.now) || s.Opens.After(tt.now.Add(n.Lead)) {
+				t.Errorf("due(%s) returned unexpected schedule %v", tt.desc, s)
+			}
+		}
+	}
+	if len(n.notified) != 2 {
+		t.Errorf("notified set holds %d entries, want recent openings retained", len(n.notified))
+	}
+	n.due(windows, day.Add(2*time.Hour+notifiedRetention+time.Minute))
+	if len(n.notified) != 1 {
+		t.Errorf("notified set holds %d entries, want past openings pruned", len(n.notified))
+	}
+}
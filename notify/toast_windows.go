@@ -0,0 +1,117 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+// +build windows
+
+package notify
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+
+	"golang.org/x/sys/windows"
+)
+
+// toastAppID is the application the toast is attributed to. Toasts must
+// come from a registered application; PowerShell is present on every host.
+const toastAppID = `{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe`
+
+var xmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&apos;")
+
+// toastScript returns a PowerShell script raising a toast notification.
+func toastScript(title, message string) string {
+	xml := fmt.Sprintf(`<toast><visual><binding template="ToastGeneric"><text>%s</text><text>%s</text></binding></visual></toast>`,
+		xmlEscaper.Replace(title), xmlEscaper.Replace(message))
+	return strings.Join([]string{
+		`[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null`,
+		`[Windows.Data.Xml.Dom.XmlDocument, Windows.Data.Xml.Dom.XmlDocument, ContentType = WindowsRuntime] > $null`,
+		`$x = New-Object Windows.Data.Xml.Dom.XmlDocument`,
+		fmt.Sprintf(`$x.LoadXml('%s')`, strings.ReplaceAll(xml, "'", "''")),
+		fmt.Sprintf(`[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('%s').Show([Windows.UI.Notifications.ToastNotification]::new($x))`, toastAppID),
+	}, "\n")
+}
+
+// encodeCommand encodes script for powershell -EncodedCommand, avoiding any
+// command line quoting of its contents.
+func encodeCommand(script string) string {
+	u := utf16.Encode([]rune(script))
+	b := make([]byte, 2*len(u))
+	for i, c := range u {
+		binary.LittleEndian.PutUint16(b[2*i:], c)
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// envBlock converts env to a Unicode environment block.
+func envBlock(env []string) *uint16 {
+	var b []uint16
+	for _, e := range env {
+		b = append(b, utf16.Encode([]rune(e))...)
+		b = append(b, 0)
+	}
+	b = append(b, 0)
+	return &b[0]
+}
+
+// Show raises a toast notification for the user logged on to the console.
+// The service runs in session 0, which has no desktop, so the toast is
+// raised by a PowerShell process started in the user's session.
+func Show(title, message string) error {
+	session := windows.WTSGetActiveConsoleSessionId()
+	if session == 0xFFFFFFFF {
+		return fmt.Errorf("Show: no user is logged on to the console")
+	}
+	var token windows.Token
+	if err := windows.WTSQueryUserToken(session, &token); err != nil {
+		return fmt.Errorf("Show: WTSQueryUserToken: %w", err)
+	}
+	defer token.Close()
+	env, err := token.Environ(false)
+	if err != nil {
+		return fmt.Errorf("Show: %w", err)
+	}
+	sysDir, err := windows.GetSystemDirectory()
+	if err != nil {
+		return fmt.Errorf("Show: %w", err)
+	}
+	exe := sysDir + `\WindowsPowerShell\v1.0\powershell.exe`
+	cmd, err := windows.UTF16PtrFromString(windows.ComposeCommandLine([]string{
+		exe, "-NoProfile", "-NonInteractive", "-WindowStyle", "Hidden", "-EncodedCommand", encodeCommand(toastScript(title, message)),
+	}))
+	if err != nil {
+		return fmt.Errorf("Show: %w", err)
+	}
+	app, err := windows.UTF16PtrFromString(exe)
+	if err != nil {
+		return fmt.Errorf("Show: %w", err)
+	}
+	desktop, err := windows.UTF16PtrFromString(`winsta0\default`)
+	if err != nil {
+		return fmt.Errorf("Show: %w", err)
+	}
+	si := &windows.StartupInfo{Desktop: desktop}
+	si.Cb = uint32(binary.Size(*si))
+	var pi windows.ProcessInformation
+	if err := windows.CreateProcessAsUser(token, app, cmd, nil, nil, false,
+		windows.CREATE_NO_WINDOW|windows.CREATE_UNICODE_ENVIRONMENT, envBlock(env), nil, si, &pi); err != nil {
+		return fmt.Errorf("Show: CreateProcessAsUser: %w", err)
+	}
+	windows.CloseHandle(pi.Thread)
+	windows.CloseHandle(pi.Process)
+	return nil
+}
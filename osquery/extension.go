@@ -0,0 +1,367 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package osquery implements an osquery extension serving tables backed by
+// the Aukera service, so that fleet tooling can query window state alongside
+// other host facts. It speaks osquery's Thrift extension protocol directly
+// over the socket of the extension manager: a Unix domain socket, or on
+// Windows, usually a named pipe such as \\.\pipe\shell.em.
+package osquery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/google/deck"
+)
+
+// Column types understood by osquery.
+const (
+	TypeText    = "TEXT"
+	TypeInteger = "INTEGER"
+	TypeBigInt  = "BIGINT"
+)
+
+// Column is a column of a Table.
+type Column struct {
+	Name, Type string
+}
+
+// Table is a table plugin. Generate returns every row of the table, with
+// values keyed by column name; osquery applies the constraints of queries to
+// them itself.
+type Table struct {
+	Name     string
+	Columns  []Column
+	Generate func(ctx context.Context) ([]map[string]string, error)
+}
+
+// routes returns the column definitions of t as registered with osquery.
+func (t Table) routes() []map[string]string {
+	var r []map[string]string
+	for _, c := range t.Columns {
+		r = append(r, map[string]string{"id": "column", "name": c.Name, "type": c.Type, "op": "0"})
+	}
+	return r
+}
+
+// Extension status codes.
+const (
+	statusOK     = 0
+	statusFailed = 1
+)
+
+// sdkVersion is reported to osquery as the extension's SDK version.
+const sdkVersion = "0.0.0"
+
+// callTimeout bounds calls to the extension manager.
+const callTimeout = 10 * time.Second
+
+// Extension is a named set of tables served to osquery.
+type Extension struct {
+	Name, Version string
+	Tables        []Table
+}
+
+// Serve registers e with the osquery extension manager listening at socket,
+// waiting up to timeout for it to accept connections, and serves calls from
+// osquery until ctx is done, osquery shuts the extension down, or the manager
+// fails to answer the pings sent every interval. It returns nil once shut
+// down by osquery.
+func (e *Extension) Serve(ctx context.Context, socket string, timeout, interval time.Duration) error {
+	mgr, err := dialManager(ctx, socket, timeout)
+	if err != nil {
+		return err
+	}
+	defer mgr.conn.Close()
+	uuid, err := mgr.register(e)
+	if err != nil {
+		return err
+	}
+	path := fmt.Sprintf("%s.%d", socket, uuid)
+	ln, err := listen(path)
+	if err != nil {
+		return err
+	}
+	deck.Infof("osquery extension %q registered as %d, serving %s", e.Name, uuid, path)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	done := make(chan error, 1)
+	finish := func(err error) {
+		select {
+		case done <- err:
+		default:
+		}
+		cancel()
+	}
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				if err := mgr.ping(); err != nil {
+					finish(fmt.Errorf("extension manager %s: %w", socket, err))
+					return
+				}
+			}
+		}
+	}()
+	for {
+		conn, err := ln.Accept()
+		if err == nil {
+			go e.serveConn(ctx, conn, uuid, finish)
+			continue
+		}
+		select {
+		case err := <-done:
+			return err
+		default:
+		}
+		if ctx.Err() != nil {
+			mgr.deregister(uuid)
+			return ctx.Err()
+		}
+		return err
+	}
+}
+
+// serveConn answers the calls osquery makes over conn, calling finish once
+// osquery asks the extension to shut down.
+func (e *Extension) serveConn(ctx context.Context, conn net.Conn, uuid int64, finish func(error)) {
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-stop:
+		}
+	}()
+	defer conn.Close()
+	enc, dec := newEncoder(conn), newDecoder(conn)
+	for {
+		name, typ, seq, err := dec.messageBegin()
+		if err != nil {
+			return
+		}
+		args, err := dec.structure()
+		if err != nil || typ != msgCall {
+			return
+		}
+		switch name {
+		case "ping":
+			enc.messageBegin(name, msgReply, seq)
+			enc.field(typeStruct, 0)
+			writeStatus(enc, statusOK, "OK", uuid)
+			enc.stop()
+		case "call":
+			registry, _ := args[1].(string)
+			item, _ := args[2].(string)
+			code, msg, rows := e.call(ctx, registry, item, stringMap(args[3]))
+			enc.messageBegin(name, msgReply, seq)
+			enc.field(typeStruct, 0)
+			enc.field(typeStruct, 1)
+			writeStatus(enc, code, msg, uuid)
+			enc.field(typeList, 2)
+			enc.rows(rows)
+			enc.stop()
+			enc.stop()
+		case "shutdown":
+			enc.messageBegin(name, msgReply, seq)
+			enc.stop()
+			enc.flush()
+			finish(nil)
+			return
+		default:
+			enc.messageBegin(name, msgException, seq)
+			enc.field(typeString, 1)
+			enc.string(fmt.Sprintf("unknown method %q", name))
+			enc.field(typeI32, 2)
+			enc.i32(exceptionUnknownMethod)
+			enc.stop()
+		}
+		if err := enc.flush(); err != nil {
+			return
+		}
+	}
+}
+
+// call handles a call to the plugin item of registry.
+func (e *Extension) call(ctx context.Context, registry, item string, req map[string]string) (int32, string, []map[string]string) {
+	if registry != "table" {
+		return statusFailed, fmt.Sprintf("unknown registry %q", registry), nil
+	}
+	for _, t := range e.Tables {
+		if t.Name != item {
+			continue
+		}
+		switch req["action"] {
+		case "columns":
+			return statusOK, "OK", t.routes()
+		case "generate":
+			rows, err := t.Generate(ctx)
+			if err != nil {
+				deck.Errorf("osquery table %s: %v", t.Name, err)
+				return statusFailed, err.Error(), nil
+			}
+			return statusOK, "OK", rows
+		}
+		return statusFailed, fmt.Sprintf("unknown action %q", req["action"]), nil
+	}
+	return statusFailed, fmt.Sprintf("unknown table %q", item), nil
+}
+
+// writeStatus writes an ExtensionStatus struct.
+func writeStatus(enc *encoder, code int32, msg string, uuid int64) {
+	enc.field(typeI32, 1)
+	enc.i32(code)
+	enc.field(typeString, 2)
+	enc.string(msg)
+	enc.field(typeI64, 3)
+	enc.i64(uuid)
+	enc.stop()
+}
+
+// readStatus reads the code, message and UUID of a decoded ExtensionStatus.
+func readStatus(v any) (int32, string, int64) {
+	s, _ := v.(map[int16]any)
+	code, _ := s[1].(int32)
+	msg, _ := s[2].(string)
+	uuid, _ := s[3].(int64)
+	return code, msg, uuid
+}
+
+// manager is a connection to the osquery extension manager.
+type manager struct {
+	mu   sync.Mutex
+	conn net.Conn
+	enc  *encoder
+	dec  *decoder
+	seq  int32
+}
+
+// dialManager connects to the extension manager at socket, retrying until
+// timeout elapses since osquery may still be starting.
+func dialManager(ctx context.Context, socket string, timeout time.Duration) (*manager, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		conn, err := dial(ctx, socket)
+		if err == nil {
+			return &manager{conn: conn, enc: newEncoder(conn), dec: newDecoder(conn)}, nil
+		}
+		if ctx.Err() != nil || time.Now().After(deadline) {
+			return nil, fmt.Errorf("connecting to extension manager: %w", err)
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+}
+
+// call invokes method with the arguments written by args, returning the
+// decoded result struct.
+func (m *manager) call(method string, args func(*encoder)) (map[int16]any, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.seq++
+	m.conn.SetDeadline(time.Now().Add(callTimeout))
+	m.enc.messageBegin(method, msgCall, m.seq)
+	args(m.enc)
+	m.enc.stop()
+	if err := m.enc.flush(); err != nil {
+		return nil, err
+	}
+	name, typ, seq, err := m.dec.messageBegin()
+	if err != nil {
+		return nil, err
+	}
+	res, err := m.dec.structure()
+	if err != nil {
+		return nil, err
+	}
+	if typ == msgException {
+		msg, _ := res[1].(string)
+		return nil, fmt.Errorf("%s: %s", method, msg)
+	}
+	if typ != msgReply || name != method || seq != m.seq {
+		return nil, fmt.Errorf("%s: unexpected reply %q (type %d, sequence %d)", method, name, typ, seq)
+	}
+	return res, nil
+}
+
+// register registers the tables of e, returning the UUID osquery assigned
+// the extension.
+func (m *manager) register(e *Extension) (int64, error) {
+	res, err := m.call("registerExtension", func(enc *encoder) {
+		enc.field(typeStruct, 1)
+		for i, s := range []string{e.Name, e.Version, sdkVersion, sdkVersion} {
+			enc.field(typeString, int16(i+1))
+			enc.string(s)
+		}
+		enc.stop()
+		enc.field(typeMap, 2)
+		enc.mapBegin(typeString, typeMap, 1)
+		enc.string("table")
+		enc.mapBegin(typeString, typeList, len(e.Tables))
+		for _, t := range e.Tables {
+			enc.string(t.Name)
+			enc.rows(t.routes())
+		}
+	})
+	if err != nil {
+		return 0, fmt.Errorf("registering extension: %w", err)
+	}
+	code, msg, uuid := readStatus(res[0])
+	if code != statusOK {
+		return 0, fmt.Errorf("registering extension: %s", msg)
+	}
+	return uuid, nil
+}
+
+// ping checks that the extension manager is still running.
+func (m *manager) ping() error {
+	res, err := m.call("ping", func(*encoder) {})
+	if err != nil {
+		return err
+	}
+	if code, msg, _ := readStatus(res[0]); code != statusOK {
+		return errors.New(msg)
+	}
+	return nil
+}
+
+// deregister removes the extension with uuid, logging failures since the
+// extension is shutting down regardless.
+func (m *manager) deregister(uuid int64) {
+	_, err := m.call("deregisterExtension", func(enc *encoder) {
+		enc.field(typeI64, 1)
+		enc.i64(uuid)
+	})
+	if err != nil {
+		deck.Warningf("deregistering osquery extension: %v", err)
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package osquery
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeManager accepts a single extension registration at socket, sending the
+// registered tables to registered.
+func fakeManager(t *testing.T, socket string, registered chan<- []mapEntry) {
+	t.Helper()
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		enc, dec := newEncoder(conn), newDecoder(conn)
+		for {
+			name, _, seq, err := dec.messageBegin()
+			if err != nil {
+				return
+			}
+			args, err := dec.structure()
+			if err != nil {
+				return
+			}
+			if name == "registerExtension" {
+				for _, e := range args[2].([]mapEntry) {
+					if e.Key == "table" {
+						registered <- e.Value.([]mapEntry)
+					}
+				}
+			}
+			enc.messageBegin(name, msgReply, seq)
+			enc.field(typeStruct, 0)
+			writeStatus(enc, statusOK, "OK", 7)
+			enc.stop()
+			if err := enc.flush(); err != nil {
+				return
+			}
+		}
+	}()
+}
+
+// callExtension makes a call to the extension listening at conn.
+func callExtension(t *testing.T, conn net.Conn, method string, args func(*encoder)) (byte, map[int16]any) {
+	t.Helper()
+	enc, dec := newEncoder(conn), newDecoder(conn)
+	enc.messageBegin(method, msgCall, 1)
+	args(enc)
+	enc.stop()
+	if err := enc.flush(); err != nil {
+		t.Fatal(err)
+	}
+	_, typ, _, err := dec.messageBegin()
+	if err != nil {
+		t.Fatalf("%s: %v", method, err)
+	}
+	res, err := dec.structure()
+	if err != nil {
+		t.Fatalf("%s: %v", method, err)
+	}
+	return typ, res
+}
+
+func TestExtension(t *testing.T) {
+	// Unix socket paths are limited in length, so avoid t.TempDir.
+	dir, err := os.MkdirTemp("", "osq")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "em")
+	registered := make(chan []mapEntry, 1)
+	fakeManager(t, socket, registered)
+
+	ext := &Extension{Name: "test", Version: "1.0", Tables: []Table{{
+		Name:    "things",
+		Columns: []Column{{"name", TypeText}, {"count", TypeInteger}},
+		Generate: func(context.Context) ([]map[string]string, error) {
+			return []map[string]string{{"name": "a", "count": "1"}}, nil
+		},
+	}, {
+		Name:    "broken",
+		Columns: []Column{{"name", TypeText}},
+		Generate: func(context.Context) ([]map[string]string, error) {
+			return nil, errors.New("unavailable")
+		},
+	}}}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	served := make(chan error, 1)
+	go func() { served <- ext.Serve(ctx, socket, time.Second, time.Hour) }()
+
+	select {
+	case tables := <-registered:
+		if len(tables) != 2 || tables[0].Key != "things" {
+			t.Errorf("registerExtension() registered tables %v, want things and broken", tables)
+		}
+	case <-ctx.Done():
+		t.Fatal("extension did not register")
+	}
+	var conn net.Conn
+	for conn == nil {
+		if conn, err = net.Dial("unix", socket+".7"); err != nil {
+			if ctx.Err() != nil {
+				t.Fatalf("extension socket not available: %v", err)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	defer conn.Close()
+
+	call := func(table, action string) (int32, []map[string]string) {
+		_, res := callExtension(t, conn, "call", func(enc *encoder) {
+			enc.field(typeString, 1)
+			enc.string("table")
+			enc.field(typeString, 2)
+			enc.string(table)
+			enc.field(typeMap, 3)
+			enc.stringMap(map[string]string{"action": action})
+		})
+		resp := res[0].(map[int16]any)
+		code, _, _ := readStatus(resp[1])
+		var rows []map[string]string
+		for _, r := range resp[2].([]any) {
+			rows = append(rows, stringMap(r))
+		}
+		return code, rows
+	}
+	if code, rows := call("things", "generate"); code != statusOK || !cmp.Equal(rows, []map[string]string{{"name": "a", "count": "1"}}) {
+		t.Errorf("call(things, generate) = %d, %v", code, rows)
+	}
+	want := []map[string]string{
+		{"id": "column", "name": "name", "type": TypeText, "op": "0"},
+		{"id": "column", "name": "count", "type": TypeInteger, "op": "0"},
+	}
+	if code, rows := call("things", "columns"); code != statusOK || !cmp.Equal(rows, want) {
+		t.Errorf("call(things, columns) = %d, %v, want %v", code, rows, want)
+	}
+	for _, table := range []string{"broken", "missing"} {
+		if code, _ := call(table, "generate"); code != statusFailed {
+			t.Errorf("call(%s, generate) returned status %d, want %d", table, code, statusFailed)
+		}
+	}
+	if typ, res := callExtension(t, conn, "ping", func(*encoder) {}); typ != msgReply {
+		t.Errorf("ping returned message type %d, want %d", typ, msgReply)
+	} else if code, _, uuid := readStatus(res[0]); code != statusOK || uuid != 7 {
+		t.Errorf("ping returned status %d for %d, want %d for 7", code, uuid, statusOK)
+	}
+	if typ, _ := callExtension(t, conn, "unknown", func(*encoder) {}); typ != msgException {
+		t.Errorf("unknown method returned message type %d, want %d", typ, msgException)
+	}
+
+	callExtension(t, conn, "shutdown", func(*encoder) {})
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Errorf("Serve() after shutdown returned %v, want nil", err)
+		}
+	case <-ctx.Done():
+		t.Fatal("Serve() did not return after shutdown")
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+// +build windows
+
+package osquery
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/Microsoft/go-winio"
+)
+
+// pipePrefix begins the paths of named pipes, which osquery uses for the
+// extension manager and extension sockets on Windows.
+const pipePrefix = `\\.\pipe\`
+
+// pipeSDDL restricts the pipes served by the extension to the local system,
+// administrators and the extension's own user, matching the permissions
+// osquery gives its own pipes.
+const pipeSDDL = "D:P(A;;GA;;;SY)(A;;GA;;;BA)(A;;GA;;;OW)"
+
+// isPipe reports whether path names a named pipe rather than a Unix domain
+// socket.
+func isPipe(path string) bool {
+	return len(path) >= len(pipePrefix) && strings.EqualFold(path[:len(pipePrefix)], pipePrefix)
+}
+
+// dial connects to the named pipe or Unix domain socket at path.
+func dial(ctx context.Context, path string) (net.Conn, error) {
+	if isPipe(path) {
+		return winio.DialPipeContext(ctx, path)
+	}
+	var d net.Dialer
+	return d.DialContext(ctx, "unix", path)
+}
+
+// listen creates the named pipe or Unix domain socket at path.
+func listen(path string) (net.Listener, error) {
+	if isPipe(path) {
+		return winio.ListenPipe(path, &winio.PipeConfig{SecurityDescriptor: pipeSDDL})
+	}
+	return net.Listen("unix", path)
+}
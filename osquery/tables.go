@@ -0,0 +1,173 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package osquery
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/aukera/client"
+	"github.com/google/aukera/schedule"
+	"github.com/google/aukera/window"
+)
+
+var (
+	fnLabels    = client.Labels
+	fnSchedules = func(port int) ([]window.Schedule, error) { return client.Label(port) }
+	fnWindows   = client.Windows
+)
+
+// Tables returns the aukera_labels and aukera_windows tables, backed by the
+// Aukera service listening on port. A port of 0 or -1 discovers the port of
+// the running service.
+func Tables(port int) []Table {
+	return []Table{labelsTable(port), windowsTable(port)}
+}
+
+// labelsTable lists each label with its current schedule.
+func labelsTable(port int) Table {
+	return Table{
+		Name: "aukera_labels",
+		Columns: []Column{
+			{"name", TypeText},
+			{"state", TypeText},
+			{"opens", TypeBigInt},
+			{"closes", TypeBigInt},
+			{"duration", TypeBigInt},
+			{"reason", TypeText},
+			{"windows", TypeText},
+			{"last_queried", TypeBigInt},
+			{"description", TypeText},
+			{"category", TypeText},
+			{"severity", TypeText},
+		},
+		Generate: func(ctx context.Context) ([]map[string]string, error) {
+			labels, err := fnLabels(ctx, port)
+			if err != nil {
+				return nil, err
+			}
+			schedules, err := fnSchedules(port)
+			if err != nil {
+				return nil, err
+			}
+			return labelRows(labels, schedules), nil
+		},
+	}
+}
+
+// labelRows joins labels with their schedules.
+func labelRows(labels []schedule.Label, schedules []window.Schedule) []map[string]string {
+	byName := make(map[string]window.Schedule, len(schedules))
+	for _, s := range schedules {
+		byName[s.Name] = s
+	}
+	var rows []map[string]string
+	for _, l := range labels {
+		r := map[string]string{
+			"name":         l.Name,
+			"windows":      strings.Join(l.Windows, ","),
+			"last_queried": unix(l.LastQueried),
+			"description":  l.Description,
+			"category":     l.Category,
+			"severity":     l.Severity,
+		}
+		if s, ok := byName[l.Name]; ok {
+			r["state"] = s.CurrentState()
+			r["opens"] = unix(s.Opens)
+			r["closes"] = unix(s.Closes)
+			r["duration"] = seconds(s.Duration)
+			r["reason"] = string(s.Reason)
+		}
+		rows = append(rows, r)
+	}
+	return rows
+}
+
+// windowsTable lists each configured window.
+func windowsTable(port int) Table {
+	return Table{
+		Name: "aukera_windows",
+		Columns: []Column{
+			{"name", TypeText},
+			{"labels", TypeText},
+			{"schedule", TypeText},
+			{"duration", TypeBigInt},
+			{"starts", TypeBigInt},
+			{"expires", TypeBigInt},
+			{"state", TypeText},
+			{"opens", TypeBigInt},
+			{"closes", TypeBigInt},
+			{"requires_approval", TypeInteger},
+			{"tags", TypeText},
+			{"source", TypeText},
+		},
+		Generate: func(ctx context.Context) ([]map[string]string, error) {
+			windows, err := fnWindows(ctx, port)
+			if err != nil {
+				return nil, err
+			}
+			return windowRows(windows), nil
+		},
+	}
+}
+
+// windowRows converts windows to rows of aukera_windows.
+func windowRows(windows []window.Window) []map[string]string {
+	var rows []map[string]string
+	for _, w := range windows {
+		var tags []string
+		for k, v := range w.Tags {
+			tags = append(tags, k+"="+v)
+		}
+		sort.Strings(tags)
+		approval := "0"
+		if w.RequiresApproval {
+			approval = "1"
+		}
+		rows = append(rows, map[string]string{
+			"name":              w.Name,
+			"labels":            strings.Join(w.Labels, ","),
+			"schedule":          w.CronString,
+			"duration":          seconds(w.Duration),
+			"starts":            unix(w.Starts),
+			"expires":           unix(w.Expires),
+			"state":             w.Schedule.CurrentState(),
+			"opens":             unix(w.Schedule.Opens),
+			"closes":            unix(w.Schedule.Closes),
+			"requires_approval": approval,
+			"tags":              strings.Join(tags, ","),
+			"source":            w.Source,
+		})
+	}
+	return rows
+}
+
+// unix formats t as seconds since the Unix epoch, or the empty string if t
+// is zero.
+func unix(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return strconv.FormatInt(t.Unix(), 10)
+}
+
+// seconds formats d as whole seconds.
+func seconds(d time.Duration) string {
+	return fmt.Sprint(int64(d / time.Second))
+}
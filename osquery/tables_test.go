@@ -0,0 +1,112 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package osquery
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/aukera/client"
+	"github.com/google/aukera/schedule"
+	"github.com/google/aukera/window"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTables(t *testing.T) {
+	opens := time.Date(2026, time.January, 10, 2, 0, 0, 0, time.UTC)
+	fnLabels = func(context.Context, int) ([]schedule.Label, error) {
+		return []schedule.Label{
+			{Name: "patch", Windows: []string{"nightly", "weekend"}, LabelInfo: window.LabelInfo{Severity: "high"}},
+			{Name: "reboot", Windows: []string{"weekend"}},
+		}, nil
+	}
+	fnSchedules = func(int) ([]window.Schedule, error) {
+		return []window.Schedule{{Name: "patch", Opens: opens, Closes: opens.Add(time.Hour), Duration: time.Hour, Reason: window.ReasonBetweenOccurrences}}, nil
+	}
+	fnWindows = func(context.Context, int) ([]window.Window, error) {
+		return []window.Window{{
+			Name:             "nightly",
+			CronString:       "0 0 2 * * *",
+			Duration:         time.Hour,
+			Labels:           []string{"patch"},
+			RequiresApproval: true,
+			Tags:             map[string]string{"ring": "1", "owner": "ops"},
+			Source:           "/etc/aukera/nightly.json",
+		}}, nil
+	}
+	defer func() {
+		fnLabels = client.Labels
+		fnSchedules = func(port int) ([]window.Schedule, error) { return client.Label(port) }
+		fnWindows = client.Windows
+	}()
+
+	tables := Tables(0)
+	labels, err := tables[0].Generate(context.Background())
+	if err != nil {
+		t.Fatalf("aukera_labels: %v", err)
+	}
+	want := []map[string]string{{
+		"name":         "patch",
+		"state":        window.StateClosed,
+		"opens":        "1768010400",
+		"closes":       "1768014000",
+		"duration":     "3600",
+		"reason":       string(window.ReasonBetweenOccurrences),
+		"windows":      "nightly,weekend",
+		"last_queried": "",
+		"description":  "",
+		"category":     "",
+		"severity":     "high",
+	}, {
+		"name":         "reboot",
+		"windows":      "weekend",
+		"last_queried": "",
+		"description":  "",
+		"category":     "",
+		"severity":     "",
+	}}
+	if diff := cmp.Diff(want, labels); diff != "" {
+		t.Errorf("aukera_labels returned unexpected rows (-want +got):\n%s", diff)
+	}
+
+	windows, err := tables[1].Generate(context.Background())
+	if err != nil {
+		t.Fatalf("aukera_windows: %v", err)
+	}
+	if len(windows) != 1 {
+		t.Fatalf("aukera_windows returned %d rows, want 1", len(windows))
+	}
+	got := windows[0]
+	for col, v := range map[string]string{
+		"name":              "nightly",
+		"labels":            "patch",
+		"schedule":          "0 0 2 * * *",
+		"duration":          "3600",
+		"starts":            "",
+		"requires_approval": "1",
+		"tags":              "owner=ops,ring=1",
+		"source":            "/etc/aukera/nightly.json",
+	} {
+		if got[col] != v {
+			t.Errorf("aukera_windows column %s = %q, want %q", col, got[col], v)
+		}
+	}
+	for _, c := range tables[1].Columns {
+		if _, ok := got[c.Name]; !ok {
+			t.Errorf("aukera_windows row has no value for column %s", c.Name)
+		}
+	}
+}
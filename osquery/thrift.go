@@ -0,0 +1,354 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package osquery
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Thrift types used by the osquery extension protocol.
+const (
+	typeStop   = 0
+	typeBool   = 2
+	typeByte   = 3
+	typeDouble = 4
+	typeI16    = 6
+	typeI32    = 8
+	typeI64    = 10
+	typeString = 11
+	typeStruct = 12
+	typeMap    = 13
+	typeSet    = 14
+	typeList   = 15
+)
+
+// Thrift message types.
+const (
+	msgCall      = 1
+	msgReply     = 2
+	msgException = 3
+)
+
+// version1 marks messages of the strict binary protocol.
+const version1 = 0x80010000
+
+// exceptionUnknownMethod is the TApplicationException type of calls to
+// methods an extension does not implement.
+const exceptionUnknownMethod = 1
+
+// maxSize bounds the strings and containers read from a peer.
+const maxSize = 16 << 20
+
+// encoder writes values in the Thrift binary protocol, as used by osquery
+// over its extension sockets. The first error is kept and returned by flush.
+type encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+func newEncoder(w io.Writer) *encoder {
+	return &encoder{w: bufio.NewWriter(w)}
+}
+
+func (e *encoder) write(b []byte) {
+	if e.err == nil {
+		_, e.err = e.w.Write(b)
+	}
+}
+
+func (e *encoder) byte(v byte) {
+	e.write([]byte{v})
+}
+
+func (e *encoder) i16(v int16) {
+	b := make([]byte, 2)
+	binary.BigEndian.PutUint16(b, uint16(v))
+	e.write(b)
+}
+
+func (e *encoder) i32(v int32) {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, uint32(v))
+	e.write(b)
+}
+
+func (e *encoder) i64(v int64) {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(v))
+	e.write(b)
+}
+
+func (e *encoder) string(s string) {
+	e.i32(int32(len(s)))
+	e.write([]byte(s))
+}
+
+func (e *encoder) messageBegin(name string, typ byte, seq int32) {
+	e.i32(int32(uint32(version1) | uint32(typ)))
+	e.string(name)
+	e.i32(seq)
+}
+
+func (e *encoder) field(typ byte, id int16) {
+	e.byte(typ)
+	e.i16(id)
+}
+
+func (e *encoder) stop() {
+	e.byte(typeStop)
+}
+
+func (e *encoder) mapBegin(k, v byte, n int) {
+	e.byte(k)
+	e.byte(v)
+	e.i32(int32(n))
+}
+
+func (e *encoder) listBegin(elem byte, n int) {
+	e.byte(elem)
+	e.i32(int32(n))
+}
+
+// stringMap writes m as a map<string, string>.
+func (e *encoder) stringMap(m map[string]string) {
+	e.mapBegin(typeString, typeString, len(m))
+	for k, v := range m {
+		e.string(k)
+		e.string(v)
+	}
+}
+
+// rows writes rows as a list<map<string, string>>.
+func (e *encoder) rows(rows []map[string]string) {
+	e.listBegin(typeMap, len(rows))
+	for _, r := range rows {
+		e.stringMap(r)
+	}
+}
+
+func (e *encoder) flush() error {
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+// decoder reads values in the Thrift binary protocol.
+type decoder struct {
+	r *bufio.Reader
+}
+
+func newDecoder(r io.Reader) *decoder {
+	return &decoder{r: bufio.NewReader(r)}
+}
+
+func (d *decoder) read(n int) ([]byte, error) {
+	b := make([]byte, n)
+	_, err := io.ReadFull(d.r, b)
+	return b, err
+}
+
+func (d *decoder) byte() (byte, error) {
+	return d.r.ReadByte()
+}
+
+func (d *decoder) i16() (int16, error) {
+	b, err := d.read(2)
+	if err != nil {
+		return 0, err
+	}
+	return int16(binary.BigEndian.Uint16(b)), nil
+}
+
+func (d *decoder) i32() (int32, error) {
+	b, err := d.read(4)
+	if err != nil {
+		return 0, err
+	}
+	return int32(binary.BigEndian.Uint32(b)), nil
+}
+
+func (d *decoder) i64() (int64, error) {
+	b, err := d.read(8)
+	if err != nil {
+		return 0, err
+	}
+	return int64(binary.BigEndian.Uint64(b)), nil
+}
+
+// size reads a string length or container size.
+func (d *decoder) size() (int, error) {
+	n, err := d.i32()
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 || n > maxSize {
+		return 0, fmt.Errorf("invalid size %d", n)
+	}
+	return int(n), nil
+}
+
+func (d *decoder) string() (string, error) {
+	n, err := d.size()
+	if err != nil {
+		return "", err
+	}
+	b, err := d.read(n)
+	return string(b), err
+}
+
+// messageBegin reads the header of a message in either the strict or the
+// older unversioned binary protocol.
+func (d *decoder) messageBegin() (name string, typ byte, seq int32, err error) {
+	v, err := d.i32()
+	if err != nil {
+		return "", 0, 0, err
+	}
+	if v < 0 {
+		if uint32(v)&0xffff0000 != version1 {
+			return "", 0, 0, fmt.Errorf("unsupported protocol version %#x", uint32(v)&0xffff0000)
+		}
+		typ = byte(v & 0xff)
+		if name, err = d.string(); err != nil {
+			return "", 0, 0, err
+		}
+	} else {
+		if v > maxSize {
+			return "", 0, 0, fmt.Errorf("invalid size %d", v)
+		}
+		b, err := d.read(int(v))
+		if err != nil {
+			return "", 0, 0, err
+		}
+		name = string(b)
+		if typ, err = d.byte(); err != nil {
+			return "", 0, 0, err
+		}
+	}
+	seq, err = d.i32()
+	return name, typ, seq, err
+}
+
+// mapEntry is an entry of a decoded Thrift map.
+type mapEntry struct {
+	Key, Value any
+}
+
+// value reads a value of Thrift type typ. Structs are returned as
+// map[int16]any keyed by field ID, lists and sets as []any and maps as
+// []mapEntry.
+func (d *decoder) value(typ byte) (any, error) {
+	switch typ {
+	case typeBool:
+		b, err := d.byte()
+		return b != 0, err
+	case typeByte:
+		return d.byte()
+	case typeI16:
+		return d.i16()
+	case typeI32:
+		return d.i32()
+	case typeI64, typeDouble:
+		return d.i64()
+	case typeString:
+		return d.string()
+	case typeStruct:
+		return d.structure()
+	case typeMap:
+		kt, err := d.byte()
+		if err != nil {
+			return nil, err
+		}
+		vt, err := d.byte()
+		if err != nil {
+			return nil, err
+		}
+		n, err := d.size()
+		if err != nil {
+			return nil, err
+		}
+		m := make([]mapEntry, 0, n)
+		for i := 0; i < n; i++ {
+			k, err := d.value(kt)
+			if err != nil {
+				return nil, err
+			}
+			v, err := d.value(vt)
+			if err != nil {
+				return nil, err
+			}
+			m = append(m, mapEntry{k, v})
+		}
+		return m, nil
+	case typeSet, typeList:
+		et, err := d.byte()
+		if err != nil {
+			return nil, err
+		}
+		n, err := d.size()
+		if err != nil {
+			return nil, err
+		}
+		l := make([]any, 0, n)
+		for i := 0; i < n; i++ {
+			v, err := d.value(et)
+			if err != nil {
+				return nil, err
+			}
+			l = append(l, v)
+		}
+		return l, nil
+	}
+	return nil, fmt.Errorf("unsupported type %d", typ)
+}
+
+// structure reads a struct as a map of its fields keyed by field ID.
+func (d *decoder) structure() (map[int16]any, error) {
+	s := make(map[int16]any)
+	for {
+		typ, err := d.byte()
+		if err != nil {
+			return nil, err
+		}
+		if typ == typeStop {
+			return s, nil
+		}
+		id, err := d.i16()
+		if err != nil {
+			return nil, err
+		}
+		if s[id], err = d.value(typ); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// stringMap converts a decoded map<string, string>, ignoring entries of
+// other types.
+func stringMap(v any) map[string]string {
+	entries, _ := v.([]mapEntry)
+	m := make(map[string]string, len(entries))
+	for _, e := range entries {
+		k, kok := e.Key.(string)
+		v, vok := e.Value.(string)
+		if kok && vok {
+			m[k] = v
+		}
+	}
+	return m
+}
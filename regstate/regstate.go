@@ -0,0 +1,110 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package regstate mirrors the current state of each label to the Windows
+// registry, under HKLM\SOFTWARE\Aukera\State, so that legacy tooling such as
+// VBScript, Group Policy preferences and WMI's StdRegProv can consume window
+// state without speaking HTTP.
+package regstate
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/deck"
+	"github.com/google/aukera/window"
+)
+
+// KeyPath is the registry key, under HKEY_LOCAL_MACHINE, that holds a
+// subkey per label.
+const KeyPath = `SOFTWARE\Aukera\State`
+
+// ErrUnsupported is returned by Publish on platforms without a registry.
+var ErrUnsupported = errors.New("registry state is unsupported on this platform")
+
+// Label is the state of a label as published to its registry subkey. Times
+// are RFC 3339 strings, which VBScript and PowerShell parse readily, and are
+// empty where they do not apply.
+type Label struct {
+	Name string
+	// State is window.StateOpen or window.StateClosed.
+	State string
+	// Opens and Closes bound the current or next schedule of the label.
+	Opens, Closes string
+	// NextOpen is when the label next opens, or empty while it is open.
+	NextOpen string
+	// Reason explains why the label is closed; see window.Reason.
+	Reason string
+}
+
+// labels converts schedules, as computed at now, to the values published.
+func labels(schedules []window.Schedule, now time.Time) []Label {
+	var out []Label
+	for _, s := range schedules {
+		l := Label{
+			Name:   s.Name,
+			State:  window.StateClosed,
+			Opens:  s.Opens.Format(time.RFC3339),
+			Closes: s.Closes.Format(time.RFC3339),
+		}
+		if s.Contains(now) {
+			l.State = window.StateOpen
+		} else {
+			if s.Opens.After(now) {
+				l.NextOpen = l.Opens
+			}
+			l.Reason = string(s.Reason)
+		}
+		out = append(out, l)
+	}
+	return out
+}
+
+// Publisher periodically publishes the state of every label.
+type Publisher struct {
+	// Schedules returns the current schedule of every label.
+	Schedules func() ([]window.Schedule, error)
+	// Publish replaces the published labels; Publish is used if nil.
+	Publish func(labels []Label, updated time.Time) error
+}
+
+// Run publishes the state of every label every interval until ctx is done.
+// Labels no longer configured are removed. Schedules that cannot be computed
+// leave the last published state in place.
+func (p *Publisher) Run(ctx context.Context, interval time.Duration) {
+	publish := p.Publish
+	if publish == nil {
+		publish = Publish
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		now := time.Now()
+		s, err := p.Schedules()
+		if err != nil && !errors.Is(err, window.ErrNoWindows) {
+			deck.Warningf("regstate: unable to calculate schedules: %v", err)
+		} else if err := publish(labels(s, now), now); err != nil {
+			deck.Warningf("regstate: unable to publish state: %v", err)
+			if errors.Is(err, ErrUnsupported) {
+				return
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package regstate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/aukera/window"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLabels(t *testing.T) {
+	now := time.Date(2020, time.January, 1, 12, 0, 0, 0, time.UTC)
+	in := []window.Schedule{
+		{Name: "patch", Opens: now.Add(-time.Hour), Closes: now.Add(time.Hour)},
+		{Name: "reboot", Opens: now.Add(2 * time.Hour), Closes: now.Add(3 * time.Hour), Reason: window.ReasonBetweenOccurrences},
+		{Name: "retired", Opens: now.Add(-3 * time.Hour), Closes: now.Add(-2 * time.Hour), Reason: window.ReasonExpired},
+	}
+	want := []Label{
+		{Name: "patch", State: "open", Opens: "2020-01-01T11:00:00Z", Closes: "2020-01-01T13:00:00Z"},
+		{Name: "reboot", State: "closed", Opens: "2020-01-01T14:00:00Z", Closes: "2020-01-01T15:00:00Z", NextOpen: "2020-01-01T14:00:00Z", Reason: "between_occurrences"},
+		{Name: "retired", State: "closed", Opens: "2020-01-01T09:00:00Z", Closes: "2020-01-01T10:00:00Z", Reason: "expired"},
+	}
+	if diff := cmp.Diff(want, labels(in, now)); diff != "" {
+		t.Errorf("labels() returned diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	published := make(chan []Label, 1)
+	p := &Publisher{
+		Schedules: func() ([]window.Schedule, error) {
+			return []window.Schedule{{Name: "patch"}}, nil
+		},
+		Publish: func(labels []Label, updated time.Time) error {
+			cancel()
+			published <- labels
+			return nil
+		},
+	}
+	p.Run(ctx, time.Hour)
+	if got := <-published; len(got) != 1 || got[0].Name != "patch" {
+		t.Errorf("Run() published %v, want patch", got)
+	}
+}
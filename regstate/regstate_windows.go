@@ -0,0 +1,79 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+// +build windows
+
+package regstate
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// Publish replaces the label state published to the registry: each label is
+// written to a subkey of KeyPath named after it, subkeys of labels not given
+// are deleted, and the Updated value of KeyPath records when state was last
+// published so that consumers can detect a stopped service.
+func Publish(labels []Label, updated time.Time) error {
+	k, _, err := registry.CreateKey(registry.LOCAL_MACHINE, KeyPath, registry.ALL_ACCESS)
+	if err != nil {
+		return fmt.Errorf("unable to open %q: %w", KeyPath, err)
+	}
+	defer k.Close()
+
+	current := make(map[string]bool)
+	for _, l := range labels {
+		current[strings.ToLower(l.Name)] = true
+		if err := writeLabel(k, l); err != nil {
+			return fmt.Errorf("label(%s): %w", l.Name, err)
+		}
+	}
+	names, err := k.ReadSubKeyNames(-1)
+	if err != nil {
+		return fmt.Errorf("unable to list %q: %w", KeyPath, err)
+	}
+	for _, n := range names {
+		if current[strings.ToLower(n)] {
+			continue
+		}
+		if err := registry.DeleteKey(k, n); err != nil {
+			return fmt.Errorf("unable to delete stale label %q: %w", n, err)
+		}
+	}
+	return k.SetStringValue("Updated", updated.Format(time.RFC3339))
+}
+
+func writeLabel(parent registry.Key, l Label) error {
+	k, _, err := registry.CreateKey(parent, l.Name, registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	defer k.Close()
+	for _, v := range []struct{ name, value string }{
+		{"State", l.State},
+		{"Opens", l.Opens},
+		{"Closes", l.Closes},
+		{"NextOpen", l.NextOpen},
+		{"Reason", l.Reason},
+	} {
+		if err := k.SetStringValue(v.name, v.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/aukera/auklib"
+	"github.com/google/aukera/window"
+)
+
+// MaxBatch bounds the number of evaluations in a single Batch.
+const MaxBatch = 1000
+
+// ErrBatchTooLarge is returned by Batch for more than MaxBatch evaluations.
+var ErrBatchTooLarge = fmt.Errorf("batch exceeds %d evaluations", MaxBatch)
+
+// Evaluation asks for the schedule of Label as of At, which may be in the
+// future. A zero At denotes the time of evaluation.
+type Evaluation struct {
+	Label string
+	At    time.Time
+}
+
+// Evaluated is the outcome of an Evaluation.
+type Evaluated struct {
+	Label  string
+	At     time.Time
+	Status LabelStatus
+	Error  string `json:",omitempty"`
+	// Open reports whether Schedule is open at At.
+	Open bool
+	// Schedule is the schedule open at At, or else the next to open, as by
+	// Query with Options.At. It is nil unless Status is StatusFound.
+	Schedule *window.Schedule `json:",omitempty"`
+}
+
+// Batch evaluates many labels at many times against a single load of the
+// configuration, so that controllers planning rollouts across hosts and time
+// need not make a request per pair. Results are in the order of evals.
+// Options.At is ignored in favor of the At of each Evaluation. Evaluation is
+// abandoned, returning ctx.Err(), once ctx is done.
+func Batch(ctx context.Context, opts Options, evals []Evaluation) ([]Evaluated, error) {
+	if len(evals) > MaxBatch {
+		return nil, ErrBatchTooLarge
+	}
+	var r window.Reader
+	m, err := window.WindowsContext(ctx, auklib.ConfDir, r)
+	if err != nil {
+		return nil, err
+	}
+	if m, err = withBuiltins(m); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	out, err := batch(ctx, m, opts, evals, start)
+	auklib.ReportDuration("batch_duration", time.Since(start), nil)
+	return out, err
+}
+
+// batch evaluates evals against m, with zero times denoting now.
+func batch(ctx context.Context, m window.Map, opts Options, evals []Evaluation, now time.Time) ([]Evaluated, error) {
+	out := make([]Evaluated, 0, len(evals))
+	for _, e := range evals {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		res := Evaluated{At: e.At, Status: StatusFound}
+		if res.At.IsZero() {
+			res.At = now
+		}
+		label, err := window.NormalizeLabel(e.Label)
+		if err != nil {
+			res.Label, res.Status, res.Error = e.Label, StatusError, err.Error()
+			out = append(out, res)
+			continue
+		}
+		res.Label = label
+		o := opts
+		o.At = res.At
+		schedules := labelSchedules(m, label, o, now)
+		switch {
+		case len(m.Find(label)) == 0:
+			res.Status = StatusMissing
+		case len(schedules) == 0:
+			res.Status = StatusError
+			res.Error = fmt.Sprintf("no occurrence within %v of %s", atHorizon, res.At.Format(time.RFC3339))
+		default:
+			s := []window.Schedule{Nearest(schedules, res.At)}
+			inLocation(s, opts.Location)
+			res.Schedule = &s[0]
+			res.Open = s[0].Contains(res.At)
+		}
+		out = append(out, res)
+	}
+	return out, nil
+}
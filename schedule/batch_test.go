@@ -0,0 +1,76 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/aukera/window"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBatch(t *testing.T) {
+	m := make(window.Map)
+	var w window.Window
+	if err := w.UnmarshalJSON([]byte(`{"Name": "nightly", "Format": 1, "Schedule": "0 0 2 * * *", "Duration": "1h", "Labels": ["patch"]}`)); err != nil {
+		t.Fatal(err)
+	}
+	m.Add(w)
+	day := time.Date(2026, time.January, 10, 0, 0, 0, 0, time.Local)
+	evals := []Evaluation{
+		{Label: "Patch", At: day.Add(2*time.Hour + 30*time.Minute)},
+		{Label: "patch", At: day.Add(12 * time.Hour)},
+		{Label: "reboot", At: day},
+		{Label: "bad label!", At: day},
+		{Label: "patch"},
+	}
+	now := day.Add(5 * time.Hour)
+	got, err := batch(context.Background(), m, Options{}, evals, now)
+	if err != nil {
+		t.Fatalf("batch() returned error: %v", err)
+	}
+	type summary struct {
+		Label  string
+		At     time.Time
+		Status LabelStatus
+		Open   bool
+		Opens  time.Time
+	}
+	var sums []summary
+	for _, e := range got {
+		s := summary{Label: e.Label, At: e.At, Status: e.Status, Open: e.Open}
+		if e.Schedule != nil {
+			s.Opens = e.Schedule.Opens
+		}
+		sums = append(sums, s)
+	}
+	want := []summary{
+		{"patch", day.Add(2*time.Hour + 30*time.Minute), StatusFound, true, day.Add(2 * time.Hour)},
+		{"patch", day.Add(12 * time.Hour), StatusFound, false, day.Add(26 * time.Hour)},
+		{"reboot", day, StatusMissing, false, time.Time{}},
+		{"bad label!", day, StatusError, false, time.Time{}},
+		{"patch", now, StatusFound, false, day.Add(26 * time.Hour)},
+	}
+	if diff := cmp.Diff(want, sums); diff != "" {
+		t.Errorf("batch() returned diff (-want +got):\n%s", diff)
+	}
+
+	if _, err := Batch(context.Background(), Options{}, make([]Evaluation, MaxBatch+1)); !errors.Is(err, ErrBatchTooLarge) {
+		t.Errorf("Batch() of %d evaluations returned %v, want %v", MaxBatch+1, err, ErrBatchTooLarge)
+	}
+}
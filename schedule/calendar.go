@@ -0,0 +1,91 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/aukera/window"
+)
+
+// Span is a period during which one or more labels are open.
+type Span struct {
+	Opens, Closes time.Time
+	Labels        []string
+}
+
+// Shared is a schedule of one or more labels whose windows open over the
+// same span, such as a window carrying several labels.
+type Shared struct {
+	// Schedule is the schedule of the first of Labels.
+	Schedule window.Schedule
+	// Labels are the labels sharing the schedule, ordered by name.
+	Labels []string
+}
+
+// Share combines schedules of different labels that open and close at the
+// same time into one, ordered as their first schedule in schedules.
+func Share(schedules []window.Schedule) []Shared {
+	type key struct{ opens, closes int64 }
+	var (
+		out   []Shared
+		index = make(map[key]int)
+	)
+	for _, s := range schedules {
+		k := key{s.Opens.UnixNano(), s.Closes.UnixNano()}
+		i, ok := index[k]
+		if !ok {
+			i = len(out)
+			index[k] = i
+			out = append(out, Shared{Schedule: s})
+		}
+		out[i].Labels = append(out[i].Labels, s.Name)
+	}
+	for i := range out {
+		sort.Strings(out[i].Labels)
+		out[i].Schedule.Name = out[i].Labels[0]
+	}
+	return out
+}
+
+// calendar orders schedules by time, combining the identical spans of
+// different labels into one.
+func calendar(schedules []window.Schedule) []Span {
+	var out []Span
+	for _, s := range Share(schedules) {
+		out = append(out, Span{Opens: s.Schedule.Opens, Closes: s.Schedule.Closes, Labels: s.Labels})
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if !out[i].Opens.Equal(out[j].Opens) {
+			return out[i].Opens.Before(out[j].Opens)
+		}
+		return out[i].Closes.Before(out[j].Closes)
+	})
+	return out
+}
+
+// Calendar returns the open spans of all labels within [from, to), as by
+// Range, ordered by opening time. Labels open over the same span share a
+// single entry. Evaluation is abandoned, returning ctx.Err(), once ctx is
+// done.
+func Calendar(ctx context.Context, from, to time.Time, opts Options) ([]Span, error) {
+	s, err := Range(ctx, from, to, opts)
+	if err != nil {
+		return nil, err
+	}
+	return calendar(s), nil
+}
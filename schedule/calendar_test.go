@@ -0,0 +1,63 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/aukera/window"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCalendar(t *testing.T) {
+	src := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	at := func(h int) time.Time { return src.Add(time.Duration(h) * time.Hour) }
+	in := []window.Schedule{
+		{Name: "patch", Opens: at(26), Closes: at(28)},
+		{Name: "patch", Opens: at(2), Closes: at(4)},
+		{Name: "reboot", Opens: at(2), Closes: at(4)},
+		{Name: "backup", Opens: at(1), Closes: at(3)},
+		{Name: "app", Opens: at(2), Closes: at(4)},
+	}
+	want := []Span{
+		{Opens: at(1), Closes: at(3), Labels: []string{"backup"}},
+		{Opens: at(2), Closes: at(4), Labels: []string{"app", "patch", "reboot"}},
+		{Opens: at(26), Closes: at(28), Labels: []string{"patch"}},
+	}
+	if diff := cmp.Diff(want, calendar(in)); diff != "" {
+		t.Errorf("calendar() returned diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestShare(t *testing.T) {
+	src := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	at := func(h int) time.Time { return src.Add(time.Duration(h) * time.Hour) }
+	in := []window.Schedule{
+		{Name: "reboot", State: window.StateClosed, Opens: at(2), Closes: at(4), Duration: 2 * time.Hour},
+		{Name: "backup", State: window.StateClosed, Opens: at(1), Closes: at(3), Duration: 2 * time.Hour},
+		{Name: "patch", State: window.StateClosed, Opens: at(2), Closes: at(4), Duration: 2 * time.Hour},
+	}
+	want := []Shared{
+		{Schedule: window.Schedule{Name: "patch", State: window.StateClosed, Opens: at(2), Closes: at(4), Duration: 2 * time.Hour}, Labels: []string{"patch", "reboot"}},
+		{Schedule: window.Schedule{Name: "backup", State: window.StateClosed, Opens: at(1), Closes: at(3), Duration: 2 * time.Hour}, Labels: []string{"backup"}},
+	}
+	if diff := cmp.Diff(want, Share(in)); diff != "" {
+		t.Errorf("Share() returned diff (-want +got):\n%s", diff)
+	}
+	if got := Share(nil); got != nil {
+		t.Errorf("Share(nil) = %v, want nil", got)
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/aukera/window"
+)
+
+var (
+	// ErrInvalidIntent is returned when an intent is missing required fields.
+	ErrInvalidIntent = errors.New("invalid intent")
+	// ErrTooManyIntents is returned when MaxIntents are already registered.
+	ErrTooManyIntents = errors.New("too many intents")
+)
+
+// MaxIntents bounds the number of intents registered at once.
+const MaxIntents = 1000
+
+// Intent is an agent's declaration of what it plans to do in the upcoming
+// window of a label, so that operators can see what will happen when the
+// window opens.
+type Intent struct {
+	Label, Agent, Action string
+	EstimatedDuration    time.Duration
+	// Registered is when the intent was declared.
+	Registered time.Time
+	// Expires is when the window the intent was declared for closes, after
+	// which the intent is discarded.
+	Expires time.Time
+	// Seq orders declarations, increasing with each one.
+	Seq uint64
+}
+
+type intentJSON struct {
+	Label, Agent, Action string
+	EstimatedDuration    string
+	Registered, Expires  time.Time
+	Seq                  uint64
+}
+
+// MarshalJSON marshals EstimatedDuration as a human-readable string.
+func (i Intent) MarshalJSON() ([]byte, error) {
+	return json.Marshal(intentJSON{
+		Label:             i.Label,
+		Agent:             i.Agent,
+		Action:            i.Action,
+		EstimatedDuration: i.EstimatedDuration.String(),
+		Registered:        i.Registered,
+		Expires:           i.Expires,
+		Seq:               i.Seq,
+	})
+}
+
+// UnmarshalJSON is a custom Intent unmarshaler accepting EstimatedDuration
+// as a duration string, such as "30m".
+func (i *Intent) UnmarshalJSON(b []byte) error {
+	var conv intentJSON
+	if err := json.Unmarshal(b, &conv); err != nil {
+		return err
+	}
+	*i = Intent{
+		Label:      conv.Label,
+		Agent:      conv.Agent,
+		Action:     conv.Action,
+		Registered: conv.Registered,
+		Expires:    conv.Expires,
+		Seq:        conv.Seq,
+	}
+	if conv.EstimatedDuration == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(conv.EstimatedDuration)
+	if err != nil {
+		return fmt.Errorf("EstimatedDuration: %w", err)
+	}
+	i.EstimatedDuration = d
+	return nil
+}
+
+// intentStore holds the intents of each agent for each label in memory.
+// Agents declare intent ahead of each window, so intents are not persisted.
+type intentStore struct {
+	mu    sync.Mutex
+	seq   uint64
+	byKey map[string]Intent
+}
+
+var intents = &intentStore{byKey: make(map[string]Intent)}
+
+// expire discards intents whose window has closed. Must be called with mu
+// held.
+func (s *intentStore) expire(now time.Time) {
+	for k, i := range s.byKey {
+		if !now.Before(i.Expires) {
+			delete(s.byKey, k)
+		}
+	}
+}
+
+// add registers i, replacing any earlier intent of the same agent for the
+// same label.
+func (s *intentStore) add(i Intent, now time.Time) (Intent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire(now)
+	key := i.Label + "\x00" + i.Agent
+	if _, ok := s.byKey[key]; !ok && len(s.byKey) >= MaxIntents {
+		return Intent{}, ErrTooManyIntents
+	}
+	s.seq++
+	i.Seq = s.seq
+	s.byKey[key] = i
+	return i, nil
+}
+
+// since returns the intents declared after seq, ordered by Seq, along with
+// the latest Seq.
+func (s *intentStore) since(seq uint64, now time.Time) ([]Intent, uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire(now)
+	var out []Intent
+	for _, i := range s.byKey {
+		if i.Seq > seq {
+			out = append(out, i)
+		}
+	}
+	sort.Slice(out, func(a, b int) bool { return out[a].Seq < out[b].Seq })
+	return out, s.seq
+}
+
+// DeclareIntent registers the intent of agent to perform action, taking
+// about est, in the current or next window of label. The intent expires when
+// that window closes. Declaring again replaces the agent's earlier intent for
+// the label.
+func DeclareIntent(label, agent, action string, est time.Duration) (Intent, error) {
+	switch {
+	case agent == "":
+		return Intent{}, fmt.Errorf("%w: Agent is required", ErrInvalidIntent)
+	case action == "":
+		return Intent{}, fmt.Errorf("%w: Action is required", ErrInvalidIntent)
+	case est <= 0:
+		return Intent{}, fmt.Errorf("%w: EstimatedDuration must be positive", ErrInvalidIntent)
+	}
+	s, err := Query(Options{}, label)
+	if err != nil {
+		return Intent{}, err
+	}
+	if len(s) == 0 {
+		return Intent{}, fmt.Errorf("label %q: %w", label, window.ErrNoWindows)
+	}
+	now := time.Now()
+	return intents.add(Intent{
+		Label:             label,
+		Agent:             agent,
+		Action:            action,
+		EstimatedDuration: est,
+		Registered:        now,
+		Expires:           s[0].Closes,
+	}, now)
+}
+
+// Intents returns the registered intents for label, or for all labels if
+// label is empty, ordered by label and agent.
+func Intents(label string) []Intent {
+	all, _ := intents.since(0, time.Now())
+	out := []Intent{}
+	for _, i := range all {
+		if label == "" || i.Label == label {
+			out = append(out, i)
+		}
+	}
+	sort.Slice(out, func(a, b int) bool {
+		if out[a].Label != out[b].Label {
+			return out[a].Label < out[b].Label
+		}
+		return out[a].Agent < out[b].Agent
+	})
+	return out
+}
+
+// IntentsSince returns the intents declared after seq, ordered by Seq, and
+// the Seq of the latest declaration, for use as seq in the next call.
+// Replaced and expired intents are omitted.
+func IntentsSince(seq uint64) ([]Intent, uint64) {
+	return intents.since(seq, time.Now())
+}
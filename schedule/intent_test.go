@@ -0,0 +1,104 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIntentJSON(t *testing.T) {
+	now := time.Date(2026, time.March, 1, 2, 0, 0, 0, time.UTC)
+	want := Intent{Label: "patch", Agent: "patcher", Action: "install updates", EstimatedDuration: 30 * time.Minute, Registered: now, Expires: now.Add(time.Hour), Seq: 3}
+	b, err := json.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Intent
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("Intent.UnmarshalJSON(%s) = %v", b, err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Intent JSON round trip mismatch (-want +got):\n%s", diff)
+	}
+	if err := json.Unmarshal([]byte(`{"EstimatedDuration": "soon"}`), &got); err == nil {
+		t.Error("Intent.UnmarshalJSON() accepted an invalid EstimatedDuration")
+	}
+}
+
+func TestIntentStore(t *testing.T) {
+	now := time.Now()
+	s := &intentStore{byKey: make(map[string]Intent)}
+	add := func(label, agent string, expires time.Time) {
+		t.Helper()
+		if _, err := s.add(Intent{Label: label, Agent: agent, Action: "reboot", EstimatedDuration: time.Minute, Expires: expires}, now); err != nil {
+			t.Fatalf("add(%s, %s) = %v", label, agent, err)
+		}
+	}
+	add("patch", "a", now.Add(time.Hour))
+	add("patch", "b", now.Add(time.Hour))
+	add("reboot", "a", now.Add(2*time.Hour))
+	// Declaring again replaces the agent's intent for the label.
+	add("patch", "a", now.Add(time.Hour))
+
+	got, seq := s.since(0, now)
+	var order []string
+	for _, i := range got {
+		order = append(order, i.Label+"/"+i.Agent)
+	}
+	if diff := cmp.Diff([]string{"patch/b", "reboot/a", "patch/a"}, order); diff != "" {
+		t.Errorf("since(0) order mismatch (-want +got):\n%s", diff)
+	}
+	if seq != 4 {
+		t.Errorf("since(0) seq = %d, want 4", seq)
+	}
+	if got, _ := s.since(3, now); len(got) != 1 || got[0].Agent != "a" || got[0].Label != "patch" {
+		t.Errorf("since(3) = %v, want the replaced patch intent", got)
+	}
+
+	// Intents are discarded once their window closes.
+	if got, _ := s.since(0, now.Add(90*time.Minute)); len(got) != 1 || got[0].Label != "reboot" {
+		t.Errorf("since(0) after the patch window closed = %v, want the reboot intent", got)
+	}
+
+	full := &intentStore{byKey: make(map[string]Intent)}
+	for i := 0; i < MaxIntents; i++ {
+		full.byKey[strconv.Itoa(i)] = Intent{Expires: now.Add(time.Hour)}
+	}
+	if _, err := full.add(Intent{Label: "patch", Agent: "a", Expires: now.Add(time.Hour)}, now); !errors.Is(err, ErrTooManyIntents) {
+		t.Errorf("add() to a full store = %v, want %v", err, ErrTooManyIntents)
+	}
+}
+
+func TestDeclareIntentInvalid(t *testing.T) {
+	tests := []struct {
+		desc, agent, action string
+		est                 time.Duration
+	}{
+		{"no agent", "", "reboot", time.Minute},
+		{"no action", "patcher", "", time.Minute},
+		{"no estimate", "patcher", "reboot", 0},
+	}
+	for _, tt := range tests {
+		if _, err := DeclareIntent("patch", tt.agent, tt.action, tt.est); !errors.Is(err, ErrInvalidIntent) {
+			t.Errorf("DeclareIntent(%s) = %v, want %v", tt.desc, err, ErrInvalidIntent)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/deck"
+	"github.com/google/aukera/auklib"
+	"github.com/google/aukera/window"
+)
+
+// Label describes a configured label, its display metadata and its query
+// history.
+type Label struct {
+	Name        string
+	Windows     []string
+	LastQueried time.Time
+	// Pending names the windows carrying the label that await approval, and
+	// so are not scheduled. They are not listed in Windows.
+	Pending []string `json:",omitempty"`
+	window.LabelInfo
+}
+
+// queryLog tracks the last time each label was queried, persisted to disk
+// so that history survives service restarts. Queries are recorded in memory
+// and persisted at most once every saveDelay.
+type queryLog struct {
+	mu      sync.Mutex
+	path    string
+	loaded  bool
+	pending bool
+	last    map[string]time.Time
+}
+
+// QueryHistoryFile persists the last query time of each label.
+var QueryHistoryFile = filepath.Join(auklib.DataDir, "label_queries.json")
+
+var queries = &queryLog{}
+
+// saveDelay is how long recorded queries may wait before being persisted, so
+// that busy clients do not cause a write per request.
+var saveDelay = 30 * time.Second
+
+// maxQueriedLabels bounds the labels kept in query history. The labels
+// queried least recently are discarded first.
+const maxQueriedLabels = 1024
+
+// file returns the path history is persisted to, QueryHistoryFile unless
+// the log was created with its own path.
+func (q *queryLog) file() string {
+	if q.path != "" {
+		return q.path
+	}
+	return QueryHistoryFile
+}
+
+// read returns the query history persisted to disk.
+func (q *queryLog) read() map[string]time.Time {
+	last := make(map[string]time.Time)
+	b, err := os.ReadFile(q.file())
+	if os.IsNotExist(err) {
+		return last
+	}
+	if err != nil {
+		deck.Warningf("unable to read label query history %q: %v", q.file(), err)
+		return last
+	}
+	if err := json.Unmarshal(b, &last); err != nil {
+		deck.Warningf("unable to parse label query history %q: %v", q.file(), err)
+	}
+	return last
+}
+
+// load reads persisted query history. Must be called with mu held.
+func (q *queryLog) load() {
+	if q.loaded {
+		return
+	}
+	q.loaded = true
+	q.last = q.read()
+	q.trim()
+}
+
+// trim discards the labels queried least recently beyond maxQueriedLabels.
+// Must be called with mu held.
+func (q *queryLog) trim() {
+	if len(q.last) <= maxQueriedLabels {
+		return
+	}
+	names := make([]string, 0, len(q.last))
+	for n := range q.last {
+		names = append(names, n)
+	}
+	sort.Slice(names, func(i, j int) bool { return q.last[names[i]].After(q.last[names[j]]) })
+	for _, n := range names[maxQueriedLabels:] {
+		delete(q.last, n)
+	}
+}
+
+// save persists query history. Must be called with mu held.
+func (q *queryLog) save() error {
+	b, err := json.Marshal(q.last)
+	if err != nil {
+		return err
+	}
+	return auklib.WriteFileAtomic(q.file(), b, 0644)
+}
+
+// record sets the last query time of the given labels to t, persisting them
+// after saveDelay.
+func (q *queryLog) record(t time.Time, names ...string) {
+	if len(names) == 0 {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.load()
+	for _, n := range names {
+		n = strings.ToLower(n)
+		q.last[n] = t
+		reportLabelQueryMetric(n, t)
+	}
+	q.trim()
+	if !q.pending {
+		q.pending = true
+		time.AfterFunc(saveDelay, q.flush)
+	}
+}
+
+// flush persists recorded queries, merged with those recorded by other
+// processes since history was loaded.
+func (q *queryLog) flush() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending = false
+	if !q.loaded {
+		return
+	}
+	l, err := auklib.LockFile(q.file())
+	if err != nil {
+		// History is still kept in memory, just not persisted.
+		deck.Warningf("unable to lock label query history %q: %v", q.file(), err)
+		return
+	}
+	defer l.Unlock()
+	for n, t := range q.read() {
+		if t.After(q.last[n]) {
+			q.last[n] = t
+		}
+	}
+	q.trim()
+	if err := q.save(); err != nil {
+		deck.Warningf("unable to save label query history %q: %v", q.file(), err)
+	}
+}
+
+// FlushQueryHistory persists label queries not yet saved, such as when the
+// service stops.
+func FlushQueryHistory() {
+	queries.flush()
+}
+
+// lastQueried returns a copy of the recorded query history.
+func (q *queryLog) lastQueried() map[string]time.Time {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.load()
+	out := make(map[string]time.Time, len(q.last))
+	for k, v := range q.last {
+		out[k] = v
+	}
+	return out
+}
+
+// QueryHistory returns the last time each label was queried. Labels that
+// were never queried are absent.
+func QueryHistory() map[string]time.Time {
+	return queries.lastQueried()
+}
+
+func reportLabelQueryMetric(label string, t time.Time) {
+	auklib.ReportInt("label_last_queried", t.Unix(), map[string]string{"label": label})
+}
+
+func labels(m window.Map, last map[string]time.Time, info map[string]window.LabelInfo) []Label {
+	var out []Label
+	for _, k := range m.Keys() {
+		l := Label{Name: k, LastQueried: last[k], LabelInfo: info[k]}
+		for _, w := range m.Find(k) {
+			if w.Pending() {
+				l.Pending = append(l.Pending, w.Name)
+				continue
+			}
+			l.Windows = append(l.Windows, w.Name)
+		}
+		out = append(out, l)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Labels returns all configured labels along with their metadata and the
+// last time each was explicitly requested. Labels that were never queried
+// have a zero LastQueried. Labels carried only by windows pending approval
+// are included.
+func Labels() ([]Label, error) {
+	var r window.Reader
+	m, err := window.AllWindows(auklib.ConfDir, r)
+	if err != nil {
+		return nil, err
+	}
+	info, err := window.LabelInfos(auklib.ConfDir, r)
+	if err != nil {
+		return nil, err
+	}
+	return labels(m, queries.lastQueried(), info), nil
+}
+
+// Windows returns all configured windows ordered by name, including those
+// pending approval.
+func Windows() ([]window.Window, error) {
+	var r window.Reader
+	m, err := window.AllWindows(auklib.ConfDir, r)
+	if err != nil {
+		return nil, err
+	}
+	w := m.UniqueWindows()
+	sort.Slice(w, func(i, j int) bool { return w[i].Name < w[j].Name })
+	return w, nil
+}
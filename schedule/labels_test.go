@@ -0,0 +1,102 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/aukera/window"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestQueryLogPersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "label_queries.json")
+	ts := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	q := &queryLog{path: path}
+	q.record(ts, "Alpha", "beta")
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("record() persisted history before saveDelay: %v", err)
+	}
+	// Queries recorded by another process since are kept.
+	other := &queryLog{path: path}
+	other.record(ts.Add(time.Hour), "gamma")
+	other.flush()
+	q.flush()
+
+	reloaded := &queryLog{path: path}
+	got := reloaded.lastQueried()
+	want := map[string]time.Time{"alpha": ts, "beta": ts, "gamma": ts.Add(time.Hour)}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("lastQueried() returned diff (-want +got): %s", diff)
+	}
+}
+
+func TestQueryLogBounded(t *testing.T) {
+	q := &queryLog{path: filepath.Join(t.TempDir(), "label_queries.json")}
+	ts := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < maxQueriedLabels+10; i++ {
+		q.record(ts.Add(time.Duration(i)*time.Second), fmt.Sprintf("label%d", i))
+	}
+	got := q.lastQueried()
+	if len(got) != maxQueriedLabels {
+		t.Errorf("lastQueried() returned %d labels, want %d", len(got), maxQueriedLabels)
+	}
+	if _, ok := got["label0"]; ok {
+		t.Errorf("lastQueried() kept the label queried least recently")
+	}
+	if _, ok := got[fmt.Sprintf("label%d", maxQueriedLabels+9)]; !ok {
+		t.Errorf("lastQueried() dropped the label queried most recently")
+	}
+}
+
+func TestQueried(t *testing.T) {
+	labels := []LabelResult{
+		{Label: "patch", Status: StatusFound},
+		{Label: "reboot", Status: StatusError},
+		{Label: "unconfigured", Status: StatusMissing},
+		{Label: "bad label!", Status: StatusMissing},
+	}
+	if diff := cmp.Diff([]string{"patch", "reboot", "unconfigured"}, queried(labels)); diff != "" {
+		t.Errorf("queried() returned diff (-want +got): %s", diff)
+	}
+}
+
+func TestLabels(t *testing.T) {
+	orig := window.ApprovalsFile
+	defer func() { window.ApprovalsFile = orig }()
+	window.ApprovalsFile = filepath.Join(t.TempDir(), "approvals.json")
+	ts := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	m := make(window.Map)
+	m.Add(
+		window.Window{Name: "w1", Labels: []string{"b", "a"}},
+		window.Window{Name: "w2", Labels: []string{"a"}},
+		window.Window{Name: "w3", Labels: []string{"a", "c"}, RequiresApproval: true},
+	)
+	info := map[string]window.LabelInfo{"a": {Description: "OS patching", Severity: "high"}}
+	got := labels(m, map[string]time.Time{"a": ts, "orphan": ts}, info)
+	want := []Label{
+		{Name: "a", Windows: []string{"w1", "w2"}, LastQueried: ts, Pending: []string{"w3"}, LabelInfo: info["a"]},
+		{Name: "b", Windows: []string{"w1"}},
+		{Name: "c", Pending: []string{"w3"}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("labels() returned diff (-want +got): %s", diff)
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/aukera/auklib"
+	"github.com/google/aukera/window"
+)
+
+// Preference classifies a candidate schedule relative to the time it is
+// evaluated at. Lower preferences are presented first.
+type Preference int
+
+const (
+	// PreferOpen schedules are open.
+	PreferOpen Preference = iota
+	// PreferUpcoming schedules have yet to open.
+	PreferUpcoming
+	// PreferPast schedules have closed.
+	PreferPast
+)
+
+func (p Preference) String() string {
+	switch p {
+	case PreferOpen:
+		return "open"
+	case PreferUpcoming:
+		return "upcoming"
+	case PreferPast:
+		return "past"
+	}
+	return fmt.Sprintf("Preference(%d)", int(p))
+}
+
+// MarshalText marshals p as its name.
+func (p Preference) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// NearestRationale describes how Nearest chooses between schedules.
+const NearestRationale = "An open schedule is preferred, then the schedule opening soonest, " +
+	"then the schedule that opened most recently. Ties are broken by the earliest opening time, then by name."
+
+// Candidate is a schedule scored by Rank.
+type Candidate struct {
+	Schedule   window.Schedule
+	Preference Preference
+	// Distance is how far the schedule opens from the evaluation time.
+	// It is zero for open schedules.
+	Distance time.Duration
+}
+
+// MarshalJSON is a custom marshaler for Candidate to ensure Distance is
+// marshalled as a human-readable string.
+func (c Candidate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Schedule   *window.Schedule
+		Preference Preference
+		Distance   string
+	}{&c.Schedule, c.Preference, c.Distance.String()})
+}
+
+// score returns the candidate for s evaluated at now.
+func score(s window.Schedule, now time.Time) Candidate {
+	c := Candidate{Schedule: s}
+	switch {
+	case s.Contains(now):
+		c.Preference = PreferOpen
+	case !s.Opens.Before(now):
+		c.Preference, c.Distance = PreferUpcoming, s.Opens.Sub(now)
+	default:
+		c.Preference, c.Distance = PreferPast, now.Sub(s.Opens)
+	}
+	return c
+}
+
+// less reports whether c is preferred over o, as described by
+// NearestRationale.
+func (c Candidate) less(o Candidate) bool {
+	if c.Preference != o.Preference {
+		return c.Preference < o.Preference
+	}
+	if c.Distance != o.Distance {
+		return c.Distance < o.Distance
+	}
+	if !c.Schedule.Opens.Equal(o.Schedule.Opens) {
+		return c.Schedule.Opens.Before(o.Schedule.Opens)
+	}
+	return c.Schedule.Name < o.Schedule.Name
+}
+
+// Rank scores schedules evaluated at now, ordered from most to least
+// preferred as described by NearestRationale.
+func Rank(schedules []window.Schedule, now time.Time) []Candidate {
+	out := make([]Candidate, len(schedules))
+	for i, s := range schedules {
+		out[i] = score(s, now)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].less(out[j]) })
+	return out
+}
+
+// Nearest returns the schedule to present at now, as described by
+// NearestRationale, or the zero Schedule if there are none.
+func Nearest(schedules []window.Schedule, now time.Time) window.Schedule {
+	var best Candidate
+	for i, s := range schedules {
+		if c := score(s, now); i == 0 || c.less(best) {
+			best = c
+		}
+	}
+	return best.Schedule
+}
+
+// Explanation describes how the schedule of a label was chosen.
+type Explanation struct {
+	Label string
+	// At is the time the schedules were evaluated at.
+	At        time.Time
+	Rationale string
+	// Chosen is the schedule returned by schedule queries for Label.
+	Chosen window.Schedule
+	// Candidates are the aggregated schedules of Label, ordered by
+	// preference.
+	Candidates []Candidate
+	// Sources maps the names of the windows carrying Label to where each
+	// was loaded from, as recorded in window.Window.Source.
+	Sources map[string]string `json:",omitempty"`
+}
+
+// Explain ranks the schedules of label using opts, as evaluated by
+// QueryResult, without recording the label as queried. It returns an error
+// wrapping window.ErrNoWindows if the label has no windows.
+func Explain(ctx context.Context, opts Options, label string) (Explanation, error) {
+	var r window.Reader
+	m, err := window.WindowsContext(ctx, auklib.ConfDir, r)
+	if err != nil {
+		return Explanation{}, err
+	}
+	if m, err = withBuiltins(m); err != nil {
+		return Explanation{}, err
+	}
+	return explain(m, opts, label, time.Now())
+}
+
+// explain ranks the schedules of label in m using opts, evaluated at opts.At
+// or else now.
+func explain(m window.Map, opts Options, label string, now time.Time) (Explanation, error) {
+	label = strings.ToLower(label)
+	if len(m.Find(label)) == 0 {
+		return Explanation{}, fmt.Errorf("label %q: %w", label, window.ErrNoWindows)
+	}
+	at := opts.At
+	if at.IsZero() {
+		at = now
+	}
+	schedules := labelSchedules(m, label, opts, now)
+	inLocation(schedules, opts.Location)
+	if opts.Location != nil {
+		at = at.In(opts.Location)
+	}
+	e := Explanation{Label: label, At: at, Rationale: NearestRationale, Candidates: Rank(schedules, at)}
+	for _, w := range m.Find(label) {
+		if w.Source == "" {
+			continue
+		}
+		if e.Sources == nil {
+			e.Sources = make(map[string]string)
+		}
+		e.Sources[w.Name] = w.Source
+	}
+	if len(e.Candidates) > 0 {
+		e.Chosen = e.Candidates[0].Schedule
+	}
+	return e, nil
+}
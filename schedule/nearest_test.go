@@ -0,0 +1,103 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/aukera/window"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRank(t *testing.T) {
+	at := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+	sched := func(name string, opens, closes time.Duration) window.Schedule {
+		return window.Schedule{Name: name, Opens: at.Add(opens), Closes: at.Add(closes)}
+	}
+	schedules := []window.Schedule{
+		sched("past_far", -48*time.Hour, -47*time.Hour),
+		sched("upcoming_b", time.Hour, 2*time.Hour),
+		sched("past_near", -3*time.Hour, -2*time.Hour),
+		sched("open_late", -time.Hour, time.Hour),
+		sched("upcoming_a", time.Hour, 3*time.Hour),
+		sched("open_early", -2*time.Hour, time.Hour),
+		sched("upcoming_far", 24*time.Hour, 25*time.Hour),
+	}
+	want := []string{"open_early", "open_late", "upcoming_a", "upcoming_b", "upcoming_far", "past_near", "past_far"}
+	var got []string
+	for _, c := range Rank(schedules, at) {
+		got = append(got, c.Schedule.Name)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Rank() returned unexpected order (-want +got):\n%s", diff)
+	}
+
+	// Nearest agrees with Rank regardless of the order of its input.
+	for i := range schedules {
+		rotated := append(append([]window.Schedule(nil), schedules[i:]...), schedules[:i]...)
+		if n := Nearest(rotated, at); n.Name != "open_early" {
+			t.Errorf("Nearest(rotated by %d) = %q, want %q", i, n.Name, "open_early")
+		}
+		if n := Nearest(rotated[1:], at.Add(4*time.Hour)); n.Name == "" || n.Name != Rank(rotated[1:], at.Add(4*time.Hour))[0].Schedule.Name {
+			t.Errorf("Nearest(rotated by %d) = %q, disagrees with Rank()", i, n.Name)
+		}
+	}
+	if n := Nearest(nil, at); !n.Opens.IsZero() {
+		t.Errorf("Nearest(nil) = %v, want the zero Schedule", n)
+	}
+}
+
+func TestCandidateJSON(t *testing.T) {
+	at := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+	c := score(window.Schedule{Name: "patch", Opens: at.Add(90 * time.Minute), Closes: at.Add(2 * time.Hour), Duration: 30 * time.Minute}, at)
+	b, err := json.Marshal(c)
+	if err != nil {
+		t.Fatalf("json.Marshal() returned error: %v", err)
+	}
+	for _, want := range []string{`"Preference":"upcoming"`, `"Distance":"1h30m0s"`, `"Duration":"30m0s"`} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("json.Marshal(%+v) = %s, want %s", c, b, want)
+		}
+	}
+}
+
+func TestExplain(t *testing.T) {
+	at := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+	m := make(window.Map)
+	m.Add(
+		window.Window{Name: "later", Labels: []string{"patch"}, Schedule: window.Schedule{Opens: at.Add(5 * time.Hour), Closes: at.Add(6 * time.Hour)}},
+		window.Window{Name: "sooner", Labels: []string{"patch"}, Schedule: window.Schedule{Opens: at.Add(time.Hour), Closes: at.Add(2 * time.Hour)}, Source: "/etc/aukera/patch.json"},
+	)
+	e, err := explain(m, Options{}, "Patch", at)
+	if err != nil {
+		t.Fatalf("explain() returned error: %v", err)
+	}
+	if e.Label != "patch" || !e.At.Equal(at) || e.Rationale != NearestRationale {
+		t.Errorf("explain() = %+v", e)
+	}
+	if len(e.Candidates) != 2 || !e.Chosen.Opens.Equal(at.Add(time.Hour)) || e.Candidates[0].Schedule != e.Chosen {
+		t.Errorf("explain() chose %v from %v, want the schedule opening at %v", e.Chosen, e.Candidates, at.Add(time.Hour))
+	}
+	if diff := cmp.Diff(map[string]string{"sooner": "/etc/aukera/patch.json"}, e.Sources); diff != "" {
+		t.Errorf("explain() returned unexpected Sources (-want +got):\n%s", diff)
+	}
+	if _, err := explain(m, Options{}, "reboot", at); !errors.Is(err, window.ErrNoWindows) {
+		t.Errorf("explain(reboot) returned %v, want %v", err, window.ErrNoWindows)
+	}
+}
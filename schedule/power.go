@@ -0,0 +1,86 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"time"
+
+	"github.com/google/deck"
+	"github.com/google/aukera/window"
+)
+
+// Generation returns a counter that changes each time previously computed
+// schedules are invalidated. Callers caching schedule data should discard
+// it when the generation differs from the one it was computed under.
+func Generation() int64 {
+	return window.Generation()
+}
+
+// Resume invalidates computed schedules after the host wakes from sleep or
+// hibernation and logs the current state of every label, so that state
+// changes which occurred while asleep are visible immediately.
+func Resume() {
+	window.ResetCache()
+	deck.Info("Host resumed from sleep; recomputing schedules.")
+	s, err := Schedule()
+	if err != nil {
+		deck.Errorf("unable to recompute schedules after resume: %v", err)
+		return
+	}
+	for _, sch := range s {
+		deck.Infof("Label %q is %s after resume (opens %v, closes %v).", sch.Name, sch.CurrentState(), sch.Opens, sch.Closes)
+	}
+}
+
+// slept reports whether the difference between elapsed wall-clock and
+// monotonic time indicates the host was suspended. The monotonic clock does
+// not advance while the host sleeps.
+func slept(wall, mono, tolerance time.Duration) bool {
+	return wall-mono > tolerance
+}
+
+// fnSleepSignals subscribes to notifications of the host resuming from
+// sleep, replaced in tests.
+var fnSleepSignals = sleepSignals
+
+// WatchResume calls Resume whenever the host resumes from sleep, as
+// signalled by logind on Linux, or appears to have been suspended between
+// checks performed every interval, which also catches the wall clock jumping
+// forward and covers hosts without logind. It returns when stop is closed.
+func WatchResume(interval, tolerance time.Duration, stop <-chan struct{}) {
+	resumed, err := fnSleepSignals(stop)
+	if err != nil {
+		deck.Infof("Detecting resume from sleep by clock only: %v", err)
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	prev := time.Now()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-resumed:
+			Resume()
+			// The clock check would otherwise report the same sleep again.
+			prev = time.Now()
+		case <-t.C:
+			now := time.Now()
+			if slept(now.Round(0).Sub(prev.Round(0)), now.Sub(prev), tolerance) {
+				Resume()
+			}
+			prev = now
+		}
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package schedule
+
+import (
+	"github.com/godbus/dbus/v5"
+	"github.com/google/deck"
+)
+
+// logindPath and logindManager identify the logind manager, which signals
+// PrepareForSleep before the host sleeps and again once it resumes.
+const (
+	logindPath    = "/org/freedesktop/login1"
+	logindManager = "org.freedesktop.login1.Manager"
+)
+
+// sleepSignals returns a channel receiving a value each time logind reports
+// that the host resumed from sleep, until stop is closed.
+func sleepSignals(stop <-chan struct{}) (<-chan struct{}, error) {
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.AddMatchSignal(
+		dbus.WithMatchObjectPath(logindPath),
+		dbus.WithMatchInterface(logindManager),
+		dbus.WithMatchMember("PrepareForSleep"),
+	); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	signals := make(chan *dbus.Signal, 4)
+	conn.Signal(signals)
+	resumed := make(chan struct{}, 1)
+	go func() {
+		defer conn.Close()
+		for {
+			select {
+			case <-stop:
+				return
+			case s, ok := <-signals:
+				if !ok {
+					deck.Warning("logind sleep signals stopped; detecting resume by clock only.")
+					return
+				}
+				// The argument is true before sleeping and false after resuming.
+				if len(s.Body) == 1 && s.Body[0] == false {
+					select {
+					case resumed <- struct{}{}:
+					default:
+					}
+				}
+			}
+		}
+	}()
+	return resumed, nil
+}
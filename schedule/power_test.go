@@ -0,0 +1,68 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSlept(t *testing.T) {
+	tests := []struct {
+		desc       string
+		wall, mono time.Duration
+		want       bool
+	}{
+		{"awake", time.Minute, time.Minute, false},
+		{"small drift", time.Minute + time.Second, time.Minute, false},
+		{"suspended", 2 * time.Hour, time.Minute, true},
+		{"clock set backwards", time.Second, time.Minute, false},
+	}
+	for _, tt := range tests {
+		if got := slept(tt.wall, tt.mono, 30*time.Second); got != tt.want {
+			t.Errorf("slept(%s) = %t, want %t", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestResumeGeneration(t *testing.T) {
+	before := Generation()
+	Resume()
+	if got := Generation(); got != before+1 {
+		t.Errorf("Generation() after Resume() = %d, want %d", got, before+1)
+	}
+}
+
+func TestWatchResumeSignal(t *testing.T) {
+	defer func() { fnSleepSignals = sleepSignals }()
+	resumed := make(chan struct{})
+	fnSleepSignals = func(<-chan struct{}) (<-chan struct{}, error) { return resumed, nil }
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		WatchResume(time.Hour, time.Minute, stop)
+		close(done)
+	}()
+	before := Generation()
+	resumed <- struct{}{}
+	// Resume has run once WatchResume accepts the next signal.
+	resumed <- struct{}{}
+	close(stop)
+	<-done
+	if got := Generation(); got < before+1 {
+		t.Errorf("Generation() after a resume signal = %d, want at least %d", got, before+1)
+	}
+}
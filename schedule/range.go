@@ -0,0 +1,101 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/aukera/auklib"
+	"github.com/google/aukera/window"
+)
+
+// MaxRange bounds the period a single Range query may cover.
+const MaxRange = 366 * 24 * time.Hour
+
+// ErrInvalidRange is returned by Range for periods that are empty or longer
+// than MaxRange.
+var ErrInvalidRange = errors.New("invalid time range")
+
+// checkRange validates the period [from, to).
+func checkRange(from, to time.Time) error {
+	switch {
+	case from.IsZero() || to.IsZero():
+		return fmt.Errorf("%w: both from and to are required", ErrInvalidRange)
+	case !to.After(from):
+		return fmt.Errorf("%w: to (%s) must be after from (%s)", ErrInvalidRange, to.Format(time.RFC3339), from.Format(time.RFC3339))
+	case to.Sub(from) > MaxRange:
+		return fmt.Errorf("%w: %v exceeds the maximum of %v", ErrInvalidRange, to.Sub(from), MaxRange)
+	}
+	return nil
+}
+
+// Range returns every open span of the named labels, or of all labels if
+// none are named, that overlaps [from, to), ordered as by
+// window.SortSchedules. Unlike Query, which returns one schedule per label,
+// a label may have many spans. Periods longer than MaxRange, or in which a
+// window activates too often to compute every occurrence, return
+// ErrInvalidRange; names without any window return an error wrapping
+// window.ErrNoWindows. Evaluation is abandoned, returning ctx.Err(), once
+// ctx is done.
+func Range(ctx context.Context, from, to time.Time, opts Options, names ...string) ([]window.Schedule, error) {
+	if err := checkRange(from, to); err != nil {
+		return nil, err
+	}
+	var r window.Reader
+	m, err := window.WindowsContext(ctx, auklib.ConfDir, r)
+	if err != nil {
+		return nil, err
+	}
+	if m, err = withBuiltins(m); err != nil {
+		return nil, err
+	}
+	return spans(ctx, m, from, to, opts, names)
+}
+
+// spans returns the open spans of the named labels of m, or of all its labels
+// if none are named, that overlap [from, to).
+func spans(ctx context.Context, m window.Map, from, to time.Time, opts Options, names []string) ([]window.Schedule, error) {
+	if len(names) == 0 {
+		names = m.Keys()
+	}
+	var (
+		out     []window.Schedule
+		missing []string
+	)
+	for _, n := range names {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if len(m.Find(n)) == 0 {
+			missing = append(missing, n)
+			continue
+		}
+		occ, err := m.Occurrences(n, from, to, opts.Aggregation)
+		if errors.Is(err, window.ErrTooManyOccurrences) {
+			return nil, fmt.Errorf("%w: label %s: %v", ErrInvalidRange, n, err)
+		}
+		out = append(out, occ...)
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("label(s) %s: %w", strings.Join(missing, ", "), window.ErrNoWindows)
+	}
+	window.SortSchedules(out)
+	inLocation(out, opts.Location)
+	return out, nil
+}
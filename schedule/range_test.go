@@ -0,0 +1,107 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/aukera/window"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCheckRange(t *testing.T) {
+	from := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		desc     string
+		from, to time.Time
+		wantErr  bool
+	}{
+		{"day", from, from.AddDate(0, 0, 1), false},
+		{"maximum", from, from.Add(MaxRange), false},
+		{"too long", from, from.Add(MaxRange + time.Second), true},
+		{"empty", from, from, true},
+		{"reversed", from, from.Add(-time.Hour), true},
+		{"no to", from, time.Time{}, true},
+	}
+	for _, tt := range tests {
+		err := checkRange(tt.from, tt.to)
+		if (err != nil) != tt.wantErr || (err != nil && !errors.Is(err, ErrInvalidRange)) {
+			t.Errorf("%s: checkRange() = %v, want error: %t", tt.desc, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSpans(t *testing.T) {
+	from := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	m := make(window.Map)
+	for _, w := range []struct {
+		name, label string
+		h           int
+	}{
+		{"early", "patch", 1},
+		{"late", "patch", 30},
+		{"outside", "patch", 50},
+		{"other", "reboot", 2},
+	} {
+		opens := from.Add(time.Duration(w.h) * time.Hour)
+		m.Add(window.Window{Name: w.name, Labels: []string{w.label}, Schedule: window.Schedule{Name: w.name, Opens: opens, Closes: opens.Add(time.Hour)}})
+	}
+	to := from.Add(48 * time.Hour)
+	opens := func(s []window.Schedule) []time.Time {
+		var out []time.Time
+		for _, sch := range s {
+			out = append(out, sch.Opens)
+		}
+		return out
+	}
+
+	got, err := spans(context.Background(), m, from, to, Options{}, []string{"Patch"})
+	if err != nil {
+		t.Fatalf("spans(patch) returned error: %v", err)
+	}
+	want := []time.Time{from.Add(time.Hour), from.Add(30 * time.Hour)}
+	if diff := cmp.Diff(want, opens(got)); diff != "" {
+		t.Errorf("spans(patch) returned unexpected spans (-want +got):\n%s", diff)
+	}
+
+	got, err = spans(context.Background(), m, from, to, Options{}, nil)
+	if err != nil {
+		t.Fatalf("spans() returned error: %v", err)
+	}
+	if len(got) != 3 {
+		t.Errorf("spans() returned %d spans, want 3: %v", len(got), got)
+	}
+
+	if _, err := spans(context.Background(), m, from, to, Options{}, []string{"patch", "missing"}); !errors.Is(err, window.ErrNoWindows) {
+		t.Errorf("spans(patch, missing) returned %v, want %v", err, window.ErrNoWindows)
+	}
+}
+
+func TestSpansTooManyOccurrences(t *testing.T) {
+	var w window.Window
+	if err := json.Unmarshal([]byte(`{"Name": "minutely", "Format": 1, "Schedule": "0 * * * * *", "Duration": "1m", "Labels": ["patch"]}`), &w); err != nil {
+		t.Fatal(err)
+	}
+	m := make(window.Map)
+	m.Add(w)
+	from := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := spans(context.Background(), m, from, from.Add(MaxRange), Options{}, nil); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("spans() returned %v, want %v", err, ErrInvalidRange)
+	}
+}
@@ -16,87 +16,291 @@
 package schedule
 
 import (
+	"context"
 	"fmt"
 	"runtime"
 	"strings"
 	"time"
 
-	"github.com/google/cabbie/metrics"
 	"github.com/google/deck"
 	"github.com/google/aukera/auklib"
 	"github.com/google/aukera/window"
 )
 
-// findNearest calculates the nearest schedule to now to present to the user
-func findNearest(schedules []window.Schedule) window.Schedule {
-	var next window.Schedule
-	now := time.Now()
-	for _, s := range schedules {
-		// prefer an open schedule
-		if s.IsOpen() {
-			next = s
-			break
-		}
-		// Evaluate the next, closest closed schedule
-		if next.Opens.IsZero() {
-			next = s
-			continue
-		}
-		bestOpens := next.Opens.Sub(now).Seconds()
-		thisOpens := s.Opens.Sub(now).Seconds()
-		// New schedule in future, current in the past
-		if thisOpens > 0 && bestOpens < 0 {
-			next = s
+// Options modify how a schedule query is evaluated.
+type Options struct {
+	// Aggregation selects how overlapping windows within a label are combined.
+	Aggregation window.Aggregation
+	// Location, if set, is the time zone Opens and Closes are presented in.
+	Location *time.Location
+	// At, if set, computes schedules as of At rather than now, from the
+	// occurrences of windows within atHorizon of At. The built-in active
+	// hours windows only cover the current day.
+	At time.Time
+}
+
+// atHorizon bounds how far past Options.At schedules are searched for.
+const atHorizon = 366 * 24 * time.Hour
+
+// inLocation presents the open and close times of schedules in loc.
+func inLocation(schedules []window.Schedule, loc *time.Location) {
+	if loc == nil {
+		return
+	}
+	for i := range schedules {
+		schedules[i].Opens = schedules[i].Opens.In(loc)
+		schedules[i].Closes = schedules[i].Closes.In(loc)
+	}
+}
+
+// DisableActiveHours omits the built-in active hours windows from all
+// schedules, so that their labels are unknown.
+var DisableActiveHours bool
+
+// withActiveHours adds the built-in active hours windows to m where the
+// platform supports them. On Linux, active hours depend on a desktop session
+// being present, so hosts without one simply lack the built-in windows.
+func withActiveHours(m window.Map) (window.Map, error) {
+	if DisableActiveHours {
+		return m, nil
+	}
+	switch runtime.GOOS {
+	case "windows":
+		return window.ActiveHoursWindow(m)
+	case "linux":
+		am, err := window.ActiveHoursWindow(m)
+		if err != nil {
+			deck.InfofA("active hours unavailable: %v", err).With(deck.V(1)).Go()
+			return m, nil
 		}
-		// Both schedules in the future, new schedule closer to now
-		if thisOpens >= 0 && bestOpens >= 0 && thisOpens < bestOpens {
-			next = s
+		return am, nil
+	}
+	return m, nil
+}
+
+// EnableOSUpdates adds the built-in os_updates window to all schedules where
+// the operating system installs updates on a schedule.
+var EnableOSUpdates bool
+
+// withBuiltins adds the enabled built-in windows to m.
+func withBuiltins(m window.Map) (window.Map, error) {
+	m, err := withActiveHours(m)
+	if err != nil || !EnableOSUpdates {
+		return m, err
+	}
+	um, err := window.OSUpdatesWindow(m)
+	if err != nil {
+		deck.InfofA("os updates unavailable: %v", err).With(deck.V(1)).Go()
+		return m, nil
+	}
+	return um, nil
+}
+
+// LabelStatus is the outcome of a schedule query for a single label.
+type LabelStatus string
+
+const (
+	// StatusFound labels have a schedule.
+	StatusFound LabelStatus = "found"
+	// StatusMissing labels have no configured windows.
+	StatusMissing LabelStatus = "missing"
+	// StatusError labels have windows from which no schedule could be
+	// calculated, such as when none occurs within atHorizon of Options.At.
+	StatusError LabelStatus = "error"
+)
+
+// LabelResult reports the outcome of a schedule query for Label.
+type LabelResult struct {
+	Label  string
+	Status LabelStatus
+	Error  string `json:",omitempty"`
+}
+
+// Result is the outcome of a schedule query: the schedules of the labels
+// that were found, and the status of every label queried.
+type Result struct {
+	Schedules []window.Schedule
+	Labels    []LabelResult
+	// EvaluatedAt is the single time every label was evaluated at: Options.At
+	// if it is set, and otherwise when evaluation began.
+	EvaluatedAt time.Time
+}
+
+// Failed returns the labels of r that have no schedule.
+func (r Result) Failed() []LabelResult {
+	var out []LabelResult
+	for _, l := range r.Labels {
+		if l.Status != StatusFound {
+			out = append(out, l)
 		}
-		// Both schedules in the past, new schedule closer to now
-		if thisOpens < 0 && bestOpens < 0 && thisOpens > bestOpens {
-			next = s
+	}
+	return out
+}
+
+// LabelsError is returned when none of the requested labels has a schedule.
+// It wraps window.ErrNoWindows.
+type LabelsError struct {
+	Labels []LabelResult
+}
+
+func (e *LabelsError) Error() string {
+	var parts []string
+	for _, l := range e.Labels {
+		p := fmt.Sprintf("%s (%s", l.Label, l.Status)
+		if l.Error != "" {
+			p += ": " + l.Error
 		}
+		parts = append(parts, p+")")
 	}
-	return next
+	return fmt.Sprintf("label(s) %s: %v", strings.Join(parts, ", "), window.ErrNoWindows)
+}
+
+func (e *LabelsError) Unwrap() error {
+	return window.ErrNoWindows
 }
 
 // Schedule calculates schedule per label and returns label whose names match the given string(s).
 func Schedule(names ...string) ([]window.Schedule, error) {
-	var r window.Reader
-	m, err := window.Windows(auklib.ConfDir, r)
+	return Query(Options{}, names...)
+}
+
+// Query calculates schedule per label using opts and returns label whose
+// names match the given string(s). Labels without a schedule are omitted;
+// use QueryResult to learn which.
+func Query(opts Options, names ...string) ([]window.Schedule, error) {
+	res, err := QueryResult(context.Background(), opts, names...)
 	if err != nil {
 		return nil, err
 	}
-	switch runtime.GOOS {
-	case "windows":
-		m, err = window.ActiveHoursWindow(m)
-		if err != nil {
-			return nil, err
-		}
+	return res.Schedules, nil
+}
+
+// QueryResult calculates schedule per label using opts, reporting the
+// status of each label queried. If names are given and none of them has a
+// schedule, the Result is returned along with a *LabelsError. Schedules are
+// ordered as by window.SortSchedules. Evaluation is
+// abandoned, returning ctx.Err(), once ctx is done.
+func QueryResult(ctx context.Context, opts Options, names ...string) (Result, error) {
+	var r window.Reader
+	m, err := window.WindowsContext(ctx, auklib.ConfDir, r)
+	if err != nil {
+		return Result{}, err
+	}
+	if m, err = withBuiltins(m); err != nil {
+		return Result{}, err
 	}
-	if len(names) == 0 {
+	requested := len(names) > 0
+	if !requested {
 		names = m.Keys()
 	}
+	res, err := evaluate(ctx, m, opts, names)
+	if err != nil {
+		return Result{}, err
+	}
+	if requested {
+		queries.record(time.Now(), queried(res.Labels)...)
+	}
+	if failed := res.Failed(); requested && len(failed) == len(res.Labels) {
+		return res, &LabelsError{Labels: failed}
+	}
+	return res, nil
+}
+
+// queried returns the labels worth recording as queried: those that were
+// found or had no schedule, and those missing whose names are valid labels,
+// so that arbitrary names queried by clients are not recorded.
+func queried(labels []LabelResult) []string {
+	var out []string
+	for _, l := range labels {
+		if l.Status == StatusMissing {
+			if _, err := window.NormalizeLabel(l.Label); err != nil {
+				continue
+			}
+		}
+		out = append(out, l.Label)
+	}
+	return out
+}
+
+// labelSchedules returns the aggregated schedules of label in m using opts:
+// the schedules of its windows as of now, or their occurrences within
+// atHorizon of opts.At if it is set. The schedules are pinned to the time
+// they were evaluated at.
+func labelSchedules(m window.Map, label string, opts Options, now time.Time) []window.Schedule {
+	if opts.At.IsZero() {
+		return m.AggregateAt(label, opts.Aggregation, now)
+	}
+	// Windows activating too often to compute every occurrence within
+	// atHorizon still yield those nearest opts.At, which are the ones used.
+	schedules, _ := m.Occurrences(label, opts.At, opts.At.Add(atHorizon), opts.Aggregation)
+	for i := range schedules {
+		schedules[i].Pin(opts.At)
+	}
+	return schedules
+}
+
+// evaluate calculates the schedules of names in m using opts, stopping
+// once ctx is done. Every label is evaluated at the same time, so that labels
+// computed moments apart cannot straddle a boundary inconsistently.
+func evaluate(ctx context.Context, m window.Map, opts Options, names []string) (Result, error) {
 	deck.Infof("Aggregating schedule for label(s): %s", strings.Join(names, ", "))
-	var out []window.Schedule
+	res := Result{EvaluatedAt: opts.At}
+	if res.EvaluatedAt.IsZero() {
+		res.EvaluatedAt = time.Now()
+	}
 	for i := range names {
-		schedules := m.AggregateSchedules(names[i])
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		start := time.Now()
+		schedules := labelSchedules(m, names[i], opts, res.EvaluatedAt)
+		// Labels are not attached, so that the number of series is bounded.
+		auklib.ReportDuration("aggregate_duration", time.Since(start), nil)
+		lr := LabelResult{Label: strings.ToLower(names[i]), Status: StatusFound}
+		switch {
+		case len(m.Find(names[i])) == 0:
+			lr.Status = StatusMissing
+		case len(schedules) == 0:
+			lr.Status = StatusError
+			lr.Error = fmt.Sprintf("no occurrence within %v of %s", atHorizon, res.EvaluatedAt.Format(time.RFC3339))
+			if opts.At.IsZero() {
+				lr.Error = "no upcoming occurrence applies to this host"
+			}
+		}
+		res.Labels = append(res.Labels, lr)
+
 		var success int64 = 1
-		if len(schedules) == 0 {
-			deck.Errorf("no schedule found for label %q", names[i])
+		if lr.Status != StatusFound {
+			deck.Errorf("no schedule found for label %q: %s", names[i], lr.Status)
 			success = 0
+		}
+		auklib.ReportInt("schedule_retrieved", success, map[string]string{"request": names[i]})
+		if success == 0 {
 			continue
 		}
 
-		metricName := fmt.Sprintf("%s/%s", auklib.MetricRoot, "schedule_retrieved")
-		metric, err := metrics.NewInt(metricName, auklib.MetricSvc)
-		if err != nil {
-			deck.Warningf("could not create metric: %v", err)
-		}
-		metric.Data.AddStringField("request", names[i])
-		metric.Set(success)
+		res.Schedules = append(res.Schedules, Nearest(schedules, res.EvaluatedAt))
+	}
+	window.SortSchedules(res.Schedules)
+	inLocation(res.Schedules, opts.Location)
+	return res, nil
+}
+
+// Occurrences calculates the schedules of all labels within [from, to),
+// ordered as by window.SortSchedules.
+func Occurrences(from, to time.Time, opts Options) ([]window.Schedule, error) {
+	return occurrences(context.Background(), from, to, opts)
+}
 
-		out = append(out, findNearest(schedules))
+// occurrences is Occurrences, stopping once ctx is done.
+func occurrences(ctx context.Context, from, to time.Time, opts Options) ([]window.Schedule, error) {
+	var r window.Reader
+	m, err := window.WindowsContext(ctx, auklib.ConfDir, r)
+	if err != nil {
+		return nil, err
+	}
+	if m, err = withBuiltins(m); err != nil {
+		return nil, err
 	}
-	return out, nil
+	return spans(ctx, m, from, to, opts, nil)
 }
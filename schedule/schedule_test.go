@@ -15,10 +15,13 @@
 package schedule
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/google/aukera/window"
+	"github.com/google/go-cmp/cmp"
 )
 
 type ts map[string]window.Schedule
@@ -76,7 +79,7 @@ func modSched(add ts, del []string) map[string]window.Schedule {
 	return s
 }
 
-func TestFindNearest(t *testing.T) {
+func TestNearest(t *testing.T) {
 	tests := []struct {
 		in   ts
 		want string
@@ -95,9 +98,149 @@ func TestFindNearest(t *testing.T) {
 			[]string{"plus_2_days", "plus_10_days", "plus_30_days"}), "minus_6_days"},
 	}
 	for _, tt := range tests {
-		res := findNearest(tt.in.vals())
+		res := Nearest(tt.in.vals(), time.Now())
 		if res != tt.in[tt.want] {
-			t.Errorf("findNearest(%v) = %v, want (%v)", tt.in, res, tt.in[tt.want])
+			t.Errorf("Nearest(%v) = %v, want (%v)", tt.in, res, tt.in[tt.want])
 		}
 	}
 }
+
+func TestNearestAt(t *testing.T) {
+	tests := []struct {
+		desc string
+		at   time.Time
+		want string
+	}{
+		{"future, between windows", now.Add(5 * 24 * time.Hour), "plus_10_days"},
+		{"future, open window", now.Add(2*24*time.Hour + time.Hour), "plus_2_days"},
+		{"after all windows", now.Add(60 * 24 * time.Hour), "plus_30_days"},
+	}
+	for _, tt := range tests {
+		if res := Nearest(testSchedules.vals(), tt.at); res != testSchedules[tt.want] {
+			t.Errorf("Nearest(%s) = %v, want %v", tt.desc, res, testSchedules[tt.want])
+		}
+	}
+}
+
+func TestInLocation(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	opens := time.Date(2020, time.January, 1, 12, 0, 0, 0, time.UTC)
+	s := []window.Schedule{{Name: "tz", Opens: opens, Closes: opens.Add(time.Hour)}}
+	inLocation(s, loc)
+	if s[0].Opens.Location() != loc || s[0].Closes.Location() != loc {
+		t.Errorf("inLocation() = %v, want times in %v", s[0], loc)
+	}
+	if !s[0].Opens.Equal(opens) || s[0].Opens.Hour() != 7 {
+		t.Errorf("inLocation() changed the instant or hour: %v", s[0].Opens)
+	}
+}
+
+func TestDisableActiveHours(t *testing.T) {
+	defer func(d bool) { DisableActiveHours = d }(DisableActiveHours)
+	DisableActiveHours = true
+	m := make(window.Map)
+	m.Add(window.Window{Name: "nightly", Labels: []string{"patch"}})
+	got, err := withActiveHours(m)
+	if err != nil {
+		t.Fatalf("withActiveHours() returned error: %v", err)
+	}
+	for _, l := range []string{window.ActiveHoursLabel, window.OutsideActiveHoursLabel} {
+		if len(got.Find(l)) != 0 {
+			t.Errorf("withActiveHours() with active hours disabled added label %q", l)
+		}
+	}
+	if len(got.Find("patch")) != 1 {
+		t.Errorf("withActiveHours() dropped configured windows: %v", got)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	m := make(window.Map)
+	m.Add(window.Window{
+		Name:     "nightly",
+		Labels:   []string{"patch"},
+		Schedule: window.Schedule{Opens: now.Add(time.Hour), Closes: now.Add(2 * time.Hour)},
+	})
+	res, err := evaluate(context.Background(), m, Options{}, []string{"Patch", "reboot"})
+	if err != nil {
+		t.Fatalf("evaluate() returned error: %v", err)
+	}
+	want := []LabelResult{{Label: "patch", Status: StatusFound}, {Label: "reboot", Status: StatusMissing}}
+	if diff := cmp.Diff(want, res.Labels); diff != "" {
+		t.Errorf("evaluate() returned unexpected label statuses (-want +got):\n%s", diff)
+	}
+	if len(res.Schedules) != 1 || res.Schedules[0].Name != "patch" {
+		t.Errorf("evaluate() returned schedules %v, want one for patch", res.Schedules)
+	}
+	if res.EvaluatedAt.IsZero() || !res.Schedules[0].EvaluatedAt.Equal(res.EvaluatedAt) {
+		t.Errorf("evaluate() evaluated at %v, schedules at %v, want the same time", res.EvaluatedAt, res.Schedules[0].EvaluatedAt)
+	}
+	at := now.Add(90 * time.Minute)
+	pinned, err := evaluate(context.Background(), m, Options{At: at}, []string{"patch"})
+	if err != nil {
+		t.Fatalf("evaluate(at) returned error: %v", err)
+	}
+	if len(pinned.Schedules) != 1 || !pinned.EvaluatedAt.Equal(at) || pinned.Schedules[0].State != window.StateOpen {
+		t.Errorf("evaluate(at) = %+v, want a schedule open when evaluated at %v", pinned, at)
+	}
+	if diff := cmp.Diff(want[1:], res.Failed()); diff != "" {
+		t.Errorf("Failed() returned unexpected labels (-want +got):\n%s", diff)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := evaluate(ctx, m, Options{}, []string{"patch"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("evaluate() with a canceled context returned %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestEvaluateUnsampled(t *testing.T) {
+	var w window.Window
+	if err := w.UnmarshalJSON([]byte(`{"Name": "rare", "Format": 1, "Schedule": "0 0 * * * *", "Duration": "1h", "Labels": ["patch"], "SampleRate": 1e-12}`)); err != nil {
+		t.Fatal(err)
+	}
+	m := make(window.Map)
+	m.Add(w)
+	res, err := evaluate(context.Background(), m, Options{}, []string{"patch"})
+	if err != nil {
+		t.Fatalf("evaluate() returned error: %v", err)
+	}
+	if len(res.Schedules) != 0 || len(res.Labels) != 1 || res.Labels[0].Status != StatusError {
+		t.Errorf("evaluate() of a window this host is never sampled into = %+v, want status %q", res, StatusError)
+	}
+}
+
+func TestLabelsError(t *testing.T) {
+	err := error(&LabelsError{Labels: []LabelResult{
+		{Label: "patch", Status: StatusMissing},
+		{Label: "reboot", Status: StatusError, Error: "no occurrence"},
+	}})
+	if !errors.Is(err, window.ErrNoWindows) {
+		t.Errorf("LabelsError does not wrap window.ErrNoWindows")
+	}
+	if want := "label(s) patch (missing), reboot (error: no occurrence): no windows found"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestEvaluateOrder(t *testing.T) {
+	m := make(window.Map)
+	for l, h := range map[string]int{"zeta": 1, "alpha": 3, "mid": 2, "beta": 2} {
+		opens := now.Add(time.Duration(h) * time.Hour)
+		m.Add(window.Window{Name: l, Labels: []string{l}, Schedule: window.Schedule{Opens: opens, Closes: opens.Add(time.Hour)}})
+	}
+	res, err := evaluate(context.Background(), m, Options{}, m.Keys())
+	if err != nil {
+		t.Fatalf("evaluate() returned error: %v", err)
+	}
+	var got []string
+	for _, s := range res.Schedules {
+		got = append(got, s.Name)
+	}
+	if diff := cmp.Diff([]string{"zeta", "beta", "mid", "alpha"}, got); diff != "" {
+		t.Errorf("evaluate() returned schedules in unexpected order (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/aukera/window"
+)
+
+// selfTestWindow opens hourly for two hours, so it is always open.
+const selfTestWindow = `{"Name": "aukera-selftest", "Format": 1, "Schedule": "0 0 * * * *", "Duration": "2h", "Labels": ["aukera-selftest"]}`
+
+// Step is the outcome of a single self-test stage.
+type Step struct {
+	Name     string
+	Passed   bool
+	Error    string `json:",omitempty"`
+	Duration time.Duration
+}
+
+// SelfTestResult is the outcome of SelfTest.
+type SelfTestResult struct {
+	Passed   bool
+	Steps    []Step
+	Duration time.Duration
+}
+
+// SelfTest evaluates a synthetic window through parsing, aggregation and
+// marshaling, independently of the configuration on disk.
+func SelfTest() SelfTestResult {
+	var (
+		res   = SelfTestResult{Passed: true}
+		start = time.Now()
+		w     window.Window
+		s     []window.Schedule
+	)
+	run := func(name string, fn func() error) {
+		if !res.Passed {
+			return
+		}
+		t := time.Now()
+		err := fn()
+		step := Step{Name: name, Passed: err == nil, Duration: time.Since(t)}
+		if err != nil {
+			step.Error = err.Error()
+			res.Passed = false
+		}
+		res.Steps = append(res.Steps, step)
+	}
+
+	run("parse", func() error {
+		return json.Unmarshal([]byte(selfTestWindow), &w)
+	})
+	run("aggregate", func() error {
+		m := make(window.Map)
+		m.Add(w)
+		s = m.Aggregate(w.Labels[0], window.AggregateMerge)
+		if len(s) != 1 {
+			return fmt.Errorf("got %d schedules, want 1", len(s))
+		}
+		if !s[0].IsOpen() {
+			return fmt.Errorf("schedule %v is not open", s[0])
+		}
+		return nil
+	})
+	run("marshal", func() error {
+		b, err := json.Marshal(s)
+		if err != nil {
+			return err
+		}
+		var got []window.Schedule
+		if err := json.Unmarshal(b, &got); err != nil {
+			return err
+		}
+		if len(got) != 1 || !got[0].Opens.Equal(s[0].Opens) || !got[0].Closes.Equal(s[0].Closes) || got[0].State != s[0].State {
+			return fmt.Errorf("schedule changed in round trip: got %v, want %v", got, s)
+		}
+		return nil
+	})
+	res.Duration = time.Since(start)
+	return res
+}
@@ -0,0 +1,27 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import "testing"
+
+func TestSelfTest(t *testing.T) {
+	res := SelfTest()
+	if !res.Passed {
+		t.Errorf("SelfTest() failed: %+v", res)
+	}
+	if len(res.Steps) != 3 {
+		t.Errorf("SelfTest() ran %d steps, want 3", len(res.Steps))
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/deck"
+	"github.com/google/aukera/window"
+)
+
+// selfUpdatePoll bounds how long AwaitSelfUpdate waits before re-evaluating
+// the schedule, so that configuration changes are picked up.
+var selfUpdatePoll = time.Minute
+
+var fnSelfSchedule = Schedule
+
+// AwaitSelfUpdate blocks until Aukera may restart to apply an update: when
+// the window.SelfUpdateLabel label is open, or no windows carry it. It gives
+// up after max, or when ctx is done, returning an error; callers are
+// expected to proceed with the restart regardless so that updates are
+// delayed rather than blocked.
+func AwaitSelfUpdate(ctx context.Context, max time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, max)
+	defer cancel()
+	for {
+		wait := selfUpdatePoll
+		s, err := fnSelfSchedule(window.SelfUpdateLabel)
+		switch {
+		case errors.Is(err, window.ErrNoWindows):
+			return nil
+		case err != nil:
+			deck.Warningf("unable to determine %s schedule: %v", window.SelfUpdateLabel, err)
+		case len(s) > 0:
+			now := time.Now()
+			if s[0].Contains(now) {
+				return nil
+			}
+			if d := s[0].TimeUntilOpen(now); d > 0 && d < wait {
+				wait = d
+			}
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return fmt.Errorf("AwaitSelfUpdate: %s did not open: %w", window.SelfUpdateLabel, ctx.Err())
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/aukera/window"
+)
+
+func TestAwaitSelfUpdate(t *testing.T) {
+	defer func(p time.Duration) { selfUpdatePoll = p }(selfUpdatePoll)
+	defer func() { fnSelfSchedule = Schedule }()
+	selfUpdatePoll = 10 * time.Millisecond
+
+	tests := []struct {
+		desc    string
+		sched   func(...string) ([]window.Schedule, error)
+		wantErr bool
+	}{
+		{"unconfigured", func(...string) ([]window.Schedule, error) {
+			return nil, fmt.Errorf("label(s) %s: %w", window.SelfUpdateLabel, window.ErrNoWindows)
+		}, false},
+		{"open", func(...string) ([]window.Schedule, error) {
+			return []window.Schedule{{Name: window.SelfUpdateLabel, Opens: time.Now().Add(-time.Minute), Closes: time.Now().Add(time.Hour)}}, nil
+		}, false},
+		{"opens soon", func() func(...string) ([]window.Schedule, error) {
+			opens := time.Now().Add(30 * time.Millisecond)
+			return func(...string) ([]window.Schedule, error) {
+				return []window.Schedule{{Name: window.SelfUpdateLabel, Opens: opens, Closes: opens.Add(time.Hour)}}, nil
+			}
+		}(), false},
+		{"closed", func(...string) ([]window.Schedule, error) {
+			return []window.Schedule{{Name: window.SelfUpdateLabel, Opens: time.Now().Add(time.Hour), Closes: time.Now().Add(2 * time.Hour)}}, nil
+		}, true},
+		{"failing", func(...string) ([]window.Schedule, error) {
+			return nil, errors.New("unreadable")
+		}, true},
+	}
+	for _, tt := range tests {
+		fnSelfSchedule = tt.sched
+		err := AwaitSelfUpdate(context.Background(), 200*time.Millisecond)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("AwaitSelfUpdate(%s) returned %v, want error %t", tt.desc, err, tt.wantErr)
+		}
+		if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("AwaitSelfUpdate(%s) returned %v, want %v", tt.desc, err, context.DeadlineExceeded)
+		}
+	}
+}
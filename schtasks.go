@@ -0,0 +1,124 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/google/aukera/export"
+	"github.com/google/aukera/schedule"
+	"github.com/google/aukera/window"
+)
+
+// runSchtasks implements the schtasks subcommand, converting the upcoming
+// occurrences of a label into a Windows Task Scheduler task so that tooling
+// driven by schtasks can follow windows without querying the service.
+func runSchtasks(args []string) error {
+	fs := flag.NewFlagSet("schtasks", flag.ContinueOnError)
+	label := fs.String("label", "", "Label whose windows trigger the task (required)")
+	command := fs.String("command", "", "Program the task runs when a window opens (required)")
+	arguments := fs.String("arguments", "", "Command line arguments of the program")
+	days := fs.Int("days", 14, fmt.Sprintf("Number of days of windows to include, up to %d occurrences; re-run before they run out", export.MaxTaskTriggers))
+	out := fs.String("out", "", "Output file path for the task XML (default: stdout)")
+	register := fs.String("register", "", "On Windows, register the task with schtasks under this name instead of writing XML")
+	mode := fs.String("mode", "", "Aggregation of overlapping windows: merge, or a comma-separated list of conservative and adjacent")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *label == "" || *command == "" {
+		fs.Usage()
+		return fmt.Errorf("schtasks: -label and -command are required")
+	}
+	if *days <= 0 {
+		return fmt.Errorf("schtasks: days must be positive (found: %d)", *days)
+	}
+	a, err := window.ParseAggregation(*mode)
+	if err != nil {
+		return fmt.Errorf("schtasks: %v", err)
+	}
+
+	now := time.Now()
+	all, err := schedule.Range(context.Background(), now, now.AddDate(0, 0, *days), schedule.Options{Aggregation: a}, *label)
+	if err != nil && !errors.Is(err, window.ErrNoWindows) {
+		return fmt.Errorf("schtasks: %v", err)
+	}
+	var s []window.Schedule
+	for _, o := range all {
+		if o.Opens.After(now) {
+			s = append(s, o)
+		}
+	}
+	if len(s) == 0 {
+		return fmt.Errorf("schtasks: label %q has no windows opening within %d days", *label, *days)
+	}
+	if len(s) > export.MaxTaskTriggers {
+		fmt.Fprintf(os.Stderr, "schtasks: only the first %d of %d windows are included; re-run before %s\n",
+			export.MaxTaskTriggers, len(s), s[export.MaxTaskTriggers-1].Opens.Format(time.RFC3339))
+		s = s[:export.MaxTaskTriggers]
+	}
+	t := export.Task{
+		Description: fmt.Sprintf("Runs while Aukera label %q is open, from windows computed at %s.", strings.ToLower(*label), now.Format(time.RFC3339)),
+		Command:     *command,
+		Arguments:   *arguments,
+		Schedules:   s,
+	}
+	if *register != "" {
+		return registerTask(*register, t)
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return fmt.Errorf("schtasks: %v", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	return export.WriteTask(w, t)
+}
+
+// registerTask creates or replaces the scheduled task name with t.
+func registerTask(name string, t export.Task) error {
+	if runtime.GOOS != "windows" {
+		return fmt.Errorf("schtasks: -register is only supported on Windows")
+	}
+	f, err := os.CreateTemp("", "aukera-task-*.xml")
+	if err != nil {
+		return fmt.Errorf("schtasks: %v", err)
+	}
+	defer os.Remove(f.Name())
+	if err := export.WriteTask(f, t); err != nil {
+		f.Close()
+		return fmt.Errorf("schtasks: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("schtasks: %v", err)
+	}
+	out, err := exec.Command("schtasks.exe", "/Create", "/TN", name, "/XML", f.Name(), "/F").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("schtasks: registering task %q: %v: %s", name, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"runtime/debug"
+)
+
+// Version is the version of the running binary, as reported by GET /api. It
+// defaults to the module version recorded at build time, falling back to the
+// VCS revision.
+var Version = buildVersion()
+
+func buildVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	if v := info.Main.Version; v != "" && v != "(devel)" {
+		return v
+	}
+	for _, s := range info.Settings {
+		if s.Key == "vcs.revision" {
+			return s.Value
+		}
+	}
+	return "(devel)"
+}
+
+// Param describes a parameter of an Endpoint.
+type Param struct {
+	Name string
+	// In is where the parameter is sent: "path", "query" or "body".
+	In          string
+	Description string
+	// Deprecated is the warning sent to clients using the parameter, if it
+	// is deprecated.
+	Deprecated string `json:",omitempty"`
+}
+
+// Endpoint describes a route of the API.
+type Endpoint struct {
+	Path        string
+	Methods     []string
+	Description string
+	Params      []Param `json:",omitempty"`
+	// Admin endpoints require the admin token; see requireAdmin.
+	Admin bool `json:",omitempty"`
+	// Deprecated is the warning sent to clients using the endpoint, if it
+	// is deprecated.
+	Deprecated string `json:",omitempty"`
+}
+
+// Catalog describes the API served by the running binary.
+type Catalog struct {
+	Version   string
+	Endpoints []Endpoint
+}
+
+// Parameters shared by the schedule query endpoints; see queryOptions.
+var queryParams = []Param{
+	{Name: "mode", In: "query", Description: "Comma-separated aggregation of the windows of a label: merge (default), conservative and adjacent"},
+	{Name: "tz", In: "query", Description: "IANA time zone schedule times are reported in"},
+	{Name: "at", In: "query", Description: "RFC 3339 time schedules are evaluated at instead of now"},
+}
+
+// scheduleParams are the parameters specific to schedule requests.
+var scheduleParams = []Param{
+	{Name: "dedup", In: "query", Description: "Whether labels whose windows open over the same span share one entry"},
+	{Name: "from", In: "query", Description: "RFC 3339 start of a range every span open within is returned for; requires to"},
+	{Name: "to", In: "query", Description: "RFC 3339 end of the range; requires from"},
+}
+
+// params concatenates parameter lists.
+func params(p ...[]Param) []Param {
+	var out []Param
+	for _, ps := range p {
+		out = append(out, ps...)
+	}
+	return out
+}
+
+// endpoints lists the routes of muxRouter.
+var endpoints = []Endpoint{
+	{Path: "/", Methods: []string{http.MethodGet}, Description: "Human-readable summary of all labels, as HTML if preferred by the client, else plain text"},
+	{Path: "/api", Methods: []string{http.MethodGet}, Description: "This catalog of endpoints"},
+	{Path: "/status", Methods: []string{http.MethodGet}, Description: "Responds OK while the service is running"},
+	{Path: "/healthz", Methods: []string{http.MethodGet}, Description: "Liveness probe responding 204 No Content"},
+	{Path: "/selftest", Methods: []string{http.MethodGet}, Description: "Result of the schedule self-test; 503 if it failed"},
+	{Path: "/configcheck", Methods: []string{http.MethodGet}, Description: "Validation results for every configuration file"},
+	{Path: "/schema", Methods: []string{http.MethodGet}, Description: "JSON Schema of configuration files"},
+	{Path: "/validate", Methods: []string{http.MethodPost}, Description: "Validates a configuration file without installing it", Params: []Param{
+		{Name: "name", In: "query", Description: "File name reported in the results"},
+		{Name: "body", In: "body", Description: "Configuration file content"},
+	}},
+	{Path: "/labels", Methods: []string{http.MethodGet}, Description: "Configured labels and the windows making them up"},
+	{Path: "/windows", Methods: []string{http.MethodGet}, Description: "Configured window definitions", Params: []Param{
+		{Name: "tag", In: "query", Description: "name=value or name that listed windows must carry; repeatable"},
+	}},
+	{Path: "/windows", Methods: []string{http.MethodPost}, Description: "Registers an ephemeral window", Admin: true, Params: []Param{
+		{Name: "body", In: "body", Description: "The ephemeral window, with its TTL and reason"},
+	}},
+	{Path: "/windows/{name}", Methods: []string{http.MethodDelete}, Description: "Deletes an ephemeral window", Admin: true, Params: []Param{
+		{Name: "name", In: "path", Description: "Window name"},
+	}},
+	{Path: "/calendar", Methods: []string{http.MethodGet}, Description: "Occurrences of every label over the coming days", Params: params([]Param{
+		{Name: "days", In: "query", Description: "Days covered, from 1 to 366 (default 7)"},
+	}, queryParams)},
+	{Path: "/explain/{label}", Methods: []string{http.MethodGet}, Description: "How the schedule of a label was chosen, with the source of each window", Params: params([]Param{
+		{Name: "label", In: "path", Description: "Label name"},
+	}, queryParams)},
+	{Path: "/evaluate", Methods: []string{http.MethodPost}, Description: "Evaluates several labels, each at its own time, in one request", Params: params([]Param{
+		{Name: "body", In: "body", Description: "JSON array of {Label, At} pairs, at most 1000"},
+	}, queryParams)},
+	{Path: "/intent", Methods: []string{http.MethodGet}, Description: "Registered intents to use windows", Params: []Param{
+		{Name: "label", In: "query", Description: "Only list intents for this label"},
+	}},
+	{Path: "/intent/{label}", Methods: []string{http.MethodPost}, Description: "Declares an intent to use the current window of a label", Params: []Param{
+		{Name: "label", In: "path", Description: "Label name"},
+		{Name: "body", In: "body", Description: "The intent: agent, action and estimated duration"},
+	}},
+	{Path: "/approve/{window}", Methods: []string{http.MethodPost}, Description: "Approves a window requiring approval", Admin: true, Params: []Param{
+		{Name: "window", In: "path", Description: "Window name"},
+	}},
+	{Path: "/schedule", Methods: []string{http.MethodGet}, Description: "Schedules of all labels", Params: params(queryParams, scheduleParams)},
+	{Path: "/schedule/{label}", Methods: []string{http.MethodGet}, Description: "Schedule of a label", Params: params([]Param{
+		{Name: "label", In: "path", Description: "Label name"},
+	}, queryParams, scheduleParams)},
+	{Path: "/events", Methods: []string{http.MethodGet}, Description: "Server-sent events reporting schedule changes", Params: params([]Param{
+		{Name: "label", In: "query", Description: "Only report changes to this label"},
+	}, queryParams)},
+}
+
+// catalog returns the API catalog, with current deprecations applied.
+func catalog() Catalog {
+	c := Catalog{Version: Version}
+	for _, e := range endpoints {
+		e.Params = append([]Param(nil), e.Params...)
+		for _, d := range deprecations {
+			if d.Route != "" && d.Route != e.Path {
+				continue
+			}
+			if d.Param == "" {
+				e.Deprecated = d.warning()
+				continue
+			}
+			for i := range e.Params {
+				if e.Params[i].Name == d.Param && e.Params[i].In == "query" {
+					e.Params[i].Deprecated = d.warning()
+				}
+			}
+		}
+		c.Endpoints = append(c.Endpoints, e)
+	}
+	return c
+}
+
+// serveAPI responds with the catalog of the API.
+func serveAPI(w http.ResponseWriter, r *http.Request) {
+	c := catalog()
+	sendJSONResponse(w, &c)
+}
@@ -0,0 +1,93 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/google/deck"
+	"github.com/google/aukera/auklib"
+)
+
+// TokenPath is the location of the administrative bearer token. The file is
+// only readable by privileged users, who present its contents to perform
+// administrative requests.
+var TokenPath = filepath.Join(auklib.DataDir, "admin.token")
+
+var (
+	tokenMu sync.Mutex
+	token   string
+)
+
+// adminToken loads the administrative token, generating it on first use or
+// when the token file is empty.
+func adminToken() (string, error) {
+	tokenMu.Lock()
+	defer tokenMu.Unlock()
+	if token != "" {
+		return token, nil
+	}
+	b, err := os.ReadFile(TokenPath)
+	if err == nil {
+		if t := strings.TrimSpace(string(b)); t != "" {
+			token = t
+			return token, nil
+		}
+		deck.Warningf("admin token %q is empty, generating a new one", TokenPath)
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(TokenPath), 0755); err != nil {
+		return "", err
+	}
+	t := hex.EncodeToString(raw)
+	if err := os.WriteFile(TokenPath, []byte(t), 0600); err != nil {
+		return "", err
+	}
+	token = t
+	return token, nil
+}
+
+// requireAdmin rejects requests without the administrative bearer token.
+// It fails closed: requests without a bearer token are rejected even if no
+// token could be loaded.
+func requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		want, err := adminToken()
+		if err != nil {
+			deck.Errorf("unable to load admin token: %v", err)
+			sendHTTPResponse(w, http.StatusInternalServerError, []byte("admin token unavailable"))
+			return
+		}
+		auth := r.Header.Get("Authorization")
+		got := strings.TrimPrefix(auth, "Bearer ")
+		if !strings.HasPrefix(auth, "Bearer ") || got == "" || want == "" || subtle.ConstantTimeCompare([]byte(got), []byte(want)) != 1 {
+			sendHTTPResponse(w, http.StatusUnauthorized, []byte("unauthorized"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,233 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/deck"
+	"github.com/google/aukera/auklib"
+	"github.com/google/aukera/control"
+	"github.com/google/aukera/schedule"
+	"github.com/google/aukera/window"
+)
+
+// draining is non-zero while the server is draining; see SetDraining.
+var draining int32
+
+// SetDraining sets whether the server is draining, such as ahead of
+// maintenance of the host. While draining, requests are refused with 503
+// Service Unavailable and open event streams end.
+func SetDraining(on bool) {
+	var v int32
+	if on {
+		v = 1
+	}
+	if atomic.SwapInt32(&draining, v) != v {
+		deck.Infof("Draining set to %t.", on)
+	}
+}
+
+// Draining reports whether the server is draining.
+func Draining() bool {
+	return atomic.LoadInt32(&draining) != 0
+}
+
+// refuseDraining responds 503 Service Unavailable to every request while the
+// server is draining.
+func refuseDraining(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if Draining() {
+			sendUnavailable(w, drainRetryAfter, "server is draining")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+var fnReload = func() (window.Map, error) {
+	window.ResetCache()
+	return window.Windows(auklib.ConfDir, window.Reader{})
+}
+
+// ReloadResult is the result of the reload control command.
+type ReloadResult struct {
+	Windows    int
+	ConfigHash string
+}
+
+func controlReload(ctx context.Context, args json.RawMessage) (any, error) {
+	m, err := fnReload()
+	if err != nil {
+		return nil, err
+	}
+	return ReloadResult{Windows: len(m.UniqueWindows()), ConfigHash: window.ConfigHash(auklib.ConfDir)}, nil
+}
+
+// State is the result of the dump-state control command.
+type State struct {
+	ConfigHash string
+	// StaleSince is set while windows are served from the last good load;
+	// see window.StaleSince.
+	StaleSince *time.Time           `json:",omitempty"`
+	LastChange *window.ConfigChange `json:",omitempty"`
+	Draining   bool
+	Schedules  []window.Schedule
+	Intents    []schedule.Intent
+}
+
+func controlDumpState(ctx context.Context, args json.RawMessage) (any, error) {
+	s, err := fnSchedule(schedule.Options{})
+	if err != nil && !errors.Is(err, window.ErrNoWindows) {
+		return nil, err
+	}
+	for i := range s {
+		s[i].State = s[i].CurrentState()
+	}
+	st := State{
+		ConfigHash: window.ConfigHash(auklib.ConfDir),
+		Draining:   Draining(),
+		Schedules:  s,
+		Intents:    fnIntents(""),
+	}
+	if t, ok := fnStaleSince(auklib.ConfDir); ok {
+		st.StaleSince = &t
+	}
+	if c, ok := fnLastChange(auklib.ConfDir); ok {
+		st.LastChange = &c
+	}
+	return st, nil
+}
+
+// overrideArgs are the arguments of the set-override control command.
+// Window is written to the OverridesDir of the configuration directory,
+// taking precedence over any configured window of the same name. Clear
+// removes the override of the window called Name instead.
+type overrideArgs struct {
+	Window json.RawMessage
+	Name   string
+	Clear  bool
+}
+
+// overrideFile returns the file holding the override of the named window.
+// Bytes other than letters, digits and dashes are written as an underscore
+// followed by their hex value, so that names cannot escape the overrides
+// directory and distinct names, such as "a.b" and "a_b", never share a file.
+func overrideFile(name string) string {
+	var safe strings.Builder
+	for i := 0; i < len(name); i++ {
+		switch c := name[i]; {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-':
+			safe.WriteByte(c)
+		default:
+			fmt.Fprintf(&safe, "_%02x", c)
+		}
+	}
+	return filepath.Join(auklib.ConfDir, window.OverridesDir, "control-"+safe.String()+".json")
+}
+
+func controlSetOverride(ctx context.Context, args json.RawMessage) (any, error) {
+	var a overrideArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+	if a.Clear {
+		if a.Name == "" {
+			return nil, errors.New("Name is required to clear an override")
+		}
+		if err := os.Remove(overrideFile(a.Name)); err != nil {
+			if os.IsNotExist(err) {
+				return nil, fmt.Errorf("window(%s): no override set", a.Name)
+			}
+			return nil, err
+		}
+		deck.Infof("window(%s): override cleared", a.Name)
+		return nil, nil
+	}
+	if len(a.Window) == 0 {
+		return nil, errors.New("Window is required")
+	}
+	// Validate the window exactly as the service would load it.
+	var w window.Window
+	if err := json.Unmarshal(a.Window, &w); err != nil {
+		return nil, err
+	}
+	b, err := json.MarshalIndent(struct{ Windows []json.RawMessage }{[]json.RawMessage{a.Window}}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	path := overrideFile(w.Name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	if err := auklib.WriteFileAtomic(path, append(b, '\n'), 0644); err != nil {
+		return nil, err
+	}
+	deck.Infof("window(%s): override written to %s", w.Name, path)
+	return map[string]string{"File": path}, nil
+}
+
+// drainArgs are the arguments of the drain control command. Draining is
+// enabled unless Enable is false.
+type drainArgs struct {
+	Enable *bool
+}
+
+func controlDrain(ctx context.Context, args json.RawMessage) (any, error) {
+	var a drainArgs
+	if len(args) > 0 {
+		if err := json.Unmarshal(args, &a); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %v", err)
+		}
+	}
+	on := a.Enable == nil || *a.Enable
+	SetDraining(on)
+	return map[string]bool{"Draining": on}, nil
+}
+
+// ControlServer returns a control.Server handling the local administrative
+// commands: reload, dump-state, set-override and drain.
+func ControlServer() *control.Server {
+	s := &control.Server{}
+	s.Handle("reload", controlReload)
+	s.Handle("dump-state", controlDumpState)
+	s.Handle("set-override", controlSetOverride)
+	s.Handle("drain", controlDrain)
+	return s
+}
+
+// RunControl serves the local administrative commands on the control socket
+// at path. It refuses to start on platforms where the socket would admit
+// any local user.
+func RunControl(path string) error {
+	if err := control.Check(); err != nil {
+		return err
+	}
+	ln, err := control.Listen(path)
+	if err != nil {
+		return err
+	}
+	deck.Infof("Control socket listening on %s.", path)
+	return ControlServer().Serve(ln)
+}
@@ -0,0 +1,57 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/google/deck"
+	"github.com/go-chi/chi/v5"
+)
+
+// DebugPort is the default localhost port of the debug server.
+const DebugPort = 6060
+
+func debugRouter() http.Handler {
+	rtr := chi.NewRouter()
+	rtr.HandleFunc("/debug/pprof/", pprof.Index)
+	rtr.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	rtr.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	rtr.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	rtr.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	rtr.Handle("/debug/pprof/{profile}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pprof.Handler(chi.URLParam(r, "profile")).ServeHTTP(w, r)
+	}))
+	rtr.Handle("/debug/vars", expvar.Handler())
+	return rtr
+}
+
+// RunDebug serves pprof profiles and expvar variables on port, bound to the
+// loopback addresses only. Profiles expose process internals, so the debug
+// server must never be reachable from other hosts.
+func RunDebug(port int) error {
+	lns, err := listenLoopback(port)
+	if err != nil {
+		return err
+	}
+	for _, ln := range lns {
+		deck.Infof("Debug server listening on %s.", ln.Addr())
+	}
+	// Profiles such as /debug/pprof/profile stream for longer than the
+	// schedule server's write timeout, so none is set.
+	return serveAll(&http.Server{Handler: debugRouter()}, lns)
+}
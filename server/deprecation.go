@@ -0,0 +1,158 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/deck"
+	"github.com/go-chi/chi/v5"
+)
+
+// DeprecationHeader carries a warning for each deprecated part of a
+// request, so that clients can be migrated before support is removed.
+const DeprecationHeader = "X-Aukera-Deprecation"
+
+// deprecationField lists the warnings of a request within JSON object
+// responses. Array responses, whose shape cannot change, carry only headers.
+const deprecationField = "Deprecations"
+
+// deprecation describes a route or query parameter that clients should stop
+// sending.
+type deprecation struct {
+	// Route is the route pattern, such as "/schedule/{label}", the
+	// deprecation applies to. Empty matches every route.
+	Route string
+	// Param is a deprecated query parameter. Empty deprecates the route
+	// itself.
+	Param string
+	// Message tells clients what to send instead.
+	Message string
+	// Sunset is when support is removed, if known.
+	Sunset time.Time
+}
+
+// matches reports whether r, routed to route, uses d.
+func (d deprecation) matches(route string, r *http.Request) bool {
+	if d.Route != "" && d.Route != route {
+		return false
+	}
+	return d.Param == "" || r.URL.Query().Has(d.Param)
+}
+
+// warning returns the warning sent to clients using d.
+func (d deprecation) warning() string {
+	var what string
+	switch {
+	case d.Param != "" && d.Route != "":
+		what = fmt.Sprintf("parameter %q of %s", d.Param, d.Route)
+	case d.Param != "":
+		what = fmt.Sprintf("parameter %q", d.Param)
+	default:
+		what = d.Route
+	}
+	w := fmt.Sprintf("%s is deprecated", what)
+	if !d.Sunset.IsZero() {
+		w += fmt.Sprintf(" and will be removed after %s", d.Sunset.UTC().Format("2006-01-02"))
+	}
+	if d.Message != "" {
+		w += ": " + d.Message
+	}
+	return w
+}
+
+// deprecations lists the deprecated parts of the API.
+var deprecations []deprecation
+
+// warnDeprecated annotates responses to requests that use deprecated routes
+// or parameters: a Deprecation header, a Sunset header with the earliest
+// removal date, a DeprecationHeader per warning, and, in JSON object
+// responses, a deprecationField listing the warnings. It must run after
+// routing, so that the route pattern is known.
+func warnDeprecated(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var route string
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			route = rctx.RoutePattern()
+		}
+		var (
+			warnings []string
+			sunset   time.Time
+		)
+		for _, d := range deprecations {
+			if !d.matches(route, r) {
+				continue
+			}
+			warnings = append(warnings, d.warning())
+			if !d.Sunset.IsZero() && (sunset.IsZero() || d.Sunset.Before(sunset)) {
+				sunset = d.Sunset
+			}
+		}
+		if len(warnings) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		deck.Warningf("deprecated request %s %s from %s: %s", r.Method, r.URL.Path, r.RemoteAddr, strings.Join(warnings, "; "))
+		h := w.Header()
+		h.Set("Deprecation", "true")
+		if !sunset.IsZero() {
+			h.Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+		}
+		for _, warn := range warnings {
+			h.Add(DeprecationHeader, warn)
+		}
+		next.ServeHTTP(&deprecationWriter{ResponseWriter: w, warnings: warnings}, r)
+	})
+}
+
+// deprecationWriter adds deprecation warnings to the JSON object response
+// written in a single Write, as by sendHTTPResponse.
+type deprecationWriter struct {
+	http.ResponseWriter
+	warnings []string
+	written  bool
+}
+
+func (dw *deprecationWriter) Write(p []byte) (int, error) {
+	if dw.written || !strings.HasPrefix(dw.Header().Get("Content-Type"), "application/json") {
+		dw.written = true
+		return dw.ResponseWriter.Write(p)
+	}
+	dw.written = true
+	body := bytes.TrimSpace(p)
+	if len(body) < 2 || body[0] != '{' {
+		return dw.ResponseWriter.Write(p)
+	}
+	field, err := json.Marshal(map[string][]string{deprecationField: dw.warnings})
+	if err != nil {
+		return dw.ResponseWriter.Write(p)
+	}
+	// Splice the field into the start of the object.
+	rest := bytes.TrimSpace(body[1:])
+	out := append([]byte(nil), field[:len(field)-1]...)
+	if rest[0] != '}' {
+		out = append(out, ',')
+	}
+	out = append(out, rest...)
+	if _, err := dw.ResponseWriter.Write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
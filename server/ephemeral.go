@@ -0,0 +1,82 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/aukera/auklib"
+	"github.com/google/aukera/window"
+	"github.com/go-chi/chi/v5"
+)
+
+// maxEphemeralBytes bounds the size of ephemeral window registrations.
+const maxEphemeralBytes = 16 << 10
+
+var (
+	fnRegisterEphemeral = func(e window.Ephemeral) (window.Ephemeral, error) {
+		return window.RegisterEphemeral(auklib.ConfDir, window.Reader{}, e)
+	}
+	fnDeleteEphemeral = func(name, source string) (window.Ephemeral, error) {
+		return window.DeleteEphemeral(auklib.ConfDir, window.Reader{}, name, source)
+	}
+)
+
+// registerWindow registers the ephemeral window sent as the request body,
+// such as {"Window": {...}, "TTL": "4h", "Reason": "emergency patch"},
+// recording the address it was sent from and responding with the
+// registration.
+func registerWindow(w http.ResponseWriter, r *http.Request) {
+	var e window.Ephemeral
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxEphemeralBytes)).Decode(&e); err != nil {
+		sendHTTPResponse(w, http.StatusBadRequest, []byte(err.Error()))
+		return
+	}
+	e.Source = r.RemoteAddr
+	e, err := fnRegisterEphemeral(e)
+	switch {
+	case errors.Is(err, window.ErrInvalidEphemeral):
+		sendHTTPResponse(w, http.StatusBadRequest, []byte(err.Error()))
+		return
+	case errors.Is(err, window.ErrWindowExists):
+		sendHTTPResponse(w, http.StatusConflict, []byte(err.Error()))
+		return
+	case err != nil:
+		sendHTTPResponse(w, http.StatusInternalServerError, []byte(err.Error()))
+		return
+	}
+	sendJSONResponse(w, &e)
+}
+
+// deleteWindow discards an ephemeral window, recording the address the
+// request was sent from and responding with the discarded registration.
+// Windows configured in files cannot be deleted.
+func deleteWindow(w http.ResponseWriter, r *http.Request) {
+	e, err := fnDeleteEphemeral(chi.URLParam(r, "name"), r.RemoteAddr)
+	switch {
+	case errors.Is(err, window.ErrNotEphemeral):
+		sendHTTPResponse(w, http.StatusForbidden, []byte(err.Error()))
+		return
+	case errors.Is(err, window.ErrNoWindows):
+		sendHTTPResponse(w, http.StatusNotFound, []byte(err.Error()))
+		return
+	case err != nil:
+		sendHTTPResponse(w, http.StatusInternalServerError, []byte(err.Error()))
+		return
+	}
+	sendJSONResponse(w, &e)
+}
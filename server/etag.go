@@ -0,0 +1,67 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/aukera/window"
+)
+
+var (
+	fnConfigHash         = window.ConfigHash
+	fnApprovalGeneration = window.ApprovalGeneration
+)
+
+// etag derives an entity tag from the configuration hash, the approval
+// state of its windows and any other inputs the response depends on. It
+// returns "" if the configuration hash is unknown.
+func etag(configHash string, parts ...string) string {
+	if configHash == "" {
+		return ""
+	}
+	h := sha256.New()
+	h.Write([]byte(configHash))
+	h.Write([]byte{0})
+	h.Write([]byte(strconv.FormatUint(fnApprovalGeneration(), 10)))
+	for _, p := range parts {
+		h.Write([]byte{0})
+		h.Write([]byte(p))
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil))[:32] + `"`
+}
+
+// notModified sets the ETag of the response to tag and, if the request's
+// If-None-Match matches it, responds 304 Not Modified and returns true.
+// Clients must revalidate cached responses on every use.
+func notModified(w http.ResponseWriter, r *http.Request, tag string) bool {
+	if tag == "" {
+		return false
+	}
+	w.Header().Set("ETag", tag)
+	w.Header().Set("Cache-Control", "no-cache")
+	for _, t := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		t = strings.TrimPrefix(strings.TrimSpace(t), "W/")
+		if t == tag || t == "*" {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/aukera/schedule"
+	"github.com/google/aukera/window"
+)
+
+// maxEvaluateBytes bounds the size of batch evaluation requests.
+const maxEvaluateBytes = 256 << 10
+
+var fnBatch = schedule.Batch
+
+// evaluateBatch evaluates the labels and times sent as the request body,
+// such as [{"Label": "patch", "At": "2026-01-01T02:00:00Z"}], responding
+// with a schedule.Evaluated for each in order. The mode and tz parameters
+// apply to every evaluation.
+func evaluateBatch(w http.ResponseWriter, r *http.Request) {
+	opts, err := queryOptions(r)
+	if err != nil {
+		sendHTTPResponse(w, http.StatusBadRequest, []byte(err.Error()))
+		return
+	}
+	var evals []schedule.Evaluation
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxEvaluateBytes)).Decode(&evals); err != nil {
+		sendHTTPResponse(w, http.StatusBadRequest, []byte(err.Error()))
+		return
+	}
+	res, err := fnBatch(r.Context(), opts, evals)
+	switch {
+	case errors.Is(err, schedule.ErrBatchTooLarge):
+		sendHTTPResponse(w, http.StatusRequestEntityTooLarge, []byte(err.Error()))
+		return
+	case errors.Is(err, window.ErrNotLoaded):
+		sendUnavailable(w, loadRetryAfter, err.Error())
+		return
+	case err != nil:
+		sendHTTPResponse(w, http.StatusInternalServerError, []byte(err.Error()))
+		return
+	}
+	sendJSONResponse(w, &res)
+}
@@ -0,0 +1,233 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/google/deck"
+	"github.com/google/aukera/auklib"
+	"github.com/google/aukera/schedule"
+	"github.com/google/aukera/window"
+)
+
+// eventInterval is how often schedules are re-evaluated for event streams.
+var eventInterval = 10 * time.Second
+
+var fnGeneration = schedule.Generation
+
+// writeTimeout is the server's write timeout, which event streams renew
+// before each write rather than being cut off by.
+var writeTimeout = DefaultConfig.WriteTimeout
+
+// connKey is the request context key of the connection a request arrived
+// on; see connContext.
+type connKey struct{}
+
+// connContext records the connection of each request so that event streams
+// can renew its write deadline. It is the http.Server ConnContext.
+func connContext(ctx context.Context, c net.Conn) context.Context {
+	return context.WithValue(ctx, connKey{}, c)
+}
+
+// renewWriteDeadline sets the write deadline of the connection r arrived on
+// to d from now, or clears it if d is zero, so that a stream outlives the
+// server's write timeout while each of its writes remains bounded.
+func renewWriteDeadline(r *http.Request, d time.Duration) {
+	c, ok := r.Context().Value(connKey{}).(net.Conn)
+	if !ok {
+		return
+	}
+	var t time.Time
+	if d > 0 {
+		t = time.Now().Add(d)
+	}
+	if err := c.SetWriteDeadline(t); err != nil {
+		deck.Warningf("event stream: unable to renew write deadline: %v", err)
+	}
+}
+
+// scheduleChanged reports whether a subscriber holding old, with the State
+// it was sent with, needs s.
+func scheduleChanged(old, s window.Schedule) bool {
+	return old.State != s.CurrentState() || !old.Opens.Equal(s.Opens) || !old.Closes.Equal(s.Closes)
+}
+
+// writeEvent writes v as a server-sent event of the given type.
+func writeEvent(w http.ResponseWriter, event string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, b)
+	return err
+}
+
+var fnLastChange = window.LastChange
+
+// affects reports whether c changed the schedule of any of labels, or of any
+// label if none are given.
+func affects(c window.ConfigChange, labels []string) bool {
+	if len(labels) == 0 {
+		return true
+	}
+	for _, d := range c.Labels {
+		for _, l := range labels {
+			if d.Label == l {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// serveEvents streams schedule updates as server-sent events. The current
+// schedule of every requested label is sent on connect, so that reconnecting
+// subscribers resynchronize, followed by a "schedule" event whenever a
+// label's schedule changes, subject to DefaultEventLimits. A "config" event
+// summarizing each configuration change that affects the requested labels
+// precedes the schedule events it causes. An "intent" event is sent for each
+// intent registered for the requested labels, starting with those registered
+// before the stream began. If the system clock is stepped backwards, or
+// computed schedules are invalidated as when the host resumes from sleep
+// (see schedule.Generation), the schedules are recomputed and every label is
+// resent as on connect, rather than reporting transitions relative to the
+// time before the step. The server's write timeout bounds each write rather
+// than the whole stream. Streams end when the client disconnects, a write
+// times out or the server starts draining.
+func serveEvents(w http.ResponseWriter, r *http.Request) {
+	f, ok := w.(http.Flusher)
+	if !ok {
+		sendHTTPResponse(w, http.StatusInternalServerError, []byte("streaming unsupported"))
+		return
+	}
+	var req []string
+	if l := r.URL.Query().Get("label"); l != "" {
+		req = append(req, l)
+	}
+	opts, err := queryOptions(r)
+	if err != nil {
+		sendHTTPResponse(w, http.StatusBadRequest, []byte(err.Error()))
+		return
+	}
+	s, err := fnSchedule(opts, req...)
+	if errors.Is(err, window.ErrNoWindows) {
+		sendHTTPResponse(w, http.StatusNotFound, []byte(err.Error()))
+		return
+	}
+	if errors.Is(err, window.ErrNotLoaded) {
+		sendUnavailable(w, loadRetryAfter, err.Error())
+		return
+	}
+	if err != nil {
+		sendHTTPResponse(w, http.StatusInternalServerError, []byte(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	sent := make(map[string]window.Schedule)
+	limiter := newTransitionLimiter(DefaultEventLimits)
+	seen, _ := fnLastChange(auklib.ConfDir)
+	var intentSeq uint64
+	var clock auklib.ClockWatch
+	gen := fnGeneration()
+	ticker := time.NewTicker(eventInterval)
+	defer ticker.Stop()
+	for {
+		changed := false
+		now := time.Now()
+		renewWriteDeadline(r, writeTimeout)
+		d := clock.SteppedBack(now)
+		if d > 0 {
+			deck.Warningf("event stream: system clock stepped back by %v, resynchronizing schedules", d)
+			window.ResetCache()
+		}
+		if g := fnGeneration(); d > 0 || g != gen {
+			if d == 0 {
+				deck.Infof("event stream: schedules invalidated, such as on resume from sleep; resynchronizing")
+			}
+			gen = g
+			if fresh, err := fnSchedule(opts, req...); err == nil {
+				s = fresh
+			}
+			sent = make(map[string]window.Schedule)
+			limiter = newTransitionLimiter(DefaultEventLimits)
+		}
+		if c, ok := fnLastChange(auklib.ConfDir); ok && c.Seq > seen.Seq {
+			seen = c
+			if affects(c, req) {
+				if err := writeEvent(w, "config", &c); err != nil {
+					return
+				}
+				changed = true
+			}
+		}
+		var declared []schedule.Intent
+		declared, intentSeq = fnIntentsSince(intentSeq)
+		for _, i := range declared {
+			if len(req) > 0 && i.Label != req[0] {
+				continue
+			}
+			if err := writeEvent(w, "intent", &i); err != nil {
+				return
+			}
+			changed = true
+		}
+		for _, sch := range s {
+			old, ok := sent[sch.Name]
+			if ok && !scheduleChanged(old, sch) {
+				continue
+			}
+			// The initial schedule of each label is always sent. Throttled
+			// transitions are retried on the next evaluation.
+			if ok && !limiter.allow(sch.Name, now) {
+				continue
+			}
+			sch.State = sch.CurrentState()
+			if err := writeEvent(w, "schedule", &sch); err != nil {
+				return
+			}
+			sent[sch.Name] = sch
+			changed = true
+		}
+		if !changed {
+			// Comments keep idle connections alive and detect disconnects.
+			if _, err := fmt.Fprint(w, ":\n\n"); err != nil {
+				return
+			}
+		}
+		f.Flush()
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+		if Draining() {
+			return
+		}
+		if s, err = fnSchedule(opts, req...); err != nil {
+			deck.Warningf("event stream: unable to calculate schedule: %v", err)
+		}
+	}
+}
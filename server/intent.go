@@ -0,0 +1,69 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/aukera/schedule"
+	"github.com/google/aukera/window"
+	"github.com/go-chi/chi/v5"
+)
+
+// maxIntentBytes bounds the size of intent declarations.
+const maxIntentBytes = 4 << 10
+
+var (
+	fnDeclareIntent = schedule.DeclareIntent
+	fnIntents       = schedule.Intents
+	fnIntentsSince  = schedule.IntentsSince
+)
+
+// declareIntent registers the intent sent as the request body, such as
+// {"Agent": "patcher", "Action": "install updates", "EstimatedDuration": "30m"},
+// for the upcoming window of the label, responding with the registered
+// intent.
+func declareIntent(w http.ResponseWriter, r *http.Request) {
+	var req schedule.Intent
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxIntentBytes)).Decode(&req); err != nil {
+		sendHTTPResponse(w, http.StatusBadRequest, []byte(err.Error()))
+		return
+	}
+	i, err := fnDeclareIntent(chi.URLParam(r, "label"), req.Agent, req.Action, req.EstimatedDuration)
+	switch {
+	case errors.Is(err, schedule.ErrInvalidIntent):
+		sendHTTPResponse(w, http.StatusBadRequest, []byte(err.Error()))
+		return
+	case errors.Is(err, window.ErrNoWindows):
+		sendHTTPResponse(w, http.StatusNotFound, []byte(err.Error()))
+		return
+	case errors.Is(err, schedule.ErrTooManyIntents):
+		sendHTTPResponse(w, http.StatusTooManyRequests, []byte(err.Error()))
+		return
+	case err != nil:
+		sendHTTPResponse(w, http.StatusInternalServerError, []byte(err.Error()))
+		return
+	}
+	sendJSONResponse(w, &i)
+}
+
+// serveIntents lists registered intents, optionally restricted to a label
+// given as label=name.
+func serveIntents(w http.ResponseWriter, r *http.Request) {
+	l := fnIntents(r.URL.Query().Get("label"))
+	sendJSONResponse(w, &l)
+}
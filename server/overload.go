@@ -0,0 +1,65 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Retry-After delays advertised with 503 Service Unavailable responses.
+const (
+	// overloadRetryAfter is sent when too many requests are in flight.
+	overloadRetryAfter = 2 * time.Second
+	// loadRetryAfter is sent when the configuration cannot be loaded, such
+	// as while a configuration push holds its files.
+	loadRetryAfter = 10 * time.Second
+	// drainRetryAfter is sent while the server is draining.
+	drainRetryAfter = time.Minute
+)
+
+// maxInFlight is the limit applied by muxRouter; see Config.MaxInFlight.
+var maxInFlight = DefaultConfig.MaxInFlight
+
+// sendUnavailable responds 503 Service Unavailable with message, advising
+// clients to retry after retry, which is rounded up to whole seconds.
+func sendUnavailable(w http.ResponseWriter, retry time.Duration, message string) {
+	secs := int((retry + time.Second - 1) / time.Second)
+	w.Header().Set("Retry-After", strconv.Itoa(secs))
+	sendHTTPResponse(w, http.StatusServiceUnavailable, []byte(message))
+}
+
+// limitInFlight refuses requests with 503 Service Unavailable while n others
+// are being handled, rather than queueing them, so that bursts of clients,
+// such as after a configuration push reaches many hosts at once, back off
+// and return later. An n of zero or less disables the limit.
+func limitInFlight(n int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if n <= 0 {
+			return next
+		}
+		sem := make(chan struct{}, n)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+				next.ServeHTTP(w, r)
+			default:
+				sendUnavailable(w, overloadRetryAfter, "server overloaded")
+			}
+		})
+	}
+}
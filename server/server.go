@@ -16,14 +16,24 @@
 package server
 
 import (
+	"crypto/ed25519"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/deck"
+	"github.com/google/aukera/auklib"
 	"github.com/google/aukera/schedule"
+	"github.com/google/aukera/window"
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 )
 
 func sendHTTPResponse(w http.ResponseWriter, statusCode int, message []byte) {
@@ -34,7 +44,74 @@ func sendHTTPResponse(w http.ResponseWriter, statusCode int, message []byte) {
 	}
 }
 
-var fnSchedule = schedule.Schedule
+// StaleHeader is set on responses computed from previously loaded
+// configuration because the configuration directory could not be read. Its
+// value is the time of the last successful load in RFC 3339 format.
+const StaleHeader = "X-Aukera-Stale"
+
+var fnStaleSince = window.StaleSince
+
+// sendJSONResponse marshals v and sends it with a JSON content type, which
+// also makes the response eligible for compression.
+func sendJSONResponse(w http.ResponseWriter, v any) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		sendHTTPResponse(w, http.StatusInternalServerError, []byte(err.Error()))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if t, ok := fnStaleSince(auklib.ConfDir); ok {
+		w.Header().Set(StaleHeader, t.UTC().Format(time.RFC3339))
+	}
+	sendHTTPResponse(w, http.StatusOK, b)
+}
+
+var (
+	fnSchedule = schedule.Query
+	fnQuery    = schedule.QueryResult
+)
+
+// LabelStatusHeader lists the status of each label of a schedule request as
+// comma separated label=status pairs, such as "patch=found, reboot=missing",
+// since labels without a schedule are omitted from the response body.
+const LabelStatusHeader = "X-Aukera-Label-Status"
+
+// setLabelStatus sets LabelStatusHeader from labels.
+func setLabelStatus(w http.ResponseWriter, labels []schedule.LabelResult) {
+	if len(labels) == 0 {
+		return
+	}
+	pairs := make([]string, len(labels))
+	for i, l := range labels {
+		pairs[i] = fmt.Sprintf("%s=%s", l.Label, l.Status)
+	}
+	w.Header().Set(LabelStatusHeader, strings.Join(pairs, ", "))
+}
+
+// queryOptions parses schedule query options from request parameters.
+func queryOptions(r *http.Request) (schedule.Options, error) {
+	var opts schedule.Options
+	a, err := window.ParseAggregation(r.URL.Query().Get("mode"))
+	if err != nil {
+		return opts, err
+	}
+	opts.Aggregation = a
+	if tz := r.URL.Query().Get("tz"); tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return opts, fmt.Errorf("invalid time zone %q: %w", tz, err)
+		}
+		opts.Location = loc
+	}
+	if at := r.URL.Query().Get("at"); at != "" {
+		t, err := time.Parse(time.RFC3339, at)
+		if err != nil {
+			return opts, fmt.Errorf("invalid time %q: must be RFC 3339: %w", at, err)
+		}
+		opts.At = t
+	}
+	return opts, nil
+}
 
 func serve(w http.ResponseWriter, r *http.Request) {
 	var req []string
@@ -42,37 +119,512 @@ func serve(w http.ResponseWriter, r *http.Request) {
 	if label != "" {
 		req = append(req, label)
 	}
-	s, err := fnSchedule(req...)
+	opts, err := queryOptions(r)
+	if err != nil {
+		sendHTTPResponse(w, http.StatusBadRequest, []byte(err.Error()))
+		return
+	}
+	dedup, err := dedupOption(r)
+	if err != nil {
+		sendHTTPResponse(w, http.StatusBadRequest, []byte(err.Error()))
+		return
+	}
+	from, to, ok, err := rangeOption(r)
+	if err != nil {
+		sendHTTPResponse(w, http.StatusBadRequest, []byte(err.Error()))
+		return
+	}
+	if ok {
+		serveRange(w, r, from, to, opts, dedup, req)
+		return
+	}
+	res, err := fnQuery(r.Context(), opts, req...)
+	setLabelStatus(w, res.Labels)
+	if errors.Is(err, window.ErrNoWindows) {
+		sendHTTPResponse(w, http.StatusNotFound, []byte(err.Error()))
+		return
+	}
+	if errors.Is(err, window.ErrNotLoaded) {
+		sendUnavailable(w, loadRetryAfter, err.Error())
+		return
+	}
 	if err != nil {
 		sendHTTPResponse(w, http.StatusInternalServerError, []byte(err.Error()))
+		return
+	}
+	if dedup {
+		shared := schedule.Share(res.Schedules)
+		sendJSONResponse(w, &shared)
+		return
 	}
-	b, err := json.Marshal(&s)
+	sendJSONResponse(w, &res.Schedules)
+}
+
+// dedupOption reports whether the dedup request parameter asks for labels
+// whose windows open over the same span to share one entry, as
+// schedule.Shared, rather than each reporting it independently.
+func dedupOption(r *http.Request) (bool, error) {
+	d := r.URL.Query().Get("dedup")
+	if d == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(d)
 	if err != nil {
+		return false, fmt.Errorf("invalid dedup %q: must be a boolean", d)
+	}
+	return b, nil
+}
+
+// rangeOption parses the from and to request parameters, which ask for every
+// span open within [from, to) rather than a single schedule per label.
+func rangeOption(r *http.Request) (from, to time.Time, ok bool, err error) {
+	q := r.URL.Query()
+	f, t := q.Get("from"), q.Get("to")
+	if f == "" && t == "" {
+		return from, to, false, nil
+	}
+	if f == "" || t == "" {
+		return from, to, false, errors.New("from and to must be given together")
+	}
+	if q.Get("at") != "" {
+		return from, to, false, errors.New("at cannot be combined with from and to")
+	}
+	if from, err = time.Parse(time.RFC3339, f); err != nil {
+		return from, to, false, fmt.Errorf("invalid from %q: must be RFC 3339: %w", f, err)
+	}
+	if to, err = time.Parse(time.RFC3339, t); err != nil {
+		return from, to, false, fmt.Errorf("invalid to %q: must be RFC 3339: %w", t, err)
+	}
+	return from, to, true, nil
+}
+
+var fnRange = schedule.Range
+
+// serveRange responds with every span of the requested labels open within
+// [from, to).
+func serveRange(w http.ResponseWriter, r *http.Request, from, to time.Time, opts schedule.Options, dedup bool, req []string) {
+	s, err := fnRange(r.Context(), from, to, opts, req...)
+	switch {
+	case errors.Is(err, schedule.ErrInvalidRange):
+		sendHTTPResponse(w, http.StatusBadRequest, []byte(err.Error()))
+		return
+	case errors.Is(err, window.ErrNoWindows):
+		sendHTTPResponse(w, http.StatusNotFound, []byte(err.Error()))
+		return
+	case errors.Is(err, window.ErrNotLoaded):
+		sendUnavailable(w, loadRetryAfter, err.Error())
+		return
+	case err != nil:
 		sendHTTPResponse(w, http.StatusInternalServerError, []byte(err.Error()))
+		return
 	}
-	sendHTTPResponse(w, http.StatusOK, b)
+	if dedup {
+		shared := schedule.Share(s)
+		sendJSONResponse(w, &shared)
+		return
+	}
+	if s == nil {
+		s = []window.Schedule{}
+	}
+	sendJSONResponse(w, &s)
+}
+
+var fnExplain = schedule.Explain
+
+// explain reports how the schedule of a label was chosen from its candidate
+// schedules, accepting the options of schedule requests.
+func explain(w http.ResponseWriter, r *http.Request) {
+	opts, err := queryOptions(r)
+	if err != nil {
+		sendHTTPResponse(w, http.StatusBadRequest, []byte(err.Error()))
+		return
+	}
+	e, err := fnExplain(r.Context(), opts, chi.URLParam(r, "label"))
+	if errors.Is(err, window.ErrNoWindows) {
+		sendHTTPResponse(w, http.StatusNotFound, []byte(err.Error()))
+		return
+	}
+	if err != nil {
+		sendHTTPResponse(w, http.StatusInternalServerError, []byte(err.Error()))
+		return
+	}
+	sendJSONResponse(w, &e)
+}
+
+// maxCalendarDays bounds the period a single calendar request may cover.
+const maxCalendarDays = 366
+
+var fnCalendar = schedule.Calendar
+
+func serveCalendar(w http.ResponseWriter, r *http.Request) {
+	days := 7
+	if d := r.URL.Query().Get("days"); d != "" {
+		n, err := strconv.Atoi(d)
+		if err != nil || n <= 0 || n > maxCalendarDays {
+			sendHTTPResponse(w, http.StatusBadRequest, []byte(fmt.Sprintf("days must be between 1 and %d", maxCalendarDays)))
+			return
+		}
+		days = n
+	}
+	opts, err := queryOptions(r)
+	if err != nil {
+		sendHTTPResponse(w, http.StatusBadRequest, []byte(err.Error()))
+		return
+	}
+	now := time.Now()
+	c, err := fnCalendar(r.Context(), now, now.AddDate(0, 0, days), opts)
+	if err != nil {
+		sendHTTPResponse(w, http.StatusInternalServerError, []byte(err.Error()))
+		return
+	}
+	sendJSONResponse(w, &c)
+}
+
+var fnSelfTest = schedule.SelfTest
+
+// selfTest reports the result of the schedule self-test, failing with 503 so
+// that monitoring treats a failed self-test as unhealthy.
+func selfTest(w http.ResponseWriter, r *http.Request) {
+	res := fnSelfTest()
+	b, err := json.Marshal(&res)
+	if err != nil {
+		sendHTTPResponse(w, http.StatusInternalServerError, []byte(err.Error()))
+		return
+	}
+	code := http.StatusOK
+	if !res.Passed {
+		code = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	sendHTTPResponse(w, code, b)
+}
+
+var fnConfigCheck = func() ([]window.FileCheck, error) {
+	return window.CheckQueried(auklib.ConfDir, window.Reader{}, schedule.QueryHistory())
+}
+
+func configCheck(w http.ResponseWriter, r *http.Request) {
+	c, err := fnConfigCheck()
+	if err != nil {
+		sendHTTPResponse(w, http.StatusInternalServerError, []byte(err.Error()))
+		return
+	}
+	sendJSONResponse(w, &c)
+}
+
+// serveSchema serves the JSON Schema of configuration files.
+func serveSchema(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/schema+json")
+	sendHTTPResponse(w, http.StatusOK, window.Schema)
+}
+
+// maxValidateBytes bounds the size of configuration files accepted by
+// validateConfig.
+const maxValidateBytes = 1 << 20
+
+// validateConfig checks a configuration file sent as the request body
+// without installing it, reporting the results as a window.FileCheck.
+func validateConfig(w http.ResponseWriter, r *http.Request) {
+	b, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxValidateBytes))
+	if err != nil {
+		sendHTTPResponse(w, http.StatusRequestEntityTooLarge, []byte(err.Error()))
+		return
+	}
+	c := window.CheckContent(r.URL.Query().Get("name"), b)
+	out, err := json.Marshal(&c)
+	if err != nil {
+		sendHTTPResponse(w, http.StatusInternalServerError, []byte(err.Error()))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	sendHTTPResponse(w, http.StatusOK, out)
+}
+
+var fnLabels = schedule.Labels
+
+func serveLabels(w http.ResponseWriter, r *http.Request) {
+	l, err := fnLabels()
+	if err != nil {
+		sendHTTPResponse(w, http.StatusInternalServerError, []byte(err.Error()))
+		return
+	}
+	// Labels also report query history, so the tag changes with it.
+	var queried time.Time
+	for _, label := range l {
+		if label.LastQueried.After(queried) {
+			queried = label.LastQueried
+		}
+	}
+	if notModified(w, r, etag(fnConfigHash(auklib.ConfDir), queried.UTC().Format(time.RFC3339Nano))) {
+		return
+	}
+	sendJSONResponse(w, &l)
+}
+
+var fnWindows = schedule.Windows
+
+// serveWindows lists window definitions, optionally restricted to those
+// carrying every tag given as tag=name=value (or tag=name for any value).
+// Windows with Expires report their remaining occurrences.
+func serveWindows(w http.ResponseWriter, r *http.Request) {
+	filter, err := window.ParseTagFilter(r.URL.Query()["tag"])
+	if err != nil {
+		sendHTTPResponse(w, http.StatusBadRequest, []byte(err.Error()))
+		return
+	}
+	all, err := fnWindows()
+	if err != nil {
+		sendHTTPResponse(w, http.StatusInternalServerError, []byte(err.Error()))
+		return
+	}
+	now := time.Now()
+	l := []window.Listing{}
+	// Remaining occurrences change over time, so the tag changes with them.
+	var remaining []string
+	for i := range all {
+		if all[i].MatchTags(filter) {
+			li := window.NewListing(all[i], now)
+			if li.RemainingOccurrences != nil {
+				remaining = append(remaining, fmt.Sprintf("%s=%d", li.Name, *li.RemainingOccurrences))
+			}
+			l = append(l, li)
+		}
+	}
+	if notModified(w, r, etag(fnConfigHash(auklib.ConfDir), r.URL.Query().Encode(), strings.Join(remaining, ","))) {
+		return
+	}
+	sendJSONResponse(w, &l)
+}
+
+var fnApprove = func(name string) error {
+	return window.Approve(auklib.ConfDir, window.Reader{}, name)
+}
+
+func approve(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "window")
+	err := fnApprove(name)
+	if errors.Is(err, window.ErrNoWindows) {
+		sendHTTPResponse(w, http.StatusNotFound, []byte(err.Error()))
+		return
+	}
+	if err != nil {
+		sendHTTPResponse(w, http.StatusInternalServerError, []byte(err.Error()))
+		return
+	}
+	sendHTTPResponse(w, http.StatusOK, []byte("OK"))
 }
 
 func respondOk(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodHead {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
 	sendHTTPResponse(w, http.StatusOK, []byte("OK"))
 }
 
+// healthz reports liveness without a body for probes that reject one.
+func healthz(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// unmatchedRoute is the route reported for requests matching no route, so
+// that probes of arbitrary paths do not each create a metric series.
+const unmatchedRoute = "unmatched"
+
+// reportLatency records end-to-end handler latency per route pattern. Event
+// streams are not recorded, since they last as long as their subscribers.
+func reportLatency(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+		route := unmatchedRoute
+		if rc := chi.RouteContext(r.Context()); rc != nil && rc.RoutePattern() != "" {
+			route = rc.RoutePattern()
+		}
+		if route == "/events" {
+			return
+		}
+		auklib.ReportDuration("handler_latency", time.Since(start), map[string]string{
+			"route":  route,
+			"method": r.Method,
+			"status": strconv.Itoa(ww.Status()),
+		})
+	})
+}
+
+// validLabel rejects requests whose label, as a {label} path variable or
+// label query parameter, could never match a configured label, before it
+// reaches logs or lookups. Valid labels are lowercased in place.
+func validLabel(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			for i, k := range rctx.URLParams.Keys {
+				if k != "label" {
+					continue
+				}
+				v, err := url.PathUnescape(rctx.URLParams.Values[i])
+				if err == nil {
+					v, err = window.NormalizeLabel(v)
+				}
+				if err != nil {
+					sendHTTPResponse(w, http.StatusBadRequest, []byte(err.Error()))
+					return
+				}
+				rctx.URLParams.Values[i] = v
+			}
+		}
+		if q := r.URL.Query(); q.Has("label") {
+			l, err := window.NormalizeLabel(q.Get("label"))
+			if err != nil {
+				sendHTTPResponse(w, http.StatusBadRequest, []byte(err.Error()))
+				return
+			}
+			q.Set("label", l)
+			r.URL.RawQuery = q.Encode()
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 func muxRouter() http.Handler {
 	rtr := chi.NewRouter()
-	rtr.HandleFunc("/status", respondOk)
-	rtr.HandleFunc("/schedule", serve)
-	rtr.HandleFunc("/schedule/{label}", serve)
+	rtr.Use(reportLatency)
+	rtr.Use(refuseDraining)
+	// Responses are compressed when the client sends a matching Accept-Encoding.
+	rtr.Use(middleware.Compress(5))
+	// Events are streamed for as long as the client listens.
+	rtr.With(validLabel).Get("/events", serveEvents)
+	rtr.Group(func(rtr chi.Router) {
+		rtr.Use(limitInFlight(maxInFlight))
+		rtr.Use(withTimeout(handlerTimeout))
+		rtr.Use(warnDeprecated)
+		rtr.HandleFunc("/", statusPage)
+		rtr.Get("/api", serveAPI)
+		rtr.HandleFunc("/status", respondOk)
+		rtr.HandleFunc("/healthz", healthz)
+		rtr.HandleFunc("/selftest", selfTest)
+		rtr.HandleFunc("/configcheck", configCheck)
+		rtr.Get("/schema", serveSchema)
+		rtr.Post("/validate", validateConfig)
+		rtr.HandleFunc("/labels", serveLabels)
+		rtr.HandleFunc("/windows", serveWindows)
+		rtr.With(requireAdmin).Post("/windows", registerWindow)
+		rtr.With(requireAdmin).Delete("/windows/{name}", deleteWindow)
+		rtr.HandleFunc("/calendar", serveCalendar)
+		rtr.With(validLabel).Get("/explain/{label}", explain)
+		rtr.Post("/evaluate", evaluateBatch)
+		rtr.With(validLabel).Get("/intent", serveIntents)
+		rtr.With(validLabel).Post("/intent/{label}", declareIntent)
+		rtr.With(requireAdmin).Post("/approve/{window}", approve)
+		rtr.With(signResponses).HandleFunc("/schedule", serve)
+		rtr.With(validLabel, signResponses).HandleFunc("/schedule/{label}", serve)
+	})
 	return rtr
 }
 
-// Run runs the internal schedule server on port.
+// Config holds HTTP server settings.
+type Config struct {
+	ReadTimeout, WriteTimeout, IdleTimeout time.Duration
+	MaxHeaderBytes                         int
+	// HandlerTimeout bounds the time taken to handle each request other
+	// than event streams; see withTimeout. It should be shorter than
+	// WriteTimeout. Zero disables it.
+	HandlerTimeout time.Duration
+	// SigningKey, if set, signs schedule responses.
+	SigningKey ed25519.PrivateKey
+	// MaxInFlight bounds the number of requests handled at once; see
+	// limitInFlight. Event streams are not counted. Zero disables it.
+	MaxInFlight int
+}
+
+// DefaultConfig is the Config used by Run.
+var DefaultConfig = Config{
+	ReadTimeout:    time.Second * 15,
+	WriteTimeout:   time.Second * 15,
+	IdleTimeout:    time.Second * 60,
+	MaxHeaderBytes: http.DefaultMaxHeaderBytes,
+	HandlerTimeout: time.Second * 10,
+	MaxInFlight:    64,
+}
+
+// Run runs the internal schedule server on port using DefaultConfig.
 func Run(port int) error {
+	return RunWithConfig(port, DefaultConfig)
+}
+
+// loopbackHosts are the addresses the servers listen on. Both are bound
+// because "localhost" resolves to ::1 on some hosts and to 127.0.0.1 on
+// others, and a dual-stack wildcard bind is not available everywhere.
+var loopbackHosts = []string{"127.0.0.1", "::1"}
+
+// listenLoopback listens on port on each of loopbackHosts. When port is 0,
+// the port bound by the first listener is reused for the others so that
+// clients find the service at the same port on either address. Addresses
+// that cannot be bound, such as ::1 on hosts without IPv6, are skipped; an
+// error is returned only if none could be.
+func listenLoopback(port int) ([]net.Listener, error) {
+	var lns []net.Listener
+	var errs []string
+	for _, host := range loopbackHosts {
+		ln, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		lns = append(lns, ln)
+		port = ln.Addr().(*net.TCPAddr).Port
+	}
+	if len(lns) == 0 {
+		return nil, fmt.Errorf("listenLoopback: %s", strings.Join(errs, "; "))
+	}
+	for _, e := range errs {
+		deck.Warningf("listenLoopback: %s", e)
+	}
+	return lns, nil
+}
+
+// serveAll serves srv on each of lns, returning the first error. The
+// remaining listeners are closed when any of them fails.
+func serveAll(srv *http.Server, lns []net.Listener) error {
+	errch := make(chan error, len(lns))
+	for _, ln := range lns {
+		go func(ln net.Listener) { errch <- srv.Serve(ln) }(ln)
+	}
+	err := <-errch
+	for _, ln := range lns {
+		ln.Close()
+	}
+	return err
+}
+
+// RunWithConfig runs the internal schedule server on port using cfg. The
+// server listens on the IPv4 and IPv6 loopback addresses. The bound port is
+// recorded with auklib.WritePort for client discovery; passing 0 selects a
+// free port.
+func RunWithConfig(port int, cfg Config) error {
+	signingKey = cfg.SigningKey
+	handlerTimeout = cfg.HandlerTimeout
+	writeTimeout = cfg.WriteTimeout
+	maxInFlight = cfg.MaxInFlight
 	srv := &http.Server{
-		Addr:         fmt.Sprintf(":%d", port),
-		WriteTimeout: time.Second * 15,
-		ReadTimeout:  time.Second * 15,
-		IdleTimeout:  time.Second * 60,
-		Handler:      muxRouter(),
+		WriteTimeout:   cfg.WriteTimeout,
+		ReadTimeout:    cfg.ReadTimeout,
+		IdleTimeout:    cfg.IdleTimeout,
+		MaxHeaderBytes: cfg.MaxHeaderBytes,
+		Handler:        muxRouter(),
+		ConnContext:    connContext,
+	}
+	lns, err := listenLoopback(port)
+	if err != nil {
+		return err
+	}
+	bound := lns[0].Addr().(*net.TCPAddr).Port
+	if err := auklib.WritePort(bound); err != nil {
+		deck.Warningf("unable to record service port %d: %v", bound, err)
+	}
+	for _, ln := range lns {
+		deck.Infof("Schedule server listening on %s.", ln.Addr())
 	}
-	return srv.ListenAndServe()
+	return serveAll(srv, lns)
 }
@@ -15,18 +15,46 @@
 package server
 
 import (
+	"bufio"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/google/aukera/auklib"
+	"github.com/google/aukera/schedule"
+	"github.com/google/aukera/signing"
 	"github.com/google/aukera/window"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/go-cmp/cmp"
 )
 
+// queryFrom adapts a schedule.Query stub for use as fnQuery.
+func queryFrom(fn func(schedule.Options, ...string) ([]window.Schedule, error)) func(context.Context, schedule.Options, ...string) (schedule.Result, error) {
+	return func(ctx context.Context, opts schedule.Options, names ...string) (schedule.Result, error) {
+		s, err := fn(opts, names...)
+		return schedule.Result{Schedules: s}, err
+	}
+}
+
 func TestHandler(t *testing.T) {
 	tests := []struct {
 		desc     string
 		inURL    string
-		fn       func(...string) ([]window.Schedule, error)
+		fn       func(schedule.Options, ...string) ([]window.Schedule, error)
 		wantCode int
 		wantErr  error
 	}{
@@ -39,7 +67,7 @@ func TestHandler(t *testing.T) {
 			desc:     "base schedule with error",
 			wantCode: 500,
 			inURL:    "/schedule",
-			fn: func(names ...string) ([]window.Schedule, error) {
+			fn: func(opts schedule.Options, names ...string) ([]window.Schedule, error) {
 				return nil, errors.New("schedule error")
 			},
 		},
@@ -47,7 +75,7 @@ func TestHandler(t *testing.T) {
 			desc:     "schedule label with success",
 			wantCode: 200,
 			inURL:    "/schedule/specific",
-			fn: func(names ...string) ([]window.Schedule, error) {
+			fn: func(opts schedule.Options, names ...string) ([]window.Schedule, error) {
 				if len(names) != 1 {
 					t.Errorf("expected 1 argment, got: %d", len(names))
 				}
@@ -61,21 +89,86 @@ func TestHandler(t *testing.T) {
 			desc:     "schedule label with error",
 			wantCode: 500,
 			inURL:    "/schedule/specific",
-			fn: func(names ...string) ([]window.Schedule, error) {
+			fn: func(opts schedule.Options, names ...string) ([]window.Schedule, error) {
 				return nil, errors.New("schedule error")
 			},
 		},
+		{
+			desc:     "conservative mode",
+			wantCode: 200,
+			inURL:    "/schedule/specific?mode=conservative",
+			fn: func(opts schedule.Options, names ...string) ([]window.Schedule, error) {
+				if opts.Aggregation != window.AggregateConservative {
+					t.Errorf("schedule called with unexpected aggregation: %v", opts.Aggregation)
+				}
+				return nil, nil
+			},
+		},
+		{
+			desc:     "time zone",
+			wantCode: 200,
+			inURL:    "/schedule/specific?tz=America/New_York",
+			fn: func(opts schedule.Options, names ...string) ([]window.Schedule, error) {
+				if opts.Location == nil || opts.Location.String() != "America/New_York" {
+					t.Errorf("schedule called with unexpected location: %v", opts.Location)
+				}
+				return nil, nil
+			},
+		},
+		{
+			desc:     "invalid time zone",
+			wantCode: 400,
+			inURL:    "/schedule/specific?tz=Mars/Olympus_Mons",
+		},
+		{
+			desc:     "invalid mode",
+			wantCode: 400,
+			inURL:    "/schedule/specific?mode=bogus",
+		},
+		{
+			desc:     "at",
+			wantCode: 200,
+			inURL:    "/schedule/specific?at=2030-01-02T03:04:05Z",
+			fn: func(opts schedule.Options, names ...string) ([]window.Schedule, error) {
+				if want := time.Date(2030, time.January, 2, 3, 4, 5, 0, time.UTC); !opts.At.Equal(want) {
+					t.Errorf("schedule called with At %v, want %v", opts.At, want)
+				}
+				return nil, nil
+			},
+		},
+		{
+			desc:     "invalid at",
+			wantCode: 400,
+			inURL:    "/schedule/specific?at=tomorrow",
+		},
+		{
+			desc:     "/labels success",
+			wantCode: 200,
+			inURL:    "/labels",
+		},
+		{
+			desc:     "schedule label not found",
+			wantCode: 404,
+			inURL:    "/schedule/unknown",
+			fn: func(opts schedule.Options, names ...string) ([]window.Schedule, error) {
+				return nil, fmt.Errorf("label(s) unknown: %w", window.ErrNoWindows)
+			},
+		},
 		{
 			desc:     "invalid path",
 			wantCode: 404,
 			inURL:    "/missing",
-			fn: func(names ...string) ([]window.Schedule, error) {
+			fn: func(opts schedule.Options, names ...string) ([]window.Schedule, error) {
 				return nil, nil
 			},
 		},
 	}
+	fnLabels = func() ([]schedule.Label, error) { return nil, nil }
 	for _, tt := range tests {
 		fnSchedule = tt.fn
+		if tt.fn != nil {
+			fnQuery = queryFrom(tt.fn)
+		}
 		srv := httptest.NewServer(muxRouter())
 		defer srv.Close()
 
@@ -93,3 +186,1764 @@ func TestHandler(t *testing.T) {
 		}
 	}
 }
+
+func TestConfigCheck(t *testing.T) {
+	tests := []struct {
+		desc     string
+		fn       func() ([]window.FileCheck, error)
+		wantCode int
+		want     []window.FileCheck
+	}{
+		{
+			desc:     "check with error",
+			wantCode: 500,
+			fn: func() ([]window.FileCheck, error) {
+				return nil, errors.New("check error")
+			},
+		},
+		{
+			desc:     "check with results",
+			wantCode: 200,
+			fn: func() ([]window.FileCheck, error) {
+				return []window.FileCheck{{Path: "a.json", Status: window.CheckOK, Windows: 1}}, nil
+			},
+			want: []window.FileCheck{{Path: "a.json", Status: window.CheckOK, Windows: 1}},
+		},
+	}
+	for _, tt := range tests {
+		fnConfigCheck = tt.fn
+		srv := httptest.NewServer(muxRouter())
+		defer srv.Close()
+
+		res, err := srv.Client().Get(srv.URL + "/configcheck")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		if res.StatusCode != tt.wantCode {
+			t.Errorf("%s: produced unexpected status code: got %d, want %d", tt.desc, res.StatusCode, tt.wantCode)
+		}
+		if tt.wantCode != 200 {
+			continue
+		}
+		var got []window.FileCheck
+		if err := json.NewDecoder(res.Body).Decode(&got); err != nil {
+			t.Fatalf("%s: error decoding response: %v", tt.desc, err)
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("%s: produced unexpected diff (-want +got): %s", tt.desc, diff)
+		}
+	}
+}
+
+func TestStatusPage(t *testing.T) {
+	at := time.Date(2020, time.January, 1, 3, 0, 0, 0, time.UTC)
+	fnQuery = func(ctx context.Context, opts schedule.Options, names ...string) (schedule.Result, error) {
+		if !opts.At.Equal(at) {
+			t.Errorf("status page queried at %v, want %v", opts.At, at)
+		}
+		return schedule.Result{EvaluatedAt: opts.At, Schedules: []window.Schedule{
+			{Name: "zeta", State: "closed"}, {Name: "alpha", State: "open"},
+		}}, nil
+	}
+	srv := httptest.NewServer(muxRouter())
+	defer srv.Close()
+
+	tests := []struct {
+		desc, accept, wantType string
+	}{
+		{"plaintext", "", "text/plain; charset=utf-8"},
+		{"html", "text/html,application/xhtml+xml", "text/html; charset=utf-8"},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+"/?at="+at.Format(time.RFC3339), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Accept", tt.accept)
+		res, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := res.Header.Get("Content-Type"); got != tt.wantType {
+			t.Errorf("%s: unexpected content type: got %q, want %q", tt.desc, got, tt.wantType)
+		}
+		body := string(b)
+		if a, z := strings.Index(body, "alpha"), strings.Index(body, "zeta"); a < 0 || z < 0 || a > z {
+			t.Errorf("%s: labels missing or unsorted in body: %s", tt.desc, body)
+		}
+		if !strings.Contains(body, at.Format(time.RFC3339)) || !strings.Contains(body, "open") {
+			t.Errorf("%s: body not evaluated at %v: %s", tt.desc, at, body)
+		}
+	}
+}
+
+func TestLabelStatusHeader(t *testing.T) {
+	fnQuery = func(ctx context.Context, opts schedule.Options, names ...string) (schedule.Result, error) {
+		res := schedule.Result{Labels: []schedule.LabelResult{{Label: "patch", Status: schedule.StatusMissing}}}
+		if names[0] == "patch" {
+			return res, &schedule.LabelsError{Labels: res.Labels}
+		}
+		res.Schedules = []window.Schedule{{Name: "reboot"}}
+		res.Labels = append(res.Labels, schedule.LabelResult{Label: "reboot", Status: schedule.StatusFound})
+		return res, nil
+	}
+	srv := httptest.NewServer(muxRouter())
+	defer srv.Close()
+	tests := []struct {
+		path   string
+		status int
+		want   string
+	}{
+		{"/schedule/patch", http.StatusNotFound, "patch=missing"},
+		{"/schedule/reboot", http.StatusOK, "patch=missing, reboot=found"},
+	}
+	for _, tt := range tests {
+		res, err := http.Get(srv.URL + tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != tt.status {
+			t.Errorf("%s returned status %d, want %d", tt.path, res.StatusCode, tt.status)
+		}
+		if got := res.Header.Get(LabelStatusHeader); got != tt.want {
+			t.Errorf("%s returned %s %q, want %q", tt.path, LabelStatusHeader, got, tt.want)
+		}
+	}
+}
+
+func TestCompression(t *testing.T) {
+	fnSchedule = func(opts schedule.Options, names ...string) ([]window.Schedule, error) {
+		return []window.Schedule{{Name: "compressed"}}, nil
+	}
+	fnQuery = queryFrom(fnSchedule)
+	srv := httptest.NewServer(muxRouter())
+	defer srv.Close()
+
+	for _, enc := range []string{"", "gzip"} {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+"/schedule", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Setting Accept-Encoding disables the transport's transparent decompression.
+		req.Header.Set("Accept-Encoding", enc)
+		res, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if got := res.Header.Get("Content-Encoding"); got != enc {
+			t.Errorf("GET /schedule with Accept-Encoding %q: Content-Encoding = %q, want %q", enc, got, enc)
+		}
+	}
+}
+
+func TestSignedResponses(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signingKey = priv
+	defer func() { signingKey = nil }()
+	fnSchedule = func(opts schedule.Options, names ...string) ([]window.Schedule, error) {
+		return []window.Schedule{{Name: "signed"}}, nil
+	}
+	fnQuery = queryFrom(fnSchedule)
+	srv := httptest.NewServer(muxRouter())
+	defer srv.Close()
+
+	res, err := srv.Client().Get(srv.URL + "/schedule/signed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := signing.Verify(pub, b, res.Header.Get(signing.Header)); err != nil {
+		t.Errorf("signature of /schedule/signed did not verify: %v", err)
+	}
+}
+
+func TestApprove(t *testing.T) {
+	origPath, origToken := TokenPath, token
+	defer func() { TokenPath, token = origPath, origToken }()
+	TokenPath, token = filepath.Join(t.TempDir(), "admin.token"), ""
+	admin, err := adminToken()
+	if err != nil {
+		t.Fatalf("adminToken() returned error: %v", err)
+	}
+
+	var approved string
+	fnApprove = func(name string) error {
+		if name == "missing" {
+			return fmt.Errorf("window(missing): %w", window.ErrNoWindows)
+		}
+		approved = name
+		return nil
+	}
+	srv := httptest.NewServer(muxRouter())
+	defer srv.Close()
+
+	tests := []struct {
+		desc, method, path, token string
+		wantCode                  int
+	}{
+		{"approved", http.MethodPost, "/approve/emergency", admin, 200},
+		{"no token", http.MethodPost, "/approve/emergency", "", 401},
+		{"wrong token", http.MethodPost, "/approve/emergency", "guess", 401},
+		{"unknown window", http.MethodPost, "/approve/missing", admin, 404},
+		{"wrong method", http.MethodGet, "/approve/emergency", admin, 405},
+	}
+	for _, tt := range tests {
+		approved = ""
+		req, err := http.NewRequest(tt.method, srv.URL+tt.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tt.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+		res, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != tt.wantCode {
+			t.Errorf("%s: produced unexpected status code: got %d, want %d", tt.desc, res.StatusCode, tt.wantCode)
+		}
+		if (tt.wantCode == 200) != (approved == "emergency") {
+			t.Errorf("%s: approved %q", tt.desc, approved)
+		}
+	}
+}
+
+func TestRequireAdminEmptyToken(t *testing.T) {
+	origPath, origToken := TokenPath, token
+	defer func() { TokenPath, token = origPath, origToken }()
+	TokenPath, token = filepath.Join(t.TempDir(), "admin.token"), ""
+	if err := os.WriteFile(TokenPath, []byte(" \n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	admin, err := adminToken()
+	if err != nil {
+		t.Fatalf("adminToken() returned error: %v", err)
+	}
+	if admin == "" {
+		t.Fatal("adminToken() returned an empty token for an empty token file")
+	}
+
+	h := requireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	tests := []struct {
+		desc, auth string
+		wantCode   int
+	}{
+		{"no header", "", 401},
+		{"empty bearer", "Bearer ", 401},
+		{"missing prefix", admin, 401},
+		{"bearer", "Bearer " + admin, 200},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/approve/x", nil)
+		if tt.auth != "" {
+			req.Header.Set("Authorization", tt.auth)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tt.wantCode {
+			t.Errorf("%s: produced unexpected status code: got %d, want %d", tt.desc, rec.Code, tt.wantCode)
+		}
+	}
+}
+
+func TestRegisterWindow(t *testing.T) {
+	origPath, origToken := TokenPath, token
+	defer func() { TokenPath, token = origPath, origToken }()
+	TokenPath, token = filepath.Join(t.TempDir(), "admin.token"), ""
+	admin, err := adminToken()
+	if err != nil {
+		t.Fatalf("adminToken() returned error: %v", err)
+	}
+
+	var registered window.Ephemeral
+	fnRegisterEphemeral = func(e window.Ephemeral) (window.Ephemeral, error) {
+		switch {
+		case e.Window.Name == "regular":
+			return window.Ephemeral{}, fmt.Errorf("window(regular): %w", window.ErrWindowExists)
+		case e.TTL > time.Hour:
+			return window.Ephemeral{}, fmt.Errorf("%w: TTL too long", window.ErrInvalidEphemeral)
+		}
+		registered = e
+		return e, nil
+	}
+	srv := httptest.NewServer(muxRouter())
+	defer srv.Close()
+
+	body := func(name, ttl string) string {
+		return fmt.Sprintf(`{"Window": {"Name": %q, "Starts": "2026-01-01T00:00:00Z", "Expires": "2026-01-01T01:00:00Z", "Duration": "30m", "Labels": ["a"]}, "TTL": %q, "Reason": "hotfix"}`, name, ttl)
+	}
+	tests := []struct {
+		desc, body, token string
+		wantCode          int
+	}{
+		{"registered", body("hotfix", "1h"), admin, 200},
+		{"no token", body("hotfix", "1h"), "", 401},
+		{"configured name", body("regular", "1h"), admin, 409},
+		{"invalid", body("hotfix", "2h"), admin, 400},
+		{"malformed", `{"Window": {}}`, admin, 400},
+	}
+	for _, tt := range tests {
+		registered = window.Ephemeral{}
+		req, err := http.NewRequest(http.MethodPost, srv.URL+"/windows", strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tt.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+		res, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != tt.wantCode {
+			t.Errorf("%s: produced unexpected status code: got %d, want %d", tt.desc, res.StatusCode, tt.wantCode)
+		}
+		if (tt.wantCode == 200) != (registered.Window.Name == "hotfix") {
+			t.Errorf("%s: registered %q", tt.desc, registered.Window.Name)
+		}
+		if tt.wantCode == 200 && (registered.Source == "" || registered.Reason != "hotfix") {
+			t.Errorf("%s: registered without audit details: %+v", tt.desc, registered)
+		}
+	}
+
+	// Listing windows remains unauthenticated.
+	fnWindows = func() ([]window.Window, error) { return nil, nil }
+	res, err := srv.Client().Get(srv.URL + "/windows")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("GET /windows produced unexpected status code: got %d, want %d", res.StatusCode, http.StatusOK)
+	}
+}
+
+func TestDeleteWindow(t *testing.T) {
+	origPath, origToken := TokenPath, token
+	defer func() { TokenPath, token = origPath, origToken }()
+	TokenPath, token = filepath.Join(t.TempDir(), "admin.token"), ""
+	admin, err := adminToken()
+	if err != nil {
+		t.Fatalf("adminToken() returned error: %v", err)
+	}
+
+	var deleted, source string
+	fnDeleteEphemeral = func(name, src string) (window.Ephemeral, error) {
+		switch name {
+		case "regular":
+			return window.Ephemeral{}, fmt.Errorf("window(regular): %w", window.ErrNotEphemeral)
+		case "missing":
+			return window.Ephemeral{}, fmt.Errorf("ephemeral window(missing): %w", window.ErrNoWindows)
+		}
+		deleted, source = name, src
+		return window.Ephemeral{Window: window.Window{Name: name}}, nil
+	}
+	srv := httptest.NewServer(muxRouter())
+	defer srv.Close()
+
+	tests := []struct {
+		desc, path, token string
+		wantCode          int
+	}{
+		{"deleted", "/windows/hotfix", admin, 200},
+		{"no token", "/windows/hotfix", "", 401},
+		{"configured", "/windows/regular", admin, 403},
+		{"unknown", "/windows/missing", admin, 404},
+	}
+	for _, tt := range tests {
+		deleted, source = "", ""
+		req, err := http.NewRequest(http.MethodDelete, srv.URL+tt.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tt.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+		res, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != tt.wantCode {
+			t.Errorf("%s: produced unexpected status code: got %d, want %d", tt.desc, res.StatusCode, tt.wantCode)
+		}
+		if (tt.wantCode == 200) != (deleted == "hotfix" && source != "") {
+			t.Errorf("%s: deleted %q from %q", tt.desc, deleted, source)
+		}
+	}
+}
+
+func TestServeWindows(t *testing.T) {
+	fnWindows = func() ([]window.Window, error) {
+		return []window.Window{{Name: "nightly", Format: 1, CronString: "0 0 2 * * *", Duration: time.Hour, Labels: []string{"patch"}}}, nil
+	}
+	srv := httptest.NewServer(muxRouter())
+	defer srv.Close()
+	res, err := http.Get(srv.URL + "/windows")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	var got []window.Window
+	if err := json.NewDecoder(res.Body).Decode(&got); err != nil {
+		t.Fatalf("decoding /windows response: %v", err)
+	}
+	if len(got) != 1 || got[0].Name != "nightly" || got[0].CronString != "0 0 2 * * *" {
+		t.Errorf("/windows returned %+v", got)
+	}
+}
+
+func TestServeWindowsRemaining(t *testing.T) {
+	var expiring window.Window
+	if err := json.Unmarshal([]byte(`{"Name": "temp", "Format": 1, "Schedule": "0 0 2 * * *", "Duration": "1h", "Labels": ["patch"], "Expires": "2099-01-01T00:00:00Z"}`), &expiring); err != nil {
+		t.Fatal(err)
+	}
+	fnWindows = func() ([]window.Window, error) {
+		return []window.Window{expiring, {Name: "nightly", Format: 1, CronString: "0 0 2 * * *", Duration: time.Hour, Labels: []string{"patch"}}}, nil
+	}
+	srv := httptest.NewServer(muxRouter())
+	defer srv.Close()
+	res, err := http.Get(srv.URL + "/windows")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	var got []window.Listing
+	if err := json.NewDecoder(res.Body).Decode(&got); err != nil {
+		t.Fatalf("decoding /windows response: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("/windows returned %+v", got)
+	}
+	if r := got[0].RemainingOccurrences; r == nil || *r != window.MaxRemainingOccurrences {
+		t.Errorf("/windows RemainingOccurrences of temp = %v, want %d", r, window.MaxRemainingOccurrences)
+	}
+	if r := got[1].RemainingOccurrences; r != nil {
+		t.Errorf("/windows RemainingOccurrences of nightly = %d, want none", *r)
+	}
+}
+
+func TestServeWindowsByTag(t *testing.T) {
+	fnWindows = func() ([]window.Window, error) {
+		return []window.Window{
+			{Name: "canary", Format: 1, CronString: "0 0 2 * * *", Duration: time.Hour, Labels: []string{"patch"}, Tags: map[string]string{"ring": "canary", "owner": "netops"}},
+			{Name: "stable", Format: 1, CronString: "0 0 2 * * *", Duration: time.Hour, Labels: []string{"patch"}, Tags: map[string]string{"ring": "stable"}},
+			{Name: "untagged", Format: 1, CronString: "0 0 2 * * *", Duration: time.Hour, Labels: []string{"patch"}},
+		}, nil
+	}
+	srv := httptest.NewServer(muxRouter())
+	defer srv.Close()
+	tests := []struct {
+		query  string
+		status int
+		want   []string
+	}{
+		{"", http.StatusOK, []string{"canary", "stable", "untagged"}},
+		{"?tag=ring", http.StatusOK, []string{"canary", "stable"}},
+		{"?tag=ring=stable", http.StatusOK, []string{"stable"}},
+		{"?tag=ring=canary&tag=owner=netops", http.StatusOK, []string{"canary"}},
+		{"?tag=team", http.StatusOK, []string{}},
+		{"?tag==x", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		res, err := http.Get(srv.URL + "/windows" + tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != tt.status {
+			t.Errorf("/windows%s returned status %d, want %d", tt.query, res.StatusCode, tt.status)
+		}
+		if tt.status == http.StatusOK {
+			var got []window.Window
+			if err := json.NewDecoder(res.Body).Decode(&got); err != nil {
+				t.Fatalf("decoding /windows%s response: %v", tt.query, err)
+			}
+			names := []string{}
+			for _, w := range got {
+				names = append(names, w.Name)
+			}
+			if diff := cmp.Diff(tt.want, names); diff != "" {
+				t.Errorf("/windows%s returned diff (-want +got):\n%s", tt.query, diff)
+			}
+		}
+		res.Body.Close()
+	}
+}
+
+func TestStaleHeader(t *testing.T) {
+	defer func() { fnStaleSince = window.StaleSince }()
+	fnWindows = func() ([]window.Window, error) { return nil, nil }
+	srv := httptest.NewServer(muxRouter())
+	defer srv.Close()
+
+	fnStaleSince = func(string) (time.Time, bool) { return time.Time{}, false }
+	res, err := http.Get(srv.URL + "/windows")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if got := res.Header.Get(StaleHeader); got != "" {
+		t.Errorf("fresh response has %s header %q", StaleHeader, got)
+	}
+
+	loaded := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+	fnStaleSince = func(string) (time.Time, bool) { return loaded, true }
+	res, err = http.Get(srv.URL + "/windows")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if got, want := res.Header.Get(StaleHeader), "2026-03-01T12:00:00Z"; got != want {
+		t.Errorf("stale response has %s header %q, want %q", StaleHeader, got, want)
+	}
+}
+
+func TestConditionalGet(t *testing.T) {
+	defer func() { fnConfigHash, fnApprovalGeneration = window.ConfigHash, window.ApprovalGeneration }()
+	fnWindows = func() ([]window.Window, error) { return nil, nil }
+	fnLabels = func() ([]schedule.Label, error) { return []schedule.Label{{Name: "patch"}}, nil }
+	hash := "abc"
+	fnConfigHash = func(string) string { return hash }
+	var gen uint64
+	fnApprovalGeneration = func() uint64 { return gen }
+	srv := httptest.NewServer(muxRouter())
+	defer srv.Close()
+
+	get := func(path, inm string) *http.Response {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if inm != "" {
+			req.Header.Set("If-None-Match", inm)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		return res
+	}
+	for _, path := range []string{"/windows", "/labels", "/windows?tag=ring"} {
+		hash, gen = "abc", 0
+		res := get(path, "")
+		tag := res.Header.Get("ETag")
+		if res.StatusCode != http.StatusOK || tag == "" {
+			t.Fatalf("%s returned status %d with ETag %q", path, res.StatusCode, tag)
+		}
+		if res := get(path, tag); res.StatusCode != http.StatusNotModified {
+			t.Errorf("%s with matching If-None-Match returned status %d, want %d", path, res.StatusCode, http.StatusNotModified)
+		}
+		if res := get(path, `"other", W/`+tag); res.StatusCode != http.StatusNotModified {
+			t.Errorf("%s with weak matching If-None-Match returned status %d, want %d", path, res.StatusCode, http.StatusNotModified)
+		}
+		gen++
+		if res := get(path, tag); res.StatusCode != http.StatusOK {
+			t.Errorf("%s after an approval returned status %d, want %d", path, res.StatusCode, http.StatusOK)
+		}
+		hash, gen = "def", 0
+		if res := get(path, tag); res.StatusCode != http.StatusOK {
+			t.Errorf("%s after a configuration change returned status %d, want %d", path, res.StatusCode, http.StatusOK)
+		}
+	}
+	if a, b := get("/windows", "").Header.Get("ETag"), get("/windows?tag=ring", "").Header.Get("ETag"); a == b {
+		t.Errorf("/windows with and without a tag filter share ETag %q", a)
+	}
+}
+
+func TestDebugRouter(t *testing.T) {
+	srv := httptest.NewServer(debugRouter())
+	defer srv.Close()
+	for _, path := range []string{"/debug/vars", "/debug/pprof/", "/debug/pprof/goroutine?debug=1", "/debug/pprof/cmdline"} {
+		res, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			t.Errorf("%s returned status %d, want %d", path, res.StatusCode, http.StatusOK)
+		}
+	}
+	// The schedule server does not expose debug endpoints.
+	sched := httptest.NewServer(muxRouter())
+	defer sched.Close()
+	res, err := http.Get(sched.URL + "/debug/vars")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("schedule server /debug/vars returned status %d, want %d", res.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestListenLoopback(t *testing.T) {
+	lns, err := listenLoopback(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := lns[0].Addr().(*net.TCPAddr).Port
+	for _, ln := range lns {
+		a := ln.Addr().(*net.TCPAddr)
+		if !a.IP.IsLoopback() {
+			t.Errorf("listenLoopback bound non-loopback address %s", a)
+		}
+		if a.Port != port {
+			t.Errorf("listenLoopback bound %s, want port %d", a, port)
+		}
+	}
+	srv := &http.Server{Handler: muxRouter()}
+	errch := make(chan error, 1)
+	go func() { errch <- serveAll(srv, lns) }()
+	for _, ln := range lns {
+		res, err := http.Get(fmt.Sprintf("http://%s/status", ln.Addr()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			t.Errorf("%s/status returned status %d, want %d", ln.Addr(), res.StatusCode, http.StatusOK)
+		}
+	}
+	srv.Close()
+	if err := <-errch; !errors.Is(err, http.ErrServerClosed) {
+		t.Errorf("serveAll() returned %v, want %v", err, http.ErrServerClosed)
+	}
+}
+
+func TestHandlerTimeout(t *testing.T) {
+	defer func(d time.Duration) { handlerTimeout = d }(handlerTimeout)
+	handlerTimeout = 20 * time.Millisecond
+	var canceled int32
+	fnQuery = func(ctx context.Context, opts schedule.Options, names ...string) (schedule.Result, error) {
+		if names[0] == "fast" {
+			return schedule.Result{Schedules: []window.Schedule{{Name: "fast"}}}, nil
+		}
+		<-ctx.Done()
+		atomic.StoreInt32(&canceled, 1)
+		return schedule.Result{}, ctx.Err()
+	}
+	srv := httptest.NewServer(muxRouter())
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/schedule/fast")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK || !strings.Contains(string(b), "fast") || res.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("/schedule/fast returned status %d, Content-Type %q and body %s", res.StatusCode, res.Header.Get("Content-Type"), b)
+	}
+
+	res, err = http.Get(srv.URL + "/schedule/slow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("/schedule/slow returned status %d, want %d", res.StatusCode, http.StatusServiceUnavailable)
+	}
+	if res.Header.Get("Retry-After") == "" {
+		t.Errorf("/schedule/slow response lacks Retry-After")
+	}
+	if atomic.LoadInt32(&canceled) == 0 {
+		t.Errorf("/schedule/slow did not cancel the schedule query context")
+	}
+}
+
+func TestTransitionLimiter(t *testing.T) {
+	now := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+	l := newTransitionLimiter(EventLimits{PerLabel: time.Minute, PerLabelBurst: 2, Global: time.Second, GlobalBurst: 3})
+	for i, want := range []bool{true, true, false} {
+		if got := l.allow("flapping", now); got != want {
+			t.Errorf("allow(flapping) #%d = %t, want %t", i, got, want)
+		}
+	}
+	// A throttled label does not consume the global budget of others.
+	if !l.allow("steady", now) {
+		t.Errorf("allow(steady) = false, want true")
+	}
+	if l.allow("other", now) {
+		t.Errorf("allow(other) beyond the global burst = true, want false")
+	}
+	if !l.allow("other", now.Add(time.Second)) {
+		t.Errorf("allow(other) after the global refill = false, want true")
+	}
+	if l.allow("flapping", now.Add(30*time.Second)) {
+		t.Errorf("allow(flapping) before the per-label refill = true, want false")
+	}
+	if !l.allow("flapping", now.Add(time.Minute)) {
+		t.Errorf("allow(flapping) after the per-label refill = false, want true")
+	}
+}
+
+func TestTransitionLimiterClockStepBack(t *testing.T) {
+	now := time.Date(2026, time.March, 1, 2, 0, 0, 0, time.UTC)
+	l := newTransitionLimiter(EventLimits{PerLabel: time.Minute, PerLabelBurst: 1, Global: time.Second, GlobalBurst: 3})
+	if !l.allow("patch", now) {
+		t.Fatal("allow(patch) = false, want true")
+	}
+	// A clock stepped back two hours must not leave the label throttled for
+	// two hours once time resumes.
+	back := now.Add(-2 * time.Hour)
+	l.allow("patch", back)
+	if !l.allow("patch", back.Add(time.Minute)) {
+		t.Errorf("allow(patch) a minute after the clock stepped back = false, want true")
+	}
+}
+
+func TestLabelValidation(t *testing.T) {
+	var got []string
+	fnSchedule = func(opts schedule.Options, names ...string) ([]window.Schedule, error) {
+		got = names
+		return []window.Schedule{}, nil
+	}
+	fnQuery = queryFrom(fnSchedule)
+	srv := httptest.NewServer(muxRouter())
+	defer srv.Close()
+	tests := []struct {
+		path   string
+		status int
+		want   []string
+	}{
+		{"/schedule/OS_Patch", http.StatusOK, []string{"os_patch"}},
+		{"/schedule/active_hours", http.StatusOK, []string{"active_hours"}},
+		{"/schedule/" + strings.Repeat("a", 65), http.StatusBadRequest, nil},
+		{"/schedule/bad%0Alabel", http.StatusBadRequest, nil},
+		{"/schedule/a%2Fb", http.StatusBadRequest, nil},
+		{"/events?label=bad%0Alabel", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		got = nil
+		res, err := http.Get(srv.URL + tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != tt.status {
+			t.Errorf("%s returned status %d, want %d", tt.path, res.StatusCode, tt.status)
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("%s queried unexpected labels (-want +got):\n%s", tt.path, diff)
+		}
+	}
+}
+
+func TestHealthProbes(t *testing.T) {
+	srv := httptest.NewServer(muxRouter())
+	defer srv.Close()
+	tests := []struct {
+		method, path string
+		wantCode     int
+		wantBody     string
+	}{
+		{http.MethodGet, "/status", http.StatusOK, "OK"},
+		{http.MethodHead, "/status", http.StatusOK, ""},
+		{http.MethodGet, "/healthz", http.StatusNoContent, ""},
+		{http.MethodHead, "/healthz", http.StatusNoContent, ""},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequest(tt.method, srv.URL+tt.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != tt.wantCode || string(b) != tt.wantBody {
+			t.Errorf("%s %s = (%d, %q), want (%d, %q)", tt.method, tt.path, res.StatusCode, b, tt.wantCode, tt.wantBody)
+		}
+	}
+}
+
+func TestSchemaAndValidate(t *testing.T) {
+	srv := httptest.NewServer(muxRouter())
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/schema")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusOK || !json.Valid(b) || res.Header.Get("Content-Type") != "application/schema+json" {
+		t.Errorf("GET /schema = (%d, %q, valid JSON: %t), want (200, application/schema+json, true)", res.StatusCode, res.Header.Get("Content-Type"), json.Valid(b))
+	}
+
+	tests := []struct {
+		desc, body string
+		want       string
+	}{
+		{"valid", `{"Windows":[{"Name":"a","Format":1,"Schedule":"0 0 2 * * *","Duration":"1h","Labels":["a"]}]}`, window.CheckOK},
+		{"unknown field", `{"Windows":[{"Name":"a","Format":1,"Schedule":"0 0 2 * * *","Duration":"1h","Labels":["a"],"Duraton":"2h"}]}`, window.CheckError},
+		{"bad cron", `{"Windows":[{"Name":"a","Format":1,"Schedule":"nope","Duration":"1h","Labels":["a"]}]}`, window.CheckError},
+	}
+	for _, tt := range tests {
+		res, err := http.Post(srv.URL+"/validate?name=test.json", "application/json", strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got window.FileCheck
+		err = json.NewDecoder(res.Body).Decode(&got)
+		res.Body.Close()
+		if err != nil {
+			t.Fatalf("POST /validate(%s): %v", tt.desc, err)
+		}
+		if got.Status != tt.want || got.Path != "test.json" {
+			t.Errorf("POST /validate(%s) = %+v, want status %q", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestServeCalendar(t *testing.T) {
+	var gotDays int
+	fnCalendar = func(ctx context.Context, from, to time.Time, opts schedule.Options) ([]schedule.Span, error) {
+		for gotDays = 0; from.AddDate(0, 0, gotDays).Before(to); gotDays++ {
+		}
+		return []schedule.Span{{Opens: from, Closes: from.Add(time.Hour), Labels: []string{"patch"}}}, nil
+	}
+	srv := httptest.NewServer(muxRouter())
+	defer srv.Close()
+	tests := []struct {
+		query    string
+		wantCode int
+		wantDays int
+	}{
+		{"", 200, 7},
+		{"?days=30", 200, 30},
+		{"?days=0", 400, 0},
+		{"?days=1000", 400, 0},
+		{"?days=x", 400, 0},
+		{"?days=1&mode=bogus", 400, 0},
+	}
+	for _, tt := range tests {
+		gotDays = 0
+		res, err := http.Get(srv.URL + "/calendar" + tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var spans []schedule.Span
+		if res.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(res.Body).Decode(&spans); err != nil {
+				t.Errorf("/calendar%s: decoding response: %v", tt.query, err)
+			}
+		}
+		res.Body.Close()
+		if res.StatusCode != tt.wantCode || gotDays != tt.wantDays {
+			t.Errorf("/calendar%s = (%d, %d days), want (%d, %d days)", tt.query, res.StatusCode, gotDays, tt.wantCode, tt.wantDays)
+		}
+		if tt.wantCode == 200 && (len(spans) != 1 || spans[0].Labels[0] != "patch") {
+			t.Errorf("/calendar%s returned %+v", tt.query, spans)
+		}
+	}
+}
+
+func TestServeEvents(t *testing.T) {
+	orig := eventInterval
+	defer func() { eventInterval = orig }()
+	eventInterval = 10 * time.Millisecond
+
+	now := time.Now()
+	var calls int32
+	fnSchedule = func(opts schedule.Options, names ...string) ([]window.Schedule, error) {
+		if len(names) != 1 || names[0] != "patch" {
+			return nil, window.ErrNoWindows
+		}
+		s := window.Schedule{Name: "patch", Opens: now.Add(time.Hour), Closes: now.Add(2 * time.Hour)}
+		// The schedule opens from the third evaluation onwards.
+		if atomic.AddInt32(&calls, 1) >= 3 {
+			s.Opens = now.Add(-time.Hour)
+		}
+		return []window.Schedule{s}, nil
+	}
+	srv := httptest.NewServer(muxRouter())
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/events?label=missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("/events?label=missing returned %d, want 404", res.StatusCode)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/events?label=patch", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err = srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if ct := res.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("/events Content-Type = %q, want text/event-stream", ct)
+	}
+	var states []string
+	sc := bufio.NewScanner(res.Body)
+	for len(states) < 2 && sc.Scan() {
+		line := sc.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		var s window.Schedule
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &s); err != nil {
+			t.Fatalf("unable to parse event %q: %v", line, err)
+		}
+		states = append(states, s.State)
+	}
+	if fmt.Sprint(states) != "[closed open]" {
+		t.Errorf("/events delivered states %v, want [closed open]", states)
+	}
+}
+
+func TestServeEventsResume(t *testing.T) {
+	orig := eventInterval
+	defer func() { eventInterval = orig }()
+	eventInterval = 10 * time.Millisecond
+
+	now := time.Now()
+	fnSchedule = func(opts schedule.Options, names ...string) ([]window.Schedule, error) {
+		return []window.Schedule{{Name: "patch", Opens: now.Add(time.Hour), Closes: now.Add(2 * time.Hour)}}, nil
+	}
+	srv := httptest.NewServer(muxRouter())
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/events?label=patch", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	var events int
+	sc := bufio.NewScanner(res.Body)
+	for events < 2 && sc.Scan() {
+		if !strings.HasPrefix(sc.Text(), "event: schedule") {
+			continue
+		}
+		events++
+		if events == 1 {
+			// The unchanged schedule is resent once the host resumes.
+			schedule.Resume()
+		}
+	}
+	if events != 2 {
+		t.Errorf("/events delivered %d schedule events, want the initial one and one after resume", events)
+	}
+}
+
+func TestServeEventsOutlivesWriteTimeout(t *testing.T) {
+	origInterval, origTimeout := eventInterval, writeTimeout
+	defer func() { eventInterval, writeTimeout = origInterval, origTimeout }()
+	eventInterval, writeTimeout = 20*time.Millisecond, 100*time.Millisecond
+
+	now := time.Now()
+	fnSchedule = func(opts schedule.Options, names ...string) ([]window.Schedule, error) {
+		return []window.Schedule{{Name: "patch", Opens: now.Add(time.Hour), Closes: now.Add(2 * time.Hour)}}, nil
+	}
+	srv := httptest.NewUnstartedServer(muxRouter())
+	srv.Config.WriteTimeout = writeTimeout
+	srv.Config.ConnContext = connContext
+	srv.Start()
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/events?label=patch", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	start := time.Now()
+	sc := bufio.NewScanner(res.Body)
+	for time.Since(start) < 5*writeTimeout && sc.Scan() {
+	}
+	if d := time.Since(start); d < 5*writeTimeout {
+		t.Errorf("/events ended after %v (%v), want it to outlive the write timeout of %v", d, sc.Err(), writeTimeout)
+	}
+}
+
+func TestServeConfigEvents(t *testing.T) {
+	orig := eventInterval
+	defer func() { eventInterval = orig }()
+	eventInterval = 10 * time.Millisecond
+	defer func(f func(string) (window.ConfigChange, bool)) { fnLastChange = f }(fnLastChange)
+
+	now := time.Now()
+	fnSchedule = func(opts schedule.Options, names ...string) ([]window.Schedule, error) {
+		return []window.Schedule{{Name: "patch", Opens: now.Add(time.Hour), Closes: now.Add(2 * time.Hour)}}, nil
+	}
+	var calls int32
+	fnLastChange = func(string) (window.ConfigChange, bool) {
+		// A change is recorded from the third call onwards, after the stream
+		// has started.
+		if atomic.AddInt32(&calls, 1) < 3 {
+			return window.ConfigChange{}, false
+		}
+		return window.ConfigChange{Seq: 1, Added: []string{"nightly"}, Labels: []window.LabelDelta{{Label: "patch"}}}, true
+	}
+	srv := httptest.NewServer(muxRouter())
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/events?label=patch", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	var events []string
+	sc := bufio.NewScanner(res.Body)
+	for sc.Scan() {
+		line := sc.Text()
+		if !strings.HasPrefix(line, "event: ") {
+			continue
+		}
+		events = append(events, strings.TrimPrefix(line, "event: "))
+		if events[len(events)-1] != "config" {
+			continue
+		}
+		sc.Scan()
+		var c window.ConfigChange
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(sc.Text(), "data: ")), &c); err != nil {
+			t.Fatalf("unable to parse config event %q: %v", sc.Text(), err)
+		}
+		if c.Seq != 1 || len(c.Added) != 1 {
+			t.Errorf("config event = %+v, want the recorded change", c)
+		}
+		break
+	}
+	if fmt.Sprint(events) != "[schedule config]" {
+		t.Errorf("/events delivered %v, want [schedule config]", events)
+	}
+}
+
+func TestAffects(t *testing.T) {
+	c := window.ConfigChange{Labels: []window.LabelDelta{{Label: "patch"}}}
+	tests := []struct {
+		labels []string
+		want   bool
+	}{
+		{nil, true},
+		{[]string{"patch"}, true},
+		{[]string{"reboot"}, false},
+	}
+	for _, tt := range tests {
+		if got := affects(c, tt.labels); got != tt.want {
+			t.Errorf("affects(%v) = %t, want %t", tt.labels, got, tt.want)
+		}
+	}
+}
+
+func TestSelfTest(t *testing.T) {
+	srv := httptest.NewServer(muxRouter())
+	defer srv.Close()
+	for _, passed := range []bool{true, false} {
+		fnSelfTest = func() schedule.SelfTestResult {
+			return schedule.SelfTestResult{Passed: passed, Steps: []schedule.Step{{Name: "parse", Passed: passed}}}
+		}
+		res, err := http.Get(srv.URL + "/selftest")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got schedule.SelfTestResult
+		err = json.NewDecoder(res.Body).Decode(&got)
+		res.Body.Close()
+		if err != nil {
+			t.Fatalf("decoding /selftest response: %v", err)
+		}
+		want := http.StatusOK
+		if !passed {
+			want = http.StatusServiceUnavailable
+		}
+		if res.StatusCode != want || got.Passed != passed {
+			t.Errorf("/selftest = (%d, passed %t), want (%d, passed %t)", res.StatusCode, got.Passed, want, passed)
+		}
+	}
+}
+
+func TestIntents(t *testing.T) {
+	defer func(d func(string, string, string, time.Duration) (schedule.Intent, error), l func(string) []schedule.Intent, s func(uint64) ([]schedule.Intent, uint64)) {
+		fnDeclareIntent, fnIntents, fnIntentsSince = d, l, s
+	}(fnDeclareIntent, fnIntents, fnIntentsSince)
+	now := time.Now()
+	var registered []schedule.Intent
+	fnDeclareIntent = func(label, agent, action string, est time.Duration) (schedule.Intent, error) {
+		switch {
+		case label != "patch":
+			return schedule.Intent{}, window.ErrNoWindows
+		case agent == "":
+			return schedule.Intent{}, schedule.ErrInvalidIntent
+		case agent == "flood":
+			return schedule.Intent{}, schedule.ErrTooManyIntents
+		}
+		i := schedule.Intent{Label: label, Agent: agent, Action: action, EstimatedDuration: est, Registered: now, Expires: now.Add(time.Hour), Seq: uint64(len(registered) + 1)}
+		registered = append(registered, i)
+		return i, nil
+	}
+	fnIntents = func(label string) []schedule.Intent {
+		out := []schedule.Intent{}
+		for _, i := range registered {
+			if label == "" || i.Label == label {
+				out = append(out, i)
+			}
+		}
+		return out
+	}
+	srv := httptest.NewServer(muxRouter())
+	defer srv.Close()
+
+	tests := []struct {
+		desc, label, body string
+		want              int
+	}{
+		{"registered", "PATCH", `{"Agent": "patcher", "Action": "install updates", "EstimatedDuration": "30m"}`, http.StatusOK},
+		{"unknown label", "reboot", `{"Agent": "patcher", "Action": "reboot", "EstimatedDuration": "5m"}`, http.StatusNotFound},
+		{"invalid", "patch", `{"Action": "reboot", "EstimatedDuration": "5m"}`, http.StatusBadRequest},
+		{"malformed", "patch", `{"Agent": `, http.StatusBadRequest},
+		{"bad duration", "patch", `{"Agent": "patcher", "Action": "reboot", "EstimatedDuration": "soon"}`, http.StatusBadRequest},
+		{"full", "patch", `{"Agent": "flood", "Action": "reboot", "EstimatedDuration": "5m"}`, http.StatusTooManyRequests},
+		{"bad label", "bad%20label", `{}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		res, err := http.Post(srv.URL+"/intent/"+tt.label, "application/json", strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != tt.want {
+			t.Errorf("%s: POST /intent/%s returned %d, want %d", tt.desc, tt.label, res.StatusCode, tt.want)
+		}
+	}
+
+	res, err := http.Get(srv.URL + "/intent?label=patch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	var got []schedule.Intent
+	if err := json.NewDecoder(res.Body).Decode(&got); err != nil {
+		t.Fatalf("decoding /intent response: %v", err)
+	}
+	if len(got) != 1 || got[0].Agent != "patcher" || got[0].EstimatedDuration != 30*time.Minute {
+		t.Errorf("/intent?label=patch = %+v, want the patcher intent", got)
+	}
+
+	// Event streams deliver registered intents for the requested label.
+	orig := eventInterval
+	defer func() { eventInterval = orig }()
+	eventInterval = 10 * time.Millisecond
+	fnSchedule = func(opts schedule.Options, names ...string) ([]window.Schedule, error) {
+		return []window.Schedule{{Name: "patch", Opens: now.Add(time.Hour), Closes: now.Add(2 * time.Hour)}}, nil
+	}
+	fnIntentsSince = func(seq uint64) ([]schedule.Intent, uint64) {
+		other := schedule.Intent{Label: "reboot", Agent: "other", Seq: 2}
+		if seq >= 2 {
+			return nil, 2
+		}
+		return append(append([]schedule.Intent(nil), registered...), other), 2
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/events?label=patch", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ev.Body.Close()
+	var events []string
+	sc := bufio.NewScanner(ev.Body)
+	for len(events) < 2 && sc.Scan() {
+		if e := strings.TrimPrefix(sc.Text(), "event: "); e != sc.Text() {
+			events = append(events, e)
+		}
+	}
+	if diff := cmp.Diff([]string{"intent", "schedule"}, events); diff != "" {
+		t.Errorf("/events mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWarnDeprecated(t *testing.T) {
+	defer func(d []deprecation) { deprecations = d }(deprecations)
+	sunset := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	deprecations = []deprecation{
+		{Route: "/schedule/{label}", Param: "mode", Message: "aggregate on the client instead", Sunset: sunset},
+		{Route: "/selftest", Sunset: sunset.AddDate(0, 6, 0)},
+	}
+	fnSchedule = func(opts schedule.Options, names ...string) ([]window.Schedule, error) {
+		return []window.Schedule{{Name: "patch"}}, nil
+	}
+	fnQuery = queryFrom(fnSchedule)
+	fnSelfTest = func() schedule.SelfTestResult { return schedule.SelfTestResult{Passed: true} }
+	srv := httptest.NewServer(muxRouter())
+	defer srv.Close()
+
+	tests := []struct {
+		desc, path   string
+		wantWarnings []string
+		wantSunset   time.Time
+	}{
+		{"current", "/schedule/patch", nil, time.Time{}},
+		{"other route", "/schedule?mode=merge", nil, time.Time{}},
+		{"deprecated parameter", "/schedule/patch?mode=merge",
+			[]string{`parameter "mode" of /schedule/{label} is deprecated and will be removed after 2027-01-01: aggregate on the client instead`}, sunset},
+		{"deprecated route", "/selftest", []string{"/selftest is deprecated and will be removed after 2027-07-01"}, sunset.AddDate(0, 6, 0)},
+	}
+	for _, tt := range tests {
+		res, err := http.Get(srv.URL + tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("%s: produced unexpected status code: got %d, want %d", tt.desc, res.StatusCode, http.StatusOK)
+		}
+		if got := res.Header.Values(DeprecationHeader); !cmp.Equal(got, tt.wantWarnings) {
+			t.Errorf("%s: %s = %q, want %q", tt.desc, DeprecationHeader, got, tt.wantWarnings)
+		}
+		if got, want := res.Header.Get("Deprecation") == "true", tt.wantWarnings != nil; got != want {
+			t.Errorf("%s: Deprecation header present = %t, want %t", tt.desc, got, want)
+		}
+		if !tt.wantSunset.IsZero() && res.Header.Get("Sunset") != tt.wantSunset.Format(http.TimeFormat) {
+			t.Errorf("%s: Sunset = %q, want %q", tt.desc, res.Header.Get("Sunset"), tt.wantSunset.Format(http.TimeFormat))
+		}
+		// Object responses also carry the warnings, while arrays keep their shape.
+		if strings.HasPrefix(tt.path, "/selftest") {
+			var got struct {
+				Passed       bool
+				Deprecations []string
+			}
+			if err := json.Unmarshal(b, &got); err != nil {
+				t.Fatalf("%s: unable to decode %s: %v", tt.desc, b, err)
+			}
+			if !got.Passed || !cmp.Equal(got.Deprecations, tt.wantWarnings) {
+				t.Errorf("%s: response = %s, want Deprecations %q", tt.desc, b, tt.wantWarnings)
+			}
+		} else if err := json.Unmarshal(b, &[]window.Schedule{}); err != nil {
+			t.Errorf("%s: unable to decode %s as schedules: %v", tt.desc, b, err)
+		}
+	}
+}
+
+func TestDeprecationWriterEmptyObject(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rec.Header().Set("Content-Type", "application/json")
+	dw := &deprecationWriter{ResponseWriter: rec, warnings: []string{"old"}}
+	if _, err := dw.Write([]byte("{}")); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := rec.Body.String(), `{"Deprecations":["old"]}`; got != want {
+		t.Errorf("Write({}) wrote %s, want %s", got, want)
+	}
+}
+
+func TestExplain(t *testing.T) {
+	var gotOpts schedule.Options
+	fnExplain = func(ctx context.Context, opts schedule.Options, label string) (schedule.Explanation, error) {
+		if label != "patch" {
+			return schedule.Explanation{}, fmt.Errorf("label %q: %w", label, window.ErrNoWindows)
+		}
+		gotOpts = opts
+		return schedule.Explanation{Label: label, Rationale: schedule.NearestRationale, Candidates: []schedule.Candidate{{Preference: schedule.PreferUpcoming}}}, nil
+	}
+	srv := httptest.NewServer(muxRouter())
+	defer srv.Close()
+
+	tests := []struct {
+		path     string
+		wantCode int
+	}{
+		{"/explain/Patch?at=2026-03-01T00:00:00Z", http.StatusOK},
+		{"/explain/reboot", http.StatusNotFound},
+		{"/explain/patch?at=tomorrow", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		res, err := http.Get(srv.URL + tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != tt.wantCode {
+			t.Errorf("%s: produced unexpected status code: got %d, want %d", tt.path, res.StatusCode, tt.wantCode)
+			continue
+		}
+		if tt.wantCode != http.StatusOK {
+			continue
+		}
+		if !gotOpts.At.Equal(time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("%s: explained at %v", tt.path, gotOpts.At)
+		}
+		if !strings.Contains(string(b), `"Preference":"upcoming"`) || !strings.Contains(string(b), `"Rationale":`) {
+			t.Errorf("%s: unexpected response %s", tt.path, b)
+		}
+	}
+}
+
+func TestScheduleDedup(t *testing.T) {
+	opens := time.Now().Add(time.Hour).Truncate(time.Second)
+	span := window.Schedule{Opens: opens, Closes: opens.Add(time.Hour), Duration: time.Hour}
+	fnSchedule = func(opts schedule.Options, names ...string) ([]window.Schedule, error) {
+		var out []window.Schedule
+		for _, l := range []string{"reboot", "patch"} {
+			s := span
+			s.Name = l
+			out = append(out, s)
+		}
+		return out, nil
+	}
+	fnQuery = queryFrom(fnSchedule)
+	srv := httptest.NewServer(muxRouter())
+	defer srv.Close()
+
+	tests := []struct {
+		path     string
+		wantCode int
+		want     []schedule.Shared
+	}{
+		{"/schedule?dedup=true", http.StatusOK, []schedule.Shared{{Labels: []string{"patch", "reboot"}}}},
+		{"/schedule?dedup=maybe", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		res, err := http.Get(srv.URL + tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []schedule.Shared
+		if res.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(res.Body).Decode(&got); err != nil {
+				t.Fatalf("%s: unable to decode response: %v", tt.path, err)
+			}
+		}
+		res.Body.Close()
+		if res.StatusCode != tt.wantCode {
+			t.Errorf("%s: produced unexpected status code: got %d, want %d", tt.path, res.StatusCode, tt.wantCode)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: returned %d schedules, want %d: %+v", tt.path, len(got), len(tt.want), got)
+			continue
+		}
+		for i := range got {
+			if !cmp.Equal(got[i].Labels, tt.want[i].Labels) || got[i].Schedule.Name != "patch" || !got[i].Schedule.Opens.Equal(opens) {
+				t.Errorf("%s: returned %+v, want one span for labels %v", tt.path, got[i], tt.want[i].Labels)
+			}
+		}
+	}
+
+	// Without dedup each label reports the span independently.
+	res, err := http.Get(srv.URL + "/schedule")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	var plain []window.Schedule
+	if err := json.NewDecoder(res.Body).Decode(&plain); err != nil || len(plain) != 2 {
+		t.Errorf("/schedule returned %v (%v), want 2 schedules", plain, err)
+	}
+}
+
+func TestControlSetOverride(t *testing.T) {
+	orig := auklib.ConfDir
+	defer func() { auklib.ConfDir = orig }()
+	auklib.ConfDir = t.TempDir()
+	ctx := context.Background()
+
+	w := `{"Name": "patch/../x", "Format": 1, "Schedule": "0 0 2 * * *", "Duration": "1h", "Labels": ["patch"]}`
+	got, err := controlSetOverride(ctx, json.RawMessage(`{"Window": `+w+`}`))
+	if err != nil {
+		t.Fatalf("set-override returned error: %v", err)
+	}
+	path := filepath.Join(auklib.ConfDir, window.OverridesDir, "control-patch_2f_2e_2e_2fx.json")
+	if want := map[string]string{"File": path}; !cmp.Equal(got, want) {
+		t.Errorf("set-override = %v, want %v", got, want)
+	}
+	var conf struct{ Windows []window.Window }
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(b, &conf); err != nil || len(conf.Windows) != 1 || conf.Windows[0].Name != "patch/../x" {
+		t.Errorf("override file holds %s (%v), want window patch/../x", b, err)
+	}
+
+	for _, args := range []string{
+		`{"Window": {"Name": "bad", "Format": 1, "Schedule": "nonsense", "Duration": "1h"}}`,
+		`{}`,
+		`{"Clear": true}`,
+		`{"Name": "unset", "Clear": true}`,
+	} {
+		if _, err := controlSetOverride(ctx, json.RawMessage(args)); err == nil {
+			t.Errorf("set-override(%s) returned nil error", args)
+		}
+	}
+
+	if _, err := controlSetOverride(ctx, json.RawMessage(`{"Name": "patch/../x", "Clear": true}`)); err != nil {
+		t.Fatalf("set-override clear returned error: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("override file remains after clear: %v", err)
+	}
+}
+
+func TestOverrideFileDistinct(t *testing.T) {
+	if a, b := overrideFile("a.b"), overrideFile("a_b"); a == b {
+		t.Errorf("overrideFile(a.b) = overrideFile(a_b) = %s, want distinct files", a)
+	}
+}
+
+func TestControlDrain(t *testing.T) {
+	defer SetDraining(false)
+	srv := httptest.NewServer(muxRouter())
+	defer srv.Close()
+	ctx := context.Background()
+
+	tests := []struct {
+		args     string
+		wantCode int
+	}{
+		{``, http.StatusServiceUnavailable},
+		{`{"Enable": false}`, http.StatusOK},
+		{`{"Enable": true}`, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		if _, err := controlDrain(ctx, json.RawMessage(tt.args)); err != nil {
+			t.Fatalf("drain(%s) returned error: %v", tt.args, err)
+		}
+		res, err := http.Get(srv.URL + "/status")
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != tt.wantCode {
+			t.Errorf("after drain(%s): GET /status = %d, want %d", tt.args, res.StatusCode, tt.wantCode)
+		}
+		if tt.wantCode == http.StatusServiceUnavailable && res.Header.Get("Retry-After") == "" {
+			t.Errorf("after drain(%s): no Retry-After header", tt.args)
+		}
+	}
+}
+
+func TestScheduleRange(t *testing.T) {
+	from := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	var gotFrom, gotTo time.Time
+	fnRange = func(ctx context.Context, f, to time.Time, opts schedule.Options, names ...string) ([]window.Schedule, error) {
+		gotFrom, gotTo = f, to
+		if len(names) > 0 && names[0] == "missing" {
+			return nil, window.ErrNoWindows
+		}
+		if to.Sub(f) > schedule.MaxRange {
+			return nil, schedule.ErrInvalidRange
+		}
+		return []window.Schedule{
+			{Name: "patch", Opens: f.Add(time.Hour), Closes: f.Add(2 * time.Hour)},
+			{Name: "patch", Opens: f.Add(25 * time.Hour), Closes: f.Add(26 * time.Hour)},
+		}, nil
+	}
+	defer func() { fnRange = schedule.Range }()
+	srv := httptest.NewServer(muxRouter())
+	defer srv.Close()
+
+	tests := []struct {
+		path      string
+		wantCode  int
+		wantSpans int
+	}{
+		{"/schedule/patch?from=2020-01-01T00:00:00Z&to=2020-01-03T00:00:00Z", http.StatusOK, 2},
+		{"/schedule?from=2020-01-01T00:00:00Z&to=2020-01-03T00:00:00Z", http.StatusOK, 2},
+		{"/schedule/missing?from=2020-01-01T00:00:00Z&to=2020-01-03T00:00:00Z", http.StatusNotFound, 0},
+		{"/schedule/patch?from=2020-01-01T00:00:00Z&to=2022-01-03T00:00:00Z", http.StatusBadRequest, 0},
+		{"/schedule/patch?from=2020-01-01T00:00:00Z", http.StatusBadRequest, 0},
+		{"/schedule/patch?from=yesterday&to=2020-01-03T00:00:00Z", http.StatusBadRequest, 0},
+		{"/schedule/patch?from=2020-01-01T00:00:00Z&to=2020-01-03T00:00:00Z&at=2020-01-02T00:00:00Z", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		res, err := http.Get(srv.URL + tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []window.Schedule
+		if res.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(res.Body).Decode(&got); err != nil {
+				t.Errorf("%s: unable to decode response: %v", tt.path, err)
+			}
+		}
+		res.Body.Close()
+		if res.StatusCode != tt.wantCode || len(got) != tt.wantSpans {
+			t.Errorf("GET %s = (%d, %d spans), want (%d, %d spans)", tt.path, res.StatusCode, len(got), tt.wantCode, tt.wantSpans)
+		}
+		if tt.wantCode == http.StatusOK && (!gotFrom.Equal(from) || !gotTo.Equal(from.AddDate(0, 0, 2))) {
+			t.Errorf("GET %s queried [%v, %v)", tt.path, gotFrom, gotTo)
+		}
+	}
+}
+
+func TestLimitInFlight(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	h := limitInFlight(1)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}))
+	done := make(chan struct{})
+	go func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/schedule", nil))
+		close(done)
+	}()
+	<-started
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/schedule", nil))
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") != "2" {
+		t.Errorf("request over the limit = (%d, Retry-After %q), want (%d, \"2\")", rec.Code, rec.Header().Get("Retry-After"), http.StatusServiceUnavailable)
+	}
+	close(release)
+	<-done
+
+	started = make(chan struct{})
+	release = make(chan struct{})
+	close(release)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/schedule", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("request after the limit cleared = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestScheduleNotLoaded(t *testing.T) {
+	fnQuery = func(ctx context.Context, opts schedule.Options, names ...string) (schedule.Result, error) {
+		return schedule.Result{}, fmt.Errorf("%w: directory locked", window.ErrNotLoaded)
+	}
+	defer func() { fnQuery = schedule.QueryResult }()
+	srv := httptest.NewServer(muxRouter())
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/schedule/patch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusServiceUnavailable || res.Header.Get("Retry-After") == "" {
+		t.Errorf("GET /schedule/patch while not loaded = (%d, Retry-After %q), want 503 with Retry-After", res.StatusCode, res.Header.Get("Retry-After"))
+	}
+}
+
+func TestEvaluateBatch(t *testing.T) {
+	at := time.Date(2026, time.January, 10, 2, 30, 0, 0, time.UTC)
+	var got []schedule.Evaluation
+	fnBatch = func(ctx context.Context, opts schedule.Options, evals []schedule.Evaluation) ([]schedule.Evaluated, error) {
+		if len(evals) > 2 {
+			return nil, schedule.ErrBatchTooLarge
+		}
+		got = evals
+		var out []schedule.Evaluated
+		for _, e := range evals {
+			out = append(out, schedule.Evaluated{Label: e.Label, At: e.At, Status: schedule.StatusFound})
+		}
+		return out, nil
+	}
+	defer func() { fnBatch = schedule.Batch }()
+	srv := httptest.NewServer(muxRouter())
+	defer srv.Close()
+
+	tests := []struct {
+		body     string
+		wantCode int
+	}{
+		{`[{"Label": "patch", "At": "2026-01-10T02:30:00Z"}, {"Label": "reboot"}]`, http.StatusOK},
+		{`[{}, {}, {}]`, http.StatusRequestEntityTooLarge},
+		{`{"Label": "patch"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		res, err := http.Post(srv.URL+"/evaluate", "application/json", strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out []schedule.Evaluated
+		if res.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(res.Body).Decode(&out); err != nil {
+				t.Errorf("POST /evaluate %s: unable to decode response: %v", tt.body, err)
+			}
+		}
+		res.Body.Close()
+		if res.StatusCode != tt.wantCode {
+			t.Errorf("POST /evaluate %s = %d, want %d", tt.body, res.StatusCode, tt.wantCode)
+		}
+		if tt.wantCode == http.StatusOK && (len(out) != 2 || out[1].Label != "reboot") {
+			t.Errorf("POST /evaluate %s returned %v, want results in request order", tt.body, out)
+		}
+	}
+	want := []schedule.Evaluation{{Label: "patch", At: at}, {Label: "reboot"}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("POST /evaluate passed unexpected evaluations (-want +got):\n%s", diff)
+	}
+
+	res, err := http.Get(srv.URL + "/evaluate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET /evaluate = %d, want %d", res.StatusCode, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestAPICatalog(t *testing.T) {
+	defer func(d []deprecation) { deprecations = d }(deprecations)
+	deprecations = []deprecation{{Route: "/schedule", Param: "dedup", Message: "use /schedule/{label}"}}
+	srv := httptest.NewServer(muxRouter())
+	defer srv.Close()
+	res, err := http.Get(srv.URL + "/api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("GET /api = %d, want %d", res.StatusCode, http.StatusOK)
+	}
+	var c Catalog
+	if err := json.NewDecoder(res.Body).Decode(&c); err != nil {
+		t.Fatalf("GET /api returned an invalid catalog: %v", err)
+	}
+	if c.Version == "" {
+		t.Error("GET /api returned no Version")
+	}
+
+	// The catalog and the router describe the same routes.
+	listed := make(map[string]bool)
+	for _, e := range c.Endpoints {
+		for _, m := range e.Methods {
+			listed[m+" "+e.Path] = true
+		}
+		for _, p := range e.Params {
+			if want := e.Path == "/schedule" && p.Name == "dedup"; (p.Deprecated != "") != want {
+				t.Errorf("GET /api parameter %s of %s has Deprecated = %q", p.Name, e.Path, p.Deprecated)
+			}
+		}
+	}
+	routed := make(map[string]bool)
+	paths := make(map[string]bool)
+	err = chi.Walk(muxRouter().(chi.Routes), func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		routed[method+" "+route] = true
+		paths[route] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for r := range listed {
+		if !routed[r] {
+			t.Errorf("GET /api lists %s, which is not routed", r)
+		}
+	}
+	for p := range paths {
+		found := false
+		for _, e := range c.Endpoints {
+			found = found || e.Path == p
+		}
+		if !found {
+			t.Errorf("GET /api does not list routed path %s", p)
+		}
+	}
+}
+
+// fakeMetrics records the samples reported through auklib.Metrics.
+type fakeMetrics struct {
+	mu      sync.Mutex
+	samples map[string][]map[string]string
+}
+
+func (f *fakeMetrics) SetInt(name string, v int64, fields map[string]string) error {
+	return f.SetString(name, strconv.FormatInt(v, 10), fields)
+}
+
+func (f *fakeMetrics) SetDistribution(name string, d auklib.Distribution, fields map[string]string) error {
+	return f.SetString(name, strconv.FormatInt(d.Count, 10), fields)
+}
+
+func (f *fakeMetrics) SetString(name, v string, fields map[string]string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	name = strings.TrimPrefix(name, auklib.Defaults.MetricRoot+"/")
+	f.samples[name] = append(f.samples[name], fields)
+	return nil
+}
+
+func TestReportLatency(t *testing.T) {
+	defer func(m auklib.MetricSink) { auklib.Metrics = m }(auklib.Metrics)
+	f := &fakeMetrics{samples: make(map[string][]map[string]string)}
+	auklib.Metrics = f
+
+	rtr := chi.NewRouter()
+	rtr.Use(reportLatency)
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	rtr.Get("/schedule/{label}", ok)
+	rtr.Get("/events", ok)
+	for _, p := range []string{"/schedule/patch", "/events", "/probe/1", "/probe/2"} {
+		rtr.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, p, nil))
+	}
+	want := []map[string]string{
+		{"route": "/schedule/{label}", "method": "GET", "status": "200"},
+		{"route": unmatchedRoute, "method": "GET", "status": "404"},
+		{"route": unmatchedRoute, "method": "GET", "status": "404"},
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if diff := cmp.Diff(want, f.samples["handler_latency"]); diff != "" {
+		t.Errorf("handler_latency samples mismatch (-want +got):\n%s", diff)
+	}
+}
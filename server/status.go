@@ -0,0 +1,96 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/aukera/window"
+)
+
+var statusTmpl = template.Must(template.New("status").Parse(`<!DOCTYPE html>
+<html><head><title>Aukera</title></head><body>
+<h1>Aukera</h1>
+<p>Generated {{.Now}}</p>
+<table border="1" cellpadding="4">
+<tr><th>Label</th><th>State</th><th>Opens</th><th>Closes</th><th>Duration</th></tr>
+{{range .Schedules}}<tr><td>{{.Name}}</td><td>{{.State}}</td><td>{{.Opens}}</td><td>{{.Closes}}</td><td>{{.Duration}}</td></tr>
+{{end}}</table>
+</body></html>
+`))
+
+// statusText renders schedules as an aligned plaintext table.
+func statusText(now time.Time, schedules []window.Schedule) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Aukera status as of %s\n\n", now.Format(time.RFC3339))
+	tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "LABEL\tSTATE\tOPENS\tCLOSES\tDURATION")
+	for _, s := range schedules {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", s.Name, s.State,
+			s.Opens.Format(time.RFC3339), s.Closes.Format(time.RFC3339), s.Duration)
+	}
+	tw.Flush()
+	return buf.Bytes()
+}
+
+// statusPage serves a human-readable summary of all labels, accepting the
+// request parameters of schedule requests. Plaintext is returned unless the
+// client prefers HTML, keeping output readable via curl.
+func statusPage(w http.ResponseWriter, r *http.Request) {
+	opts, err := queryOptions(r)
+	if err != nil {
+		sendHTTPResponse(w, http.StatusBadRequest, []byte(err.Error()))
+		return
+	}
+	res, err := fnQuery(r.Context(), opts)
+	if errors.Is(err, window.ErrNotLoaded) {
+		sendUnavailable(w, loadRetryAfter, err.Error())
+		return
+	}
+	if err != nil {
+		sendHTTPResponse(w, http.StatusInternalServerError, []byte(err.Error()))
+		return
+	}
+	s := res.Schedules
+	sort.Slice(s, func(i, j int) bool { return s[i].Name < s[j].Name })
+	now := res.EvaluatedAt
+	if opts.Location != nil {
+		now = now.In(opts.Location)
+	}
+
+	if !strings.Contains(r.Header.Get("Accept"), "text/html") {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		sendHTTPResponse(w, http.StatusOK, statusText(now, s))
+		return
+	}
+	var buf bytes.Buffer
+	if err := statusTmpl.Execute(&buf, struct {
+		Now       string
+		Schedules []window.Schedule
+	}{now.Format(time.RFC3339), s}); err != nil {
+		sendHTTPResponse(w, http.StatusInternalServerError, []byte(err.Error()))
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	sendHTTPResponse(w, http.StatusOK, buf.Bytes())
+}
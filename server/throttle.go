@@ -0,0 +1,99 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"time"
+)
+
+// bucket is a token bucket allowing burst events at once, refilled at one
+// token per interval.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func (b *bucket) allow(now time.Time, burst int, interval time.Duration) bool {
+	if b.last.IsZero() {
+		b.tokens = float64(burst)
+	} else if elapsed := now.Sub(b.last); interval > 0 && elapsed > 0 {
+		// A clock stepped backwards refills nothing, rather than draining
+		// the bucket for as long as it was stepped.
+		b.tokens += float64(elapsed) / float64(interval)
+	}
+	if b.tokens > float64(burst) {
+		b.tokens = float64(burst)
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// EventLimits bounds how often schedule transitions are delivered to a
+// subscriber, so that a flapping configuration cannot flood it. Transitions
+// over the limit are delayed rather than dropped: once allowed, the latest
+// schedule is delivered, coalescing any intermediate changes.
+type EventLimits struct {
+	// PerLabel allows PerLabelBurst transitions of a label at once, and one
+	// more every PerLabel.
+	PerLabel      time.Duration
+	PerLabelBurst int
+	// Global allows GlobalBurst transitions across all labels at once, and
+	// one more every Global.
+	Global      time.Duration
+	GlobalBurst int
+}
+
+// DefaultEventLimits are the limits applied to event streams.
+var DefaultEventLimits = EventLimits{
+	PerLabel:      time.Minute,
+	PerLabelBurst: 3,
+	Global:        5 * time.Second,
+	GlobalBurst:   20,
+}
+
+// transitionLimiter applies EventLimits to a single subscriber.
+type transitionLimiter struct {
+	limits EventLimits
+	global bucket
+	labels map[string]*bucket
+}
+
+func newTransitionLimiter(l EventLimits) *transitionLimiter {
+	return &transitionLimiter{limits: l, labels: make(map[string]*bucket)}
+}
+
+// allow reports whether a transition of label may be delivered at now.
+func (t *transitionLimiter) allow(label string, now time.Time) bool {
+	b, ok := t.labels[label]
+	if !ok {
+		b = &bucket{}
+		t.labels[label] = b
+	}
+	// Check the label first so that one flapping label does not spend the
+	// global budget of the others.
+	if !b.allow(now, t.limits.PerLabelBurst, t.limits.PerLabel) {
+		return false
+	}
+	if !t.global.allow(now, t.limits.GlobalBurst, t.limits.Global) {
+		// Refund the label token; nothing was delivered.
+		b.tokens++
+		return false
+	}
+	return true
+}
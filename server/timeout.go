@@ -0,0 +1,117 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// handlerTimeout is the timeout applied to handlers by muxRouter.
+var handlerTimeout = DefaultConfig.HandlerTimeout
+
+// retryAfter is advertised to clients of requests that timed out.
+const retryAfter = 5 * time.Second
+
+// timeoutWriter buffers a response until the handler writing it returns,
+// discarding it if the handler has timed out.
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	status   int
+	body     bytes.Buffer
+	timedOut bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if !tw.timedOut && tw.status == 0 {
+		tw.status = status
+	}
+}
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+	return tw.body.Write(p)
+}
+
+// withTimeout bounds the time taken by handlers to d. Handlers receive a
+// request context that is done after d, and should abandon their work when
+// it is. A handler that takes longer has its response replaced by 503
+// Service Unavailable with a Retry-After header, rather than having the
+// connection closed by the server's write timeout. Nothing is sent if the
+// client has gone away. A d of zero or less disables the timeout.
+func withTimeout(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if d <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+			tw := &timeoutWriter{header: make(http.Header)}
+			done := make(chan struct{})
+			panicked := make(chan any, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+						return
+					}
+					close(done)
+				}()
+				next.ServeHTTP(tw, r.WithContext(ctx))
+			}()
+			select {
+			case p := <-panicked:
+				panic(p)
+			case <-done:
+				if ctx.Err() != context.DeadlineExceeded {
+					for k, v := range tw.header {
+						w.Header()[k] = v
+					}
+					if tw.status == 0 {
+						tw.status = http.StatusOK
+					}
+					sendHTTPResponse(w, tw.status, tw.body.Bytes())
+					return
+				}
+			case <-ctx.Done():
+			}
+			tw.mu.Lock()
+			tw.timedOut = true
+			tw.mu.Unlock()
+			if r.Context().Err() != nil {
+				return
+			}
+			sendUnavailable(w, retryAfter, "request timed out")
+		})
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package signing signs and verifies Aukera responses using a host ed25519
+// key, encoded as detached JSON Web Signatures (RFC 7515, RFC 8037).
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/aukera/auklib"
+)
+
+// Header is the HTTP response header carrying the signature.
+const Header = "Aukera-Signature"
+
+var (
+	// KeyPath is the location of the host private key.
+	KeyPath = filepath.Join(auklib.DataDir, "signing.key")
+	// PublicKeyPath is the location of the host public key, readable by
+	// unprivileged consumers.
+	PublicKeyPath = filepath.Join(auklib.DataDir, "signing.pub")
+
+	// ErrInvalidSignature is returned when a signature does not verify.
+	ErrInvalidSignature = errors.New("invalid signature")
+)
+
+// protected is the encoded JWS protected header.
+var protected = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"EdDSA"}`))
+
+// LoadOrCreateKey loads the host private key from KeyPath, generating and
+// persisting a new key pair if none exists.
+func LoadOrCreateKey() (ed25519.PrivateKey, error) {
+	b, err := os.ReadFile(KeyPath)
+	if err == nil {
+		return parsePrivateKey(b)
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("LoadOrCreateKey: %w", err)
+	}
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("LoadOrCreateKey: %w", err)
+	}
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return nil, fmt.Errorf("LoadOrCreateKey: %w", err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, fmt.Errorf("LoadOrCreateKey: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(KeyPath), 0755); err != nil {
+		return nil, fmt.Errorf("LoadOrCreateKey: %w", err)
+	}
+	if err := os.WriteFile(KeyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}), 0600); err != nil {
+		return nil, fmt.Errorf("LoadOrCreateKey: %w", err)
+	}
+	if err := os.WriteFile(PublicKeyPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0644); err != nil {
+		return nil, fmt.Errorf("LoadOrCreateKey: %w", err)
+	}
+	return priv, nil
+}
+
+func parsePrivateKey(b []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("parsePrivateKey: no PEM data in %q", KeyPath)
+	}
+	k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsePrivateKey: %w", err)
+	}
+	priv, ok := k.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("parsePrivateKey: %q is not an ed25519 key", KeyPath)
+	}
+	return priv, nil
+}
+
+// LoadPublicKey loads the host public key from PublicKeyPath.
+func LoadPublicKey() (ed25519.PublicKey, error) {
+	b, err := os.ReadFile(PublicKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("LoadPublicKey: %w", err)
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("LoadPublicKey: no PEM data in %q", PublicKeyPath)
+	}
+	k, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("LoadPublicKey: %w", err)
+	}
+	pub, ok := k.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("LoadPublicKey: %q is not an ed25519 key", PublicKeyPath)
+	}
+	return pub, nil
+}
+
+func signingInput(payload []byte) []byte {
+	return []byte(protected + "." + base64.RawURLEncoding.EncodeToString(payload))
+}
+
+// Sign returns a detached JWS of payload.
+func Sign(key ed25519.PrivateKey, payload []byte) string {
+	sig := ed25519.Sign(key, signingInput(payload))
+	return protected + ".." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+// Verify checks that jws is a valid detached signature of payload by pub.
+func Verify(pub ed25519.PublicKey, payload []byte, jws string) error {
+	parts := strings.Split(jws, ".")
+	if len(parts) != 3 || parts[1] != "" {
+		return fmt.Errorf("%w: malformed detached JWS", ErrInvalidSignature)
+	}
+	if parts[0] != protected {
+		return fmt.Errorf("%w: unsupported header", ErrInvalidSignature)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	if !ed25519.Verify(pub, signingInput(payload), sig) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
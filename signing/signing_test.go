@@ -0,0 +1,64 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signing
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestSignVerify(t *testing.T) {
+	dir := t.TempDir()
+	origKey, origPub := KeyPath, PublicKeyPath
+	defer func() { KeyPath, PublicKeyPath = origKey, origPub }()
+	KeyPath, PublicKeyPath = filepath.Join(dir, "signing.key"), filepath.Join(dir, "signing.pub")
+
+	priv, err := LoadOrCreateKey()
+	if err != nil {
+		t.Fatalf("LoadOrCreateKey() returned error: %v", err)
+	}
+	again, err := LoadOrCreateKey()
+	if err != nil {
+		t.Fatalf("LoadOrCreateKey() on existing key returned error: %v", err)
+	}
+	if !priv.Equal(again) {
+		t.Errorf("LoadOrCreateKey() generated a new key when one existed")
+	}
+	pub, err := LoadPublicKey()
+	if err != nil {
+		t.Fatalf("LoadPublicKey() returned error: %v", err)
+	}
+
+	payload := []byte(`[{"Name":"patch","State":"open"}]`)
+	jws := Sign(priv, payload)
+	if err := Verify(pub, payload, jws); err != nil {
+		t.Errorf("Verify() of valid signature returned error: %v", err)
+	}
+	tests := []struct {
+		desc    string
+		payload []byte
+		jws     string
+	}{
+		{"tampered payload", []byte(`[{"Name":"patch","State":"closed"}]`), jws},
+		{"malformed", payload, "not-a-jws"},
+		{"attached payload", payload, "a.b.c"},
+	}
+	for _, tt := range tests {
+		if err := Verify(pub, tt.payload, tt.jws); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("Verify(%s) returned %v, want %v", tt.desc, err, ErrInvalidSignature)
+		}
+	}
+}
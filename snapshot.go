@@ -0,0 +1,79 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/aukera/snapshot"
+)
+
+// parseSnapshotTime accepts RFC 3339 times, or durations such as 2h meaning
+// that long ago.
+func parseSnapshotTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: must be RFC 3339 or a duration ago such as 2h", s)
+}
+
+// runSnapshot implements the snapshot subcommand for inspecting recorded
+// schedule snapshots.
+func runSnapshot(args []string) error {
+	fs := flag.NewFlagSet("snapshot", flag.ContinueOnError)
+	dir := fs.String("dir", snapshot.Dir, "Directory snapshots are stored in")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: aukera snapshot [-dir path] list | diff <t1> <t2>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	switch fs.Arg(0) {
+	case "list":
+		times, err := snapshot.List(*dir)
+		if err != nil {
+			return fmt.Errorf("snapshot: %v", err)
+		}
+		for _, t := range times {
+			fmt.Println(t.Format(time.RFC3339))
+		}
+		return nil
+	case "diff":
+		if fs.NArg() != 3 {
+			fs.Usage()
+			return fmt.Errorf("snapshot: diff requires two times")
+		}
+		var snaps [2]snapshot.Snapshot
+		for i, arg := range fs.Args()[1:] {
+			t, err := parseSnapshotTime(arg)
+			if err != nil {
+				return fmt.Errorf("snapshot: %v", err)
+			}
+			if snaps[i], err = snapshot.At(*dir, t); err != nil {
+				return fmt.Errorf("snapshot: %v", err)
+			}
+		}
+		return snapshot.WriteDiff(os.Stdout, snaps[0], snaps[1])
+	}
+	fs.Usage()
+	return fmt.Errorf("snapshot: unknown command %q", fs.Arg(0))
+}
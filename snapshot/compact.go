@@ -0,0 +1,145 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/aukera/auklib"
+)
+
+// gzSuffix is appended to the names of compressed snapshots.
+const gzSuffix = ".gz"
+
+var (
+	// CompressAfter is the age at which snapshots are compressed. Zero
+	// disables compression.
+	CompressAfter = 24 * time.Hour
+	// MaxBytes bounds the disk space used by snapshots. The oldest
+	// snapshots are removed once it is exceeded, though the most recent
+	// snapshot is always kept. Zero removes the bound.
+	MaxBytes int64 = 64 << 20
+)
+
+// Compact removes snapshots in dir taken more than retention before now,
+// compresses those older than CompressAfter and removes the oldest of the
+// remainder until they fit within MaxBytes, then reports the disk space
+// used by snapshots.
+func Compact(dir string, retention time.Duration, now time.Time) error {
+	if err := Prune(dir, retention, now); err != nil {
+		return err
+	}
+	if CompressAfter > 0 {
+		if err := Compress(dir, CompressAfter, now); err != nil {
+			return err
+		}
+	}
+	if MaxBytes > 0 {
+		if err := Trim(dir, MaxBytes); err != nil {
+			return err
+		}
+	}
+	files, err := list(dir)
+	if err != nil {
+		return fmt.Errorf("Compact: %w", err)
+	}
+	var total int64
+	for _, f := range files {
+		total += f.size
+	}
+	auklib.ReportInt("snapshot_disk_bytes", total, nil)
+	auklib.ReportInt("snapshot_files", int64(len(files)), nil)
+	return nil
+}
+
+// Compress gzip compresses the snapshots in dir taken more than age before
+// now.
+func Compress(dir string, age time.Duration, now time.Time) error {
+	files, err := list(dir)
+	if err != nil {
+		return fmt.Errorf("Compress: %w", err)
+	}
+	for _, f := range files {
+		if now.Sub(f.taken) <= age {
+			break
+		}
+		if f.compressed {
+			continue
+		}
+		path := filepath.Join(dir, f.name)
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("Compress: %w", err)
+		}
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write(b)
+		if err := zw.Close(); err != nil {
+			return fmt.Errorf("Compress: %w", err)
+		}
+		if err := auklib.WriteFileAtomic(path+gzSuffix, buf.Bytes(), 0644); err != nil {
+			return fmt.Errorf("Compress: %w", err)
+		}
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("Compress: %w", err)
+		}
+	}
+	return nil
+}
+
+// Trim removes the oldest snapshots in dir until the remainder uses at most
+// max bytes, always keeping the most recent snapshot.
+func Trim(dir string, max int64) error {
+	files, err := list(dir)
+	if err != nil {
+		return fmt.Errorf("Trim: %w", err)
+	}
+	var total int64
+	for _, f := range files {
+		total += f.size
+	}
+	for i := 0; total > max && i < len(files)-1; i++ {
+		if err := os.Remove(filepath.Join(dir, files[i].name)); err != nil {
+			return fmt.Errorf("Trim: %w", err)
+		}
+		total -= files[i].size
+	}
+	return nil
+}
+
+// read returns the content of the snapshot at path, decompressing it if
+// compressed is set.
+func read(path string, compressed bool) ([]byte, error) {
+	if !compressed {
+		return os.ReadFile(path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
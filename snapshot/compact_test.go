@@ -0,0 +1,86 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/aukera/window"
+)
+
+func TestCompact(t *testing.T) {
+	defer func(c time.Duration, m int64) { CompressAfter, MaxBytes = c, m }(CompressAfter, MaxBytes)
+	dir := t.TempDir()
+	base := time.Date(2026, time.March, 1, 3, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		taken := base.Add(time.Duration(i) * 24 * time.Hour)
+		s := Snapshot{Taken: taken, Schedules: []window.Schedule{{Name: "patch", Opens: taken, Closes: taken.Add(time.Hour), Duration: time.Hour}}}
+		if _, err := Write(dir, s); err != nil {
+			t.Fatalf("Write() returned error: %v", err)
+		}
+	}
+	now := base.Add(3*24*time.Hour + time.Hour)
+	CompressAfter, MaxBytes = 36*time.Hour, 0
+	if err := Compact(dir, 30*24*time.Hour, now); err != nil {
+		t.Fatalf("Compact() returned error: %v", err)
+	}
+	files, err := list(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var compressed []bool
+	for _, f := range files {
+		compressed = append(compressed, f.compressed)
+		if f.compressed != strings.HasSuffix(f.name, gzSuffix) {
+			t.Errorf("list() entry %s has compressed = %t", f.name, f.compressed)
+		}
+	}
+	if len(compressed) != 4 || !compressed[0] || !compressed[1] || compressed[2] || compressed[3] {
+		t.Errorf("Compact() left snapshots compressed as %v, want the two older than %v compressed", compressed, CompressAfter)
+	}
+
+	// Compressed snapshots remain readable.
+	s, err := At(dir, base.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("At() of a compressed snapshot returned error: %v", err)
+	}
+	if !s.Taken.Equal(base) || len(s.Schedules) != 1 {
+		t.Errorf("At() of a compressed snapshot = %+v, want the snapshot taken at %v", s, base)
+	}
+	if times, _ := List(dir); len(times) != 4 {
+		t.Errorf("List() = %v, want 4 snapshots", times)
+	}
+
+	// Exceeding MaxBytes removes the oldest snapshots, keeping the latest.
+	latest := files[len(files)-1]
+	MaxBytes = latest.size + files[2].size
+	if err := Compact(dir, 30*24*time.Hour, now); err != nil {
+		t.Fatalf("Compact() returned error: %v", err)
+	}
+	if times, _ := List(dir); len(times) != 2 || !times[1].Equal(latest.taken) {
+		t.Errorf("List() after exceeding MaxBytes = %v, want the two most recent snapshots", times)
+	}
+	MaxBytes = 1
+	if err := Trim(dir, MaxBytes); err != nil {
+		t.Fatalf("Trim() returned error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, latest.name)); err != nil {
+		t.Errorf("Trim() below the size of one snapshot removed the latest: %v", err)
+	}
+}
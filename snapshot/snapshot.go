@@ -0,0 +1,248 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package snapshot records the schedules Aukera computed over time, so that
+// what it believed at any moment can be reconstructed after an incident.
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/deck"
+	"github.com/google/aukera/auklib"
+	"github.com/google/aukera/window"
+)
+
+// Dir is the default directory snapshots are stored in.
+var Dir = filepath.Join(auklib.DataDir, "snapshots")
+
+const (
+	prefix = "snapshot-"
+	suffix = ".json"
+	// stamp is the file name timestamp layout, which sorts chronologically.
+	stamp = "20060102T150405Z"
+)
+
+// ErrNoSnapshot is returned when no snapshot was taken at or before a time.
+var ErrNoSnapshot = errors.New("no snapshot found")
+
+// Snapshot is the computed schedule of every label at a point in time.
+type Snapshot struct {
+	Taken     time.Time
+	Schedules []window.Schedule
+}
+
+func fileName(t time.Time) string {
+	return prefix + t.UTC().Format(stamp) + suffix
+}
+
+// Write stores s in dir and returns the path written.
+func Write(dir string, s Snapshot) (string, error) {
+	b, err := json.MarshalIndent(&s, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("Write: %w", err)
+	}
+	path := filepath.Join(dir, fileName(s.Taken))
+	if err := auklib.WriteFileAtomic(path, b, 0644); err != nil {
+		return "", fmt.Errorf("Write: %w", err)
+	}
+	return path, nil
+}
+
+// List returns the times of all snapshots in dir, oldest first.
+func List(dir string) ([]time.Time, error) {
+	files, err := list(dir)
+	if err != nil {
+		return nil, fmt.Errorf("List: %w", err)
+	}
+	var out []time.Time
+	for _, f := range files {
+		out = append(out, f.taken)
+	}
+	return out, nil
+}
+
+// file is a snapshot file, which may be compressed.
+type file struct {
+	name       string
+	taken      time.Time
+	size       int64
+	compressed bool
+}
+
+// list returns the snapshot files in dir, oldest first.
+func list(dir string) ([]file, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var out []file
+	for _, e := range entries {
+		n := e.Name()
+		f := file{name: n, compressed: strings.HasSuffix(n, gzSuffix)}
+		ts := strings.TrimSuffix(n, gzSuffix)
+		if !strings.HasPrefix(ts, prefix) || !strings.HasSuffix(ts, suffix) {
+			continue
+		}
+		if f.taken, err = time.Parse(stamp, strings.TrimSuffix(strings.TrimPrefix(ts, prefix), suffix)); err != nil {
+			continue
+		}
+		if info, err := e.Info(); err == nil {
+			f.size = info.Size()
+		}
+		out = append(out, f)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].taken.Before(out[j].taken) })
+	return out, nil
+}
+
+// At returns the most recent snapshot in dir taken at or before t. It
+// returns an error wrapping ErrNoSnapshot if there is none.
+func At(dir string, t time.Time) (Snapshot, error) {
+	var s Snapshot
+	files, err := list(dir)
+	if err != nil {
+		return s, fmt.Errorf("At: %w", err)
+	}
+	i := sort.Search(len(files), func(i int) bool { return files[i].taken.After(t) })
+	if i == 0 {
+		return s, fmt.Errorf("At(%s): %w", t.Format(time.RFC3339), ErrNoSnapshot)
+	}
+	f := files[i-1]
+	b, err := read(filepath.Join(dir, f.name), f.compressed)
+	if err != nil {
+		return s, fmt.Errorf("At: %w", err)
+	}
+	if err := json.Unmarshal(b, &s); err != nil {
+		return s, fmt.Errorf("At: unable to parse snapshot %s: %w", f.name, err)
+	}
+	return s, nil
+}
+
+// Prune removes snapshots in dir taken more than retention before now.
+func Prune(dir string, retention time.Duration, now time.Time) error {
+	files, err := list(dir)
+	if err != nil {
+		return fmt.Errorf("Prune: %w", err)
+	}
+	for _, f := range files {
+		if now.Sub(f.taken) <= retention {
+			break
+		}
+		if err := os.Remove(filepath.Join(dir, f.name)); err != nil {
+			return fmt.Errorf("Prune: %w", err)
+		}
+	}
+	return nil
+}
+
+// Change describes how the schedule of a label differs between snapshots.
+// Before or After is nil if the label is absent from that snapshot.
+type Change struct {
+	Label         string
+	Before, After *window.Schedule
+}
+
+func (c Change) String() string {
+	describe := func(s *window.Schedule) string {
+		return fmt.Sprintf("%s [%s, %s)", s.State, s.Opens.Format(time.RFC3339), s.Closes.Format(time.RFC3339))
+	}
+	switch {
+	case c.Before == nil:
+		return fmt.Sprintf("+ %s: %s", c.Label, describe(c.After))
+	case c.After == nil:
+		return fmt.Sprintf("- %s: %s", c.Label, describe(c.Before))
+	}
+	return fmt.Sprintf("~ %s: %s -> %s", c.Label, describe(c.Before), describe(c.After))
+}
+
+// Diff returns the labels whose schedule differs between a and b, ordered by
+// label.
+func Diff(a, b Snapshot) []Change {
+	index := func(s Snapshot) map[string]*window.Schedule {
+		m := make(map[string]*window.Schedule)
+		for i := range s.Schedules {
+			m[s.Schedules[i].Name] = &s.Schedules[i]
+		}
+		return m
+	}
+	before, after := index(a), index(b)
+	var out []Change
+	for l, s := range before {
+		t, ok := after[l]
+		switch {
+		case !ok:
+			out = append(out, Change{Label: l, Before: s})
+		case s.State != t.State || !s.Opens.Equal(t.Opens) || !s.Closes.Equal(t.Closes):
+			out = append(out, Change{Label: l, Before: s, After: t})
+		}
+	}
+	for l, t := range after {
+		if _, ok := before[l]; !ok {
+			out = append(out, Change{Label: l, After: t})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Label < out[j].Label })
+	return out
+}
+
+// WriteDiff writes the changes between a and b to w, one per line.
+func WriteDiff(w io.Writer, a, b Snapshot) error {
+	if _, err := fmt.Fprintf(w, "Snapshots %s and %s\n", a.Taken.Format(time.RFC3339), b.Taken.Format(time.RFC3339)); err != nil {
+		return err
+	}
+	changes := Diff(a, b)
+	if len(changes) == 0 {
+		_, err := fmt.Fprintln(w, "No differences.")
+		return err
+	}
+	for _, c := range changes {
+		if _, err := fmt.Fprintln(w, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run writes a snapshot of the schedules returned by compute to dir every
+// interval, compacting dir with a retention of retention, until ctx is done.
+func Run(ctx context.Context, dir string, interval, retention time.Duration, compute func() ([]window.Schedule, error)) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		now := time.Now()
+		if s, err := compute(); err != nil {
+			deck.Warningf("unable to compute schedules for snapshot: %v", err)
+		} else if _, err := Write(dir, Snapshot{Taken: now, Schedules: s}); err != nil {
+			deck.Warningf("unable to write schedule snapshot: %v", err)
+		}
+		if err := Compact(dir, retention, now); err != nil {
+			deck.Warningf("unable to compact schedule snapshots: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/aukera/window"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWriteAt(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2026, time.March, 1, 3, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		taken := base.Add(time.Duration(i) * 10 * time.Minute)
+		s := Snapshot{Taken: taken, Schedules: []window.Schedule{{Name: "patch", Opens: taken, Closes: taken.Add(time.Hour), Duration: time.Hour}}}
+		if _, err := Write(dir, s); err != nil {
+			t.Fatalf("Write() returned error: %v", err)
+		}
+	}
+	times, err := List(dir)
+	if err != nil {
+		t.Fatalf("List() returned error: %v", err)
+	}
+	if len(times) != 3 || !times[0].Equal(base) {
+		t.Errorf("List() = %v, want 3 snapshots from %v", times, base)
+	}
+
+	s, err := At(dir, base.Add(12*time.Minute))
+	if err != nil {
+		t.Fatalf("At() returned error: %v", err)
+	}
+	if want := base.Add(10 * time.Minute); !s.Taken.Equal(want) || len(s.Schedules) != 1 || !s.Schedules[0].Opens.Equal(want) {
+		t.Errorf("At(03:12) = %+v, want snapshot taken at %v", s, want)
+	}
+	if _, err := At(dir, base.Add(-time.Second)); !errors.Is(err, ErrNoSnapshot) {
+		t.Errorf("At() before the first snapshot returned %v, want %v", err, ErrNoSnapshot)
+	}
+
+	if err := Prune(dir, 15*time.Minute, base.Add(25*time.Minute)); err != nil {
+		t.Fatalf("Prune() returned error: %v", err)
+	}
+	if times, _ := List(dir); len(times) != 2 || !times[0].Equal(base.Add(10*time.Minute)) {
+		t.Errorf("List() after Prune() = %v, want the two most recent snapshots", times)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	now := time.Date(2026, time.March, 1, 3, 0, 0, 0, time.UTC)
+	a := Snapshot{Taken: now, Schedules: []window.Schedule{
+		{Name: "patch", State: window.StateClosed, Opens: now.Add(time.Hour), Closes: now.Add(2 * time.Hour)},
+		{Name: "reboot", State: window.StateOpen, Opens: now, Closes: now.Add(time.Hour)},
+		{Name: "retired", State: window.StateClosed, Opens: now.Add(time.Hour), Closes: now.Add(2 * time.Hour)},
+	}}
+	b := Snapshot{Taken: now.Add(time.Hour), Schedules: []window.Schedule{
+		{Name: "added", State: window.StateOpen, Opens: now, Closes: now.Add(2 * time.Hour)},
+		{Name: "patch", State: window.StateOpen, Opens: now.Add(time.Hour), Closes: now.Add(2 * time.Hour)},
+		{Name: "reboot", State: window.StateOpen, Opens: now, Closes: now.Add(time.Hour)},
+	}}
+	var got []string
+	for _, c := range Diff(a, b) {
+		got = append(got, c.Label)
+	}
+	if diff := cmp.Diff([]string{"added", "patch", "retired"}, got); diff != "" {
+		t.Errorf("Diff() returned unexpected labels (-want +got):\n%s", diff)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteDiff(&buf, a, b); err != nil {
+		t.Fatalf("WriteDiff() returned error: %v", err)
+	}
+	for _, want := range []string{"+ added: open", "~ patch: closed", "-> open", "- retired: closed"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("WriteDiff() output missing %q:\n%s", want, buf.String())
+		}
+	}
+	buf.Reset()
+	if err := WriteDiff(&buf, a, a); err != nil || !strings.Contains(buf.String(), "No differences.") {
+		t.Errorf("WriteDiff() of identical snapshots = %q, %v", buf.String(), err)
+	}
+}
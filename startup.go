@@ -0,0 +1,118 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/deck"
+	"github.com/google/aukera/auklib"
+	"github.com/google/aukera/schedule"
+	"github.com/google/aukera/window"
+)
+
+// configSummary describes the configuration loaded at startup.
+type configSummary struct {
+	// Files counts configuration files by window.FileCheck status.
+	Files   map[string]int
+	Windows int
+	Labels  int
+}
+
+func (s configSummary) String() string {
+	var files []string
+	for _, st := range []string{window.CheckOK, window.CheckWarning, window.CheckError} {
+		files = append(files, fmt.Sprintf("%d %s", s.Files[st], st))
+	}
+	return fmt.Sprintf("%d windows with %d labels loaded from configuration files (%s)", s.Windows, s.Labels, strings.Join(files, ", "))
+}
+
+// errNoValidWindows is returned by loadConfig when no valid windows load.
+var errNoValidWindows = errors.New("no valid windows found")
+
+// loadedConfig is the configuration in auklib.ConfDir as the service loads
+// it: the check of each configuration file, and the valid windows.
+type loadedConfig struct {
+	Checks  []window.FileCheck
+	Windows []window.Window
+}
+
+// loadConfig checks and loads the configuration in auklib.ConfDir as the
+// service will serve it. It returns the configuration along with an error
+// wrapping errNoValidWindows if no valid windows load.
+func loadConfig() (loadedConfig, error) {
+	var c loadedConfig
+	var err error
+	if c.Checks, err = window.Check(auklib.ConfDir, window.Reader{}); err != nil {
+		return c, fmt.Errorf("checking configuration: %v", err)
+	}
+	if c.Windows, err = schedule.Windows(); err != nil {
+		return c, fmt.Errorf("loading configuration: %v", err)
+	}
+	if len(c.Windows) == 0 {
+		return c, fmt.Errorf("%w in %q", errNoValidWindows, auklib.ConfDir)
+	}
+	return c, nil
+}
+
+// summarizeConfig loads the configuration as by loadConfig, then logs and
+// reports metrics summarizing it. Configuration without valid windows is
+// summarized along with the error.
+func summarizeConfig() (configSummary, error) {
+	s := configSummary{Files: make(map[string]int)}
+	c, err := loadConfig()
+	if err != nil && !errors.Is(err, errNoValidWindows) {
+		return s, err
+	}
+	for _, fc := range c.Checks {
+		s.Files[fc.Status]++
+	}
+	labels := make(map[string]bool)
+	for _, w := range c.Windows {
+		for _, l := range w.Labels {
+			labels[strings.ToLower(l)] = true
+		}
+	}
+	s.Windows, s.Labels = len(c.Windows), len(labels)
+
+	deck.Infof("Startup configuration: %s.", s)
+	for st, n := range s.Files {
+		auklib.ReportInt("startup_config_files", int64(n), map[string]string{"status": st})
+	}
+	auklib.ReportInt("startup_windows", int64(s.Windows), nil)
+	auklib.ReportInt("startup_labels", int64(s.Labels), nil)
+	return s, err
+}
+
+// checkStartupConfig summarizes the configuration at startup. With
+// -require_valid_config, it returns an error if no valid windows load, so
+// that the service refuses to start rather than run without a schedule.
+func checkStartupConfig() error {
+	_, err := summarizeConfig()
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, errNoValidWindows):
+		deck.Warningf("Startup configuration: %v.", err)
+	default:
+		deck.Warningf("Unable to summarize startup configuration: %v", err)
+	}
+	if *requireValid {
+		return err
+	}
+	return nil
+}
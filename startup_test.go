@@ -0,0 +1,72 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/aukera/auklib"
+	"github.com/google/aukera/window"
+)
+
+func TestCheckStartupConfig(t *testing.T) {
+	defer func(dir string, require bool) { auklib.ConfDir, *requireValid = dir, require }(auklib.ConfDir, *requireValid)
+	tests := []struct {
+		desc    string
+		files   map[string]string
+		windows int
+		failed  int
+		// wantErr reports whether checkStartupConfig fails with
+		// -require_valid_config. It never fails without it.
+		wantErr bool
+	}{
+		{desc: "empty", wantErr: true},
+		{
+			desc:    "invalid",
+			files:   map[string]string{"bad.json": `{"Windows": [{"Name": "bad", "Format": 1, "Schedule": "not cron", "Duration": "1h", "Labels": ["patch"]}]}`},
+			failed:  1,
+			wantErr: true,
+		},
+		{
+			desc: "valid",
+			files: map[string]string{
+				"patch.json": `{"Windows": [{"Name": "nightly", "Format": 1, "Schedule": "0 0 2 * * *", "Duration": "1h", "Labels": ["patch", "reboot"]}]}`,
+			},
+			windows: 1,
+		},
+	}
+	for _, tt := range tests {
+		auklib.ConfDir = t.TempDir()
+		for name, content := range tt.files {
+			if err := os.WriteFile(filepath.Join(auklib.ConfDir, name), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		s, err := summarizeConfig()
+		if s.Windows != tt.windows || s.Files[window.CheckError] != tt.failed || errors.Is(err, errNoValidWindows) != (tt.windows == 0) {
+			t.Errorf("%s: summarizeConfig() = %v, %v; want %d windows and %d files in error", tt.desc, s, err, tt.windows, tt.failed)
+		}
+		for _, require := range []bool{false, true} {
+			*requireValid = require
+			err := checkStartupConfig()
+			if got := err != nil; got != (require && tt.wantErr) {
+				t.Errorf("%s: checkStartupConfig() with -require_valid_config=%t returned %v, want error %t", tt.desc, require, err, require && tt.wantErr)
+			}
+		}
+	}
+}
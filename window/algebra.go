@@ -0,0 +1,126 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package window
+
+import (
+	"sort"
+	"time"
+)
+
+// The functions below treat schedules as sets of time: each Schedule is the
+// half-open interval [Opens, Closes). They return normalized schedules:
+// sorted by Opens, non-empty, and neither overlapping nor adjacent. Results
+// take their Name from the first schedule of the left-hand operand they
+// cover, and their Duration and State are recalculated.
+
+// normalize returns a sorted copy of s with empty schedules dropped and
+// overlapping or adjacent schedules combined.
+func normalize(s []Schedule) []Schedule {
+	var in []Schedule
+	for _, x := range s {
+		if x.Opens.Before(x.Closes) {
+			in = append(in, x)
+		}
+	}
+	sort.SliceStable(in, func(i, j int) bool { return in[i].Opens.Before(in[j].Opens) })
+	var out []Schedule
+	for _, x := range in {
+		if n := len(out); n > 0 && !x.Opens.After(out[n-1].Closes) {
+			if x.Closes.After(out[n-1].Closes) {
+				out[n-1].Closes = x.Closes
+			}
+			continue
+		}
+		out = append(out, x)
+	}
+	for i := range out {
+		out[i].update()
+	}
+	return out
+}
+
+// clip returns s limited to [from, to), and whether anything remains.
+func clip(s Schedule, from, to time.Time) (Schedule, bool) {
+	if s.Opens.Before(from) {
+		s.Opens = from
+	}
+	if s.Closes.After(to) {
+		s.Closes = to
+	}
+	s.update()
+	return s, s.Opens.Before(s.Closes)
+}
+
+// Union returns the time covered by either a or b.
+func Union(a, b []Schedule) []Schedule {
+	return normalize(append(append([]Schedule(nil), a...), b...))
+}
+
+// Intersect returns the time covered by both a and b.
+func Intersect(a, b []Schedule) []Schedule {
+	a, b = normalize(a), normalize(b)
+	var out []Schedule
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		if s, ok := clip(a[i], b[j].Opens, b[j].Closes); ok {
+			out = append(out, s)
+		}
+		// Advance whichever interval ends first.
+		if a[i].Closes.Before(b[j].Closes) {
+			i++
+		} else {
+			j++
+		}
+	}
+	return normalize(out)
+}
+
+// Subtract returns the time covered by a but not by deny.
+func Subtract(a, deny []Schedule) []Schedule {
+	a, deny = normalize(a), normalize(deny)
+	var out []Schedule
+	j := 0
+	for _, s := range a {
+		// Skip deny intervals that end before s begins.
+		for j < len(deny) && !deny[j].Closes.After(s.Opens) {
+			j++
+		}
+		cur := s
+		for k := j; k < len(deny) && deny[k].Opens.Before(cur.Closes); k++ {
+			if before, ok := clip(cur, cur.Opens, deny[k].Opens); ok {
+				out = append(out, before)
+			}
+			cur.Opens = deny[k].Closes
+			if !cur.Opens.Before(cur.Closes) {
+				break
+			}
+		}
+		if cur.Opens.Before(cur.Closes) {
+			cur.update()
+			out = append(out, cur)
+		}
+	}
+	return normalize(out)
+}
+
+// ClipTo returns the time covered by s within [from, to).
+func ClipTo(s []Schedule, from, to time.Time) []Schedule {
+	var out []Schedule
+	for _, x := range normalize(s) {
+		if c, ok := clip(x, from, to); ok {
+			out = append(out, c)
+		}
+	}
+	return out
+}
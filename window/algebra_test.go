@@ -0,0 +1,155 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package window
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var algebraBase = time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+// hours builds schedules from pairs of hour offsets from algebraBase.
+func hours(name string, pairs ...int) []Schedule {
+	var out []Schedule
+	for i := 0; i+1 < len(pairs); i += 2 {
+		out = append(out, Schedule{
+			Name:     name,
+			Opens:    algebraBase.Add(time.Duration(pairs[i]) * time.Hour),
+			Closes:   algebraBase.Add(time.Duration(pairs[i+1]) * time.Hour),
+			Duration: time.Duration(pairs[i+1]-pairs[i]) * time.Hour,
+		})
+	}
+	return out
+}
+
+// spans renders schedules as name[opens,closes) in hour offsets.
+func spans(s []Schedule) []string {
+	out := []string{}
+	for _, x := range s {
+		out = append(out, fmt.Sprintf("%s[%d,%d)", x.Name, int(x.Opens.Sub(algebraBase).Hours()), int(x.Closes.Sub(algebraBase).Hours())))
+		if x.Duration != x.Closes.Sub(x.Opens) {
+			out = append(out, fmt.Sprintf("bad duration %v", x.Duration))
+		}
+	}
+	return out
+}
+
+func TestUnion(t *testing.T) {
+	tests := []struct {
+		desc string
+		a, b []Schedule
+		want []string
+	}{
+		{"empty", nil, nil, []string{}},
+		{"disjoint", hours("a", 0, 1), hours("b", 2, 3), []string{"a[0,1)", "b[2,3)"}},
+		{"overlapping", hours("a", 0, 2), hours("b", 1, 3), []string{"a[0,3)"}},
+		{"adjacent", hours("a", 0, 1), hours("b", 1, 2), []string{"a[0,2)"}},
+		{"contained", hours("a", 0, 4), hours("b", 1, 2), []string{"a[0,4)"}},
+		{"unsorted input", hours("a", 5, 6, 0, 1), hours("b", 3, 4), []string{"a[0,1)", "b[3,4)", "a[5,6)"}},
+		{"chain", hours("a", 0, 2, 4, 6), hours("b", 1, 5), []string{"a[0,6)"}},
+		{"empty schedule dropped", hours("a", 2, 2), hours("b", 3, 4), []string{"b[3,4)"}},
+	}
+	for _, tt := range tests {
+		if diff := cmp.Diff(tt.want, spans(Union(tt.a, tt.b))); diff != "" {
+			t.Errorf("Union(%s) returned diff (-want +got):\n%s", tt.desc, diff)
+		}
+	}
+}
+
+func TestIntersect(t *testing.T) {
+	tests := []struct {
+		desc string
+		a, b []Schedule
+		want []string
+	}{
+		{"empty", hours("a", 0, 1), nil, []string{}},
+		{"disjoint", hours("a", 0, 1), hours("b", 2, 3), []string{}},
+		{"adjacent", hours("a", 0, 1), hours("b", 1, 2), []string{}},
+		{"overlapping", hours("a", 0, 2), hours("b", 1, 3), []string{"a[1,2)"}},
+		{"contained", hours("a", 0, 4), hours("b", 1, 2), []string{"a[1,2)"}},
+		{"identical", hours("a", 0, 4), hours("b", 0, 4), []string{"a[0,4)"}},
+		{"many", hours("a", 0, 10), hours("b", 1, 2, 3, 4, 9, 12), []string{"a[1,2)", "a[3,4)", "a[9,10)"}},
+		{"both sides", hours("a", 0, 3, 5, 8), hours("b", 2, 6), []string{"a[2,3)", "a[5,6)"}},
+	}
+	for _, tt := range tests {
+		if diff := cmp.Diff(tt.want, spans(Intersect(tt.a, tt.b))); diff != "" {
+			t.Errorf("Intersect(%s) returned diff (-want +got):\n%s", tt.desc, diff)
+		}
+	}
+}
+
+func TestSubtract(t *testing.T) {
+	tests := []struct {
+		desc    string
+		a, deny []Schedule
+		want    []string
+	}{
+		{"nothing denied", hours("a", 0, 4), nil, []string{"a[0,4)"}},
+		{"disjoint", hours("a", 0, 1), hours("d", 2, 3), []string{"a[0,1)"}},
+		{"adjacent before", hours("a", 1, 2), hours("d", 0, 1), []string{"a[1,2)"}},
+		{"adjacent after", hours("a", 1, 2), hours("d", 2, 3), []string{"a[1,2)"}},
+		{"fully denied", hours("a", 1, 2), hours("d", 0, 3), []string{}},
+		{"exactly denied", hours("a", 1, 2), hours("d", 1, 2), []string{}},
+		{"middle", hours("a", 0, 4), hours("d", 1, 2), []string{"a[0,1)", "a[2,4)"}},
+		{"start", hours("a", 0, 4), hours("d", 0, 1), []string{"a[1,4)"}},
+		{"end", hours("a", 0, 4), hours("d", 3, 5), []string{"a[0,3)"}},
+		{"several holes", hours("a", 0, 10), hours("d", 1, 2, 4, 5, 9, 11), []string{"a[0,1)", "a[2,4)", "a[5,9)"}},
+		{"deny spans schedules", hours("a", 0, 2, 3, 5), hours("d", 1, 4), []string{"a[0,1)", "a[4,5)"}},
+	}
+	for _, tt := range tests {
+		if diff := cmp.Diff(tt.want, spans(Subtract(tt.a, tt.deny))); diff != "" {
+			t.Errorf("Subtract(%s) returned diff (-want +got):\n%s", tt.desc, diff)
+		}
+	}
+}
+
+func TestClipTo(t *testing.T) {
+	from, to := algebraBase.Add(2*time.Hour), algebraBase.Add(6*time.Hour)
+	tests := []struct {
+		desc string
+		s    []Schedule
+		want []string
+	}{
+		{"before", hours("a", 0, 2), []string{}},
+		{"after", hours("a", 6, 8), []string{}},
+		{"inside", hours("a", 3, 4), []string{"a[3,4)"}},
+		{"straddles start", hours("a", 1, 3), []string{"a[2,3)"}},
+		{"straddles end", hours("a", 5, 7), []string{"a[5,6)"}},
+		{"covers range", hours("a", 0, 8), []string{"a[2,6)"}},
+		{"several", hours("a", 0, 3, 4, 5, 5, 9), []string{"a[2,3)", "a[4,6)"}},
+	}
+	for _, tt := range tests {
+		if diff := cmp.Diff(tt.want, spans(ClipTo(tt.s, from, to))); diff != "" {
+			t.Errorf("ClipTo(%s) returned diff (-want +got):\n%s", tt.desc, diff)
+		}
+	}
+}
+
+func TestAlgebraDoesNotModifyInput(t *testing.T) {
+	a := hours("a", 5, 6, 0, 2)
+	b := hours("b", 1, 3)
+	want := spans(a)
+	Union(a, b)
+	Intersect(a, b)
+	Subtract(a, b)
+	ClipTo(a, algebraBase, algebraBase.Add(time.Hour))
+	if diff := cmp.Diff(want, spans(a)); diff != "" {
+		t.Errorf("schedule algebra modified its input (-want +got):\n%s", diff)
+	}
+}
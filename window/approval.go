@@ -0,0 +1,169 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package window
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/deck"
+	"github.com/google/aukera/auklib"
+)
+
+// approval records when a window was approved.
+type approval struct {
+	Name     string
+	Approved time.Time
+}
+
+// approvalStore persists window approvals. Approvals are keyed by the
+// window's identity, so editing any field of an approved window requires
+// approving it again, and windows sharing a name are approved separately.
+type approvalStore struct {
+	mu     sync.Mutex
+	path   string
+	loaded bool
+	byID   map[string]approval
+	// gen is incremented by every approval.
+	gen uint64
+}
+
+// ApprovalsFile persists window approvals.
+var ApprovalsFile = filepath.Join(auklib.DataDir, "approvals.json")
+
+var approvals = &approvalStore{}
+
+// file returns the path approvals are persisted to, ApprovalsFile unless
+// the store was created with its own path.
+func (a *approvalStore) file() string {
+	if a.path != "" {
+		return a.path
+	}
+	return ApprovalsFile
+}
+
+// load reads persisted approvals. Must be called with mu held.
+func (a *approvalStore) load() {
+	if a.loaded {
+		return
+	}
+	a.loaded = true
+	a.byID = make(map[string]approval)
+	b, err := os.ReadFile(a.file())
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		deck.Warningf("unable to read window approvals %q: %v", a.file(), err)
+		return
+	}
+	if err := json.Unmarshal(b, &a.byID); err != nil {
+		deck.Warningf("unable to parse window approvals %q: %v", a.file(), err)
+	}
+}
+
+func (a *approvalStore) approved(w Window) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.load()
+	_, ok := a.byID[w.identity()]
+	return ok
+}
+
+func (a *approvalStore) approve(w Window, t time.Time) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	l, err := auklib.LockFile(a.file())
+	if err != nil {
+		return err
+	}
+	defer l.Unlock()
+	// Reload under the lock to keep approvals recorded by other processes.
+	a.loaded = false
+	a.load()
+	a.byID[w.identity()] = approval{Name: w.Name, Approved: t}
+	a.gen++
+	b, err := json.Marshal(a.byID)
+	if err != nil {
+		return err
+	}
+	return auklib.WriteFileAtomic(a.file(), b, 0644)
+}
+
+// ApprovalGeneration returns a counter incremented by every approval, which
+// changes whether windows are pending without changing the configuration.
+func ApprovalGeneration() uint64 {
+	approvals.mu.Lock()
+	defer approvals.mu.Unlock()
+	return approvals.gen
+}
+
+// Pending reports whether the window requires approval it has not received.
+func (w *Window) Pending() bool {
+	return w.RequiresApproval && !approvals.approved(*w)
+}
+
+// pendingLogged holds the identities of the windows last found pending
+// approval, so that each is logged once while it remains pending rather than
+// on every load.
+var pendingLogged = struct {
+	sync.Mutex
+	ids map[string]bool
+}{}
+
+// reportPending logs the windows pending approval in pending, which maps
+// their identities to their names, that were not already pending at the
+// previous load.
+func reportPending(pending map[string]string) {
+	pendingLogged.Lock()
+	defer pendingLogged.Unlock()
+	ids := make(map[string]bool, len(pending))
+	for id, name := range pending {
+		if !pendingLogged.ids[id] {
+			deck.Infof("window(%s): pending approval, excluded from schedules", name)
+		}
+		ids[id] = true
+	}
+	pendingLogged.ids = ids
+}
+
+// Approve approves all windows named name within dir that require approval.
+// It returns an error wrapping ErrNoWindows if there are none.
+func Approve(dir string, cr ConfigReader, name string) error {
+	windows, _, err := loadWindows(dir, cr)
+	if err != nil {
+		return err
+	}
+	var found bool
+	now := time.Now()
+	for _, w := range windows {
+		if w.Name != name || !w.RequiresApproval {
+			continue
+		}
+		found = true
+		if err := approvals.approve(w, now); err != nil {
+			return fmt.Errorf("window(%s): unable to persist approval: %w", name, err)
+		}
+		deck.Infof("window(%s): approved", name)
+	}
+	if !found {
+		return fmt.Errorf("window(%s) requiring approval: %w", name, ErrNoWindows)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package window
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/deck"
+	"github.com/google/deck/backends/logger"
+)
+
+func TestApprove(t *testing.T) {
+	orig := approvals
+	defer func() { approvals = orig }()
+	path := filepath.Join(t.TempDir(), "approvals.json")
+	approvals = &approvalStore{path: path}
+
+	conf := func(schedule string) fileReader {
+		return fileReader{files: map[string]string{
+			"a.json": `{"Windows": [
+				{"Name": "regular", "Format": 1, "Schedule": "0 0 2 * * *", "Duration": "1h", "Labels": ["a"]},
+				{"Name": "emergency", "Format": 1, "Schedule": "` + schedule + `", "Duration": "1h", "Labels": ["a"], "RequiresApproval": true}
+			]}`,
+		}}
+	}
+	r := conf("0 0 3 * * *")
+	count := func(r ConfigReader) int {
+		m, err := Windows("conf", r)
+		if err != nil {
+			t.Fatalf("Windows() returned error: %v", err)
+		}
+		return len(m.Find("a"))
+	}
+
+	if got := count(r); got != 1 {
+		t.Errorf("Windows() before approval returned %d windows, want 1", got)
+	}
+	all, err := AllWindows("conf", r)
+	if err != nil {
+		t.Fatalf("AllWindows() returned error: %v", err)
+	}
+	if got := all.Find("a"); len(got) != 2 || !NewListing(got[0], time.Now()).Pending && !NewListing(got[1], time.Now()).Pending {
+		t.Errorf("AllWindows() before approval = %+v, want both windows, one listed as pending", got)
+	}
+	if err := Approve("conf", r, "regular"); !errors.Is(err, ErrNoWindows) {
+		t.Errorf("Approve(regular) returned %v, want %v", err, ErrNoWindows)
+	}
+	gen := ApprovalGeneration()
+	if err := Approve("conf", r, "emergency"); err != nil {
+		t.Fatalf("Approve(emergency) returned error: %v", err)
+	}
+	if got := ApprovalGeneration(); got == gen {
+		t.Errorf("ApprovalGeneration() after approval = %d, want it changed", got)
+	}
+	if got := count(r); got != 2 {
+		t.Errorf("Windows() after approval returned %d windows, want 2", got)
+	}
+
+	// Approvals persist across restarts but not across definition changes.
+	approvals = &approvalStore{path: path}
+	if got := count(r); got != 2 {
+		t.Errorf("Windows() after reload returned %d windows, want 2", got)
+	}
+	if got := count(conf("0 0 4 * * *")); got != 1 {
+		t.Errorf("Windows() after definition change returned %d windows, want 1", got)
+	}
+}
+
+func TestApprovalIdentity(t *testing.T) {
+	orig := approvals
+	defer func() { approvals = orig }()
+	approvals = &approvalStore{path: filepath.Join(t.TempDir(), "approvals.json")}
+
+	conf := func(maxTask string) fileReader {
+		return fileReader{files: map[string]string{
+			"a.json": `{"Windows": [
+				{"Name": "emergency", "Format": 1, "Schedule": "0 0 3 * * *", "Duration": "1h", "Labels": ["a"], "RequiresApproval": true, "MaxTaskDuration": "` + maxTask + `"}
+			]}`,
+			"b.json": `{"Windows": [
+				{"Name": "emergency", "Format": 1, "Schedule": "0 0 5 * * *", "Duration": "1h", "Labels": ["b"], "RequiresApproval": true}
+			]}`,
+		}}
+	}
+	count := func(r ConfigReader, label string) int {
+		m, err := Windows("conf", r)
+		if err != nil {
+			t.Fatalf("Windows() returned error: %v", err)
+		}
+		return len(m.Find(label))
+	}
+
+	r := conf("30m")
+	if err := Approve("conf", r, "emergency"); err != nil {
+		t.Fatalf("Approve(emergency) returned error: %v", err)
+	}
+	if got := count(r, "a"); got != 1 {
+		t.Errorf("Windows() label a after approval returned %d windows, want 1", got)
+	}
+	if got := count(r, "b"); got != 1 {
+		t.Errorf("Windows() label b after approval returned %d windows, want 1", got)
+	}
+
+	// Editing a field outside the schedule invalidates only that window's
+	// approval, even though the other window shares its name.
+	r = conf("45m")
+	if got := count(r, "a"); got != 0 {
+		t.Errorf("Windows() label a after edit returned %d windows, want 0", got)
+	}
+	if got := count(r, "b"); got != 1 {
+		t.Errorf("Windows() label b after edit returned %d windows, want 1", got)
+	}
+}
+
+func TestReportPending(t *testing.T) {
+	defer func() { pendingLogged.ids = nil }()
+	var logBuffer bytes.Buffer
+	deck.Add(logger.Init(&logBuffer, 0))
+	count := func() int {
+		return strings.Count(logBuffer.String(), "window(emergency): pending approval")
+	}
+
+	for i := 0; i < 3; i++ {
+		reportPending(map[string]string{"id1": "emergency"})
+	}
+	if got := count(); got != 1 {
+		t.Errorf("reportPending() logged %d messages while pending, want 1", got)
+	}
+	// An edited definition has a new identity, so it is logged anew.
+	reportPending(map[string]string{"id2": "emergency"})
+	if got := count(); got != 2 {
+		t.Errorf("reportPending() logged %d messages after an edit, want 2", got)
+	}
+}
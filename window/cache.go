@@ -0,0 +1,227 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package window
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/deck"
+)
+
+// activationCache memoizes computed window activations for the current
+// evaluation minute and generation. Entries are keyed on the window
+// definition, so edited configuration never hits a stale entry, and each
+// expires at the next time its activation may change, so that boundaries
+// within the minute are not served stale.
+type activationCache struct {
+	mu      sync.Mutex
+	minute  time.Time
+	gen     int64
+	entries map[string]activationEntry
+}
+
+// activationEntry is an activation computed at from, valid until until, or
+// for the rest of the minute if until is zero.
+type activationEntry struct {
+	s           Schedule
+	from, until time.Time
+}
+
+var activations = &activationCache{}
+
+// generation is incremented by ResetCache; see Generation.
+var generation int64
+
+// Generation returns a counter incremented each time ResetCache invalidates
+// computed activations. Callers holding schedules computed under an earlier
+// generation should recompute them.
+func Generation() int64 {
+	return atomic.LoadInt64(&generation)
+}
+
+// roll discards entries computed in a previous minute or generation. Must be
+// called with mu held.
+func (c *activationCache) roll(now time.Time) {
+	m, g := now.Truncate(time.Minute), Generation()
+	if !c.minute.Equal(m) || c.gen != g || c.entries == nil {
+		c.minute, c.gen = m, g
+		c.entries = make(map[string]activationEntry)
+	}
+}
+
+func (c *activationCache) get(key string, now time.Time) (Schedule, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.roll(now)
+	e, ok := c.entries[key]
+	if !ok || now.Before(e.from) || (!e.until.IsZero() && !now.Before(e.until)) {
+		return Schedule{}, false
+	}
+	return e.s, true
+}
+
+// set records s, computed at now and valid until until; see activationEntry.
+func (c *activationCache) set(key string, now, until time.Time, s Schedule) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.roll(now)
+	c.entries[key] = activationEntry{s: s, from: now, until: until}
+}
+
+// ResetCache discards all memoized window activations and advances
+// Generation, such as after the system clock changes unexpectedly or the
+// host resumes from sleep.
+func ResetCache() {
+	atomic.AddInt64(&generation, 1)
+	activations.mu.Lock()
+	defer activations.mu.Unlock()
+	activations.entries = nil
+}
+
+// Directory reads are retried with exponential backoff, since transient
+// failures (such as antivirus holding a lock on Windows) are common.
+var (
+	loadAttempts = 3
+	loadBackoff  = 100 * time.Millisecond
+)
+
+// goodLoad is the last successful load of a configuration directory.
+type goodLoad struct {
+	windows []Window
+	hash    string
+	at      time.Time
+	stale   bool
+}
+
+// notLoadedError is returned when a directory cannot be read and has no last
+// good load. It matches both ErrNotLoaded and the underlying error.
+type notLoadedError struct {
+	err error
+}
+
+func (e *notLoadedError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrNotLoaded, e.err)
+}
+
+func (e *notLoadedError) Is(target error) bool {
+	return target == ErrNotLoaded
+}
+
+func (e *notLoadedError) Unwrap() error {
+	return e.err
+}
+
+// loadCache holds the last good load per directory, served when the
+// directory becomes temporarily unreadable so that a transient failure does
+// not look like every window closing.
+type loadCache struct {
+	mu      sync.Mutex
+	entries map[string]*goodLoad
+	// changes holds the last change to the windows of each directory.
+	changes map[string]ConfigChange
+}
+
+var loads = &loadCache{entries: make(map[string]*goodLoad)}
+
+// load reads the windows in dir, retrying failed reads and falling back to
+// the last good load if all attempts fail. Retries stop once ctx is done.
+// The cached windows are shared between goroutines, so callers receive their
+// own copy of the slice. changed reports whether the configuration was read
+// for the first time or differs from the previous load.
+func (c *loadCache) load(ctx context.Context, dir string, cr ConfigReader) (windows []Window, changed bool, err error) {
+	var hash string
+	backoff := loadBackoff
+	for i := 0; i < loadAttempts; i++ {
+		if i > 0 {
+			t := time.NewTimer(backoff)
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				t.Stop()
+				return nil, false, ctx.Err()
+			}
+			backoff *= 2
+		}
+		if windows, hash, err = loadWindows(dir, cr); err == nil {
+			break
+		}
+		deck.Warningf("unable to read configuration directory %q (attempt %d of %d): %v", dir, i+1, loadAttempts, err)
+	}
+	c.mu.Lock()
+	if err == nil {
+		prev, ok := c.entries[dir]
+		c.entries[dir] = &goodLoad{windows: windows, hash: hash, at: time.Now()}
+		c.mu.Unlock()
+		if ok && prev.hash != hash {
+			c.recordChange(dir, prev, windows, hash)
+		}
+		return append([]Window(nil), windows...), !ok || prev.hash != hash, nil
+	}
+	defer c.mu.Unlock()
+	g, ok := c.entries[dir]
+	if !ok {
+		return nil, false, &notLoadedError{err}
+	}
+	deck.Errorf("serving windows last loaded from %q at %s: %v", dir, g.at.Format(time.RFC3339), err)
+	g.stale = true
+	return append([]Window(nil), g.windows...), false, nil
+}
+
+// recordChange records and reports how windows, loaded from dir with the
+// given hash, differ from the previous load.
+func (c *loadCache) recordChange(dir string, prev *goodLoad, windows []Window, hash string) {
+	ch := diffWindows(prev.windows, windows, time.Now())
+	if ch.Empty() {
+		deck.Infof("configuration in %q changed without changing any window", dir)
+		return
+	}
+	ch.Hash, ch.PreviousHash = hash, prev.hash
+	c.mu.Lock()
+	if c.changes == nil {
+		c.changes = make(map[string]ConfigChange)
+	}
+	ch.Seq = c.changes[dir].Seq + 1
+	c.changes[dir] = ch
+	c.mu.Unlock()
+	reportChange(dir, ch)
+}
+
+// StaleSince reports whether the windows most recently served for dir came
+// from the last good load rather than a fresh read, and when that load
+// happened.
+func StaleSince(dir string) (time.Time, bool) {
+	loads.mu.Lock()
+	defer loads.mu.Unlock()
+	g, ok := loads.entries[dir]
+	if !ok || !g.stale {
+		return time.Time{}, false
+	}
+	return g.at, true
+}
+
+// ConfigHash returns a hash of the configuration content of the windows most
+// recently served for dir, or "" if dir has never been loaded.
+func ConfigHash(dir string) string {
+	loads.mu.Lock()
+	defer loads.mu.Unlock()
+	if g, ok := loads.entries[dir]; ok {
+		return g.hash
+	}
+	return ""
+}
@@ -0,0 +1,228 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package window
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestActivationCache(t *testing.T) {
+	c := &activationCache{}
+	now := time.Date(2020, time.January, 1, 0, 0, 10, 0, time.UTC)
+	s := Schedule{Name: "cached", Opens: now}
+
+	if _, ok := c.get("k", now); ok {
+		t.Errorf("get() on empty cache returned a hit")
+	}
+	c.set("k", now, time.Time{}, s)
+	if got, ok := c.get("k", now.Add(40*time.Second)); !ok || got != s {
+		t.Errorf("get() within the same minute = %v, %t; want %v, true", got, ok, s)
+	}
+	if _, ok := c.get("k", now.Add(time.Minute)); ok {
+		t.Errorf("get() in the following minute returned a hit")
+	}
+}
+
+func TestActivationCacheUntil(t *testing.T) {
+	c := &activationCache{}
+	now := time.Date(2020, time.January, 1, 0, 0, 10, 0, time.UTC)
+	c.set("k", now, now.Add(20*time.Second), Schedule{Name: "cached", Opens: now})
+	if _, ok := c.get("k", now.Add(19*time.Second)); !ok {
+		t.Errorf("get() before the entry expired returned a miss")
+	}
+	if _, ok := c.get("k", now.Add(20*time.Second)); ok {
+		t.Errorf("get() once the entry expired returned a hit")
+	}
+}
+
+func TestActivationCacheSubMinute(t *testing.T) {
+	cr, err := cronParser.Parse("0 0 0 * * *")
+	if err != nil {
+		t.Fatalf("cronParser.Parse() returned error: %v", err)
+	}
+	w := Window{Name: "seconds", Format: FormatCron, CronString: "0 0 0 * * *", Cron: cr, Duration: 30 * time.Second, Labels: []string{"patch"}}
+	open := time.Date(2020, time.January, 1, 0, 0, 10, 0, time.UTC)
+	w.calculateScheduleAt(open)
+	if !w.Schedule.Contains(open) {
+		t.Fatalf("calculateScheduleAt(%v) = [%v, %v], want it open", open, w.Schedule.Opens, w.Schedule.Closes)
+	}
+	// The window closes at 00:00:30, within the minute it was first cached.
+	closed := open.Add(30 * time.Second)
+	w.calculateScheduleAt(closed)
+	if want := time.Date(2020, time.January, 2, 0, 0, 0, 0, time.UTC); !w.Schedule.Opens.Equal(want) {
+		t.Errorf("calculateScheduleAt(%v) opens at %v, want %v rather than the cached occurrence", closed, w.Schedule.Opens, want)
+	}
+}
+
+func TestActivationCacheClockStepBack(t *testing.T) {
+	c := &activationCache{}
+	now := time.Date(2020, time.January, 1, 2, 0, 10, 0, time.UTC)
+	c.set("k", now, time.Time{}, Schedule{Name: "cached", Opens: now})
+	// Activations computed before the clock was stepped back are stale.
+	if _, ok := c.get("k", now.Add(-2*time.Hour)); ok {
+		t.Errorf("get() after the clock stepped back returned a hit")
+	}
+}
+
+func TestResetCache(t *testing.T) {
+	now := time.Now()
+	activations.set("reset", now, time.Time{}, Schedule{Name: "reset"})
+	gen := Generation()
+	ResetCache()
+	if _, ok := activations.get("reset", now); ok {
+		t.Errorf("get() after ResetCache() returned a hit")
+	}
+	if got := Generation(); got != gen+1 {
+		t.Errorf("Generation() after ResetCache() = %d, want %d", got, gen+1)
+	}
+}
+
+func TestActivationCacheGeneration(t *testing.T) {
+	c := &activationCache{}
+	now := time.Date(2020, time.January, 1, 0, 0, 10, 0, time.UTC)
+	c.set("k", now, time.Time{}, Schedule{Name: "cached", Opens: now})
+	// Caches other than activations are invalidated by the generation alone.
+	ResetCache()
+	if _, ok := c.get("k", now); ok {
+		t.Errorf("get() after the generation changed returned a hit")
+	}
+}
+
+// flakyReader fails to list its directory while failures is positive.
+type flakyReader struct {
+	TestReader
+	failures *int
+}
+
+func (r flakyReader) JSONFiles(path string) ([]os.DirEntry, error) {
+	if *r.failures > 0 {
+		*r.failures--
+		return nil, errors.New("directory locked")
+	}
+	return r.TestReader.JSONFiles(path)
+}
+
+func TestLoadCache(t *testing.T) {
+	defer func(b time.Duration) { loadBackoff = b }(loadBackoff)
+	loadBackoff = 0
+	c := &loadCache{entries: make(map[string]*goodLoad)}
+	failures := 0
+	r := flakyReader{
+		TestReader: TestReader{windows: []Window{{Name: "nightly", Format: FormatCron, CronString: "0 0 2 * * *", Duration: time.Hour, Labels: []string{"patch"}}}},
+		failures:   &failures,
+	}
+
+	failures = loadAttempts
+	if _, _, err := c.load(context.Background(), "conf/config.json", r); !errors.Is(err, ErrNotLoaded) {
+		t.Errorf("load() without a previous good load returned %v, want %v", err, ErrNotLoaded)
+	}
+
+	failures = loadAttempts - 1
+	w, fresh, err := c.load(context.Background(), "conf/config.json", r)
+	if err != nil || len(w) != 1 || !fresh {
+		t.Fatalf("load() after transient failures = %v, %t, %v; want 1 changed window", w, fresh, err)
+	}
+	if c.entries["conf/config.json"].stale {
+		t.Errorf("load() after a successful retry marked the cache stale")
+	}
+	hash := c.entries["conf/config.json"].hash
+	if hash == "" {
+		t.Errorf("load() recorded an empty configuration hash")
+	}
+
+	failures = loadAttempts
+	w, fresh, err = c.load(context.Background(), "conf/config.json", r)
+	if err != nil || len(w) != 1 || fresh {
+		t.Errorf("load() with an unreadable directory = %v, %t, %v; want last good windows, unchanged", w, fresh, err)
+	}
+	if !c.entries["conf/config.json"].stale {
+		t.Errorf("load() serving the last good windows did not mark the cache stale")
+	}
+
+	if _, fresh, err := c.load(context.Background(), "conf/config.json", r); err != nil || fresh {
+		t.Fatalf("load() of unchanged configuration = %t, %v; want unchanged", fresh, err)
+	}
+	if c.entries["conf/config.json"].stale {
+		t.Errorf("load() after recovery left the cache stale")
+	}
+
+	changed := r
+	changed.windows = append([]Window{{Name: "weekly", Format: FormatCron, CronString: "0 0 2 * * SUN", Duration: time.Hour, Labels: []string{"patch"}}}, r.windows...)
+	if _, fresh, err := c.load(context.Background(), "conf/config.json", changed); err != nil || !fresh {
+		t.Fatalf("load() of changed configuration = %t, %v; want changed", fresh, err)
+	}
+	if got := c.entries["conf/config.json"].hash; got == hash {
+		t.Errorf("load() of changed configuration kept hash %q", got)
+	}
+	ch, ok := c.changes["conf/config.json"]
+	if !ok || ch.Seq != 1 || len(ch.Added) != 1 || ch.Added[0] != "weekly" || ch.PreviousHash != hash {
+		t.Errorf("load() of changed configuration recorded change %+v, %t; want weekly added", ch, ok)
+	}
+}
+
+func TestLoadCacheCanceled(t *testing.T) {
+	defer func(b time.Duration) { loadBackoff = b }(loadBackoff)
+	loadBackoff = time.Hour
+	c := &loadCache{entries: make(map[string]*goodLoad)}
+	failures := loadAttempts
+	r := flakyReader{failures: &failures}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, _, err := c.load(ctx, "conf/config.json", r); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("load() with a canceled context returned %v, want %v", err, context.DeadlineExceeded)
+	}
+	if failures != loadAttempts-1 {
+		t.Errorf("load() with a canceled context made %d attempts, want 1", loadAttempts-failures)
+	}
+}
+
+// TestConcurrentWindows loads and evaluates configuration from several
+// goroutines while it changes; run with -race.
+func TestConcurrentWindows(t *testing.T) {
+	r := TestReader{windows: []Window{
+		{Name: "nightly", Format: FormatCron, CronString: "0 0 2 * * *", Duration: time.Hour, Labels: []string{"patch"}},
+		{Name: "weekly", Format: FormatCron, CronString: "0 0 4 * * SUN", Duration: time.Hour, Labels: []string{"patch", "reboot"}},
+	}}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				cr := r
+				if (i+j)%3 == 0 {
+					cr.windows = r.windows[:1]
+				}
+				m, err := Windows("conf/concurrent.json", cr)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				now := time.Now()
+				m.Aggregate("patch", AggregateMerge)
+				m.Occurrences("patch", now, now.Add(72*time.Hour), AggregateMerge)
+				LastChange("conf/concurrent.json")
+				ConfigHash("conf/concurrent.json")
+				ResetCache()
+			}
+		}(i)
+	}
+	wg.Wait()
+}
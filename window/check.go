@@ -0,0 +1,280 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package window
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// CheckOK denotes a configuration file without errors or warnings.
+	CheckOK = "ok"
+	// CheckWarning denotes a configuration file that loaded with warnings.
+	CheckWarning = "warning"
+	// CheckError denotes a configuration file containing errors.
+	CheckError = "error"
+)
+
+// FileCheck holds the validation results of a single configuration file.
+type FileCheck struct {
+	Path     string
+	Status   string
+	Windows  int
+	Errors   []string `json:",omitempty"`
+	Warnings []string `json:",omitempty"`
+}
+
+func (fc *FileCheck) errorf(format string, v ...any) {
+	fc.Errors = append(fc.Errors, fmt.Sprintf(format, v...))
+}
+
+func (fc *FileCheck) warnf(format string, v ...any) {
+	fc.Warnings = append(fc.Warnings, fmt.Sprintf(format, v...))
+}
+
+func (fc *FileCheck) setStatus() {
+	switch {
+	case len(fc.Errors) > 0:
+		fc.Status = CheckError
+	case len(fc.Warnings) > 0:
+		fc.Status = CheckWarning
+	default:
+		fc.Status = CheckOK
+	}
+}
+
+// windowKey identifies windows whose definitions are functionally identical:
+// every configured field but the name, with labels and tags in any order.
+func windowKey(w Window) string {
+	labels := append([]string(nil), w.Labels...)
+	sort.Strings(labels)
+	var tags []string
+	for k, v := range w.Tags {
+		tags = append(tags, k+"="+v)
+	}
+	sort.Strings(tags)
+	clock := w.Clock
+	if clock == "" {
+		clock = ClockWall
+	}
+	return fmt.Sprintf("%d|%s|%s|%s|%s|%v|%t|%s|%s|%t|%s|%s", w.Format, w.CronString, w.Duration,
+		w.Starts, w.Expires, w.SampleRate, w.TruncateAtExpiry, clock, strings.Join(labels, ","),
+		w.RequiresApproval, strings.Join(tags, ","), w.MaxTaskDuration)
+}
+
+// Check validates all configuration files within dir and its OverridesDir,
+// and Inline. Unlike Windows, every window in every file is evaluated so that
+// all problems are reported at once.
+func Check(dir string, cr ConfigReader) ([]FileCheck, error) {
+	return CheckQueried(dir, cr, nil)
+}
+
+// CheckQueried is Check, additionally warning about labels that have never
+// been queried according to queried, the last query time of each label. A
+// nil queried disables the warning.
+func CheckQueried(dir string, cr ConfigReader, queried map[string]time.Time) ([]FileCheck, error) {
+	var (
+		out     []FileCheck
+		windows []Window
+		names   = make(map[string]string)
+		defs    = make(map[string]string)
+	)
+	if readsDir(dir, cr) {
+		var err error
+		if out, windows, err = checkDir(dir, cr, names, defs); err != nil {
+			return nil, err
+		}
+	}
+	for i, b := range Inline {
+		fc := FileCheck{Path: InlineName(i)}
+		windows = append(windows, checkContent(&fc, b, names, defs)...)
+		out = append(out, fc)
+	}
+	od := filepath.Join(dir, OverridesDir)
+	if ok, err := cr.PathExists(od); err == nil && ok {
+		// Overrides replace windows by name, so names defined in dir are
+		// not reported as duplicates.
+		oNames := make(map[string]string)
+		oc, overrides, err := checkDir(od, cr, oNames, make(map[string]string))
+		if err != nil {
+			return nil, fmt.Errorf("overrides: %w", err)
+		}
+		out = append(out, oc...)
+		windows = applyOverrides(windows, overrides)
+		for n, p := range oNames {
+			names[n] = p
+		}
+	}
+	byName := make(map[string]Window)
+	for _, w := range windows {
+		if _, ok := byName[w.Name]; !ok {
+			byName[w.Name] = w
+		}
+	}
+	// Conflicts are reported against the file defining the offending window.
+	// Identical windows were already reported as duplicates.
+	for _, c := range Conflicts(windows) {
+		if c.Kind == ConflictRedundant && windowKey(byName[c.Windows[0]]) == windowKey(byName[c.Windows[1]]) {
+			continue
+		}
+		for i := range out {
+			if names[c.Windows[0]] == out[i].Path {
+				out[i].warnf("%s", c)
+				break
+			}
+		}
+	}
+	if queried != nil {
+		warnUnqueried(out, windows, names, queried)
+	}
+	for i := range out {
+		out[i].setStatus()
+	}
+	return out, nil
+}
+
+// warnUnqueried warns about each label without a query in queried, against
+// the files defining windows with that label.
+func warnUnqueried(out []FileCheck, windows []Window, names map[string]string, queried map[string]time.Time) {
+	warned := make(map[string]bool)
+	for _, w := range windows {
+		for _, l := range w.Labels {
+			l = strings.ToLower(l)
+			path := names[w.Name]
+			if !queried[l].IsZero() || warned[path+"|"+l] {
+				continue
+			}
+			for i := range out {
+				if out[i].Path == path {
+					out[i].warnf("label %q has never been queried", l)
+					warned[path+"|"+l] = true
+					break
+				}
+			}
+		}
+	}
+}
+
+// checkDir validates the configuration files within dir, returning their
+// windows. The files defining each window name, and the windows defining
+// each definition, are recorded in names and defs.
+func checkDir(dir string, cr ConfigReader, names, defs map[string]string) ([]FileCheck, []Window, error) {
+	files, err := cr.JSONFiles(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		out     []FileCheck
+		windows []Window
+	)
+	for _, f := range files {
+		fp := filepath.Join(dir, f.Name())
+		fc := FileCheck{Path: fp}
+		windows = append(windows, checkFile(&fc, cr, names, defs)...)
+		out = append(out, fc)
+	}
+	return out, windows, nil
+}
+
+func checkFile(fc *FileCheck, cr ConfigReader, names, defs map[string]string) []Window {
+	b, err := cr.JSONContent(fc.Path)
+	if err != nil {
+		fc.errorf("error reading file: %v", err)
+		return nil
+	}
+	// Insecure files are reported whatever FilePermissions is, unless
+	// already refused above.
+	if _, ok := cr.(Reader); ok {
+		if problem, err := fnInsecure(fc.Path); err == nil && problem != "" {
+			fc.warnf("%v: %s", ErrInsecureFile, problem)
+		}
+	}
+	return checkContent(fc, b, names, defs)
+}
+
+// CheckContent validates the content b of a single configuration file named
+// path, such as a file about to be shipped to hosts. Only conflicts between
+// windows of this file are reported.
+func CheckContent(path string, b []byte) FileCheck {
+	fc := FileCheck{Path: path}
+	windows := checkContent(&fc, b, make(map[string]string), make(map[string]string))
+	byName := make(map[string]Window)
+	for _, w := range windows {
+		if _, ok := byName[w.Name]; !ok {
+			byName[w.Name] = w
+		}
+	}
+	for _, c := range Conflicts(windows) {
+		if c.Kind == ConflictRedundant && windowKey(byName[c.Windows[0]]) == windowKey(byName[c.Windows[1]]) {
+			continue
+		}
+		fc.warnf("%s", c)
+	}
+	fc.setStatus()
+	return fc
+}
+
+func checkContent(fc *FileCheck, b []byte, names, defs map[string]string) []Window {
+	raw := struct {
+		Windows []json.RawMessage
+		Labels  map[string]LabelInfo
+	}{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		fc.errorf("error parsing file: %v", err)
+		return nil
+	}
+	for name, l := range raw.Labels {
+		if err := l.validate(strings.ToLower(name)); err != nil {
+			fc.errorf("%v", err)
+		}
+	}
+	if len(raw.Windows) == 0 && len(raw.Labels) == 0 {
+		fc.warnf("no windows defined")
+	}
+	var windows []Window
+	for i, r := range raw.Windows {
+		var w Window
+		if err := json.Unmarshal(r, &w); err != nil {
+			fc.errorf("window %d: %v", i, err)
+			continue
+		}
+		fc.Windows++
+		windows = append(windows, w)
+		if prev, ok := names[w.Name]; ok {
+			fc.warnf("window(%s): name already defined in %s", w.Name, prev)
+		} else {
+			names[w.Name] = fc.Path
+		}
+		key := windowKey(w)
+		if prev, ok := defs[key]; ok {
+			fc.warnf("window(%s): duplicates the definition of window %s", w.Name, prev)
+		} else {
+			defs[key] = w.Name
+		}
+	}
+	// Schema violations are mostly reported above; only report those, such
+	// as unknown fields, that the checks above do not catch.
+	if len(fc.Errors) == 0 {
+		if err := ValidateSchema(b); err != nil {
+			fc.errorf("%v", err)
+		}
+	}
+	return windows
+}
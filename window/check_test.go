@@ -0,0 +1,241 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package window
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// fileReader is a ConfigReader serving file contents from memory. Files in
+// overrides are served from the OverridesDir of any directory.
+type fileReader struct {
+	files, overrides map[string]string
+}
+
+func (r fileReader) dir(path string) map[string]string {
+	if filepath.Base(path) == OverridesDir {
+		return r.overrides
+	}
+	return r.files
+}
+
+func (r fileReader) PathExists(path string) (bool, error) {
+	return r.dir(path) != nil, nil
+}
+
+func (r fileReader) AbsPath(path string) (string, error) {
+	return path, nil
+}
+
+func (r fileReader) JSONFiles(path string) ([]os.DirEntry, error) {
+	var names []string
+	for n := range r.dir(path) {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	var entries []os.DirEntry
+	for _, n := range names {
+		entries = append(entries, mockDirEntry{name: n})
+	}
+	return entries, nil
+}
+
+func (r fileReader) JSONContent(path string) ([]byte, error) {
+	c, ok := r.dir(filepath.Dir(path))[filepath.Base(path)]
+	if !ok {
+		return nil, fmt.Errorf("file not found: %s", path)
+	}
+	return []byte(c), nil
+}
+
+func TestWindowKey(t *testing.T) {
+	base := Window{Name: "a", Format: FormatCron, CronString: "0 0 2 * * *", Duration: time.Hour,
+		Labels: []string{"patch", "reboot"}, Tags: map[string]string{"owner": "x", "ring": "1"}}
+	same := base
+	same.Name = "b"
+	same.Labels = []string{"reboot", "patch"}
+	if windowKey(base) != windowKey(same) {
+		t.Errorf("windowKey() differs for windows differing only in name and label order")
+	}
+	for desc, edit := range map[string]func(*Window){
+		"RequiresApproval": func(w *Window) { w.RequiresApproval = true },
+		"Tags":             func(w *Window) { w.Tags = map[string]string{"owner": "y", "ring": "1"} },
+		"MaxTaskDuration":  func(w *Window) { w.MaxTaskDuration = time.Minute },
+	} {
+		w := base
+		edit(&w)
+		if windowKey(w) == windowKey(base) {
+			t.Errorf("windowKey() ignores %s", desc)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	r := fileReader{files: map[string]string{
+		"a.json": `{"Windows": [
+			{"Name": "one", "Format": 1, "Schedule": "0 0 2 * * *", "Duration": "1h", "Labels": ["a"]}
+		]}`,
+		"b.json": `{"Windows": [
+			{"Name": "one", "Format": 1, "Schedule": "0 0 3 * * *", "Duration": "1h", "Labels": ["b"]},
+			{"Name": "two", "Format": 1, "Schedule": "0 0 2 * * *", "Duration": "1h", "Labels": ["A"]},
+			{"Name": "three", "Format": 1, "Schedule": "bad", "Duration": "1h", "Labels": ["c"]}
+		]}`,
+		"c.json": `{"Windows": []}`,
+		"d.json": `not json`,
+	}}
+	got, err := Check("conf", r)
+	if err != nil {
+		t.Fatalf("Check() returned unexpected error: %v", err)
+	}
+	want := []struct {
+		status           string
+		windows          int
+		errors, warnings int
+	}{
+		{CheckOK, 1, 0, 0},
+		{CheckError, 2, 1, 2},
+		{CheckWarning, 0, 0, 1},
+		{CheckError, 0, 1, 0},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Check() returned %d results, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.Status != w.status || g.Windows != w.windows || len(g.Errors) != w.errors || len(g.Warnings) != w.warnings {
+			t.Errorf("Check(%s) = %+v, want status %s, %d windows, %d errors, %d warnings",
+				g.Path, g, w.status, w.windows, w.errors, w.warnings)
+		}
+	}
+}
+
+func TestCheckOverrides(t *testing.T) {
+	r := fileReader{
+		files: map[string]string{
+			"managed.json": `{"Windows": [
+				{"Name": "nightly", "Format": 1, "Schedule": "0 0 2 * * *", "Duration": "1h", "Labels": ["patch"]}
+			]}`,
+		},
+		overrides: map[string]string{
+			"local.json": `{"Windows": [
+				{"Name": "nightly", "Format": 1, "Schedule": "0 0 4 * * *", "Duration": "1h", "Labels": ["patch"]},
+				{"Name": "extra", "Format": 1, "Schedule": "bad", "Duration": "1h", "Labels": ["patch"]}
+			]}`,
+		},
+	}
+	got, err := Check("conf", r)
+	if err != nil {
+		t.Fatalf("Check() returned unexpected error: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("Check() returned %d results, want 2", len(got))
+	}
+	if g := got[0]; g.Path != filepath.Join("conf", "managed.json") || g.Status != CheckOK {
+		t.Errorf("Check() = %+v, want managed.json ok", g)
+	}
+	// Overriding nightly is not reported as a duplicate name.
+	if g := got[1]; g.Path != filepath.Join("conf", OverridesDir, "local.json") || g.Status != CheckError || len(g.Warnings) != 0 {
+		t.Errorf("Check() = %+v, want local.json with errors and no warnings", g)
+	}
+}
+
+func TestCheckInlineDuplicate(t *testing.T) {
+	orig := Inline
+	defer func() { Inline = orig }()
+	Inline = [][]byte{[]byte(`{"Windows": [
+		{"Name": "inline", "Format": 1, "Schedule": "0 0 2 * * *", "Duration": "1h", "Labels": ["a"]}
+	]}`)}
+	r := fileReader{files: map[string]string{
+		"a.json": `{"Windows": [
+			{"Name": "file", "Format": 1, "Schedule": "0 0 2 * * *", "Duration": "1h", "Labels": ["a"]}
+		]}`,
+	}}
+	got, err := Check("conf", r)
+	if err != nil {
+		t.Fatalf("Check() returned unexpected error: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("Check() returned %d results, want 2", len(got))
+	}
+	want := "window(inline): duplicates the definition of window file"
+	if g := got[1]; g.Path != InlineName(0) || len(g.Warnings) != 1 || g.Warnings[0] != want {
+		t.Errorf("Check() = %+v, want inline check warning %q", g, want)
+	}
+}
+
+func TestCheckQueried(t *testing.T) {
+	r := fileReader{files: map[string]string{
+		"a.json": `{"Windows": [
+			{"Name": "one", "Format": 1, "Schedule": "0 0 2 * * *", "Duration": "1h", "Labels": ["Queried", "idle"]},
+			{"Name": "two", "Format": 1, "Schedule": "0 0 3 * * *", "Duration": "1h", "Labels": ["idle"]}
+		]}`,
+	}}
+	queried := map[string]time.Time{"queried": time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)}
+	got, err := CheckQueried("conf", r, queried)
+	if err != nil {
+		t.Fatalf("CheckQueried() returned unexpected error: %v", err)
+	}
+	want := []string{`label "idle" has never been queried`}
+	if len(got) != 1 || got[0].Status != CheckWarning || !cmp.Equal(got[0].Warnings, want) {
+		t.Errorf("CheckQueried() = %+v, want warnings %q", got, want)
+	}
+	// Without query history, labels are not reported.
+	if got, err := Check("conf", r); err != nil || len(got) != 1 || got[0].Status != CheckOK {
+		t.Errorf("Check() = %+v, %v, want one passing check", got, err)
+	}
+}
+
+func TestLoadWindowsOverrides(t *testing.T) {
+	r := fileReader{
+		files: map[string]string{
+			"managed.json": `{"Windows": [
+				{"Name": "nightly", "Format": 1, "Schedule": "0 0 2 * * *", "Duration": "1h", "Labels": ["patch"]},
+				{"Name": "weekly", "Format": 1, "Schedule": "0 0 4 * * SUN", "Duration": "2h", "Labels": ["reboot"]}
+			]}`,
+		},
+	}
+	_, baseHash, err := loadWindows("conf", r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.overrides = map[string]string{
+		"local.json": `{"Windows": [
+			{"Name": "nightly", "Format": 1, "Schedule": "0 0 5 * * *", "Duration": "1h", "Labels": ["patch"]},
+			{"Name": "local", "Format": 1, "Schedule": "0 0 6 * * *", "Duration": "1h", "Labels": ["local"]}
+		]}`,
+	}
+	windows, hash, err := loadWindows("conf", r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash == baseHash {
+		t.Errorf("loadWindows() hash did not change with overrides")
+	}
+	got := make(map[string]string)
+	for _, w := range windows {
+		got[w.Name] = w.CronString
+	}
+	want := map[string]string{"nightly": "0 0 5 * * *", "weekly": "0 0 4 * * SUN", "local": "0 0 6 * * *"}
+	if len(windows) != len(want) || fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("loadWindows() with overrides = %v, want %v", got, want)
+	}
+}
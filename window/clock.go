@@ -0,0 +1,129 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package window
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Clock selects how a window's cron schedule is evaluated across daylight
+// saving time transitions.
+type Clock string
+
+const (
+	// ClockWall evaluates schedules in local wall-clock time, so that a
+	// window scheduled at 02:30 opens at 02:30 local time every day. A
+	// scheduled time skipped when clocks go forward is shifted forward by the
+	// length of the skipped interval, opening at 03:30 on a one hour
+	// transition, and a scheduled time repeated when clocks go back opens
+	// only at its first occurrence. This is the default.
+	ClockWall Clock = "wall"
+	// ClockFixed evaluates schedules at the fixed UTC offset of standard time
+	// in the schedule's location. Occurrences are evenly spaced in absolute
+	// time, and open an hour later in local time while a one hour daylight
+	// saving offset is in effect.
+	ClockFixed Clock = "fixed"
+)
+
+func (c Clock) validate() error {
+	switch c {
+	case "", ClockWall, ClockFixed:
+		return nil
+	}
+	return fmt.Errorf("clock must be %q or %q (found: %q)", ClockWall, ClockFixed, c)
+}
+
+// maxWallSteps bounds the scheduled times skipped while clocks repeat an
+// interval, such as the activations of a per-minute schedule.
+const maxWallSteps = 4096
+
+// activation returns the activation found by NextActivation for ts, with the
+// cron schedule evaluated according to w.Clock.
+func (w *Window) activation(ts, start time.Time) time.Time {
+	spec, ok := w.Cron.(*cron.SpecSchedule)
+	if !ok {
+		return quorum(w.Cron, ts, start)
+	}
+	loc := spec.Location
+	if w.Clock == ClockFixed {
+		fixed := *spec
+		fixed.Location = standardZone(loc, ts)
+		if a := quorum(&fixed, ts, start); !a.IsZero() {
+			return a.In(loc)
+		}
+		return time.Time{}
+	}
+	// Wall-clock times are evaluated as if they were UTC, which has no
+	// transitions, and then located.
+	floating := *spec
+	floating.Location = time.UTC
+	wall := wallClock(ts.In(loc))
+	for i := 0; i < maxWallSteps; i++ {
+		n := quorum(&floating, wall, start)
+		if n.IsZero() {
+			return n
+		}
+		at := fromWallClock(n, loc)
+		// A scheduled time after ts that locates before ts is repeated by a
+		// transition, and its first occurrence has already passed.
+		if n.Before(wall) || !at.Before(ts) {
+			return at
+		}
+		wall = n.Add(time.Second)
+	}
+	return time.Time{}
+}
+
+// wallClock returns the wall-clock time of t expressed in UTC.
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// fromWallClock returns the first instant at which the wall clock of loc
+// reads wall, a wall-clock time expressed in UTC. Wall-clock times skipped
+// by a transition are shifted forward by the length of the transition.
+func fromWallClock(wall time.Time, loc *time.Location) time.Time {
+	// Transitions are far enough apart that the offsets a day either side
+	// are the only candidates.
+	approx := time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), wall.Nanosecond(), loc)
+	_, before := approx.Add(-24 * time.Hour).Zone()
+	_, after := approx.Add(24 * time.Hour).Zone()
+	var first time.Time
+	for _, off := range []int{before, after} {
+		at := wall.Add(-time.Duration(off) * time.Second)
+		if wallClock(at.In(loc)).Equal(wall) && (first.IsZero() || at.Before(first)) {
+			first = at
+		}
+	}
+	if first.IsZero() {
+		// wall was skipped: read it with the offset in effect before.
+		first = wall.Add(-time.Duration(before) * time.Second)
+	}
+	return first.In(loc)
+}
+
+// standardZone returns a fixed zone at the standard time offset of loc in
+// the year of t, which is the lesser of its offsets in January and July.
+func standardZone(loc *time.Location, t time.Time) *time.Location {
+	y := t.In(loc).Year()
+	name, off := time.Date(y, time.January, 1, 0, 0, 0, 0, loc).Zone()
+	if n, o := time.Date(y, time.July, 1, 0, 0, 0, 0, loc).Zone(); o < off {
+		name, off = n, o
+	}
+	return time.FixedZone(name, off)
+}
@@ -0,0 +1,102 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package window
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClockDST(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	at := func(month time.Month, day, hour, min int, zone int) time.Time {
+		return time.Date(2026, month, day, hour, min, 0, 0, time.FixedZone("", zone*3600)).In(ny)
+	}
+	// Clocks spring forward at 02:00 on March 8, 2026 and fall back at 02:00
+	// on November 1, 2026.
+	tests := []struct {
+		desc     string
+		schedule string
+		clock    Clock
+		from     time.Time
+		want     []time.Time
+	}{
+		{"wall skipped time shifts forward", "0 30 2 * * *", ClockWall, at(time.March, 7, 12, 0, -5),
+			[]time.Time{at(time.March, 8, 3, 30, -4), at(time.March, 9, 2, 30, -4)}},
+		{"wall repeated time opens once", "0 30 1 * * *", "", at(time.October, 31, 12, 0, -4),
+			[]time.Time{at(time.November, 1, 1, 30, -4), at(time.November, 2, 1, 30, -5)}},
+		{"wall hourly across fall back", "0 0 * * * *", ClockWall, at(time.November, 1, 0, 30, -4),
+			[]time.Time{at(time.November, 1, 1, 0, -4), at(time.November, 1, 2, 0, -5)}},
+		{"fixed across spring forward", "0 30 1 * * *", ClockFixed, at(time.March, 7, 12, 0, -5),
+			[]time.Time{at(time.March, 8, 1, 30, -5), at(time.March, 9, 1, 30, -5)}},
+		{"fixed across fall back", "0 30 1 * * *", ClockFixed, at(time.October, 31, 12, 0, -4),
+			[]time.Time{at(time.November, 1, 1, 30, -5), at(time.November, 2, 1, 30, -5)}},
+	}
+	for _, tt := range tests {
+		cr, err := cronParser.Parse("CRON_TZ=America/New_York " + tt.schedule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := Window{Name: "w", Format: FormatCron, Cron: cr, Duration: time.Hour, Labels: []string{"a"}, Clock: tt.clock}
+		var got []time.Time
+		for a := tt.from; len(got) < len(tt.want); {
+			a = w.NextActivation(a.Add(time.Minute))
+			if a.IsZero() {
+				break
+			}
+			got = append(got, a)
+		}
+		for i := range tt.want {
+			if i >= len(got) || !got[i].Equal(tt.want[i]) {
+				t.Errorf("%s: activations = %v, want %v", tt.desc, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestClockJSON(t *testing.T) {
+	var w Window
+	in := `{"Name": "w", "Format": 1, "Schedule": "0 30 2 * * *", "Duration": "1h", "Labels": ["a"], "Clock": "fixed"}`
+	if err := json.Unmarshal([]byte(in), &w); err != nil {
+		t.Fatal(err)
+	}
+	if w.Clock != ClockFixed {
+		t.Errorf("UnmarshalJSON() Clock = %q, want %q", w.Clock, ClockFixed)
+	}
+	b, err := json.Marshal(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"Clock":"fixed"`) {
+		t.Errorf("MarshalJSON() = %s, want Clock", b)
+	}
+	if err := ValidateSchema([]byte(`{"Windows": [` + in + `]}`)); err != nil {
+		t.Errorf("ValidateSchema() = %v", err)
+	}
+
+	bad := strings.Replace(in, "fixed", "utc", 1)
+	if err := json.Unmarshal([]byte(bad), &w); err == nil || !strings.Contains(err.Error(), "clock must be") {
+		t.Errorf("UnmarshalJSON(%s) = %v, want clock error", bad, err)
+	}
+	if err := ValidateSchema([]byte(`{"Windows": [` + bad + `]}`)); err == nil {
+		t.Error("ValidateSchema() accepted an unknown clock")
+	}
+}
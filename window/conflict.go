@@ -0,0 +1,164 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package window
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/deck"
+	"github.com/google/aukera/auklib"
+)
+
+const (
+	// ConflictRedundant denotes a window whose every occurrence is fully
+	// covered by another window sharing its label.
+	ConflictRedundant = "redundant"
+	// ConflictStartsAfterExpires denotes a window that can never open
+	// because it starts after it expires.
+	ConflictStartsAfterExpires = "starts_after_expires"
+	// ConflictExpired denotes a configured window that can never open
+	// again because it has expired.
+	ConflictExpired = "expired"
+)
+
+// Conflict describes a configuration problem found within or between windows.
+// For redundant windows, the first element of Windows is made redundant by
+// the second.
+type Conflict struct {
+	Kind    string
+	Label   string `json:",omitempty"`
+	Windows []string
+	// Detail adds the values at fault, such as the times of windows that
+	// never open, so that typos stand out.
+	Detail string `json:",omitempty"`
+}
+
+func (c Conflict) String() string {
+	switch c.Kind {
+	case ConflictRedundant:
+		return fmt.Sprintf("window(%s): redundant with window %s for label %q", c.Windows[0], c.Windows[1], c.Label)
+	case ConflictStartsAfterExpires:
+		return fmt.Sprintf("window(%s): starts after it expires%s", c.Windows[0], c.detail())
+	case ConflictExpired:
+		return fmt.Sprintf("window(%s): has expired and never opens again%s", c.Windows[0], c.detail())
+	}
+	return fmt.Sprintf("%s: %s%s", c.Kind, strings.Join(c.Windows, ", "), c.detail())
+}
+
+func (c Conflict) detail() string {
+	if c.Detail == "" {
+		return ""
+	}
+	return " (" + c.Detail + ")"
+}
+
+// covers reports whether every occurrence of b falls within an occurrence of
+// a on every host and at every time b applies, so that a is at least as
+// permissive as b.
+func covers(a, b Window) bool {
+	if a.Format != b.Format || a.CronString != b.CronString || a.CronString == "" || a.Clock != b.Clock {
+		return false
+	}
+	// Sampling is decided per window, so a sampled window covers no other.
+	if a.SampleRate > 0 && a.SampleRate < 1 {
+		return false
+	}
+	if a.RequiresApproval && !b.RequiresApproval {
+		return false
+	}
+	if a.TruncateAtExpiry && !a.Expires.IsZero() && !b.TruncateAtExpiry {
+		return false
+	}
+	if a.Duration < b.Duration {
+		return false
+	}
+	if !a.Starts.IsZero() && (b.Starts.IsZero() || b.Starts.Before(a.Starts)) {
+		return false
+	}
+	if !a.Expires.IsZero() && (b.Expires.IsZero() || a.Expires.Before(b.Expires)) {
+		return false
+	}
+	return true
+}
+
+// Conflicts returns the conflicts found among windows.
+func Conflicts(windows []Window) []Conflict {
+	return conflictsAt(windows, time.Now())
+}
+
+// conflictsAt returns the conflicts found among windows as of now.
+func conflictsAt(windows []Window, now time.Time) []Conflict {
+	var out []Conflict
+	for _, w := range windows {
+		switch {
+		case !w.Starts.IsZero() && !w.Expires.IsZero() && w.Starts.After(w.Expires):
+			out = append(out, Conflict{Kind: ConflictStartsAfterExpires, Windows: []string{w.Name},
+				Detail: fmt.Sprintf("starts %s, expires %s", w.Starts.Format(time.RFC3339), w.Expires.Format(time.RFC3339))})
+		// Ephemeral windows are expected to expire before they are purged.
+		case !w.Expires.IsZero() && !w.Expires.After(now) && !strings.HasPrefix(w.Source, SourceEphemeral):
+			out = append(out, Conflict{Kind: ConflictExpired, Windows: []string{w.Name},
+				Detail: "expired " + w.Expires.Format(time.RFC3339)})
+		}
+	}
+	// Group windows by label as Add would, without reporting duplicates,
+	// which only the load serving schedules does.
+	m := make(Map)
+	m.add(windows)
+	for _, l := range m.Keys() {
+		ws := m[l]
+		for i := range ws {
+			for j := range ws {
+				if i == j || !covers(ws[j], ws[i]) {
+					continue
+				}
+				// Identical windows cover each other; report only one of them.
+				if covers(ws[i], ws[j]) && j > i {
+					continue
+				}
+				out = append(out, Conflict{Kind: ConflictRedundant, Label: l, Windows: []string{ws[i].Name, ws[j].Name}})
+				break
+			}
+		}
+	}
+	return out
+}
+
+// logged holds the conflicts last logged by reportConflicts, so that each is
+// logged once while it persists rather than on every load.
+var logged = struct {
+	sync.Mutex
+	conflicts map[string]bool
+}{}
+
+func reportConflicts(conflicts []Conflict) {
+	logged.Lock()
+	defer logged.Unlock()
+	seen := make(map[string]bool, len(conflicts))
+	for _, c := range conflicts {
+		s := c.String()
+		seen[s] = true
+		if !logged.conflicts[s] {
+			deck.Warningf("window conflict: %s", s)
+		}
+		auklib.ReportString("config_conflict", c.Kind, map[string]string{
+			"windows": strings.Join(c.Windows, ","),
+			"label":   c.Label,
+		})
+	}
+	logged.conflicts = seen
+}
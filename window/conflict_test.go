@@ -0,0 +1,115 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package window
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestConflicts(t *testing.T) {
+	now := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	daily := func(name string, d time.Duration, labels ...string) Window {
+		return Window{Name: name, Format: FormatCron, CronString: "0 0 2 * * *", Duration: d, Labels: labels}
+	}
+	bounded := daily("bounded", time.Hour, "a")
+	bounded.Starts = now
+	bounded.Expires = now.Add(24 * time.Hour)
+	backwards := daily("backwards", time.Hour, "c")
+	backwards.Starts = now.Add(24 * time.Hour)
+	backwards.Expires = now
+	expired := daily("expired", time.Hour, "d")
+	expired.Expires = now.Add(-time.Hour)
+	ephemeral := expired
+	ephemeral.Source = SourceEphemeral
+	sampled := daily("sampled", 2*time.Hour, "e")
+	sampled.SampleRate = 0.5
+	pending := daily("pending", 2*time.Hour, "f")
+	pending.RequiresApproval = true
+	wall := daily("wall", 2*time.Hour, "g")
+	wall.Clock = ClockWall
+	truncated := daily("truncated", 2*time.Hour, "h")
+	truncated.Expires = now.Add(48 * time.Hour)
+	truncated.TruncateAtExpiry = true
+	ends := daily("ends", time.Hour, "h")
+	ends.Expires = now.Add(48 * time.Hour)
+
+	tests := []struct {
+		desc    string
+		windows []Window
+		want    []Conflict
+	}{
+		{
+			desc:    "no conflict across labels",
+			windows: []Window{daily("one", time.Hour, "a"), daily("two", time.Hour, "b")},
+		},
+		{
+			desc:    "shorter window redundant",
+			windows: []Window{daily("short", time.Hour, "a"), daily("long", 2*time.Hour, "a")},
+			want:    []Conflict{{Kind: ConflictRedundant, Label: "a", Windows: []string{"short", "long"}}},
+		},
+		{
+			desc:    "identical windows reported once",
+			windows: []Window{daily("one", time.Hour, "a"), daily("two", time.Hour, "a")},
+			want:    []Conflict{{Kind: ConflictRedundant, Label: "a", Windows: []string{"two", "one"}}},
+		},
+		{
+			desc:    "bounded window redundant with unbounded",
+			windows: []Window{bounded, daily("always", time.Hour, "a")},
+			want:    []Conflict{{Kind: ConflictRedundant, Label: "a", Windows: []string{"bounded", "always"}}},
+		},
+		{
+			desc:    "sampled window covers no other",
+			windows: []Window{daily("full", 2*time.Hour, "e"), sampled},
+			want:    []Conflict{{Kind: ConflictRedundant, Label: "e", Windows: []string{"sampled", "full"}}},
+		},
+		{
+			desc:    "window requiring approval covers no approved window",
+			windows: []Window{daily("approved", 2*time.Hour, "f"), pending},
+			want:    []Conflict{{Kind: ConflictRedundant, Label: "f", Windows: []string{"pending", "approved"}}},
+		},
+		{
+			desc:    "different clocks",
+			windows: []Window{daily("local", time.Hour, "g"), wall},
+		},
+		{
+			desc:    "truncated window covers no untruncated window",
+			windows: []Window{ends, truncated},
+		},
+		{
+			desc:    "starts after expires",
+			windows: []Window{backwards},
+			want: []Conflict{{Kind: ConflictStartsAfterExpires, Windows: []string{"backwards"},
+				Detail: "starts 2020-01-02T00:00:00Z, expires 2020-01-01T00:00:00Z"}},
+		},
+		{
+			desc:    "expired",
+			windows: []Window{expired},
+			want:    []Conflict{{Kind: ConflictExpired, Windows: []string{"expired"}, Detail: "expired 2019-12-31T23:00:00Z"}},
+		},
+		{
+			desc:    "expired ephemeral window",
+			windows: []Window{ephemeral},
+		},
+	}
+	for _, tt := range tests {
+		got := conflictsAt(tt.windows, now)
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("Conflicts(%s) returned diff (-want +got): %s", tt.desc, diff)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package window
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/deck"
+	"github.com/google/aukera/auklib"
+)
+
+// deltaHorizon bounds how far ahead the schedules of changed labels are
+// compared.
+const deltaHorizon = 366 * 24 * time.Hour
+
+// LabelDelta describes how the current or next schedule of a label changed.
+// Before or After is nil if the label had, or has, no upcoming schedule.
+type LabelDelta struct {
+	Label         string
+	Before, After *Schedule `json:",omitempty"`
+}
+
+// ConfigChange summarizes a configuration reload that changed the windows
+// of a directory. Seq increases with every change of the directory.
+type ConfigChange struct {
+	Seq                      uint64
+	At                       time.Time
+	Hash, PreviousHash       string
+	Added, Removed, Modified []string     `json:",omitempty"`
+	Labels                   []LabelDelta `json:",omitempty"`
+}
+
+// Empty reports whether the change altered no window, such as when only
+// formatting changed.
+func (c ConfigChange) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Modified) == 0
+}
+
+// String summarizes c for logs.
+func (c ConfigChange) String() string {
+	var parts []string
+	for _, p := range []struct {
+		kind  string
+		names []string
+	}{{"added", c.Added}, {"removed", c.Removed}, {"modified", c.Modified}} {
+		if len(p.names) > 0 {
+			parts = append(parts, fmt.Sprintf("%s %s", p.kind, strings.Join(p.names, ", ")))
+		}
+	}
+	if len(parts) == 0 {
+		parts = append(parts, "no windows changed")
+	}
+	for _, d := range c.Labels {
+		parts = append(parts, fmt.Sprintf("label %s: %s -> %s", d.Label, describe(d.Before), describe(d.After)))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func describe(s *Schedule) string {
+	if s == nil {
+		return "none"
+	}
+	return fmt.Sprintf("[%s, %s)", s.Opens.Format(time.RFC3339), s.Closes.Format(time.RFC3339))
+}
+
+// byName indexes windows by name, keeping the first definition of each.
+func byName(windows []Window) map[string]Window {
+	m := make(map[string]Window)
+	for _, w := range windows {
+		if _, ok := m[w.Name]; !ok {
+			m[w.Name] = w
+		}
+	}
+	return m
+}
+
+// sameDefinition reports whether a and b are configured identically.
+func sameDefinition(a, b Window) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(ja, jb)
+}
+
+// nextSchedule returns the schedule of label open at now, or else the next
+// to open, or nil if there is none within deltaHorizon.
+func nextSchedule(m Map, label string, now time.Time) *Schedule {
+	// Only the first schedule is needed, so truncated occurrences suffice.
+	occ, _ := m.Occurrences(label, now, now.Add(deltaHorizon), AggregateMerge)
+	for _, s := range occ {
+		if now.Before(s.Closes) {
+			return &s
+		}
+	}
+	return nil
+}
+
+// scheduled returns a Map of the windows that are not pending approval.
+func scheduled(windows []Window) Map {
+	m := make(Map)
+	for _, w := range windows {
+		if !w.Pending() {
+			m.Add(w)
+		}
+	}
+	return m
+}
+
+// diffWindows compares the windows of two loads of a configuration
+// directory, along with the schedules of the labels they carry at now.
+func diffWindows(before, after []Window, now time.Time) ConfigChange {
+	c := ConfigChange{At: now}
+	old, cur := byName(before), byName(after)
+	labels := make(map[string]bool)
+	mark := func(w Window) {
+		for _, l := range w.Labels {
+			labels[strings.ToLower(l)] = true
+		}
+	}
+	for name, w := range cur {
+		o, ok := old[name]
+		switch {
+		case !ok:
+			c.Added = append(c.Added, name)
+		case !sameDefinition(o, w):
+			c.Modified = append(c.Modified, name)
+			mark(o)
+		default:
+			continue
+		}
+		mark(w)
+	}
+	for name, o := range old {
+		if _, ok := cur[name]; !ok {
+			c.Removed = append(c.Removed, name)
+			mark(o)
+		}
+	}
+	sort.Strings(c.Added)
+	sort.Strings(c.Removed)
+	sort.Strings(c.Modified)
+
+	oldMap, curMap := scheduled(before), scheduled(after)
+	var names []string
+	for l := range labels {
+		names = append(names, l)
+	}
+	sort.Strings(names)
+	for _, l := range names {
+		b, a := nextSchedule(oldMap, l, now), nextSchedule(curMap, l, now)
+		if b == nil && a == nil || b != nil && a != nil && b.Opens.Equal(a.Opens) && b.Closes.Equal(a.Closes) {
+			continue
+		}
+		c.Labels = append(c.Labels, LabelDelta{Label: l, Before: b, After: a})
+	}
+	return c
+}
+
+// reportChange logs c and records the number of windows it changed.
+func reportChange(dir string, c ConfigChange) {
+	deck.Infof("configuration in %q changed: %s", dir, c)
+	for kind, n := range map[string]int{"added": len(c.Added), "removed": len(c.Removed), "modified": len(c.Modified)} {
+		auklib.ReportInt("config_windows_changed", int64(n), map[string]string{"change": kind})
+	}
+}
+
+// LastChange returns the most recent change to the windows of dir, if any
+// has been seen since it was first loaded.
+func LastChange(dir string) (ConfigChange, bool) {
+	loads.mu.Lock()
+	defer loads.mu.Unlock()
+	c, ok := loads.changes[dir]
+	return c, ok
+}
@@ -0,0 +1,78 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package window
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDiffWindows(t *testing.T) {
+	now := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.Local)
+	cronWindow := func(name, spec string, d time.Duration, labels ...string) Window {
+		cr, err := cronParser.Parse(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return Window{Name: name, Format: FormatCron, CronString: spec, Cron: cr, Duration: d, Labels: labels}
+	}
+	nightly := cronWindow("nightly", "0 0 2 * * *", time.Hour, "patch")
+	longer := cronWindow("nightly", "0 0 2 * * *", 3*time.Hour, "patch")
+	weekly := cronWindow("weekly", "0 0 4 * * SUN", time.Hour, "reboot")
+	doc := cronWindow("doc", "0 0 5 * * *", time.Hour, "docs")
+	tagged := doc
+	tagged.Tags = map[string]string{"owner": "docs"}
+
+	before := []Window{nightly, weekly, doc}
+	after := []Window{longer, tagged, cronWindow("hourly", "0 0 * * * *", time.Minute, "metrics")}
+	c := diffWindows(before, after, now)
+
+	if diff := cmp.Diff([]string{"hourly"}, c.Added); diff != "" {
+		t.Errorf("diffWindows() Added diff (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"weekly"}, c.Removed); diff != "" {
+		t.Errorf("diffWindows() Removed diff (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"doc", "nightly"}, c.Modified); diff != "" {
+		t.Errorf("diffWindows() Modified diff (-want +got):\n%s", diff)
+	}
+	// Tagging doc does not move its schedule, so docs is not reported.
+	var labels []string
+	for _, d := range c.Labels {
+		labels = append(labels, d.Label)
+	}
+	if diff := cmp.Diff([]string{"metrics", "patch", "reboot"}, labels); diff != "" {
+		t.Fatalf("diffWindows() label deltas diff (-want +got):\n%s", diff)
+	}
+	if d := c.Labels[0]; d.Before != nil || d.After == nil {
+		t.Errorf("diffWindows() delta for new label = %+v, want only After", d)
+	}
+	if d := c.Labels[1]; d.Before == nil || d.After == nil || d.After.Closes.Sub(d.Before.Closes) != 2*time.Hour {
+		t.Errorf("diffWindows() delta for lengthened window = %+v, want close 2h later", d)
+	}
+	if d := c.Labels[2]; d.Before == nil || d.After != nil {
+		t.Errorf("diffWindows() delta for removed label = %+v, want only Before", d)
+	}
+	if s := c.String(); !strings.Contains(s, "added hourly") || !strings.Contains(s, "label reboot:") {
+		t.Errorf("String() = %q, want a summary of the change", s)
+	}
+
+	if c := diffWindows(before, before, now); !c.Empty() || len(c.Labels) != 0 {
+		t.Errorf("diffWindows() of identical windows = %+v, want empty", c)
+	}
+}
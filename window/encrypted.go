@@ -0,0 +1,138 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package window
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/google/aukera/auklib"
+)
+
+const (
+	// SchemeAESGCM encrypts configuration with AES-256-GCM using the key
+	// stored at ConfigKeyPath.
+	SchemeAESGCM = "aes256-gcm"
+	// SchemeDPAPI encrypts configuration with the Windows Data Protection
+	// API, bound to the local machine.
+	SchemeDPAPI = "dpapi"
+)
+
+// ConfigKeyPath holds the 32 byte key used to decrypt SchemeAESGCM files.
+var ConfigKeyPath = filepath.Join(auklib.DataDir, "config.key")
+
+// ErrUnsupportedScheme is returned for encryption schemes unavailable on this platform.
+var ErrUnsupportedScheme = errors.New("unsupported encryption scheme")
+
+// envelope is the on-disk form of an encrypted configuration file:
+//
+//	{"Encrypted": {"Scheme": "aes256-gcm", "Data": "<base64>"}}
+type envelope struct {
+	Encrypted *struct {
+		Scheme string
+		Data   []byte
+	}
+}
+
+// decryptConfig returns the plaintext of b if it is an encrypted envelope,
+// or b unchanged otherwise.
+func decryptConfig(b []byte) ([]byte, error) {
+	var e envelope
+	if json.Unmarshal(b, &e) != nil || e.Encrypted == nil {
+		return b, nil
+	}
+	switch e.Encrypted.Scheme {
+	case SchemeAESGCM:
+		return openAESGCM(e.Encrypted.Data)
+	case SchemeDPAPI:
+		return unprotect(e.Encrypted.Data)
+	}
+	return nil, fmt.Errorf("decryptConfig: %q: %w", e.Encrypted.Scheme, ErrUnsupportedScheme)
+}
+
+// EncryptConfig wraps the plaintext configuration b in an encrypted envelope
+// using scheme.
+func EncryptConfig(b []byte, scheme string) ([]byte, error) {
+	var data []byte
+	var err error
+	switch scheme {
+	case SchemeAESGCM:
+		data, err = sealAESGCM(b)
+	case SchemeDPAPI:
+		data, err = protect(b)
+	default:
+		err = fmt.Errorf("%q: %w", scheme, ErrUnsupportedScheme)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("EncryptConfig: %w", err)
+	}
+	var e envelope
+	e.Encrypted = &struct {
+		Scheme string
+		Data   []byte
+	}{scheme, data}
+	return json.MarshalIndent(e, "", "  ")
+}
+
+func configCipher() (cipher.AEAD, error) {
+	key, err := os.ReadFile(ConfigKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read configuration key: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("configuration key %q must be 32 bytes (found: %d)", ConfigKeyPath, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealAESGCM encrypts b, prefixing the ciphertext with its nonce.
+func sealAESGCM(b []byte) ([]byte, error) {
+	aead, err := configCipher()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, b, nil), nil
+}
+
+func openAESGCM(data []byte) ([]byte, error) {
+	aead, err := configCipher()
+	if err != nil {
+		return nil, fmt.Errorf("decryptConfig: %w", err)
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, fmt.Errorf("decryptConfig: ciphertext too short")
+	}
+	n := aead.NonceSize()
+	b, err := aead.Open(nil, data[:n], data[n:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("decryptConfig: %w", err)
+	}
+	return b, nil
+}
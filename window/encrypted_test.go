@@ -0,0 +1,76 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package window
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncryptedConfig(t *testing.T) {
+	dir := t.TempDir()
+	orig := ConfigKeyPath
+	defer func() { ConfigKeyPath = orig }()
+	ConfigKeyPath = filepath.Join(dir, "config.key")
+	if err := os.WriteFile(ConfigKeyPath, bytes.Repeat([]byte{7}, 32), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	plain := []byte(`{"Windows": [{"Name": "patch", "Format": 1, "Schedule": "0 0 2 * * *", "Duration": "1h", "Labels": ["patch"]}]}`)
+	enc, err := EncryptConfig(plain, SchemeAESGCM)
+	if err != nil {
+		t.Fatalf("EncryptConfig() returned error: %v", err)
+	}
+	if bytes.Contains(enc, []byte("patch")) {
+		t.Errorf("EncryptConfig() output contains plaintext: %s", enc)
+	}
+	path := filepath.Join(dir, "patch.json")
+	if err := os.WriteFile(path, enc, 0644); err != nil {
+		t.Fatal(err)
+	}
+	var r Reader
+	got, err := r.JSONContent(path)
+	if err != nil {
+		t.Fatalf("JSONContent() returned error: %v", err)
+	}
+	if !bytes.Equal(got, plain) {
+		t.Errorf("JSONContent() = %s, want %s", got, plain)
+	}
+	m, err := Windows(dir, r)
+	if err != nil {
+		t.Fatalf("Windows() returned error: %v", err)
+	}
+	if len(m.Find("patch")) != 1 {
+		t.Errorf("Windows() did not load the encrypted window: %v", m)
+	}
+
+	// A different key must not decrypt the file.
+	if err := os.WriteFile(ConfigKeyPath, bytes.Repeat([]byte{8}, 32), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.JSONContent(path); err == nil {
+		t.Errorf("JSONContent() with wrong key returned nil error")
+	}
+
+	// Plaintext files are returned unchanged.
+	if got, err := decryptConfig(plain); err != nil || !bytes.Equal(got, plain) {
+		t.Errorf("decryptConfig(plaintext) = %s, %v, want input unchanged", got, err)
+	}
+	if _, err := decryptConfig([]byte(`{"Encrypted": {"Scheme": "rot13", "Data": ""}}`)); err == nil {
+		t.Errorf("decryptConfig() with unknown scheme returned nil error")
+	}
+}
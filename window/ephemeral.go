@@ -0,0 +1,331 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package window
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/deck"
+	"github.com/google/aukera/auklib"
+)
+
+var (
+	// ErrInvalidEphemeral indicates an ephemeral window registration that
+	// cannot be accepted.
+	ErrInvalidEphemeral = errors.New("invalid ephemeral window")
+	// ErrWindowExists indicates a window of the same name is configured.
+	ErrWindowExists = errors.New("window already configured")
+	// ErrNotEphemeral indicates a configured window that cannot be changed
+	// through the API.
+	ErrNotEphemeral = errors.New("window is configured in a file")
+)
+
+// MaxEphemeralTTL bounds how long an ephemeral window is kept.
+var MaxEphemeralTTL = 7 * 24 * time.Hour
+
+// EphemeralFile persists ephemeral windows.
+var EphemeralFile = filepath.Join(auklib.DataDir, "ephemeral.json")
+
+// Ephemeral is a temporary window registered through the API rather than
+// configured in a file. It is merged with configured windows until its TTL
+// elapses, and records who registered it and why.
+type Ephemeral struct {
+	Window Window
+	// TTL is how long after Registered the window is kept. Its Expires
+	// must fall within it.
+	TTL        time.Duration
+	Reason     string
+	Source     string
+	Registered time.Time
+}
+
+type ephemeralJSON struct {
+	Window     Window
+	TTL        string
+	Reason     string    `json:",omitempty"`
+	Source     string    `json:",omitempty"`
+	Registered time.Time `json:",omitempty"`
+}
+
+// MarshalJSON is a custom marshaler for Ephemeral to ensure TTL is
+// marshalled as a human-readable string.
+func (e Ephemeral) MarshalJSON() ([]byte, error) {
+	return json.Marshal(ephemeralJSON{
+		Window:     e.Window,
+		TTL:        e.TTL.String(),
+		Reason:     e.Reason,
+		Source:     e.Source,
+		Registered: e.Registered,
+	})
+}
+
+// UnmarshalJSON is a custom Ephemeral unmarshaler.
+func (e *Ephemeral) UnmarshalJSON(b []byte) error {
+	var conv ephemeralJSON
+	if err := json.Unmarshal(b, &conv); err != nil {
+		return err
+	}
+	ttl, err := time.ParseDuration(conv.TTL)
+	if err != nil {
+		return fmt.Errorf("%w: TTL: %v", ErrInvalidEphemeral, err)
+	}
+	*e = Ephemeral{Window: conv.Window, TTL: ttl, Reason: conv.Reason, Source: conv.Source, Registered: conv.Registered}
+	return nil
+}
+
+// Purges returns when the window is discarded.
+func (e Ephemeral) Purges() time.Time {
+	return e.Registered.Add(e.TTL)
+}
+
+// validate checks that e may be registered at now.
+func (e Ephemeral) validate(now time.Time) error {
+	w := e.Window
+	switch {
+	case w.Name == "":
+		return fmt.Errorf("%w: window name not defined", ErrInvalidEphemeral)
+	case w.Expires.IsZero():
+		return fmt.Errorf("%w: window(%s) must specify Expires", ErrInvalidEphemeral, w.Name)
+	case !w.Expires.After(now):
+		return fmt.Errorf("%w: window(%s) expired at %s", ErrInvalidEphemeral, w.Name, w.Expires.Format(time.RFC3339))
+	case e.TTL <= 0 || (MaxEphemeralTTL > 0 && e.TTL > MaxEphemeralTTL):
+		return fmt.Errorf("%w: TTL must be within (0, %v] (found: %v)", ErrInvalidEphemeral, MaxEphemeralTTL, e.TTL)
+	case w.Expires.After(now.Add(e.TTL)):
+		return fmt.Errorf("%w: window(%s) expires after its TTL of %v", ErrInvalidEphemeral, w.Name, e.TTL)
+	}
+	return nil
+}
+
+// ephemeralStore persists ephemeral windows by name.
+type ephemeralStore struct {
+	mu     sync.Mutex
+	path   string
+	loaded bool
+	byName map[string]Ephemeral
+}
+
+var ephemerals = &ephemeralStore{}
+
+// file returns the path ephemeral windows are persisted to, EphemeralFile
+// unless the store was created with its own path.
+func (s *ephemeralStore) file() string {
+	if s.path != "" {
+		return s.path
+	}
+	return EphemeralFile
+}
+
+// load reads persisted ephemeral windows. Must be called with mu held.
+func (s *ephemeralStore) load() {
+	if s.loaded {
+		return
+	}
+	s.loaded = true
+	s.byName = make(map[string]Ephemeral)
+	b, err := os.ReadFile(s.file())
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		deck.Warningf("unable to read ephemeral windows %q: %v", s.file(), err)
+		return
+	}
+	if err := json.Unmarshal(b, &s.byName); err != nil {
+		deck.Warningf("unable to parse ephemeral windows %q: %v", s.file(), err)
+	}
+}
+
+// active returns the ephemeral windows not yet purged at now, ordered by name.
+func (s *ephemeralStore) active(now time.Time) []Ephemeral {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	var out []Ephemeral
+	for _, e := range s.byName {
+		if now.Before(e.Purges()) {
+			out = append(out, e)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Window.Name < out[j].Window.Name })
+	return out
+}
+
+// add persists e, replacing any ephemeral window of the same name and
+// discarding those purged by now.
+func (s *ephemeralStore) add(e Ephemeral, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l, err := auklib.LockFile(s.file())
+	if err != nil {
+		return err
+	}
+	defer l.Unlock()
+	// Reload under the lock to keep windows registered by other processes.
+	s.loaded = false
+	s.load()
+	for name, old := range s.byName {
+		if !now.Before(old.Purges()) {
+			delete(s.byName, name)
+		}
+	}
+	s.byName[e.Window.Name] = e
+	b, err := json.Marshal(s.byName)
+	if err != nil {
+		return err
+	}
+	return auklib.WriteFileAtomic(s.file(), b, 0644)
+}
+
+// remove discards the ephemeral window named name, reporting whether it was
+// active at now.
+func (s *ephemeralStore) remove(name string, now time.Time) (Ephemeral, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l, err := auklib.LockFile(s.file())
+	if err != nil {
+		return Ephemeral{}, false, err
+	}
+	defer l.Unlock()
+	s.loaded = false
+	s.load()
+	e, ok := s.byName[name]
+	if !ok {
+		return Ephemeral{}, false, nil
+	}
+	delete(s.byName, name)
+	b, err := json.Marshal(s.byName)
+	if err != nil {
+		return Ephemeral{}, false, err
+	}
+	if err := auklib.WriteFileAtomic(s.file(), b, 0644); err != nil {
+		return Ephemeral{}, false, err
+	}
+	return e, now.Before(e.Purges()), nil
+}
+
+// RegisterEphemeral validates and persists e, registered now, so that its
+// window is merged with those configured in dir until its TTL elapses.
+// Registering a window of the same name as an ephemeral window replaces it,
+// while a name configured in dir returns an error wrapping ErrWindowExists.
+func RegisterEphemeral(dir string, cr ConfigReader, e Ephemeral) (Ephemeral, error) {
+	now := time.Now()
+	e.Registered = now
+	if err := e.validate(now); err != nil {
+		return Ephemeral{}, err
+	}
+	configured, err := loadConfigured(dir, cr, sha256.New(), make(map[string]map[string]bool))
+	if err != nil {
+		return Ephemeral{}, err
+	}
+	for _, w := range configured {
+		if w.Name == e.Window.Name {
+			return Ephemeral{}, fmt.Errorf("window(%s): %w", w.Name, ErrWindowExists)
+		}
+	}
+	if err := ephemerals.add(e, now); err != nil {
+		return Ephemeral{}, fmt.Errorf("window(%s): unable to persist ephemeral window: %w", e.Window.Name, err)
+	}
+	deck.Infof("window(%s): registered ephemeral window %s from %q until %s (expires %s, reason %q)",
+		e.Window.Name, windowKey(e.Window), e.Source, e.Purges().Format(time.RFC3339),
+		e.Window.Expires.Format(time.RFC3339), e.Reason)
+	auklib.ReportInt("ephemeral_registered", 1, map[string]string{"window": e.Window.Name, "source": e.Source})
+	return e, nil
+}
+
+// shadowed holds the names of ephemeral windows last found shadowed by a
+// configured window, so that each is logged once while the configured
+// window persists rather than on every load.
+var shadowed = struct {
+	sync.Mutex
+	names map[string]bool
+}{}
+
+// reportShadowed logs the ephemeral windows named in names that were not
+// already shadowed at the previous load.
+func reportShadowed(names map[string]bool) {
+	shadowed.Lock()
+	defer shadowed.Unlock()
+	for n := range names {
+		if !shadowed.names[n] {
+			deck.Warningf("window(%s): configured window takes precedence over ephemeral window", n)
+		}
+	}
+	shadowed.names = names
+}
+
+// mergeEphemeral appends the ephemeral windows active at now to configured,
+// adding their definitions to h. Configured windows take precedence over
+// ephemeral windows of the same name.
+func mergeEphemeral(configured []Window, h hash.Hash, now time.Time) []Window {
+	names := make(map[string]bool, len(configured))
+	for _, w := range configured {
+		names[w.Name] = true
+	}
+	windows := configured
+	hidden := make(map[string]bool)
+	defer reportShadowed(hidden)
+	for _, e := range ephemerals.active(now) {
+		w := e.Window
+		if names[w.Name] {
+			hidden[w.Name] = true
+			continue
+		}
+		w.Labels = append([]string(nil), w.Labels...)
+		w.Source = SourceEphemeral
+		if e.Source != "" {
+			w.Source += ":" + e.Source
+		}
+		w.calculateSchedule()
+		fmt.Fprintf(h, "ephemeral:%s\n%s\n", w.Name, windowKey(w))
+		windows = append(windows, w)
+	}
+	return windows
+}
+
+// DeleteEphemeral discards the ephemeral window named name at the request
+// of source. Windows configured in dir cannot be deleted and return an error
+// wrapping ErrNotEphemeral, while other unknown names return an error
+// wrapping ErrNoWindows.
+func DeleteEphemeral(dir string, cr ConfigReader, name, source string) (Ephemeral, error) {
+	configured, err := loadConfigured(dir, cr, sha256.New(), make(map[string]map[string]bool))
+	if err != nil {
+		return Ephemeral{}, err
+	}
+	for _, w := range configured {
+		if w.Name == name {
+			return Ephemeral{}, fmt.Errorf("window(%s): %w", name, ErrNotEphemeral)
+		}
+	}
+	e, ok, err := ephemerals.remove(name, time.Now())
+	if err != nil {
+		return Ephemeral{}, fmt.Errorf("window(%s): unable to persist ephemeral windows: %w", name, err)
+	}
+	if !ok {
+		return Ephemeral{}, fmt.Errorf("ephemeral window(%s): %w", name, ErrNoWindows)
+	}
+	deck.Infof("window(%s): deleted ephemeral window %s from %q at the request of %q",
+		name, windowKey(e.Window), e.Source, source)
+	auklib.ReportInt("ephemeral_deleted", 1, map[string]string{"window": name, "source": source})
+	return e, nil
+}
@@ -0,0 +1,210 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package window
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/deck"
+	"github.com/google/deck/backends/logger"
+)
+
+func TestRegisterEphemeral(t *testing.T) {
+	orig := ephemerals
+	defer func() { ephemerals = orig }()
+	path := filepath.Join(t.TempDir(), "ephemeral.json")
+	ephemerals = &ephemeralStore{path: path}
+
+	r := fileReader{files: map[string]string{
+		"a.json": `{"Windows": [{"Name": "regular", "Format": 1, "Schedule": "0 0 2 * * *", "Duration": "1h", "Labels": ["a"]}]}`,
+	}}
+	now := time.Now()
+	register := func(name string, expires time.Time, ttl time.Duration) error {
+		var e Ephemeral
+		in := fmt.Sprintf(`{"Window": {"Name": %q, "Starts": %q, "Expires": %q, "Duration": "30m", "Labels": ["a"]}, "TTL": %q, "Reason": "test"}`,
+			name, now.Add(-time.Minute).Format(time.RFC3339), expires.Format(time.RFC3339), ttl)
+		if err := json.Unmarshal([]byte(in), &e); err != nil {
+			return err
+		}
+		_, err := RegisterEphemeral("conf", r, e)
+		return err
+	}
+
+	tests := []struct {
+		desc    string
+		name    string
+		expires time.Time
+		ttl     time.Duration
+		wantErr error
+	}{
+		{"configured name", "regular", now.Add(time.Hour), 2 * time.Hour, ErrWindowExists},
+		{"expired", "late", now.Add(-time.Hour), 2 * time.Hour, ErrInvalidEphemeral},
+		{"no TTL", "late", now.Add(time.Hour), 0, ErrInvalidEphemeral},
+		{"TTL too long", "late", now.Add(time.Hour), MaxEphemeralTTL + time.Hour, ErrInvalidEphemeral},
+		{"expires after TTL", "late", now.Add(3 * time.Hour), 2 * time.Hour, ErrInvalidEphemeral},
+		{"registered", "hotfix", now.Add(time.Hour), 2 * time.Hour, nil},
+	}
+	for _, tt := range tests {
+		if err := register(tt.name, tt.expires, tt.ttl); !errors.Is(err, tt.wantErr) {
+			t.Errorf("RegisterEphemeral(%s) returned %v, want %v", tt.desc, err, tt.wantErr)
+		}
+	}
+
+	var e Ephemeral
+	if err := json.Unmarshal([]byte(`{"Window": {"Name": "w", "Starts": "2026-01-01T00:00:00Z", "Duration": "1h", "Labels": ["a"]}}`), &e); !errors.Is(err, ErrInvalidEphemeral) {
+		t.Errorf("json.Unmarshal(no TTL) returned %v, want %v", err, ErrInvalidEphemeral)
+	}
+
+	hash := func() string {
+		_, h, err := loadWindows("conf", r)
+		if err != nil {
+			t.Fatalf("loadWindows() returned error: %v", err)
+		}
+		return h
+	}
+	before := hash()
+	count := func() int {
+		m, err := Windows("conf", r)
+		if err != nil {
+			t.Fatalf("Windows() returned error: %v", err)
+		}
+		return len(m.Find("a"))
+	}
+	if got := count(); got != 2 {
+		t.Errorf("Windows() after registration returned %d windows, want 2", got)
+	}
+	m, err := Windows("conf", r)
+	if err != nil {
+		t.Fatalf("Windows() returned error: %v", err)
+	}
+	for name, want := range map[string]string{"regular": filepath.Join("conf", "a.json"), "hotfix": SourceEphemeral} {
+		if got := m.FindWindow(name, "a").Source; got != want {
+			t.Errorf("Windows() window %q has Source %q, want %q", name, got, want)
+		}
+	}
+
+	// Ephemeral windows persist across restarts, and changing them changes
+	// the configuration hash.
+	ephemerals = &ephemeralStore{path: path}
+	if got := count(); got != 2 {
+		t.Errorf("Windows() after reload returned %d windows, want 2", got)
+	}
+	if err := register("hotfix", now.Add(90*time.Minute), 2*time.Hour); err != nil {
+		t.Fatalf("RegisterEphemeral(replacement) returned error: %v", err)
+	}
+	if got := count(); got != 2 {
+		t.Errorf("Windows() after replacement returned %d windows, want 2", got)
+	}
+	if hash() == before {
+		t.Error("loadWindows() hash unchanged after replacing an ephemeral window")
+	}
+
+	// Windows are discarded once their TTL elapses.
+	if got := ephemerals.active(now.Add(3 * time.Hour)); len(got) != 0 {
+		t.Errorf("active() after TTL returned %d windows, want 0", len(got))
+	}
+}
+
+func TestReportShadowed(t *testing.T) {
+	defer func() { shadowed.names = nil }()
+	var logBuffer bytes.Buffer
+	deck.Add(logger.Init(&logBuffer, 0))
+	count := func() int {
+		return strings.Count(logBuffer.String(), "window(hotfix): configured window takes precedence")
+	}
+
+	for i := 0; i < 3; i++ {
+		reportShadowed(map[string]bool{"hotfix": true})
+	}
+	if got := count(); got != 1 {
+		t.Errorf("reportShadowed() logged %d warnings while shadowed, want 1", got)
+	}
+	// Shadowing again after the configured window was removed is logged anew.
+	reportShadowed(map[string]bool{})
+	reportShadowed(map[string]bool{"hotfix": true})
+	if got := count(); got != 2 {
+		t.Errorf("reportShadowed() logged %d warnings after shadowing resumed, want 2", got)
+	}
+}
+
+func TestEphemeralMarshal(t *testing.T) {
+	in := `{"Window": {"Name": "w", "Starts": "2026-01-01T00:00:00Z", "Expires": "2026-01-01T02:00:00Z", "Duration": "1h", "Labels": ["a"]}, "TTL": "3h", "Reason": "test"}`
+	var e Ephemeral
+	if err := json.Unmarshal([]byte(in), &e); err != nil {
+		t.Fatalf("json.Unmarshal() returned error: %v", err)
+	}
+	if e.TTL != 3*time.Hour || e.Window.Name != "w" || e.Reason != "test" {
+		t.Errorf("json.Unmarshal() = %+v", e)
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		t.Fatalf("json.Marshal() returned error: %v", err)
+	}
+	if !strings.Contains(string(b), `"TTL":"3h0m0s"`) {
+		t.Errorf("json.Marshal() = %s, want TTL", b)
+	}
+}
+
+func TestDeleteEphemeral(t *testing.T) {
+	orig := ephemerals
+	defer func() { ephemerals = orig }()
+	ephemerals = &ephemeralStore{path: filepath.Join(t.TempDir(), "ephemeral.json")}
+
+	r := fileReader{files: map[string]string{
+		"a.json": `{"Windows": [{"Name": "regular", "Format": 1, "Schedule": "0 0 2 * * *", "Duration": "1h", "Labels": ["a"]}]}`,
+	}}
+	now := time.Now()
+	e := Ephemeral{
+		Window: Window{Name: "hotfix", Starts: now, Expires: now.Add(time.Hour), Duration: 30 * time.Minute, Labels: []string{"a"}},
+		TTL:    2 * time.Hour,
+		Source: "127.0.0.1:1234",
+	}
+	if _, err := RegisterEphemeral("conf", r, e); err != nil {
+		t.Fatalf("RegisterEphemeral() returned error: %v", err)
+	}
+
+	tests := []struct {
+		desc, name string
+		wantErr    error
+	}{
+		{"configured", "regular", ErrNotEphemeral},
+		{"unknown", "missing", ErrNoWindows},
+		{"deleted", "hotfix", nil},
+		{"already deleted", "hotfix", ErrNoWindows},
+	}
+	for _, tt := range tests {
+		got, err := DeleteEphemeral("conf", r, tt.name, "127.0.0.1:5678")
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("DeleteEphemeral(%s) returned %v, want %v", tt.desc, err, tt.wantErr)
+		}
+		if err == nil && got.Source != e.Source {
+			t.Errorf("DeleteEphemeral(%s) = %+v, want registration from %q", tt.desc, got, e.Source)
+		}
+	}
+	m, err := Windows("conf", r)
+	if err != nil {
+		t.Fatalf("Windows() returned error: %v", err)
+	}
+	if got := len(m.Find("a")); got != 1 {
+		t.Errorf("Windows() after deletion returned %d windows, want 1", got)
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package window
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// fuzzWindows seeds the window fuzzers with valid and hostile configurations.
+var fuzzWindows = []string{
+	`{"Name":"daily","Format":1,"Schedule":"0 0 2 * * *","Duration":"2h","Labels":["a"]}`,
+	`{"Name":"oneoff","Starts":"2026-03-01T02:00:00Z","Duration":"1h","Labels":["a"]}`,
+	`{"Name":"bounded","Format":1,"Schedule":"0 30 1 * * 1-5","Duration":"90m","Starts":"2026-01-01T00:00:00Z","Expires":"2026-02-01T00:00:00Z","TruncateAtExpiry":true,"Labels":["a","b"],"SampleRate":0.5,"Tags":{"notify":"true"}}`,
+	`{"Name":"never","Format":1,"Schedule":"0 0 0 30 2 *","Duration":"1h","Labels":["a"]}`,
+	`{"Name":"huge","Format":1,"Schedule":"@daily","Duration":"2562047h","Labels":["a"]}`,
+	`{"Name":"every","Format":1,"Schedule":"@every 1m","Duration":"1h","Labels":["a"]}`,
+	`{"Name":"negative","Format":1,"Schedule":"@hourly","Duration":"-1h","Labels":["a"]}`,
+	`{"Name":"inverted","Format":1,"Schedule":"@daily","Duration":"1h","Starts":"2030-01-01T00:00:00Z","Expires":"2020-01-01T00:00:00Z","Labels":["a"]}`,
+	`{"Name":"bad","Format":7,"Schedule":"* * *","Duration":"x","Labels":[]}`,
+	`null`,
+}
+
+// fuzzFrom bounds the occurrences computed for fuzzed windows.
+var fuzzFrom = time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+func FuzzWindowUnmarshalJSON(f *testing.F) {
+	for _, s := range fuzzWindows {
+		f.Add([]byte(s))
+	}
+	f.Fuzz(func(t *testing.T, b []byte) {
+		var w Window
+		if err := json.Unmarshal(b, &w); err != nil {
+			return
+		}
+		w.Occurrences(fuzzFrom, fuzzFrom.Add(48*time.Hour))
+		out, err := json.Marshal(w)
+		if err != nil {
+			t.Fatalf("Marshal(%s) returned error: %v", b, err)
+		}
+		if w.Name == "" {
+			// Only null leaves the window unset.
+			return
+		}
+		var got Window
+		if err := json.Unmarshal(out, &got); err != nil {
+			t.Fatalf("Unmarshal(Marshal(%s)) = %s returned error: %v", b, out, err)
+		}
+		if got.Name != w.Name || got.Duration != w.Duration || got.CronString != w.CronString {
+			t.Errorf("Unmarshal(Marshal(%s)) = %+v, want %+v", b, got, w)
+		}
+	})
+}
+
+func FuzzScheduleUnmarshalJSON(f *testing.F) {
+	for _, s := range []string{
+		`{"Name":"a","State":"open","Duration":"1h","Opens":"2026-03-01T02:00:00Z","Closes":"2026-03-01T03:00:00Z"}`,
+		`{"Name":"a","Duration":"-2562047h47m16.854775808s"}`,
+		`{"Duration":"1e9h"}`,
+		`null`,
+	} {
+		f.Add([]byte(s))
+	}
+	f.Fuzz(func(t *testing.T, b []byte) {
+		var s Schedule
+		if err := json.Unmarshal(b, &s); err != nil {
+			return
+		}
+		s.CurrentState()
+		out, err := json.Marshal(&s)
+		if err != nil {
+			// Times outside the years 0-9999 cannot be marshaled.
+			return
+		}
+		var got Schedule
+		if err := json.Unmarshal(out, &got); err != nil {
+			t.Fatalf("Unmarshal(Marshal(%s)) = %s returned error: %v", b, out, err)
+		}
+		if got.Name != s.Name || got.Duration != s.Duration || !got.Opens.Equal(s.Opens) || !got.Closes.Equal(s.Closes) {
+			t.Errorf("Unmarshal(Marshal(%s)) = %+v, want %+v", b, got, s)
+		}
+	})
+}
+
+func FuzzMapUnmarshalJSON(f *testing.F) {
+	for _, s := range fuzzWindows {
+		f.Add([]byte(`{"Windows":[` + s + `]}`))
+	}
+	f.Add([]byte(`{"Windows":[` + fuzzWindows[0] + `,` + fuzzWindows[2] + `]}`))
+	f.Add([]byte(`{"Windows":null}`))
+	f.Fuzz(func(t *testing.T, b []byte) {
+		m := make(Map)
+		if err := json.Unmarshal(b, &m); err != nil {
+			return
+		}
+		for _, l := range m.Keys() {
+			m.Aggregate(l, AggregateMerge)
+			m.Occurrences(l, fuzzFrom, fuzzFrom.Add(48*time.Hour), AggregateMerge)
+		}
+		if _, err := json.Marshal(m); err != nil {
+			t.Errorf("Marshal(Unmarshal(%s)) returned error: %v", b, err)
+		}
+	})
+}
+
+func TestUnmarshalPathologicalWindows(t *testing.T) {
+	// Unbounded durations must not hang schedule calculation either.
+	defer func(d time.Duration) { MaxDuration = d }(MaxDuration)
+	MaxDuration = 0
+	tests := []struct {
+		desc    string
+		in      string
+		wantErr bool
+	}{
+		{"never activates", fuzzWindows[3], false},
+		{"huge duration", fuzzWindows[4], false},
+		{"constant delay", fuzzWindows[5], true},
+		{"negative duration", fuzzWindows[6], true},
+	}
+	for _, tt := range tests {
+		done := make(chan error, 1)
+		go func() {
+			var w Window
+			done <- json.Unmarshal([]byte(tt.in), &w)
+		}()
+		select {
+		case err := <-done:
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UnmarshalJSON(%s) returned error %v, want error: %t", tt.desc, err, tt.wantErr)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("UnmarshalJSON(%s) did not return within a second", tt.desc)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package window
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// InlineEnv is the environment variable holding inline configuration; see
+// ParseInline.
+const InlineEnv = "AUKERA_WINDOWS"
+
+// Inline holds configuration supplied other than in files, such as on the
+// command line of ephemeral containers. Each element is the content of a
+// configuration file, loaded after the files of the configuration directory
+// under the name InlineName(i). While Inline is set, a missing configuration
+// directory is treated as empty. It must be set before windows are loaded.
+var Inline [][]byte
+
+// InlineName names the i-th element of Inline in logs and check results.
+func InlineName(i int) string {
+	return fmt.Sprintf("inline[%d]", i)
+}
+
+// ParseInline converts inline configuration s into the content of a
+// configuration file. s may be a configuration file, a JSON array of
+// windows or a single window.
+func ParseInline(s string) ([]byte, error) {
+	b := bytes.TrimSpace([]byte(s))
+	if len(b) == 0 {
+		return nil, fmt.Errorf("ParseInline: empty configuration")
+	}
+	var doc []byte
+	switch b[0] {
+	case '[':
+		doc = []byte(fmt.Sprintf(`{"Windows": %s}`, b))
+	case '{':
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(b, &fields); err != nil {
+			return nil, fmt.Errorf("ParseInline: %w", err)
+		}
+		// Windows are named; configuration files are not.
+		doc = b
+		if _, ok := fields["Name"]; ok {
+			doc = []byte(fmt.Sprintf(`{"Windows": [%s]}`, b))
+		}
+	default:
+		return nil, fmt.Errorf("ParseInline: want a JSON object or array")
+	}
+	if err := ValidateSchema(doc); err != nil {
+		return nil, fmt.Errorf("ParseInline: %w", err)
+	}
+	fc := CheckContent("inline", doc)
+	if fc.Status == CheckError {
+		return nil, fmt.Errorf("ParseInline: %s", strings.Join(fc.Errors, "; "))
+	}
+	return doc, nil
+}
+
+// readsDir reports whether the configuration files of dir are read, which is
+// always unless Inline is set and dir does not exist.
+func readsDir(dir string, cr ConfigReader) bool {
+	if len(Inline) == 0 {
+		return true
+	}
+	ok, err := cr.PathExists(dir)
+	return err != nil || ok
+}
@@ -0,0 +1,109 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package window
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseInline(t *testing.T) {
+	const w = `{"Name": "nightly", "Format": 1, "Schedule": "0 0 2 * * *", "Duration": "1h", "Labels": ["patch"]}`
+	tests := []struct {
+		desc, in, want, err string
+	}{
+		{desc: "window", in: w, want: `{"Windows": [` + w + `]}`},
+		{desc: "array", in: " [" + w + "] ", want: `{"Windows": [` + w + `]}`},
+		{desc: "file", in: `{"Windows": [` + w + `]}`, want: `{"Windows": [` + w + `]}`},
+		{desc: "labels only", in: `{"Labels": {"patch": {"Description": "Patching"}}}`, want: `{"Labels": {"patch": {"Description": "Patching"}}}`},
+		{desc: "empty", in: " ", err: "empty"},
+		{desc: "scalar", in: `"nightly"`, err: "JSON object or array"},
+		{desc: "malformed", in: `{"Name": `, err: "unexpected end"},
+		{desc: "invalid schedule", in: `{"Name": "x", "Format": 1, "Schedule": "bogus", "Duration": "1h", "Labels": ["x"]}`, err: "window 0"},
+		{desc: "schema", in: `{"Name": "x", "Format": 1, "Schedule": "0 0 2 * * *", "Duration": "1h"}`, err: "schema"},
+	}
+	for _, tt := range tests {
+		got, err := ParseInline(tt.in)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: ParseInline() error = %v, want containing %q", tt.desc, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: ParseInline() = %v", tt.desc, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s: ParseInline() = %s, want %s", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestInline(t *testing.T) {
+	defer func(i [][]byte) { Inline = i }(Inline)
+	b, err := ParseInline(`{"Windows": [{"Name": "inline", "Format": 1, "Schedule": "0 0 3 * * *", "Duration": "1h", "Labels": ["patch"]}], "Labels": {"patch": {"Description": "Patching"}}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Inline = [][]byte{b}
+
+	// A missing configuration directory is empty while Inline is set.
+	missing := filepath.Join(t.TempDir(), "missing")
+	windows, _, err := loadWindows(missing, Reader{})
+	if err != nil {
+		t.Fatalf("loadWindows(missing) = %v", err)
+	}
+	if len(windows) != 1 || windows[0].Name != "inline" {
+		t.Errorf("loadWindows(missing) = %v, want the inline window", windows)
+	}
+	checks, err := Check(missing, Reader{})
+	if err != nil {
+		t.Fatalf("Check(missing) = %v", err)
+	}
+	if len(checks) != 1 || checks[0].Path != InlineName(0) || checks[0].Status != CheckOK || checks[0].Windows != 1 {
+		t.Errorf("Check(missing) = %+v, want one passing inline check", checks)
+	}
+	info, err := LabelInfos(missing, Reader{})
+	if err != nil {
+		t.Fatalf("LabelInfos(missing) = %v", err)
+	}
+	if info["patch"].Description != "Patching" {
+		t.Errorf("LabelInfos(missing) = %v, want inline label metadata", info)
+	}
+
+	// Inline windows are loaded after those of the directory.
+	dir := t.TempDir()
+	conf := `{"Windows": [{"Name": "nightly", "Format": 1, "Schedule": "0 0 2 * * *", "Duration": "1h", "Labels": ["patch"]}]}`
+	if err := os.WriteFile(filepath.Join(dir, "a.json"), []byte(conf), 0644); err != nil {
+		t.Fatal(err)
+	}
+	windows, hash, err := loadWindows(dir, Reader{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(windows) != 2 || windows[0].Name != "nightly" || windows[1].Name != "inline" {
+		t.Errorf("loadWindows() = %v, want nightly then inline", windows)
+	}
+	Inline = nil
+	if _, base, err := loadWindows(dir, Reader{}); err != nil || base == hash {
+		t.Errorf("loadWindows() hash did not change with Inline (err %v)", err)
+	}
+	if _, _, err := loadWindows(missing, Reader{}); err == nil {
+		t.Error("loadWindows(missing) without Inline succeeded")
+	}
+}
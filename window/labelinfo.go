@@ -0,0 +1,107 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package window
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/google/deck"
+)
+
+// Label severities, from least to most disruptive.
+var severities = []string{"info", "low", "medium", "high", "critical"}
+
+var colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// LabelInfo holds display metadata for a label, defined in the "Labels"
+// object of a configuration file keyed by label name.
+type LabelInfo struct {
+	Description string `json:",omitempty"`
+	Category    string `json:",omitempty"`
+	Severity    string `json:",omitempty"`
+	// Color is a hex RGB color, such as "#1a73e8".
+	Color string `json:",omitempty"`
+}
+
+// validate checks the metadata of the named label.
+func (l LabelInfo) validate(name string) error {
+	if !labelPattern.MatchString(name) {
+		return fmt.Errorf("label(%s): %w: must match %s", name, ErrInvalidLabel, labelPattern)
+	}
+	if l.Severity != "" {
+		valid := false
+		for _, s := range severities {
+			valid = valid || l.Severity == s
+		}
+		if !valid {
+			return fmt.Errorf("label(%s): severity must be one of %s (found: %q)", name, strings.Join(severities, ", "), l.Severity)
+		}
+	}
+	if l.Color != "" && !colorPattern.MatchString(l.Color) {
+		return fmt.Errorf("label(%s): color must be formatted as #rrggbb (found: %q)", name, l.Color)
+	}
+	return nil
+}
+
+// LabelInfos reads the label metadata defined within the given directory and
+// Inline. Invalid entries are skipped, and the first definition of a label is
+// used.
+func LabelInfos(dir string, cr ConfigReader) (map[string]LabelInfo, error) {
+	out := make(map[string]LabelInfo)
+	if readsDir(dir, cr) {
+		files, err := cr.JSONFiles(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			b, err := cr.JSONContent(filepath.Join(dir, f.Name()))
+			if err != nil {
+				continue
+			}
+			addLabelInfos(out, f.Name(), b)
+		}
+	}
+	for i, b := range Inline {
+		addLabelInfos(out, InlineName(i), b)
+	}
+	return out, nil
+}
+
+// addLabelInfos adds the valid label metadata of the configuration file name
+// with content b to out.
+func addLabelInfos(out map[string]LabelInfo, name string, b []byte) {
+	s := struct {
+		Labels map[string]LabelInfo
+	}{}
+	if err := json.Unmarshal(b, &s); err != nil {
+		return
+	}
+	for n, l := range s.Labels {
+		n = strings.ToLower(n)
+		if err := l.validate(n); err != nil {
+			deck.Warningf("file %q: %v", name, err)
+			continue
+		}
+		if _, ok := out[n]; ok {
+			deck.Warningf("file %q: label(%s): metadata already defined", name, n)
+			continue
+		}
+		out[n] = l
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package window
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLabelInfos(t *testing.T) {
+	r := fileReader{files: map[string]string{
+		"a.json": `{"Windows": [], "Labels": {
+			"OS_Patch": {"Description": "Operating system patching", "Category": "os", "Severity": "high", "Color": "#1a73e8"},
+			"bad color": {"Color": "blue"}
+		}}`,
+		"b.json": `{"Labels": {
+			"os_patch": {"Description": "duplicate"},
+			"reboot": {"Severity": "urgent"},
+			"app": {"Category": "apps"}
+		}}`,
+	}}
+	got, err := LabelInfos("conf", r)
+	if err != nil {
+		t.Fatalf("LabelInfos() returned error: %v", err)
+	}
+	want := map[string]LabelInfo{
+		"os_patch": {Description: "Operating system patching", Category: "os", Severity: "high", Color: "#1a73e8"},
+		"app":      {Category: "apps"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("LabelInfos() returned diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestCheckLabelInfo(t *testing.T) {
+	r := fileReader{files: map[string]string{
+		"labels.json": `{"Labels": {"reboot": {"Severity": "urgent"}}}`,
+	}}
+	got, err := Check("conf", r)
+	if err != nil {
+		t.Fatalf("Check() returned error: %v", err)
+	}
+	if len(got) != 1 || got[0].Status != CheckError || len(got[0].Errors) != 1 {
+		t.Errorf("Check() = %+v, want a single severity error", got)
+	}
+}
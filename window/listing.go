@@ -0,0 +1,71 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package window
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Listing is a Window as listed by the service, annotated with values
+// derived from its definition as of the time it was listed.
+type Listing struct {
+	Window
+	// RemainingOccurrences is the count reported by
+	// Window.RemainingOccurrences, or nil for windows without Expires.
+	RemainingOccurrences *int
+	// Pending reports whether the window awaits approval, and so is not
+	// scheduled.
+	Pending bool
+}
+
+// NewListing lists w as of now.
+func NewListing(w Window, now time.Time) Listing {
+	l := Listing{Window: w, Pending: w.Pending()}
+	if n, ok := w.RemainingOccurrences(now); ok {
+		l.RemainingOccurrences = &n
+	}
+	return l
+}
+
+// MarshalJSON marshals the listing as its window's configuration fields
+// followed by the derived fields and the window's Source.
+func (l Listing) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		windowJSON
+		RemainingOccurrences *int   `json:",omitempty"`
+		Pending              bool   `json:",omitempty"`
+		Source               string `json:",omitempty"`
+	}{l.Window.toJSON(), l.RemainingOccurrences, l.Pending, l.Window.Source})
+}
+
+// UnmarshalJSON is a custom Listing unmarshaler.
+func (l *Listing) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &l.Window); err != nil {
+		return err
+	}
+	derived := struct {
+		RemainingOccurrences *int
+		Pending              bool
+		Source               string
+	}{}
+	if err := json.Unmarshal(b, &derived); err != nil {
+		return err
+	}
+	l.RemainingOccurrences = derived.RemainingOccurrences
+	l.Pending = derived.Pending
+	l.Window.Source = derived.Source
+	return nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package window
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestListingJSON(t *testing.T) {
+	var w Window
+	if err := json.Unmarshal([]byte(`{"Name": "temp", "Format": 1, "Schedule": "0 0 2 * * *", "Duration": "1h", "Labels": ["patch"], "Expires": "2099-01-01T00:00:00Z"}`), &w); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2098, time.December, 29, 12, 0, 0, 0, time.UTC)
+	w.Source = "/etc/aukera/temp.json"
+	l := NewListing(w, now)
+	if l.RemainingOccurrences == nil {
+		t.Fatal("NewListing() of an expiring window has no RemainingOccurrences")
+	}
+	b, err := json.Marshal(l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"Schedule":"0 0 2 * * *"`) || !strings.Contains(string(b), `"RemainingOccurrences":`) {
+		t.Errorf("Listing.MarshalJSON() = %s, want configuration and derived fields", b)
+	}
+	var got Listing
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("Listing.UnmarshalJSON(%s) = %v", b, err)
+	}
+	if got.Name != "temp" || got.Source != w.Source || got.RemainingOccurrences == nil || *got.RemainingOccurrences != *l.RemainingOccurrences {
+		t.Errorf("Listing round trip = %+v, want %+v", got, l)
+	}
+
+	w.Expires = time.Time{}
+	b, err = json.Marshal(NewListing(w, now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "RemainingOccurrences") {
+		t.Errorf("Listing.MarshalJSON() of a window without Expires = %s", b)
+	}
+}
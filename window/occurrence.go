@@ -0,0 +1,135 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package window
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// maxOccurrences bounds the number of occurrences computed for a single window.
+const maxOccurrences = 10000
+
+// Occurrences returns a schedule for each activation of the window that is
+// open at any point within [from, to). Activations outside of Starts and
+// Expires, or that this host is not sampled into, are omitted. Windows with
+// more than maxOccurrences activations within the period return those found
+// among the first maxOccurrences along with an error wrapping
+// ErrTooManyOccurrences.
+func (w *Window) Occurrences(from, to time.Time) ([]Schedule, error) {
+	var out []Schedule
+	add := func(open time.Time) {
+		s := Schedule{
+			Name:            w.Name,
+			Opens:           open.Local(),
+			Closes:          w.closeTime(open).Local(),
+			MaxTaskDuration: w.MaxTaskDuration,
+		}
+		s.update()
+		out = append(out, s)
+	}
+	// Windows without a cron schedule, such as active hours, have a single
+	// occurrence, unless they are unscheduled or never open.
+	if w.Cron == nil {
+		s := w.Schedule
+		if s.Opens.Before(s.Closes) && s.Closes.After(from) && s.Opens.Before(to) {
+			out = append(out, s)
+		}
+		return out, nil
+	}
+	open := w.NextActivation(from.Add(-w.Duration))
+	for i := 0; !open.IsZero() && open.Before(to); i++ {
+		if !w.Expires.IsZero() && open.After(w.Expires) {
+			break
+		}
+		if i == maxOccurrences {
+			return out, fmt.Errorf("window(%s): %w: more than %d activations between %s and %s", w.Name, ErrTooManyOccurrences,
+				maxOccurrences, from.Format(time.RFC3339), to.Format(time.RFC3339))
+		}
+		if !open.Before(w.Starts) && w.closeTime(open).After(from) && w.Sampled(open) {
+			add(open)
+		}
+		next := w.NextActivation(open.Add(time.Minute))
+		if !next.After(open) {
+			break
+		}
+		open = next
+	}
+	return out, nil
+}
+
+// MaxRemainingOccurrences bounds the count returned by RemainingOccurrences.
+const MaxRemainingOccurrences = 1000
+
+// RemainingOccurrences counts the activations of the window after now and no
+// later than Expires, up to MaxRemainingOccurrences. Sampling is not taken
+// into account. ok is false for windows without Expires, which never age out.
+func (w *Window) RemainingOccurrences(now time.Time) (n int, ok bool) {
+	if w.Expires.IsZero() {
+		return 0, false
+	}
+	// Windows without a cron schedule have a single occurrence, opening at
+	// Starts for one-off windows whether or not this host is sampled into it.
+	if w.Cron == nil {
+		open := w.Schedule.Opens
+		if w.OneOff() {
+			open = w.Starts
+		}
+		if open.After(now) && !open.After(w.Expires) {
+			return 1, true
+		}
+		return 0, true
+	}
+	from := now
+	if w.Starts.After(from) {
+		from = w.Starts.Add(-time.Second)
+	}
+	open := w.NextActivation(from)
+	for n < MaxRemainingOccurrences && !open.IsZero() && !open.After(w.Expires) {
+		if open.After(now) && !open.Before(w.Starts) {
+			n++
+		}
+		next := w.NextActivation(open.Add(time.Minute))
+		if !next.After(open) {
+			break
+		}
+		open = next
+	}
+	return n, true
+}
+
+// Occurrences returns the occurrences of all windows with the given label
+// within [from, to), combining those that overlap using the given Aggregation.
+// If a window has too many occurrences to compute, the error of its
+// Occurrences is returned along with the occurrences found.
+func (m Map) Occurrences(label string, from, to time.Time, a Aggregation) ([]Schedule, error) {
+	label = strings.ToLower(label)
+	var (
+		schedules []Schedule
+		err       error
+	)
+	for _, w := range m[label] {
+		occ, wErr := w.Occurrences(from, to)
+		if wErr != nil && err == nil {
+			err = wErr
+		}
+		for _, s := range occ {
+			s.Name = label
+			schedules = append(schedules, s)
+		}
+	}
+	return mergeSchedules(schedules, a), err
+}
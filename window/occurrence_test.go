@@ -0,0 +1,215 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package window
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOccurrences(t *testing.T) {
+	src := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.Local)
+	cr, err := cronParser.Parse("0 0 2 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	daily := Window{Name: "daily", Format: FormatCron, Cron: cr, Duration: 2 * time.Hour, Labels: []string{"a"}}
+	expiring := daily
+	expiring.Name = "expiring"
+	expiring.Expires = src.Add(36 * time.Hour)
+
+	tests := []struct {
+		desc     string
+		w        Window
+		from, to time.Time
+		want     []time.Time
+	}{
+		{"three days", daily, src, src.Add(72 * time.Hour),
+			[]time.Time{src.Add(2 * time.Hour), src.Add(26 * time.Hour), src.Add(50 * time.Hour)}},
+		{"open at start", daily, src.Add(3 * time.Hour), src.Add(24 * time.Hour),
+			[]time.Time{src.Add(2 * time.Hour)}},
+		{"expires", expiring, src, src.Add(72 * time.Hour),
+			[]time.Time{src.Add(2 * time.Hour), src.Add(26 * time.Hour)}},
+	}
+	for _, tt := range tests {
+		got, err := tt.w.Occurrences(tt.from, tt.to)
+		if err != nil {
+			t.Errorf("Occurrences(%s) returned error: %v", tt.desc, err)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("Occurrences(%s) returned %d schedules, want %d: %v", tt.desc, len(got), len(tt.want), got)
+			continue
+		}
+		for i := range got {
+			if !got[i].Opens.Equal(tt.want[i]) || got[i].Duration != tt.w.Duration {
+				t.Errorf("Occurrences(%s)[%d] = %v, want opens %v", tt.desc, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestMapOccurrences(t *testing.T) {
+	src := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.Local)
+	two, err := cronParser.Parse("0 0 2 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	three, err := cronParser.Parse("0 0 3 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := make(Map)
+	m.Add(
+		Window{Name: "two", Format: FormatCron, Cron: two, Duration: 2 * time.Hour, Labels: []string{"a"}},
+		Window{Name: "three", Format: FormatCron, Cron: three, Duration: 2 * time.Hour, Labels: []string{"a"}},
+	)
+	got, err := m.Occurrences("A", src, src.Add(24*time.Hour), AggregateMerge)
+	if err != nil {
+		t.Fatalf("Occurrences() returned error: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("Occurrences() returned %d schedules, want 1: %v", len(got), got)
+	}
+	if got[0].Name != "a" || !got[0].Opens.Equal(src.Add(2*time.Hour)) || !got[0].Closes.Equal(src.Add(5*time.Hour)) {
+		t.Errorf("Occurrences() = %v, want label a open 02:00-05:00", got[0])
+	}
+}
+
+func TestOccurrencesTruncateAtExpiry(t *testing.T) {
+	src := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.Local)
+	cr, err := cronParser.Parse("0 0 2 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := Window{Name: "expiring", Format: FormatCron, Cron: cr, Duration: 2 * time.Hour,
+		Expires: src.Add(27 * time.Hour), Labels: []string{"a"}}
+
+	got, _ := w.Occurrences(src, src.Add(72*time.Hour))
+	if len(got) != 2 || !got[1].Closes.Equal(src.Add(28*time.Hour)) {
+		t.Errorf("Occurrences() without truncation = %v, want second closing at %v", got, src.Add(28*time.Hour))
+	}
+	w.TruncateAtExpiry = true
+	got, _ = w.Occurrences(src, src.Add(72*time.Hour))
+	if len(got) != 2 {
+		t.Fatalf("Occurrences() with truncation returned %d schedules, want 2: %v", len(got), got)
+	}
+	if !got[0].Closes.Equal(src.Add(4 * time.Hour)) {
+		t.Errorf("Occurrences()[0] closes %v, want %v", got[0].Closes, src.Add(4*time.Hour))
+	}
+	if !got[1].Closes.Equal(w.Expires) || got[1].Duration != time.Hour {
+		t.Errorf("Occurrences()[1] = %v, want closing at %v after 1h", got[1], w.Expires)
+	}
+}
+
+func TestOccurrencesTooMany(t *testing.T) {
+	src := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.Local)
+	cr, err := cronParser.Parse("0 * * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := Window{Name: "minutely", Format: FormatCron, Cron: cr, Duration: time.Minute, Labels: []string{"a"}}
+	got, err := w.Occurrences(src, src.Add(30*24*time.Hour))
+	if !errors.Is(err, ErrTooManyOccurrences) {
+		t.Errorf("Occurrences() returned error %v, want %v", err, ErrTooManyOccurrences)
+	}
+	if len(got) != maxOccurrences {
+		t.Errorf("Occurrences() returned %d schedules, want %d", len(got), maxOccurrences)
+	}
+	m := make(Map)
+	m.Add(w)
+	if _, err := m.Occurrences("a", src, src.Add(30*24*time.Hour), AggregateMerge); !errors.Is(err, ErrTooManyOccurrences) {
+		t.Errorf("Map.Occurrences() returned error %v, want %v", err, ErrTooManyOccurrences)
+	}
+}
+
+func TestOccurrencesWithoutCron(t *testing.T) {
+	src := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.Local)
+	tests := []struct {
+		desc  string
+		s     Schedule
+		count int
+	}{
+		{"open", Schedule{Opens: src.Add(time.Hour), Closes: src.Add(2 * time.Hour)}, 1},
+		{"unscheduled", Schedule{}, 0},
+		{"zero length", Schedule{Opens: src.Add(time.Hour), Closes: src.Add(time.Hour)}, 0},
+	}
+	for _, tt := range tests {
+		w := Window{Name: "hours", Schedule: tt.s}
+		got, err := w.Occurrences(src, src.Add(24*time.Hour))
+		if err != nil || len(got) != tt.count {
+			t.Errorf("Occurrences(%s) = %v, %v, want %d schedules", tt.desc, got, err, tt.count)
+		}
+	}
+}
+
+func TestCloseTime(t *testing.T) {
+	src := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.Local)
+	w := Window{Duration: 2 * time.Hour, Expires: src.Add(time.Hour), TruncateAtExpiry: true}
+	tests := []struct {
+		desc       string
+		open, want time.Time
+	}{
+		{"spans expiry", src, src.Add(time.Hour)},
+		{"before expiry", src.Add(-3 * time.Hour), src.Add(-time.Hour)},
+		{"after expiry", src.Add(2 * time.Hour), src.Add(2 * time.Hour)},
+	}
+	for _, tt := range tests {
+		if got := w.closeTime(tt.open); !got.Equal(tt.want) {
+			t.Errorf("closeTime(%s) = %v, want %v", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestRemainingOccurrences(t *testing.T) {
+	now := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.Local)
+	cr, err := cronParser.Parse("0 0 2 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	minutely, err := cronParser.Parse("0 * * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	daily := Window{Name: "daily", Format: FormatCron, Cron: cr, Duration: time.Hour, Labels: []string{"a"}}
+	oneOff := Window{Name: "once", Duration: time.Hour, Labels: []string{"a"}, Starts: now.Add(time.Hour), Expires: now.Add(48 * time.Hour)}
+	oneOff.Schedule = Schedule{Opens: oneOff.Starts, Closes: oneOff.Starts.Add(time.Hour)}
+
+	tests := []struct {
+		desc            string
+		w               Window
+		starts, expires time.Time
+		want            int
+		wantOK          bool
+	}{
+		{desc: "no expiry", w: daily, want: 0, wantOK: false},
+		{desc: "three days", w: daily, expires: now.Add(72 * time.Hour), want: 3, wantOK: true},
+		{desc: "expires at activation", w: daily, expires: now.Add(26 * time.Hour), want: 2, wantOK: true},
+		{desc: "starts later", w: daily, starts: now.Add(24 * time.Hour), expires: now.Add(72 * time.Hour), want: 2, wantOK: true},
+		{desc: "expired", w: daily, expires: now.Add(-time.Hour), want: 0, wantOK: true},
+		{desc: "bounded", w: Window{Name: "minutely", Format: FormatCron, Cron: minutely, Duration: time.Minute, Labels: []string{"a"}},
+			expires: now.AddDate(1, 0, 0), want: MaxRemainingOccurrences, wantOK: true},
+		{desc: "one-off", w: oneOff, starts: oneOff.Starts, expires: oneOff.Expires, want: 1, wantOK: true},
+	}
+	for _, tt := range tests {
+		w := tt.w
+		w.Starts, w.Expires = tt.starts, tt.expires
+		got, ok := w.RemainingOccurrences(now)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("RemainingOccurrences(%s) = %d, %t, want %d, %t", tt.desc, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package window
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/google/deck"
+	"github.com/google/aukera/auklib"
+)
+
+// PermissionMode selects how configuration that unprivileged users could
+// modify is treated. Windows gate maintenance actions such as reboots, so a
+// user-writable configuration file lets any local user trigger them.
+type PermissionMode string
+
+const (
+	// PermissionOff loads configuration regardless of its ownership and
+	// permissions. This is the default.
+	PermissionOff PermissionMode = "off"
+	// PermissionWarn loads insecure configuration, logging a warning and
+	// reporting the config_file_insecure metric.
+	PermissionWarn PermissionMode = "warn"
+	// PermissionEnforce refuses to load insecure configuration.
+	PermissionEnforce PermissionMode = "enforce"
+)
+
+// FilePermissions is the PermissionMode applied by Reader. Configuration
+// files and directories are secure when owned by root or the Administrators
+// group (or SYSTEM on Windows), or by the user running the service, and not
+// writable by everyone.
+var FilePermissions = PermissionOff
+
+// ErrInsecureFile is returned by Reader for configuration that unprivileged
+// users could modify when FilePermissions is PermissionEnforce.
+var ErrInsecureFile = errors.New("configuration is modifiable by unprivileged users")
+
+// ParsePermissionMode parses the name of a PermissionMode. The empty string
+// denotes PermissionOff.
+func ParsePermissionMode(s string) (PermissionMode, error) {
+	switch m := PermissionMode(s); m {
+	case "":
+		return PermissionOff, nil
+	case PermissionOff, PermissionWarn, PermissionEnforce:
+		return m, nil
+	}
+	return "", fmt.Errorf("invalid permission mode %q: must be one of off, warn, enforce", s)
+}
+
+// fnInsecure is insecure, replaced in tests.
+var fnInsecure = insecure
+
+// warnedInsecure holds the paths already warned about, so that loads, which
+// happen on every query, do not repeat the warning.
+var warnedInsecure = struct {
+	sync.Mutex
+	paths map[string]bool
+}{paths: make(map[string]bool)}
+
+// checkPermissions applies FilePermissions to the file or directory at path,
+// returning an error wrapping ErrInsecureFile if it is refused.
+func checkPermissions(path string) error {
+	if FilePermissions == PermissionOff || FilePermissions == "" {
+		return nil
+	}
+	problem, err := fnInsecure(path)
+	if err != nil {
+		return err
+	}
+	warnedInsecure.Lock()
+	defer warnedInsecure.Unlock()
+	if problem == "" {
+		delete(warnedInsecure.paths, path)
+		return nil
+	}
+	if FilePermissions == PermissionEnforce {
+		return fmt.Errorf("%q: %w: %s", path, ErrInsecureFile, problem)
+	}
+	if !warnedInsecure.paths[path] {
+		warnedInsecure.paths[path] = true
+		deck.Warningf("%q: %v: %s", path, ErrInsecureFile, problem)
+		auklib.ReportInt("config_file_insecure", 1, map[string]string{"file": path})
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package window
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestParsePermissionMode(t *testing.T) {
+	for in, want := range map[string]PermissionMode{"": PermissionOff, "off": PermissionOff, "warn": PermissionWarn, "enforce": PermissionEnforce} {
+		if got, err := ParsePermissionMode(in); err != nil || got != want {
+			t.Errorf("ParsePermissionMode(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParsePermissionMode("strict"); err == nil {
+		t.Errorf("ParsePermissionMode(strict) returned nil error")
+	}
+}
+
+func TestInsecure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes do not apply on Windows")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(`{"Windows": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if problem, err := insecure(path); err != nil || problem != "" {
+		t.Errorf("insecure(0644) = %q, %v; want secure", problem, err)
+	}
+	if err := os.Chmod(path, 0666); err != nil {
+		t.Fatal(err)
+	}
+	if problem, err := insecure(path); err != nil || problem == "" {
+		t.Errorf("insecure(0666) = %q, %v; want world-writable", problem, err)
+	}
+}
+
+func TestCheckPermissions(t *testing.T) {
+	defer func(m PermissionMode) { FilePermissions = m }(FilePermissions)
+	defer func(fn func(string) (string, error)) { fnInsecure = fn }(fnInsecure)
+	fnInsecure = func(path string) (string, error) {
+		if path == "bad.json" {
+			return "world-writable (mode -rw-rw-rw-)", nil
+		}
+		return "", nil
+	}
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(bad, []byte(`{"Windows": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		mode    PermissionMode
+		path    string
+		wantErr bool
+	}{
+		{PermissionOff, "bad.json", false},
+		{PermissionWarn, "bad.json", false},
+		{PermissionEnforce, "bad.json", true},
+		{PermissionEnforce, "good.json", false},
+	}
+	for _, tt := range tests {
+		FilePermissions = tt.mode
+		err := checkPermissions(tt.path)
+		if (err != nil) != tt.wantErr || (err != nil && !errors.Is(err, ErrInsecureFile)) {
+			t.Errorf("checkPermissions(%s) in mode %s = %v, want error: %t", tt.path, tt.mode, err, tt.wantErr)
+		}
+	}
+
+	// Reader refuses insecure files when enforcing.
+	fnInsecure = func(path string) (string, error) {
+		if path == bad {
+			return "world-writable (mode -rw-rw-rw-)", nil
+		}
+		return "", nil
+	}
+	FilePermissions = PermissionEnforce
+	if _, err := (Reader{}).JSONContent(bad); !errors.Is(err, ErrInsecureFile) {
+		t.Errorf("JSONContent() of an insecure file returned %v, want %v", err, ErrInsecureFile)
+	}
+	if _, err := (Reader{}).JSONFiles(dir); err != nil {
+		t.Errorf("JSONFiles() of a secure directory returned error: %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package window
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// insecure describes why the file or directory at path could be modified by
+// unprivileged users, or returns "" if it cannot.
+func insecure(path string) (string, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok && st.Uid != 0 && int(st.Uid) != os.Geteuid() {
+		return fmt.Sprintf("owned by uid %d rather than root", st.Uid), nil
+	}
+	if fi.Mode().Perm()&0002 != 0 {
+		return fmt.Sprintf("world-writable (mode %v)", fi.Mode().Perm()), nil
+	}
+	return "", nil
+}
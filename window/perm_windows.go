@@ -0,0 +1,103 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+// +build windows
+
+package window
+
+import (
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// Access rights that allow a file's content or security to be changed.
+const writeRights = windows.FILE_WRITE_DATA | windows.FILE_APPEND_DATA | windows.DELETE |
+	windows.WRITE_DAC | windows.WRITE_OWNER | windows.GENERIC_WRITE | windows.GENERIC_ALL
+
+// ACE layout, as defined by ACE_HEADER and ACCESS_ALLOWED_ACE.
+const (
+	accessAllowedACEType = 0
+	inheritOnlyACE       = 0x08
+	aclHeaderSize        = 8
+	aceSIDOffset         = 8
+)
+
+// unprivileged are the groups that include ordinary users.
+var unprivileged = []windows.WELL_KNOWN_SID_TYPE{
+	windows.WinWorldSid,
+	windows.WinAuthenticatedUserSid,
+	windows.WinBuiltinUsersSid,
+	windows.WinInteractiveSid,
+}
+
+// insecure describes why the file or directory at path could be modified by
+// unprivileged users, or returns "" if it cannot.
+func insecure(path string) (string, error) {
+	sd, err := windows.GetNamedSecurityInfo(path, windows.SE_FILE_OBJECT,
+		windows.OWNER_SECURITY_INFORMATION|windows.DACL_SECURITY_INFORMATION)
+	if err != nil {
+		return "", fmt.Errorf("GetNamedSecurityInfo(%q): %w", path, err)
+	}
+	owner, _, err := sd.Owner()
+	if err != nil {
+		return "", err
+	}
+	if !owner.IsWellKnown(windows.WinBuiltinAdministratorsSid) && !owner.IsWellKnown(windows.WinLocalSystemSid) && !isProcessUser(owner) {
+		return fmt.Sprintf("owned by %s rather than Administrators or SYSTEM", owner), nil
+	}
+	dacl, _, err := sd.DACL()
+	if err != nil {
+		return "", err
+	}
+	if dacl == nil {
+		return "no access control list, granting everyone full control", nil
+	}
+	return writableACE(dacl), nil
+}
+
+// writableACE describes the first entry of dacl allowing unprivileged users
+// to write, or returns "" if there is none.
+func writableACE(dacl *windows.ACL) string {
+	base := unsafe.Pointer(dacl)
+	count := *(*uint16)(unsafe.Add(base, 4))
+	off := aclHeaderSize
+	for i := 0; i < int(count); i++ {
+		ace := unsafe.Add(base, off)
+		aceType, flags := *(*byte)(ace), *(*byte)(unsafe.Add(ace, 1))
+		off += int(*(*uint16)(unsafe.Add(ace, 2)))
+		if aceType != accessAllowedACEType || flags&inheritOnlyACE != 0 {
+			continue
+		}
+		mask := *(*windows.ACCESS_MASK)(unsafe.Add(ace, 4))
+		sid := (*windows.SID)(unsafe.Add(ace, aceSIDOffset))
+		if mask&writeRights == 0 {
+			continue
+		}
+		for _, u := range unprivileged {
+			if sid.IsWellKnown(u) {
+				return fmt.Sprintf("writable by %s", sid)
+			}
+		}
+	}
+	return ""
+}
+
+// isProcessUser reports whether sid is the user running the service.
+func isProcessUser(sid *windows.SID) bool {
+	u, err := windows.GetCurrentProcessToken().GetTokenUser()
+	return err == nil && u.User.Sid.Equals(sid)
+}
@@ -0,0 +1,79 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package window
+
+import (
+	"time"
+)
+
+// Reason explains, in machine-readable form, why a schedule is closed.
+type Reason string
+
+const (
+	// ReasonBetweenOccurrences denotes a schedule closed until the next
+	// occurrence of its window's cron schedule.
+	ReasonBetweenOccurrences Reason = "between_occurrences"
+	// ReasonNotStarted denotes a window whose Starts has yet to pass.
+	ReasonNotStarted Reason = "not_started"
+	// ReasonExpired denotes a window whose Expires has passed.
+	ReasonExpired Reason = "expired"
+	// ReasonEnded denotes a schedule that has closed without another
+	// occurrence to follow, such as a one-off window.
+	ReasonEnded Reason = "ended"
+	// ReasonNotSampled denotes a window whose current or next occurrence
+	// does not apply to this host; see Window.SampleRate.
+	ReasonNotSampled Reason = "not_sampled"
+	// ReasonNeverActivates denotes a window whose cron schedule has no
+	// occurrence between Starts and Expires.
+	ReasonNeverActivates Reason = "never_activates"
+)
+
+// closedReason returns why the schedule of w, computed at now, is closed, or
+// "" if it is open.
+func (w *Window) closedReason(now time.Time) Reason {
+	s := w.Schedule
+	switch {
+	case s.Contains(now):
+		return ""
+	case w.OneOff() && !w.Sampled(w.Starts), w.unscheduled() && w.SampleRate > 0 && w.SampleRate < 1:
+		return ReasonNotSampled
+	case !s.Opens.Before(s.Closes) && !w.OneOff():
+		return ReasonNeverActivates
+	case !w.Expires.IsZero() && w.Expires.Before(now):
+		return ReasonExpired
+	case now.Before(w.Starts):
+		return ReasonNotStarted
+	case !s.Closes.After(now):
+		return ReasonEnded
+	case w.SampleRate > 0 && w.SampleRate < 1 && w.Cron != nil:
+		// An earlier occurrence skipped for this host lies between now and
+		// the next sampled one.
+		if next := w.NextActivation(now); !next.IsZero() && next.Before(s.Opens) {
+			return ReasonNotSampled
+		}
+	}
+	return ReasonBetweenOccurrences
+}
+
+// defaultReason returns why s is closed at now, based on its times alone.
+func (s Schedule) defaultReason(now time.Time) Reason {
+	switch {
+	case s.Contains(now):
+		return ""
+	case s.Opens.After(now):
+		return ReasonBetweenOccurrences
+	}
+	return ReasonEnded
+}
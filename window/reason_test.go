@@ -0,0 +1,105 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package window
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClosedReason(t *testing.T) {
+	day := func(d, h, m int) time.Time { return time.Date(2020, time.January, d, h, m, 0, 0, time.Local) }
+	cr, err := cronParser.Parse("0 0 2 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		desc            string
+		now             time.Time
+		starts, expires time.Time
+		want            Reason
+	}{
+		{"open", day(10, 2, 30), time.Time{}, time.Time{}, ""},
+		{"between occurrences", day(10, 12, 0), time.Time{}, time.Time{}, ReasonBetweenOccurrences},
+		{"not started", day(10, 12, 0), day(15, 0, 0), time.Time{}, ReasonNotStarted},
+		{"expired", day(10, 12, 0), time.Time{}, day(5, 3, 0), ReasonExpired},
+		{"no occurrence before expiry", day(10, 12, 0), time.Time{}, day(10, 20, 0), ReasonEnded},
+		{"never activates", day(10, 12, 0), day(5, 3, 0), day(5, 4, 0), ReasonNeverActivates},
+	}
+	for _, tt := range tests {
+		w := Window{Name: tt.desc, Format: FormatCron, Cron: cr, Duration: time.Hour,
+			Starts: tt.starts, Expires: tt.expires}
+		w.computeActivation(tt.now)
+		if got := w.closedReason(tt.now); got != tt.want {
+			t.Errorf("closedReason(%s) = %q, want %q", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestClosedReasonNotSampled(t *testing.T) {
+	origHost := hostID
+	defer func() { hostID = origHost }()
+	cr, err := cronParser.Parse("0 0 * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2020, time.January, 1, 0, 30, 0, 0, time.Local)
+	// Find a host that skips the next occurrence.
+	for i := 0; i < 1000; i++ {
+		hostID = func() string { return fmt.Sprintf("host%d", i) }
+		w := Window{Name: "hourly", Format: FormatCron, Cron: cr, Duration: time.Minute, SampleRate: 0.1}
+		if w.Sampled(now.Add(30 * time.Minute)) {
+			continue
+		}
+		w.computeActivation(now)
+		if got := w.closedReason(now); got != ReasonNotSampled {
+			t.Errorf("closedReason() = %q, want %q", got, ReasonNotSampled)
+		}
+		return
+	}
+	t.Fatal("no host skips the next occurrence")
+}
+
+func TestScheduleReasonJSON(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		desc string
+		s    Schedule
+		want string
+	}{
+		{"open", Schedule{Opens: now.Add(-time.Hour), Closes: now.Add(time.Hour), Reason: ReasonExpired}, ""},
+		{"set", Schedule{Opens: now.Add(time.Hour), Closes: now.Add(2 * time.Hour), Reason: ReasonNotStarted}, `"Reason":"not_started"`},
+		{"upcoming", Schedule{Opens: now.Add(time.Hour), Closes: now.Add(2 * time.Hour)}, `"Reason":"between_occurrences"`},
+	}
+	for _, tt := range tests {
+		b, err := json.Marshal(&tt.s)
+		if err != nil {
+			t.Fatalf("%s: json.Marshal() returned error: %v", tt.desc, err)
+		}
+		if got := strings.Contains(string(b), `"Reason"`); tt.want == "" && got || tt.want != "" && !strings.Contains(string(b), tt.want) {
+			t.Errorf("%s: json.Marshal() = %s, want Reason %s", tt.desc, b, tt.want)
+		}
+		var s Schedule
+		if err := json.Unmarshal(b, &s); err != nil {
+			t.Fatalf("%s: json.Unmarshal() returned error: %v", tt.desc, err)
+		}
+		if tt.want != "" && !strings.Contains(tt.want, string(s.Reason)) {
+			t.Errorf("%s: round trip Reason = %q, want %s", tt.desc, s.Reason, tt.want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package window
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"time"
+
+	"github.com/google/deck"
+)
+
+// maxSampleSearch bounds the number of occurrences evaluated when searching
+// for the next occurrence a host is sampled into.
+const maxSampleSearch = 1000
+
+// hostID identifies this host for occurrence sampling.
+var hostID = func() string {
+	h, err := os.Hostname()
+	if err != nil {
+		deck.Warningf("unable to determine hostname for window sampling: %v", err)
+	}
+	return h
+}
+
+// Sampled reports whether the occurrence of the window opening at open
+// applies to this host. The decision is a deterministic hash of the host,
+// window name and occurrence, so every host reaches the same answer on
+// every query while a SampleRate fraction of the fleet is included.
+func (w *Window) Sampled(open time.Time) bool {
+	if w.SampleRate <= 0 || w.SampleRate >= 1 {
+		return true
+	}
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s|%s|%d", hostID(), w.Name, open.Unix())
+	return float64(h.Sum64())/math.MaxUint64 < w.SampleRate
+}
+
+// nextSampled returns the first occurrence at or after open that this host
+// is sampled into, or the zero time if none is found within maxSampleSearch.
+func (w *Window) nextSampled(open time.Time) time.Time {
+	if w.Cron == nil {
+		return open
+	}
+	o := open
+	for i := 0; i < maxSampleSearch; i++ {
+		if w.Sampled(o) {
+			return o
+		}
+		n := w.NextActivation(o.Add(time.Minute))
+		if n.IsZero() || !n.After(o) || (!w.Expires.IsZero() && n.After(w.Expires)) {
+			break
+		}
+		o = n
+	}
+	return time.Time{}
+}
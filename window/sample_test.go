@@ -0,0 +1,136 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package window
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSampled(t *testing.T) {
+	src := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	w := Window{Name: "canary", SampleRate: 0.25}
+
+	origHost := hostID
+	defer func() { hostID = origHost }()
+
+	var in int
+	const hosts = 2000
+	for i := 0; i < hosts; i++ {
+		hostID = func() string { return fmt.Sprintf("host%d", i) }
+		a, b := w.Sampled(src), w.Sampled(src)
+		if a != b {
+			t.Fatalf("Sampled() is not deterministic for host%d", i)
+		}
+		if a {
+			in++
+		}
+	}
+	if got := float64(in) / hosts; got < 0.2 || got > 0.3 {
+		t.Errorf("Sampled() included %.2f of hosts, want approximately 0.25", got)
+	}
+
+	full := Window{Name: "all"}
+	if !full.Sampled(src) {
+		t.Errorf("Sampled() excluded host from window without a sample rate")
+	}
+}
+
+func TestNextSampled(t *testing.T) {
+	src := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	cr, err := cronParser.Parse("0 0 * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := Window{Name: "hourly", Format: FormatCron, Cron: cr, SampleRate: 0.1}
+	got := w.nextSampled(src)
+	if !w.Sampled(got) {
+		t.Errorf("nextSampled(%v) = %v, which is not sampled", src, got)
+	}
+	for o := src; o.Before(got); o = o.Add(time.Hour) {
+		if w.Sampled(o) {
+			t.Errorf("nextSampled(%v) = %v, skipped sampled occurrence %v", src, got, o)
+		}
+	}
+}
+
+func TestNextSampledNone(t *testing.T) {
+	now := time.Date(2020, time.January, 1, 0, 30, 0, 0, time.UTC)
+	cr, err := cronParser.Parse("0 0 * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := Window{Name: "hourly", Labels: []string{"patch"}, Format: FormatCron, Cron: cr, Duration: time.Minute, SampleRate: 1e-12}
+	if got := w.nextSampled(now); !got.IsZero() {
+		t.Errorf("nextSampled(%v) = %v, want the zero time", now, got)
+	}
+	// The host is not told to open on an occurrence it is excluded from.
+	m := make(Map)
+	m.Add(w)
+	if got := m.AggregateAt("patch", AggregateMerge, now); len(got) != 0 {
+		t.Errorf("AggregateAt() = %v, want no schedules", got)
+	}
+	w.calculateScheduleAt(now)
+	if w.Schedule.Reason != ReasonNotSampled {
+		t.Errorf("calculateScheduleAt() Reason = %q, want %q", w.Schedule.Reason, ReasonNotSampled)
+	}
+}
+
+func TestUnmarshalSampleRate(t *testing.T) {
+	tests := []struct {
+		rate    string
+		want    float64
+		wantErr bool
+	}{
+		{"", 0, false},
+		{`, "SampleRate": 0.5`, 0.5, false},
+		{`, "SampleRate": 1`, 1, false},
+		{`, "SampleRate": 0`, 0, true},
+		{`, "SampleRate": 1.5`, 0, true},
+	}
+	for _, tt := range tests {
+		var w Window
+		b := []byte(`{"Name": "s", "Format": 1, "Schedule": "0 0 * * * *", "Duration": "1h", "Labels": ["s"]` + tt.rate + `}`)
+		err := json.Unmarshal(b, &w)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("UnmarshalJSON(%s) returned error %v, want error: %t", b, err, tt.wantErr)
+		}
+		if err == nil && w.SampleRate != tt.want {
+			t.Errorf("UnmarshalJSON(%s) SampleRate = %v, want %v", b, w.SampleRate, tt.want)
+		}
+	}
+}
+
+func TestOneOffNotSampled(t *testing.T) {
+	now := time.Date(2020, time.January, 1, 0, 30, 0, 0, time.UTC)
+	w := Window{Name: "one-off", Labels: []string{"patch"}, Starts: now.Add(time.Hour), Duration: time.Hour, SampleRate: 1e-12}
+	w.calculateScheduleAt(now)
+	if !w.unscheduled() {
+		t.Errorf("calculateScheduleAt() schedule = [%v, %v], want unscheduled", w.Schedule.Opens, w.Schedule.Closes)
+	}
+	if w.Schedule.Reason != ReasonNotSampled {
+		t.Errorf("calculateScheduleAt() Reason = %q, want %q", w.Schedule.Reason, ReasonNotSampled)
+	}
+	if got, _ := w.Occurrences(now, now.Add(24*time.Hour)); len(got) != 0 {
+		t.Errorf("Occurrences() = %v, want none", got)
+	}
+	m := make(Map)
+	m.Add(w)
+	if got := m.AggregateAt("patch", AggregateMerge, now); len(got) != 0 {
+		t.Errorf("AggregateAt() = %v, want no schedules", got)
+	}
+}
@@ -0,0 +1,234 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package window
+
+import (
+	"bytes"
+	_ "embed" // for Schema
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Schema is the JSON Schema of configuration files. It is stable: fields may
+// be added, but existing fields keep their meaning.
+//
+//go:embed schema.json
+var Schema []byte
+
+// ErrSchema is returned when configuration does not match Schema.
+var ErrSchema = errors.New("configuration does not match schema")
+
+// schemaNode is the subset of JSON Schema used by Schema.
+type schemaNode struct {
+	Type                 string
+	Properties           map[string]*schemaNode
+	Required             []string
+	AdditionalProperties json.RawMessage
+	PropertyNames        *schemaNode
+	Items                *schemaNode
+	Enum                 []any
+	Pattern              string
+	Format               string
+	MinItems, MinLength  *int
+	Maximum              *float64
+	ExclusiveMinimum     *float64
+
+	additional   *schemaNode
+	noAdditional bool
+	re           *regexp.Regexp
+}
+
+// compile prepares n and its children for validation.
+func (n *schemaNode) compile() error {
+	var err error
+	if n.Pattern != "" {
+		if n.re, err = regexp.Compile(n.Pattern); err != nil {
+			return err
+		}
+	}
+	switch {
+	case len(n.AdditionalProperties) == 0:
+	case bytes.Equal(n.AdditionalProperties, []byte("false")):
+		n.noAdditional = true
+	case bytes.Equal(n.AdditionalProperties, []byte("true")):
+	default:
+		n.additional = new(schemaNode)
+		if err := json.Unmarshal(n.AdditionalProperties, n.additional); err != nil {
+			return err
+		}
+	}
+	children := []*schemaNode{n.additional, n.PropertyNames, n.Items}
+	for _, c := range n.Properties {
+		children = append(children, c)
+	}
+	for _, c := range children {
+		if c == nil {
+			continue
+		}
+		if err := c.compile(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var rootSchema = func() *schemaNode {
+	var n schemaNode
+	if err := json.Unmarshal(Schema, &n); err != nil {
+		panic(fmt.Sprintf("window: invalid schema: %v", err))
+	}
+	if err := n.compile(); err != nil {
+		panic(fmt.Sprintf("window: invalid schema: %v", err))
+	}
+	return &n
+}()
+
+// ValidateSchema returns an error wrapping ErrSchema listing every violation
+// of Schema by the configuration file b. A file matching Schema may still be
+// rejected when loaded, for example for an invalid cron expression.
+func ValidateSchema(b []byte) error {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var v any
+	if err := d.Decode(&v); err != nil {
+		return fmt.Errorf("%w: %v", ErrSchema, err)
+	}
+	var errs []string
+	rootSchema.validate("", v, &errs)
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %s", ErrSchema, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// validate appends the violations of n by v, located at JSON pointer path,
+// to errs.
+func (n *schemaNode) validate(path string, v any, errs *[]string) {
+	fail := func(format string, a ...any) {
+		p := path
+		if p == "" {
+			p = "/"
+		}
+		*errs = append(*errs, p+": "+fmt.Sprintf(format, a...))
+	}
+	if n.Type != "" && typeOf(v) != n.Type && !(n.Type == "number" && typeOf(v) == "integer") {
+		fail("expected %s, found %s", n.Type, typeOf(v))
+		return
+	}
+	if len(n.Enum) > 0 {
+		found := false
+		for _, e := range n.Enum {
+			found = found || reflect.DeepEqual(enumValue(v), e)
+		}
+		if !found {
+			fail("value %v is not one of %v", enumValue(v), n.Enum)
+		}
+	}
+	switch v := v.(type) {
+	case string:
+		if n.MinLength != nil && utf8.RuneCountInString(v) < *n.MinLength {
+			fail("must be at least %d characters long", *n.MinLength)
+		}
+		if n.re != nil && !n.re.MatchString(v) {
+			fail("%q does not match %s", v, n.Pattern)
+		}
+		if n.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, v); err != nil {
+				fail("%q is not an RFC 3339 date-time", v)
+			}
+		}
+	case json.Number:
+		f, _ := v.Float64()
+		if n.ExclusiveMinimum != nil && f <= *n.ExclusiveMinimum {
+			fail("must be greater than %v", *n.ExclusiveMinimum)
+		}
+		if n.Maximum != nil && f > *n.Maximum {
+			fail("must be at most %v", *n.Maximum)
+		}
+	case []any:
+		if n.MinItems != nil && len(v) < *n.MinItems {
+			fail("must have at least %d items", *n.MinItems)
+		}
+		if n.Items != nil {
+			for i, e := range v {
+				n.Items.validate(fmt.Sprintf("%s/%d", path, i), e, errs)
+			}
+		}
+	case map[string]any:
+		for _, r := range n.Required {
+			if _, ok := v[r]; !ok {
+				fail("missing required property %q", r)
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			p := path + "/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(k)
+			if n.PropertyNames != nil {
+				n.PropertyNames.validate(p, k, errs)
+			}
+			switch c, ok := n.Properties[k]; {
+			case ok:
+				c.validate(p, v[k], errs)
+			case n.noAdditional:
+				fail("unknown property %q", k)
+			case n.additional != nil:
+				n.additional.validate(p, v[k], errs)
+			}
+		}
+	}
+}
+
+// typeOf returns the JSON Schema type of a value decoded with UseNumber.
+func typeOf(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := strconv.ParseInt(v.String(), 10, 64); err == nil {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// enumValue converts numbers to float64 for comparison with Enum values.
+func enumValue(v any) any {
+	if n, ok := v.(json.Number); ok {
+		f, _ := n.Float64()
+		return f
+	}
+	return v
+}
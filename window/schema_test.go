@@ -0,0 +1,85 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package window
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateSchema(t *testing.T) {
+	tests := []struct {
+		desc    string
+		in      string
+		wantErr string
+	}{
+		{"cron", `{"Windows":[{"Name":"a","Format":1,"Schedule":"0 0 2 * * *","Duration":"1h30m","Labels":["A"],"Tags":{"notify":"true"}}]}`, ""},
+		{"one-off", `{"Windows":[{"Name":"a","Starts":"2026-03-01T02:00:00Z","Duration":"1h","Labels":["a"],"SampleRate":0.5}]}`, ""},
+		{"labels only", `{"Labels":{"os_patch":{"Severity":"high","Color":"#1a73e8"}}}`, ""},
+		{"not json", `{`, "unexpected EOF"},
+		{"not object", `[]`, "/: expected object, found array"},
+		{"unknown field", `{"Windows":[{"Name":"a","Duration":"1h","Labels":["a"],"Duraton":"2h"}]}`, `/Windows/0: unknown property "Duraton"`},
+		{"missing field", `{"Windows":[{"Name":"a","Labels":["a"]}]}`, `/Windows/0: missing required property "Duration"`},
+		{"bad format", `{"Windows":[{"Name":"a","Format":2,"Duration":"1h","Labels":["a"]}]}`, "/Windows/0/Format: value 2 is not one of"},
+		{"fractional format", `{"Windows":[{"Name":"a","Format":1.5,"Duration":"1h","Labels":["a"]}]}`, "/Windows/0/Format: expected integer, found number"},
+		{"bad duration", `{"Windows":[{"Name":"a","Duration":"-1h","Labels":["a"]}]}`, "/Windows/0/Duration:"},
+		{"bad time", `{"Windows":[{"Name":"a","Starts":"tomorrow","Duration":"1h","Labels":["a"]}]}`, "/Windows/0/Starts:"},
+		{"no labels", `{"Windows":[{"Name":"a","Duration":"1h","Labels":[]}]}`, "/Windows/0/Labels: must have at least 1 items"},
+		{"bad label", `{"Windows":[{"Name":"a","Duration":"1h","Labels":["a b"]}]}`, "/Windows/0/Labels/0:"},
+		{"sample rate", `{"Windows":[{"Name":"a","Duration":"1h","Labels":["a"],"SampleRate":0}]}`, "/Windows/0/SampleRate: must be greater than 0"},
+		{"empty tag", `{"Windows":[{"Name":"a","Duration":"1h","Labels":["a"],"Tags":{"":"x"}}]}`, "/Windows/0/Tags/: must be at least 1 characters long"},
+		{"bad severity", `{"Labels":{"a":{"Severity":"urgent"}}}`, "/Labels/a/Severity: value urgent is not one of"},
+		{"several", `{"Windows":[{"Name":"","Duration":"1h","Labels":["a"]}],"Extra":1}`, `/: unknown property "Extra"; /Windows/0/Name: must be at least 1 characters long`},
+	}
+	for _, tt := range tests {
+		err := ValidateSchema([]byte(tt.in))
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("ValidateSchema(%s) returned error: %v", tt.desc, err)
+			}
+			continue
+		}
+		if !errors.Is(err, ErrSchema) || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("ValidateSchema(%s) = %v, want %v containing %q", tt.desc, err, ErrSchema, tt.wantErr)
+		}
+	}
+}
+
+// TestSchemaMatchesMarshal ensures that configuration written by
+// Map.MarshalJSON, such as by the new-window command, matches Schema.
+func TestSchemaMatchesMarshal(t *testing.T) {
+	m := make(Map)
+	for _, s := range []string{
+		`{"Name":"cron","Format":1,"Schedule":"0 0 2 * * *","Duration":"1h","Labels":["a"],"Tags":{"notify":"true"},"SampleRate":0.25,"MaxTaskDuration":"20m"}`,
+		`{"Name":"oneoff","Starts":"2026-03-01T02:00:00Z","Expires":"2026-03-02T00:00:00Z","Duration":"90m","Labels":["b"],"TruncateAtExpiry":true}`,
+	} {
+		var w Window
+		if err := json.Unmarshal([]byte(s), &w); err != nil {
+			t.Fatal(err)
+		}
+		m.Add(w)
+	}
+	m.Add(Window{Name: "computed", Format: FormatCron, CronString: "@daily", Duration: 2*time.Hour + 30*time.Second, Labels: []string{"c"}})
+	b, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateSchema(b); err != nil {
+		t.Errorf("ValidateSchema(%s) returned error: %v", b, err)
+	}
+}
@@ -17,15 +17,20 @@ package window
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"hash"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
 	"time"
 
-	"github.com/google/cabbie/metrics"
 	"github.com/google/deck"
 	"github.com/google/aukera/auklib"
 	"github.com/google/go-cmp/cmp"
@@ -41,6 +46,63 @@ const (
 	FormatCron Format = iota + 1
 )
 
+var (
+	// ErrBadFormat is returned when a window specifies an unsupported schedule format.
+	ErrBadFormat = errors.New("invalid format")
+	// ErrNotJSON is returned when a configuration file is not JSON.
+	ErrNotJSON = errors.New("file is not JSON")
+	// ErrNoWindows is returned when no windows are found for a label.
+	ErrNoWindows = errors.New("no windows found")
+	// ErrNoOverlap is returned when combining schedules that do not overlap.
+	ErrNoOverlap = errors.New("schedules do not overlap")
+	// ErrInvalidLabel is returned when a window label is reserved or contains
+	// unsupported characters.
+	ErrInvalidLabel = errors.New("invalid label")
+	// ErrNotLoaded is matched by errors returned when a configuration
+	// directory cannot be read and no earlier load is available to serve in
+	// its place. Such failures are usually transient, such as while a
+	// configuration push holds the directory's files.
+	ErrNotLoaded = errors.New("configuration not loaded")
+	// ErrTooManyOccurrences is returned when a window has more occurrences
+	// within a period than are computed for it.
+	ErrTooManyOccurrences = errors.New("too many occurrences")
+)
+
+// ReservedLabels may not be used by configured windows, either because they
+// collide with API paths or are provided by Aukera itself.
+var ReservedLabels = []string{"status", "schedule", "labels", "any", "all", ActiveHoursLabel, OutsideActiveHoursLabel, OSUpdatesLabel}
+
+var labelPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,63}$`)
+
+// ValidateLabel returns an error wrapping ErrInvalidLabel if label is reserved
+// or is not made up of up to 64 lowercase letters, digits, '_', '.' and '-'.
+func ValidateLabel(label string) error {
+	for _, r := range ReservedLabels {
+		if label == r {
+			return fmt.Errorf("%w %q: reserved", ErrInvalidLabel, label)
+		}
+	}
+	if !labelPattern.MatchString(label) {
+		return fmt.Errorf("%w %q: must match %s", ErrInvalidLabel, label, labelPattern)
+	}
+	return nil
+}
+
+// NormalizeLabel returns label in the lowercase form labels are stored in.
+// It returns an error wrapping ErrInvalidLabel if label could never match a
+// configured label. Unlike ValidateLabel, reserved labels are accepted, since
+// built-in windows carry them.
+func NormalizeLabel(label string) (string, error) {
+	l := strings.ToLower(label)
+	if !labelPattern.MatchString(l) {
+		if len(label) > 64 {
+			label = label[:64] + "..."
+		}
+		return "", fmt.Errorf("%w %q: must match %s", ErrInvalidLabel, label, labelPattern)
+	}
+	return l, nil
+}
+
 var cronParser = cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.DowOptional | cron.Descriptor)
 
 // Map correlates windows to their defined labels.
@@ -70,22 +132,71 @@ func (m Map) MarshalJSON() ([]byte, error) {
 	return json.Marshal(jsonArr)
 }
 
-// Keys returns all configured label names.
+// Keys returns all configured label names in sorted order.
 func (m Map) Keys() []string {
 	var keys []string
 	for k := range m {
 		keys = append(keys, k)
 	}
+	sort.Strings(keys)
 	return keys
 }
 
-// Add adds windows to the appropriate label element(s).
+// Add adds windows to the appropriate label element(s). Labels are stored
+// lowercase, as Find looks them up. Adding a window identical to one
+// already stored under a label, such as one loaded from two files, leaves
+// the label unchanged.
 func (m Map) Add(windows ...Window) {
+	m.add(windows)
+}
+
+// add adds windows as Add does, returning the number of windows left out of
+// each label as duplicates.
+func (m Map) add(windows []Window) map[string]int64 {
+	dups := make(map[string]int64)
 	for _, w := range windows {
+		id := w.identity()
 		for _, l := range w.Labels {
+			l = strings.ToLower(l)
+			if m.contains(l, w.Name, id) {
+				dups[l]++
+				continue
+			}
 			m[l] = append(m[l], w)
 		}
 	}
+	return dups
+}
+
+// reportDuplicates reports the duplicates left out of each label of m,
+// including zero for labels without any, so that the metric clears once
+// duplicates are removed.
+func reportDuplicates(m Map, dups map[string]int64) {
+	for _, l := range m.Keys() {
+		auklib.ReportInt("config_windows_duplicate", dups[l], map[string]string{"label": l})
+	}
+}
+
+// contains reports whether label l holds a window named name whose
+// identity is id.
+func (m Map) contains(l, name, id string) bool {
+	for _, w := range m[l] {
+		if w.Name == name && w.identity() == id {
+			return true
+		}
+	}
+	return false
+}
+
+// identity returns a hash of w's configuration content. Windows with the
+// same name and identity are interchangeable.
+func (w Window) identity() string {
+	b, err := json.Marshal(w.toJSON())
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
 }
 
 // Find returns a Window slice that have the passed label.
@@ -115,7 +226,7 @@ func (m Map) UniqueWindows() []Window {
 	// window contents evaluation function.
 	contains := func(s []Window, w Window) bool {
 		for i := range s {
-			if cmp.Equal(s[i], w, cmpopts.IgnoreFields(cron.SpecSchedule{}, "Location")) {
+			if cmp.Equal(s[i], w, cmpopts.IgnoreFields(cron.SpecSchedule{}, "Location"), cmpopts.IgnoreFields(Window{}, "Source")) {
 				return true
 			}
 		}
@@ -131,6 +242,21 @@ func (m Map) UniqueWindows() []Window {
 	return windows
 }
 
+// SortSchedules orders schedules by opening time, then by name, then by
+// closing time, so that responses listing them are stable.
+func SortSchedules(schedules []Schedule) {
+	sort.SliceStable(schedules, func(i, j int) bool {
+		a, b := schedules[i], schedules[j]
+		if !a.Opens.Equal(b.Opens) {
+			return a.Opens.Before(b.Opens)
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Closes.Before(b.Closes)
+	})
+}
+
 func dedupSchedules(schedules []Schedule) []Schedule {
 	var unique []Schedule
 	keys := make(map[Schedule]bool)
@@ -143,31 +269,132 @@ func dedupSchedules(schedules []Schedule) []Schedule {
 	return unique
 }
 
+// Aggregation defines how overlapping schedules are combined. Values other
+// than AggregateMerge are flags and may be combined.
+type Aggregation int
+
+const (
+	// AggregateMerge combines overlapping schedules into a single schedule
+	// spanning the earliest open to the latest close.
+	AggregateMerge Aggregation = 0
+	// AggregateConservative combines overlapping schedules such that the
+	// result closes at the earliest close among its contributors,
+	// guaranteeing it falls within every constituent window.
+	AggregateConservative Aggregation = 1 << 0
+	// AggregateAdjacent additionally combines back-to-back schedules, where
+	// one closes exactly as the next opens.
+	AggregateAdjacent Aggregation = 1 << 1
+)
+
+// ParseAggregation converts a comma-separated list of aggregation names to
+// an Aggregation. An empty name selects AggregateMerge.
+func ParseAggregation(names string) (Aggregation, error) {
+	a := AggregateMerge
+	for _, n := range strings.Split(names, ",") {
+		switch strings.ToLower(strings.TrimSpace(n)) {
+		case "", "merge":
+		case "conservative":
+			a |= AggregateConservative
+		case "adjacent":
+			a |= AggregateAdjacent
+		default:
+			return AggregateMerge, fmt.Errorf("unknown aggregation %q", n)
+		}
+	}
+	return a, nil
+}
+
 // AggregateSchedules combines the schedules of labels that match a given string with those that overlap.
 //
 // This has the potential to return two or more schedules that that do not overlap. Schedule state happens
 // within Aukera's schedule package.
 func (m Map) AggregateSchedules(request string) []Schedule {
+	return m.Aggregate(request, AggregateMerge)
+}
+
+// Aggregate combines the schedules of labels that match a given string with
+// those that overlap using the given Aggregation. Windows without a schedule,
+// such as those with no occurrence this host is sampled into, are skipped.
+func (m Map) Aggregate(request string, a Aggregation) []Schedule {
 	request = strings.ToLower(request)
-	var out, schedules []Schedule
+	var schedules []Schedule
 	for _, w := range m[request] {
+		if w.unscheduled() {
+			continue
+		}
 		sch := w.Schedule // dereference window schedule to set label as schedule name
 		sch.Name = request
 		schedules = append(schedules, sch)
 	}
-	sort.Slice(schedules, func(i int, j int) bool { return schedules[i].Opens.Before(schedules[j].Opens) })
+	return mergeSchedules(schedules, a)
+}
 
-	for len(schedules) > 0 {
-		l := schedules[0]
-		schedules = schedules[1:]
-		for i := len(schedules) - 1; i >= 0; i-- {
-			if err := l.Combine(schedules[i]); err != nil {
-				continue
-			}
-			schedules = append(schedules[:i], schedules[i+1:]...)
+// AggregateAt is like Aggregate, but recomputes the schedule of each window
+// as of now rather than when it was loaded, and pins the schedules returned
+// to now, so that every label of a request is evaluated at the same instant.
+func (m Map) AggregateAt(request string, a Aggregation, now time.Time) []Schedule {
+	request = strings.ToLower(request)
+	var schedules []Schedule
+	for _, w := range m[request] {
+		w.Schedule.EvaluatedAt = now
+		if w.Cron != nil || w.OneOff() {
+			w.calculateScheduleAt(now)
+		} else {
+			// Windows without a cron schedule, such as active hours, keep
+			// the schedule they were given.
+			w.Schedule.update()
+		}
+		if w.unscheduled() {
+			continue
+		}
+		sch := w.Schedule
+		sch.Name = request
+		schedules = append(schedules, sch)
+	}
+	return mergeSchedules(schedules, a)
+}
+
+// unscheduled reports whether w has no schedule, such as when no occurrence
+// this host is sampled into was found.
+func (w *Window) unscheduled() bool {
+	return w.Schedule.Opens.IsZero() && w.Schedule.Closes.IsZero()
+}
+
+// mergeSchedules combines overlapping schedules using the given Aggregation.
+// Schedules are swept in order of opening time, extending the current
+// schedule with each one that overlaps it, so that chains of overlapping
+// schedules are coalesced even when their ends do not overlap each other.
+func mergeSchedules(schedules []Schedule, a Aggregation) []Schedule {
+	if len(schedules) == 0 {
+		return nil
+	}
+	sort.SliceStable(schedules, func(i int, j int) bool { return schedules[i].Opens.Before(schedules[j].Opens) })
+
+	var out []Schedule
+	cur := schedules[0]
+	closes := cur.Closes
+	flush := func() {
+		if a&AggregateConservative != 0 {
+			cur.Closes = closes
+			cur.update()
 		}
-		out = append(out, l)
+		out = append(out, cur)
 	}
+	combine := cur.Combine
+	if a&AggregateAdjacent != 0 {
+		combine = cur.CombineAdjacent
+	}
+	for _, s := range schedules[1:] {
+		if err := combine(s); err != nil {
+			flush()
+			cur, closes = s, s.Closes
+			continue
+		}
+		if s.Closes.Before(closes) {
+			closes = s.Closes.Local()
+		}
+	}
+	flush()
 	return dedupSchedules(out)
 }
 
@@ -180,13 +407,54 @@ type Window struct {
 	Starts, Expires  time.Time
 	Labels           []string
 	Schedule         Schedule
+	// SampleRate is the fraction of hosts, within (0, 1], that treat each
+	// occurrence of the window as open. Zero denotes all hosts.
+	SampleRate float64
+	// RequiresApproval windows are excluded from schedules until approved.
+	RequiresApproval bool
+	// TruncateAtExpiry closes occurrences no later than Expires rather than
+	// after the full Duration.
+	TruncateAtExpiry bool
+	// Tags are free-form metadata, such as owner or rollout ring, used to
+	// group windows. Unlike Labels they have no effect on schedules.
+	Tags map[string]string
+	// Clock selects how the cron schedule is evaluated across daylight
+	// saving time transitions. Empty denotes ClockWall.
+	Clock Clock
+	// MaxTaskDuration is how much of each occurrence a single consumer may
+	// use, so that several consumers can share the window in turn. Zero
+	// denotes the whole occurrence.
+	MaxTaskDuration time.Duration
+	// Source records where the window came from: the path of its
+	// configuration file, an InlineName, SourceBuiltin, or, for windows
+	// registered through the API, SourceEphemeral followed by the
+	// registering source. It is not part of the window's configuration.
+	Source string
 }
 
+const (
+	// SourceBuiltin is the Source of windows provided by Aukera itself.
+	SourceBuiltin = "builtin"
+	// SourceEphemeral prefixes the Source of ephemeral windows.
+	SourceEphemeral = "ephemeral"
+)
+
+// MaxDuration bounds the Duration of configured windows, since a window
+// open for longer is more likely a mistake, such as minutes written as hours,
+// than intended. Zero removes the bound.
+var MaxDuration = 31 * 24 * time.Hour
+
 type windowJSON struct {
 	Name, Schedule, Duration string
 	Starts, Expires          time.Time
 	Format                   Format
 	Labels                   []string
+	SampleRate               *float64          `json:",omitempty"`
+	RequiresApproval         bool              `json:",omitempty"`
+	TruncateAtExpiry         bool              `json:",omitempty"`
+	Tags                     map[string]string `json:",omitempty"`
+	Clock                    Clock             `json:",omitempty"`
+	MaxTaskDuration          string            `json:",omitempty"`
 }
 
 // UnmarshalJSON is a custom Window unmarshaler.
@@ -206,14 +474,22 @@ func (w *Window) UnmarshalJSON(b []byte) error {
 	w.Name = conv.Name
 
 	var err error
-	switch conv.Format {
-	case FormatCron:
+	switch {
+	case conv.Format == 0 && conv.Schedule == "":
+		// One-off windows open once, at Starts, for Duration.
+		if conv.Starts.IsZero() {
+			return fmt.Errorf("window(%s): windows without a schedule must specify Starts", w.Name)
+		}
+	case conv.Format == FormatCron:
 		w.Cron, err = cronParser.Parse(conv.Schedule)
 		if err != nil {
-			return fmt.Errorf("window(%s): error processing schedule %q: %v", w.Name, conv.Schedule, err)
+			return fmt.Errorf("window(%s): error processing schedule %q: %w", w.Name, conv.Schedule, err)
+		}
+		if _, ok := w.Cron.(cron.ConstantDelaySchedule); ok {
+			return fmt.Errorf("window(%s): schedule %q has no fixed activation times", w.Name, conv.Schedule)
 		}
 	default:
-		return fmt.Errorf("window(%s): invalid format specified: %d", w.Name, conv.Format)
+		return fmt.Errorf("window(%s): %w specified: %d", w.Name, ErrBadFormat, conv.Format)
 	}
 	w.Format = conv.Format
 
@@ -221,14 +497,53 @@ func (w *Window) UnmarshalJSON(b []byte) error {
 		return fmt.Errorf("window(%s): window must have minimum of one label (found: %d)", w.Name, len(conv.Labels))
 	}
 	w.Labels = auklib.UniqueStrings(conv.Labels)
+	for _, l := range w.Labels {
+		if err := ValidateLabel(l); err != nil {
+			return fmt.Errorf("window(%s): %w", w.Name, err)
+		}
+	}
+
+	if conv.SampleRate != nil {
+		if *conv.SampleRate <= 0 || *conv.SampleRate > 1 {
+			return fmt.Errorf("window(%s): sample rate must be within (0, 1] (found: %v)", w.Name, *conv.SampleRate)
+		}
+		w.SampleRate = *conv.SampleRate
+	}
 
 	w.Starts = conv.Starts
 	w.Expires = conv.Expires
 	w.CronString = conv.Schedule
+	w.RequiresApproval = conv.RequiresApproval
+	w.TruncateAtExpiry = conv.TruncateAtExpiry
+	for k := range conv.Tags {
+		if k == "" {
+			return fmt.Errorf("window(%s): tag names must not be empty", w.Name)
+		}
+	}
+	w.Tags = conv.Tags
+	if err := conv.Clock.validate(); err != nil {
+		return fmt.Errorf("window(%s): %w", w.Name, err)
+	}
+	w.Clock = conv.Clock
 
 	w.Duration, err = time.ParseDuration(conv.Duration)
 	if err != nil {
-		return err
+		return fmt.Errorf("window(%s): %w", w.Name, err)
+	}
+	if w.Duration <= 0 {
+		return fmt.Errorf("window(%s): duration must be positive (found: %v)", w.Name, w.Duration)
+	}
+	if MaxDuration > 0 && w.Duration > MaxDuration {
+		return fmt.Errorf("window(%s): duration %v exceeds the maximum of %v", w.Name, w.Duration, MaxDuration)
+	}
+	if conv.MaxTaskDuration != "" {
+		w.MaxTaskDuration, err = time.ParseDuration(conv.MaxTaskDuration)
+		if err != nil {
+			return fmt.Errorf("window(%s): max task duration: %w", w.Name, err)
+		}
+		if w.MaxTaskDuration <= 0 || w.MaxTaskDuration > w.Duration {
+			return fmt.Errorf("window(%s): max task duration must be within (0, %v] (found: %v)", w.Name, w.Duration, w.MaxTaskDuration)
+		}
 	}
 	w.calculateSchedule()
 
@@ -238,7 +553,12 @@ func (w *Window) UnmarshalJSON(b []byte) error {
 // MarshalJSON is a custom marshaler for Window to ensure JSON output
 // matches the fields within its configuration file.
 func (w Window) MarshalJSON() ([]byte, error) {
-	return json.Marshal(windowJSON{
+	return json.Marshal(w.toJSON())
+}
+
+// toJSON converts w to its configuration file representation.
+func (w Window) toJSON() windowJSON {
+	conv := windowJSON{
 		Name:     w.Name,
 		Schedule: w.CronString,
 		Duration: w.Duration.String(),
@@ -246,7 +566,51 @@ func (w Window) MarshalJSON() ([]byte, error) {
 		Expires:  w.Expires,
 		Format:   w.Format,
 		Labels:   w.Labels,
-	})
+
+		RequiresApproval: w.RequiresApproval,
+		TruncateAtExpiry: w.TruncateAtExpiry,
+		Tags:             w.Tags,
+		Clock:            w.Clock,
+	}
+	if w.SampleRate != 0 {
+		conv.SampleRate = &w.SampleRate
+	}
+	if w.MaxTaskDuration != 0 {
+		conv.MaxTaskDuration = w.MaxTaskDuration.String()
+	}
+	return conv
+}
+
+// MatchTags reports whether w carries every tag in filter. An empty filter
+// value matches any value of that tag.
+func (w *Window) MatchTags(filter map[string]string) bool {
+	for k, v := range filter {
+		got, ok := w.Tags[k]
+		if !ok || (v != "" && got != v) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseTagFilter parses tag filters of the form name=value, or name to
+// match any value.
+func ParseTagFilter(tags []string) (map[string]string, error) {
+	filter := make(map[string]string)
+	for _, t := range tags {
+		k, v, _ := strings.Cut(t, "=")
+		if k == "" {
+			return nil, fmt.Errorf("invalid tag filter %q", t)
+		}
+		filter[k] = v
+	}
+	return filter, nil
+}
+
+// OneOff reports whether the window opens only once, at Starts, rather than
+// following a schedule.
+func (w *Window) OneOff() bool {
+	return w.Format == 0 && w.CronString == "" && w.Cron == nil && !w.Starts.IsZero()
 }
 
 // Expired determines window validity comparing Expiration time to time.Now().
@@ -263,41 +627,112 @@ func (w *Window) Started() bool {
 }
 
 func (w *Window) calculateSchedule() {
-	type activation struct {
-		open, close time.Time
-	}
-	var last, next activation
-	now := time.Now()
-	switch {
-	case w.Started() && !w.Expired():
-		last.open = w.LastActivation(now)
-		next.open = w.NextActivation(now)
-	case w.Expired():
-		last.open = w.LastActivation(w.Expires)
-		// Set Next.open to be the last activation of last.open when the
-		// window has expired in order to represent the last valid window.
-		next.open = w.LastActivation(last.open)
-	case !w.Started():
-		last.open = w.NextActivation(w.Starts)
-		next.open = last.open
-	}
-	last.close = last.open.Add(w.Duration)
-	next.close = next.open.Add(w.Duration)
-	if last.open.Before(now) && now.Before(last.close) {
-		w.Schedule.Opens = last.open.Local()
-		w.Schedule.Closes = last.close.Local()
+	w.calculateScheduleAt(time.Now())
+}
+
+// calculateScheduleAt sets the schedule of the window relative to now.
+func (w *Window) calculateScheduleAt(now time.Time) {
+	key := w.Name + "|" + windowKey(*w)
+	if s, ok := activations.get(key, now); ok {
+		w.Schedule.Opens = s.Opens
+		w.Schedule.Closes = s.Closes
 	} else {
-		w.Schedule.Opens = next.open.Local()
-		w.Schedule.Closes = next.close.Local()
+		w.computeActivation(now)
+		activations.set(key, now, w.activationUntil(now), w.Schedule)
 	}
 
-	if w.Schedule.IsOpen() {
-		w.Schedule.State = "open"
-	} else {
-		w.Schedule.State = "closed"
+	w.Schedule.MaxTaskDuration = w.MaxTaskDuration
+	w.Schedule.Reason = w.closedReason(now)
+	w.Schedule.update()
+}
+
+// activationUntil returns the first time after now at which the activation
+// computed at now may change: when its schedule opens or closes, or when the
+// window starts or expires. It returns the zero time if there is none.
+func (w *Window) activationUntil(now time.Time) time.Time {
+	var until time.Time
+	for _, t := range []time.Time{w.Schedule.Opens, w.Schedule.Closes, w.Starts, w.Expires} {
+		if t.After(now) && (until.IsZero() || t.Before(until)) {
+			until = t
+		}
+	}
+	return until
+}
+
+// closeTime returns when an occurrence of the window opening at open closes.
+func (w *Window) closeTime(open time.Time) time.Time {
+	close := open.Add(w.Duration)
+	if !w.TruncateAtExpiry || w.Expires.IsZero() || !close.After(w.Expires) {
+		return close
+	}
+	if w.Expires.Before(open) {
+		return open
+	}
+	return w.Expires
+}
+
+// inBounds reports whether an activation at t lies within [Starts, Expires].
+func (w *Window) inBounds(t time.Time) bool {
+	return !t.IsZero() && !t.Before(w.Starts) && (w.Expires.IsZero() || !t.After(w.Expires))
+}
+
+// computeActivation sets the schedule open and close times relative to now.
+// Only activations within [Starts, Expires] are considered: the occurrence
+// open at now is preferred, then the next occurrence, then the final
+// occurrence before the window expired.
+func (w *Window) computeActivation(now time.Time) {
+	set := func(open time.Time) {
+		w.Schedule.Opens = open.Local()
+		w.Schedule.Closes = w.closeTime(open).Local()
+	}
+
+	if w.OneOff() {
+		if !w.Sampled(w.Starts) {
+			w.Schedule.Opens, w.Schedule.Closes = time.Time{}, time.Time{}
+			return
+		}
+		set(w.Starts)
+		return
+	}
+
+	ref := now
+	if !w.Expires.IsZero() && w.Expires.Before(ref) {
+		ref = w.Expires
+	}
+	last := w.LastActivation(ref)
+	if w.inBounds(last) && last.Before(now) && now.Before(w.closeTime(last)) && w.Sampled(last) {
+		set(last)
+		return
+	}
+
+	// Activations exactly at Starts are valid, so search from just before it.
+	from := now
+	if start := w.Starts.Add(-time.Minute); from.Before(start) {
+		from = start
+	}
+	next := w.NextActivation(from)
+	for !next.IsZero() && next.Before(w.Starts) {
+		next = w.NextActivation(next.Add(time.Minute))
+	}
+	if w.inBounds(next) || w.Expires.IsZero() {
+		// Windows with no occurrence this host is sampled into have no
+		// schedule, rather than one the host is excluded from.
+		if open := w.nextSampled(next); !open.IsZero() {
+			set(open)
+		} else {
+			w.Schedule.Opens, w.Schedule.Closes = time.Time{}, time.Time{}
+		}
+		return
 	}
 
-	w.Schedule.Duration = w.Duration
+	// No activations remain before Expires.
+	if final := w.LastActivation(w.Expires); w.inBounds(final) {
+		set(final)
+		return
+	}
+	// The window never activates between Starts and Expires.
+	w.Schedule.Opens = w.Expires.Local()
+	w.Schedule.Closes = w.Expires.Local()
 }
 
 // NextActivation determines the next activation time of cron.Schedule.
@@ -319,10 +754,21 @@ func (w *Window) NextActivation(ts time.Time) time.Time {
 	if w.Format == FormatCron && cmp.Equal(w.Cron, cr, cmpopts.IgnoreFields(cron.SpecSchedule{}, "Location")) {
 		return ts
 	}
-	a := w.Cron.Next(ts)
+	return w.activation(ts, start)
+}
+
+// quorum returns the activation of s found by crawling back from its first
+// activation after ts until two successive values agree, or the zero time if
+// none is found within 5 seconds of start.
+func quorum(s cron.Schedule, ts, start time.Time) time.Time {
+	a := s.Next(ts)
+	if a.IsZero() {
+		// The schedule never activates, such as on February 30th.
+		return a
+	}
 	// Activation time search timeout
 	for time.Since(start) < (5 * time.Second) {
-		b := w.Cron.Next(a.Add(-2 * time.Second))
+		b := s.Next(a.Add(-2 * time.Second))
 		if a.Equal(b) {
 			return b
 		}
@@ -345,30 +791,98 @@ func (w *Window) LastActivation(date time.Time) time.Time {
 	// catch schedules of all frequencies. Omitting the first number in
 	// sequence (0) as it provides no value, only computational cost.
 	fibCurrent, fibLast := 1, 1
-	for next.Equal(last) {
+	for next.Equal(last) && !next.IsZero() {
 		fibCurrent, fibLast = fibLast, fibCurrent+fibLast
 		last = w.NextActivation(date.Add(-time.Duration(fibCurrent) * time.Minute))
 	}
 	return last
 }
 
-// Schedule defines struct for schedule information.
+// Schedule states, as reported by CurrentState.
+const (
+	StateOpen   = "open"
+	StateClosed = "closed"
+)
+
+// Schedule defines struct for schedule information. State reflects the time
+// it was last computed; it is recomputed from Opens and Closes whenever the
+// schedule is marshaled, so use CurrentState rather than State directly.
 type Schedule struct {
 	Name, State   string
 	Duration      time.Duration
 	Opens, Closes time.Time
+	// MaxTaskDuration is how much of the schedule a single consumer may use.
+	// Zero denotes the whole schedule.
+	MaxTaskDuration time.Duration
+	// Reason explains why the schedule is closed. It is empty while the
+	// schedule is open.
+	Reason Reason
+	// EvaluatedAt, if set, pins the schedule to the time it was evaluated
+	// at: State and Reason are computed as of EvaluatedAt rather than now,
+	// including when the schedule is marshaled.
+	EvaluatedAt time.Time
+}
+
+// now returns the time the schedule is evaluated at: EvaluatedAt if it is
+// pinned, and the current time otherwise.
+func (s Schedule) now() time.Time {
+	if !s.EvaluatedAt.IsZero() {
+		return s.EvaluatedAt
+	}
+	return time.Now()
+}
+
+// Pin pins s to t, recomputing State and Reason as of t.
+func (s *Schedule) Pin(t time.Time) {
+	s.EvaluatedAt = t
+	s.update()
+}
+
+// CurrentState returns StateOpen if the schedule is open now, and
+// StateClosed otherwise.
+func (s Schedule) CurrentState() string {
+	if s.IsOpen() {
+		return StateOpen
+	}
+	return StateClosed
 }
 
 // MarshalJSON is a custom marshaler for Schedule to ensure the Duration
-// value is marshalled as a human-readable string.
+// value is marshalled as a human-readable string and State is current, or as
+// of EvaluatedAt if the schedule is pinned. Reason is omitted while the
+// schedule is open.
 func (s *Schedule) MarshalJSON() ([]byte, error) {
-	type temp Schedule
+	var budget string
+	if s.MaxTaskDuration != 0 {
+		budget = s.MaxTaskDuration.String()
+	}
+	var evaluated *time.Time
+	if !s.EvaluatedAt.IsZero() {
+		evaluated = &s.EvaluatedAt
+	}
+	now := s.now()
+	state, reason := StateClosed, s.Reason
+	if s.Contains(now) {
+		state, reason = StateOpen, ""
+	} else if reason == "" {
+		reason = s.defaultReason(now)
+	}
 	return json.Marshal(&struct {
-		*temp
-		Duration string
+		Name, State     string
+		Opens, Closes   time.Time
+		Duration        string
+		MaxTaskDuration string     `json:",omitempty"`
+		Reason          Reason     `json:",omitempty"`
+		EvaluatedAt     *time.Time `json:",omitempty"`
 	}{
-		temp:     (*temp)(s),
-		Duration: s.Duration.String(),
+		Name:            s.Name,
+		State:           state,
+		Opens:           s.Opens,
+		Closes:          s.Closes,
+		Duration:        s.Duration.String(),
+		MaxTaskDuration: budget,
+		Reason:          reason,
+		EvaluatedAt:     evaluated,
 	},
 	)
 }
@@ -381,8 +895,10 @@ func (s *Schedule) UnmarshalJSON(b []byte) error {
 	}
 
 	var temp = struct {
-		Name, State, Duration string
-		Opens, Closes         time.Time
+		Name, State, Duration, MaxTaskDuration string
+		Opens, Closes                          time.Time
+		Reason                                 Reason
+		EvaluatedAt                            time.Time
 	}{}
 	err := json.Unmarshal(b, &temp)
 	if err != nil {
@@ -393,47 +909,55 @@ func (s *Schedule) UnmarshalJSON(b []byte) error {
 	if err != nil {
 		return err
 	}
+	s.MaxTaskDuration = 0
+	if temp.MaxTaskDuration != "" {
+		if s.MaxTaskDuration, err = time.ParseDuration(temp.MaxTaskDuration); err != nil {
+			return err
+		}
+	}
 
 	s.Name = temp.Name
 	s.State = temp.State
 	s.Opens = temp.Opens
 	s.Closes = temp.Closes
+	s.Reason = temp.Reason
+	s.EvaluatedAt = temp.EvaluatedAt
 
 	return nil
 }
 
-// Overlaps evalutes if one schedule falls during another.
+// Overlaps evalutes if one schedule falls during another. Schedules that
+// only touch, where one closes as the other opens, do not overlap.
 func (s *Schedule) Overlaps(c Schedule) bool {
-	// c opens earlier than and closes within s
-	if c.Opens.Before(s.Opens) && s.Opens.Before(c.Closes) {
-		return true
-	}
-	// c closes later than and opens within s
-	if s.Closes.Before(c.Closes) && c.Opens.Before(s.Closes) {
-		return true
-	}
-	// c opens and closes within s
-	if s.Opens.Before(c.Opens) && c.Closes.Before(s.Closes) {
-		return true
-	}
-	// s opens and closes within c
-	if c.Opens.Before(s.Opens) && s.Closes.Before(c.Closes) {
-		return true
-	}
 	// s and c match
 	if c.Opens.Equal(s.Opens) && c.Closes.Equal(s.Closes) {
 		return true
 	}
-	return false
+	return c.Opens.Before(s.Closes) && s.Opens.Before(c.Closes)
+}
+
+// Adjacent evaluates if one schedule closes exactly as the other opens.
+func (s *Schedule) Adjacent(c Schedule) bool {
+	return s.Closes.Equal(c.Opens) || c.Closes.Equal(s.Opens)
 }
 
 // Combine combines one schedule's timeframe with another.
 func (s *Schedule) Combine(c Schedule) error {
+	return s.combine(c, false)
+}
+
+// CombineAdjacent combines one schedule's timeframe with another that either
+// overlaps it or is adjacent to it.
+func (s *Schedule) CombineAdjacent(c Schedule) error {
+	return s.combine(c, true)
+}
+
+func (s *Schedule) combine(c Schedule, adjacent bool) error {
 	if s.Name != c.Name {
 		return fmt.Errorf("names to not match: %q != %q", s.Name, c.Name)
 	}
-	if !s.Overlaps(c) {
-		return fmt.Errorf("schedules do not overlap")
+	if !s.Overlaps(c) && !(adjacent && s.Adjacent(c)) {
+		return ErrNoOverlap
 	}
 	if c.Opens.Before(s.Opens) {
 		s.Opens = c.Opens.Local()
@@ -441,22 +965,73 @@ func (s *Schedule) Combine(c Schedule) error {
 	if s.Closes.Before(c.Closes) {
 		s.Closes = c.Closes.Local()
 	}
-	now := time.Now()
-	if now.Before(s.Closes) && s.Opens.Before(now) {
-		s.State = "open"
-	} else {
-		s.State = "closed"
+	// Each consumer may use the larger budget, or the whole schedule if
+	// either window grants it.
+	if s.MaxTaskDuration == 0 || c.MaxTaskDuration == 0 {
+		s.MaxTaskDuration = 0
+	} else if c.MaxTaskDuration > s.MaxTaskDuration {
+		s.MaxTaskDuration = c.MaxTaskDuration
 	}
+	s.update()
+	return nil
+}
 
+// update recalculates State and Duration from the open/close times, and
+// bounds MaxTaskDuration by Duration. Reason is cleared while the schedule is
+// open, and otherwise derived from the open/close times unless already set.
+// Both are computed as of EvaluatedAt if the schedule is pinned.
+func (s *Schedule) update() {
+	now := s.now()
+	s.State = StateClosed
+	switch {
+	case s.Contains(now):
+		s.State, s.Reason = StateOpen, ""
+	case s.Reason == "":
+		s.Reason = s.defaultReason(now)
+	}
 	s.Duration = s.Closes.Sub(s.Opens)
-
-	return nil
+	if s.MaxTaskDuration > s.Duration {
+		s.MaxTaskDuration = s.Duration
+	}
 }
 
 // IsOpen determines if schedule is open based on open/close times.
-func (s *Schedule) IsOpen() bool {
-	now := time.Now()
-	return s.Opens.Before(now) && now.Before(s.Closes)
+func (s Schedule) IsOpen() bool {
+	return s.Contains(time.Now())
+}
+
+// Contains reports whether s is open at t. Schedules include their opening
+// time but not their closing time.
+func (s Schedule) Contains(t time.Time) bool {
+	return !t.Before(s.Opens) && t.Before(s.Closes)
+}
+
+// TimeUntilOpen returns how long after now s opens. It is zero while s is
+// open and negative once s has closed.
+func (s Schedule) TimeUntilOpen(now time.Time) time.Duration {
+	if s.Contains(now) {
+		return 0
+	}
+	return s.Opens.Sub(now)
+}
+
+// TimeUntilClose returns how long after now s closes. It is zero or negative
+// once s has closed.
+func (s Schedule) TimeUntilClose(now time.Time) time.Duration {
+	return s.Closes.Sub(now)
+}
+
+// NextTransition returns the next time after now at which s changes state,
+// and the state it changes to. It returns the zero time and "" once s has
+// closed.
+func (s Schedule) NextTransition(now time.Time) (time.Time, string) {
+	switch {
+	case now.Before(s.Opens):
+		return s.Opens, StateOpen
+	case now.Before(s.Closes):
+		return s.Closes, StateClosed
+	}
+	return time.Time{}, ""
 }
 
 func (s Schedule) String() string {
@@ -492,12 +1067,12 @@ func (r Reader) AbsPath(path string) (string, error) {
 	if !filepath.IsAbs(path) {
 		path, err = filepath.Abs(path)
 		if err != nil {
-			return path, fmt.Errorf("AbsPath: failed to determine absolute path: %v", err)
+			return path, fmt.Errorf("AbsPath: failed to determine absolute path: %w", err)
 		}
 	}
 	exist, err := r.PathExists(path)
 	if err != nil {
-		return "", fmt.Errorf("AbsPath: error finding path %q: %v", path, err)
+		return "", fmt.Errorf("AbsPath: error finding path %q: %w", path, err)
 	}
 	if !exist {
 		return "", fmt.Errorf("AbsPath: doesn't exist: %q", path)
@@ -505,15 +1080,19 @@ func (r Reader) AbsPath(path string) (string, error) {
 	return path, nil
 }
 
-// JSONFiles returns all JSON files in a given directory.
+// JSONFiles returns all JSON files in a given directory. Directories refused
+// by FilePermissions return an error wrapping ErrInsecureFile.
 func (r Reader) JSONFiles(path string) ([]os.DirEntry, error) {
 	abs, err := r.AbsPath(path)
 	if err != nil {
-		return nil, fmt.Errorf("JSONFiles: error determining absolute path: %v", err)
+		return nil, fmt.Errorf("JSONFiles: error determining absolute path: %w", err)
+	}
+	if err := checkPermissions(abs); err != nil {
+		return nil, fmt.Errorf("JSONFiles: %w", err)
 	}
 	fi, err := os.ReadDir(abs)
 	if err != nil {
-		return nil, fmt.Errorf("JSONFiles: failed to enumerate files in %q: %v", abs, err)
+		return nil, fmt.Errorf("JSONFiles: failed to enumerate files in %q: %w", abs, err)
 	}
 	var files []os.DirEntry
 	for _, f := range fi {
@@ -525,83 +1104,329 @@ func (r Reader) JSONFiles(path string) ([]os.DirEntry, error) {
 	return files, nil
 }
 
-// JSONContent returns the contents of JSON files.
+// JSONContent returns the contents of JSON files, decrypting encrypted
+// configuration files. Files refused by FilePermissions return an error
+// wrapping ErrInsecureFile.
 func (r Reader) JSONContent(path string) ([]byte, error) {
 	abs, err := r.AbsPath(path)
 	if err != nil {
-		return nil, fmt.Errorf("JSONContent: error determining absolute path: %v", err)
+		return nil, fmt.Errorf("JSONContent: error determining absolute path: %w", err)
 	}
 	if strings.ToLower(filepath.Ext(abs)) != ".json" {
-		return nil, fmt.Errorf("JSONContent: file is not JSON")
+		return nil, fmt.Errorf("JSONContent: %w", ErrNotJSON)
+	}
+	if err := checkPermissions(abs); err != nil {
+		return nil, fmt.Errorf("JSONContent: %w", err)
+	}
+	b, err := os.ReadFile(abs)
+	if err != nil {
+		return nil, err
 	}
-	return os.ReadFile(abs)
+	return decryptConfig(b)
 }
 
-// Windows gets all defined windows within given directory.
+// Windows gets all defined windows within given directory. Windows pending
+// approval are omitted. If the directory cannot be read, the windows from the
+// last successful read are returned; see StaleSince.
 func Windows(dir string, cr ConfigReader) (Map, error) {
+	return WindowsContext(context.Background(), dir, cr)
+}
+
+// WindowsContext is like Windows, but abandons retrying failed reads of the
+// directory once ctx is done, returning ctx.Err().
+func WindowsContext(ctx context.Context, dir string, cr ConfigReader) (Map, error) {
+	windows, err := loadContext(ctx, dir, cr)
+	if err != nil {
+		return nil, err
+	}
+	var active []Window
+	pending := make(map[string]string)
+	for _, w := range windows {
+		if w.Pending() {
+			pending[w.identity()] = w.Name
+			continue
+		}
+		active = append(active, w)
+	}
+	reportPending(pending)
+	m := make(Map)
+	reportDuplicates(m, m.add(active))
+	return m, nil
+}
+
+// AllWindows is like Windows, but includes the windows pending approval, for
+// listing configuration rather than scheduling from it.
+func AllWindows(dir string, cr ConfigReader) (Map, error) {
+	windows, err := loadContext(context.Background(), dir, cr)
+	if err != nil {
+		return nil, err
+	}
+	m := make(Map)
+	m.Add(windows...)
+	return m, nil
+}
+
+// loadContext loads the windows in dir through the load cache, reporting
+// the load duration and, whenever the configuration changes, any conflicts
+// between them.
+func loadContext(ctx context.Context, dir string, cr ConfigReader) ([]Window, error) {
+	start := time.Now()
+	windows, changed, err := loads.load(ctx, dir, cr)
+	auklib.ReportDuration("config_load_duration", time.Since(start), nil)
+	if err != nil {
+		return nil, err
+	}
+	if changed {
+		reportConflicts(Conflicts(windows))
+	}
+	return windows, nil
+}
+
+// OverridesDir is the subdirectory of a configuration directory holding
+// local overrides. Its windows take precedence over identically named
+// windows in the configuration directory, so that local administrators can
+// override centrally managed schedules without editing managed files.
+const OverridesDir = "overrides.d"
+
+// loadWindows reads all windows defined within the given directory and
+// Inline, with those in its OverridesDir applied, followed by active
+// ephemeral windows, along with a hash of the configuration content they
+// were read from. An OverridesDir that exists but cannot be
+// read fails the load, rather than silently reverting overridden windows.
+func loadWindows(dir string, cr ConfigReader) ([]Window, string, error) {
+	h := sha256.New()
+	windows, err := loadConfigured(dir, cr, h, make(map[string]map[string]bool))
+	if err != nil {
+		return nil, "", err
+	}
+	windows = mergeEphemeral(windows, h, time.Now())
+	return windows, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// loadConfigured reads the windows configured within dir and Inline, with
+// those in its OverridesDir applied, adding their content to h.
+func loadConfigured(dir string, cr ConfigReader, h hash.Hash, spellings map[string]map[string]bool) ([]Window, error) {
+	var windows []Window
+	if readsDir(dir, cr) {
+		var err error
+		if windows, err = loadFiles(dir, "", cr, h, spellings); err != nil {
+			return nil, err
+		}
+	}
+	for i, b := range Inline {
+		windows = append(windows, loadContent(InlineName(i), "", b, h, spellings)...)
+	}
+	od := filepath.Join(dir, OverridesDir)
+	if ok, err := cr.PathExists(od); err == nil && ok {
+		overrides, err := loadFiles(od, OverridesDir, cr, h, spellings)
+		if err != nil {
+			return nil, fmt.Errorf("overrides: %w", err)
+		}
+		windows = applyOverrides(windows, overrides)
+	}
+	warnCaseCollisions(spellings)
+	return windows, nil
+}
+
+// loadFiles reads the windows of every configuration file in dir, adding
+// their content to h under their name prefixed with prefix.
+func loadFiles(dir, prefix string, cr ConfigReader, h hash.Hash, spellings map[string]map[string]bool) ([]Window, error) {
 	files, err := cr.JSONFiles(dir)
 	if err != nil {
 		return nil, err
 	}
 	var windows []Window
 	for _, f := range files {
-		s := struct {
-			Windows []Window
-		}{}
 		fp := filepath.Join(dir, f.Name())
 		b, err := cr.JSONContent(fp)
+		if errors.Is(err, ErrInsecureFile) {
+			deck.Errorf("refusing file %q: %v", f.Name(), err)
+			reportConfFileMetric(fp, "insecure")
+			continue
+		}
 		if err != nil {
 			deck.Errorf("error reading file %q: %v", f.Name(), err)
 			reportConfFileMetric(fp, "read_err")
 			continue
 		}
-		if err := json.Unmarshal(b, &s); err != nil {
-			deck.Errorf("UnmarshalJSON error: file %q: %v", f.Name(), err)
-			reportConfFileMetric(fp, "unmarshal_err")
+		windows = append(windows, loadContent(fp, prefix, b, h, spellings)...)
+	}
+	return windows, nil
+}
+
+// loadContent reads the windows of the configuration file at path with
+// content b, adding the content to h under the file's name prefixed with
+// prefix. Invalid files are logged and yield no windows.
+func loadContent(path, prefix string, b []byte, h hash.Hash, spellings map[string]map[string]bool) []Window {
+	name := filepath.Base(path)
+	fmt.Fprintf(h, "%s\x00%d\x00", filepath.Join(prefix, name), len(b))
+	h.Write(b)
+	if err := ValidateSchema(b); err != nil {
+		deck.Errorf("file %q: %v", name, err)
+		reportConfFileMetric(path, "schema_err")
+		return nil
+	}
+	s := struct {
+		Windows []Window
+	}{}
+	if err := json.Unmarshal(b, &s); err != nil {
+		deck.Errorf("UnmarshalJSON error: file %q: %v", name, err)
+		reportConfFileMetric(path, "unmarshal_err")
+		return nil
+	}
+	reportConfFileMetric(path, "ok")
+	recordSpellings(spellings, b)
+	for i := range s.Windows {
+		s.Windows[i].Source = path
+	}
+	return s.Windows
+}
+
+// applyOverrides replaces the windows of base named like a window of
+// overrides with the overriding windows, and adds the remaining overrides.
+func applyOverrides(base, overrides []Window) []Window {
+	if len(overrides) == 0 {
+		return base
+	}
+	byName := make(map[string][]Window)
+	for _, o := range overrides {
+		byName[o.Name] = append(byName[o.Name], o)
+	}
+	out := make([]Window, 0, len(base)+len(overrides))
+	for _, w := range base {
+		if o, ok := byName[w.Name]; ok {
+			if o != nil {
+				deck.Infof("window(%s): overridden by %s", w.Name, OverridesDir)
+				out = append(out, o...)
+				byName[w.Name] = nil
+			}
 			continue
 		}
-		reportConfFileMetric(fp, "ok")
-		windows = append(windows, s.Windows...)
+		out = append(out, w)
 	}
-	m := make(Map)
-	m.Add(windows...)
-	return m, nil
+	for _, o := range overrides {
+		if byName[o.Name] != nil {
+			out = append(out, o)
+		}
+	}
+	return out
 }
 
-func reportConfFileMetric(path, result string) {
-	m, err := metrics.NewString(fmt.Sprintf("%s/%s", auklib.MetricRoot, "config_loader"), auklib.MetricSvc)
-	if err != nil {
-		deck.Warningf("could not create metric: %v", err)
+// recordSpellings adds the labels of the configuration file b, as written,
+// to spellings, keyed by their lowercase form.
+func recordSpellings(spellings map[string]map[string]bool, b []byte) {
+	var raw struct {
+		Windows []struct{ Labels []string }
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
 		return
 	}
-	m.Data.AddStringField("file_path", path)
-	m.Set(result)
+	for _, w := range raw.Windows {
+		for _, l := range w.Labels {
+			k := strings.ToLower(l)
+			if spellings[k] == nil {
+				spellings[k] = make(map[string]bool)
+			}
+			spellings[k][l] = true
+		}
+	}
 }
 
-// ActiveHoursWindow retrieves the built-in Active Hours maintenance windows if available.
+// warnCaseCollisions warns about labels written with differing case, which
+// are merged into a single lowercase label.
+func warnCaseCollisions(spellings map[string]map[string]bool) {
+	for k, sp := range spellings {
+		if len(sp) < 2 {
+			continue
+		}
+		var names []string
+		for n := range sp {
+			names = append(names, fmt.Sprintf("%q", n))
+		}
+		sort.Strings(names)
+		deck.Warningf("labels %s differ only in case and are merged into label %q", strings.Join(names, ", "), k)
+	}
+}
+
+func reportConfFileMetric(path, result string) {
+	auklib.ReportString("config_loader", result, map[string]string{"file_path": path})
+}
+
+const (
+	// ActiveHoursLabel is the label of the built-in Active Hours window.
+	ActiveHoursLabel = "active_hours"
+	// OutsideActiveHoursLabel is the label of the built-in window covering
+	// the time between the end of Active Hours and their next start.
+	OutsideActiveHoursLabel = "outside_active_hours"
+	// SelfUpdateLabel is the label governing when Aukera itself may restart
+	// to apply updates. Hosts without windows carrying it may restart at any
+	// time.
+	SelfUpdateLabel = "aukera_self"
+	// OSUpdatesLabel is the label of the built-in window covering automatic
+	// updates scheduled by the operating system itself.
+	OSUpdatesLabel = "os_updates"
+)
+
+// builtinWindow returns a window named and labelled name, open between opens and closes.
+// Its Duration is the time elapsed between them, which differs from the
+// difference of their wall-clock times when daylight saving time begins or
+// ends in between.
+func builtinWindow(name string, opens, closes time.Time) Window {
+	w := Window{
+		Name:     name,
+		Labels:   []string{name},
+		Starts:   opens,
+		Expires:  closes,
+		Duration: closes.Sub(opens),
+		Source:   SourceBuiltin,
+		Schedule: Schedule{
+			Name:     name,
+			Opens:    opens,
+			Closes:   closes,
+			Duration: closes.Sub(opens),
+		},
+	}
+	w.Schedule.State = w.Schedule.CurrentState()
+	return w
+}
+
+// activeHoursWindows returns the Active Hours window between start and end
+// along with its complement relative to now. Days are added by calendar in
+// the location of start and end, the host zone as returned by
+// auklib.ActiveHours, so the complement keeps the wall-clock bounds of active
+// hours across daylight saving time changes.
+func activeHoursWindows(start, end, now time.Time) []Window {
+	// Before today's active hours the complement began when yesterday's ended.
+	outsideOpens, outsideCloses := end, start.AddDate(0, 0, 1)
+	if now.Before(start) {
+		outsideOpens, outsideCloses = end.AddDate(0, 0, -1), start
+	}
+	return []Window{
+		builtinWindow(ActiveHoursLabel, start, end),
+		builtinWindow(OutsideActiveHoursLabel, outsideOpens, outsideCloses),
+	}
+}
+
+// ActiveHoursWindow retrieves the built-in Active Hours maintenance windows if
+// available, along with the outside_active_hours window that is open while
+// the user is expected to be away.
 func ActiveHoursWindow(m Map) (Map, error) {
 	activeStartTime, activeEndTime, err := auklib.ActiveHours()
 	if err != nil {
 		return nil, err
 	}
-	activeWindow := Window{
-		Name:     "active_hours",
-		Labels:   []string{"active_hours"},
-		Starts:   activeStartTime,
-		Expires:  activeEndTime,
-		Duration: activeEndTime.Sub(activeStartTime),
-		Schedule: Schedule{
-			Name:     "active_hours",
-			Opens:    activeStartTime,
-			Closes:   activeEndTime,
-			Duration: activeEndTime.Sub(activeStartTime),
-		},
-	}
-	if activeWindow.Schedule.IsOpen() {
-		activeWindow.Schedule.State = "open"
-	} else {
-		activeWindow.Schedule.State = "closed"
+	m.Add(activeHoursWindows(activeStartTime, activeEndTime, time.Now())...)
+	return m, nil
+}
+
+// OSUpdatesWindow retrieves the built-in os_updates window, open while the
+// operating system installs updates automatically, if it does so on a
+// schedule.
+func OSUpdatesWindow(m Map) (Map, error) {
+	opens, closes, err := auklib.OSUpdateHours()
+	if err != nil {
+		return nil, err
 	}
-	m.Add(activeWindow)
+	m.Add(builtinWindow(OSUpdatesLabel, opens, closes))
 	return m, nil
 }
@@ -17,7 +17,9 @@ package window
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math/rand"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -31,6 +33,7 @@ import (
 	"github.com/google/deck"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/aukera/auklib"
 	"github.com/robfig/cron/v3"
 )
 
@@ -260,6 +263,37 @@ func TestUnmarshalWindow(t *testing.T) {
 		}`),
 			true,
 		},
+		{
+			"one-off window",
+			[]byte(
+				`{
+		"Windows":
+			[
+				{
+					"Name": "change freeze exception",
+					"Starts": "2020-01-01T23:00:00Z",
+					"Duration": "4h",
+					"Labels": ["default"]
+				}
+			]
+		}`),
+			false,
+		},
+		{
+			"one-off window without starts",
+			[]byte(
+				`{
+		"Windows":
+			[
+				{
+					"Name": "no starts",
+					"Duration": "4h",
+					"Labels": ["default"]
+				}
+			]
+		}`),
+			true,
+		},
 		{"nil json",
 			nil,
 			true,
@@ -309,8 +343,8 @@ func TestCalculateSchedule(t *testing.T) {
 				Schedule{
 					State:    "closed",
 					Duration: dur,
-					Opens:    now.Truncate(time.Hour).Add(-2 * time.Hour),
-					Closes:   now.Truncate(time.Hour).Add(-1 * time.Hour),
+					Opens:    now.Truncate(time.Hour).Add(-1 * time.Hour),
+					Closes:   now.Truncate(time.Hour),
 				},
 			},
 			{"started no expiry",
@@ -399,6 +433,66 @@ func TestMapFind(t *testing.T) {
 	}
 }
 
+func TestMapAddDuplicates(t *testing.T) {
+	tests, err := testData(time.Now())
+	if err != nil {
+		t.Fatalf("TestMapAddDuplicates(): error getting test data: %v", err)
+	}
+
+	m := make(Map)
+	m.Add(tests...)
+	m.Add(tests...)
+	want := make(Map)
+	want.Add(tests...)
+	for _, l := range labels(tests) {
+		if got, want := len(m.Find(l)), len(want.Find(l)); got != want {
+			t.Errorf("TestMapAddDuplicates(%q): adding windows twice stored %d windows, want %d", l, got, want)
+		}
+	}
+
+	// Same name, different content is kept.
+	w := tests[0]
+	w.Duration += time.Minute
+	before := len(m.Find(w.Labels[0]))
+	m.Add(w)
+	if got := len(m.Find(w.Labels[0])); got != before+1 {
+		t.Errorf("TestMapAddDuplicates(%q): modified window stored %d windows, want %d", w.Name, got, before+1)
+	}
+}
+
+// fakeMetrics records the integer samples reported through auklib.Metrics,
+// keyed by metric name and label.
+type fakeMetrics struct {
+	auklib.NopMetrics
+	ints map[string]int64
+}
+
+func (f *fakeMetrics) SetInt(name string, v int64, fields map[string]string) error {
+	f.ints[strings.TrimPrefix(name, auklib.Defaults.MetricRoot+"/")+"/"+fields["label"]] = v
+	return nil
+}
+
+func TestReportDuplicates(t *testing.T) {
+	defer func(m auklib.MetricSink) { auklib.Metrics = m }(auklib.Metrics)
+	f := &fakeMetrics{ints: make(map[string]int64)}
+	auklib.Metrics = f
+
+	w := Window{Name: "w", Labels: []string{"a", "b"}}
+	m := make(Map)
+	reportDuplicates(m, m.add([]Window{w, w, {Name: "x", Labels: []string{"c"}}}))
+	// Building maps elsewhere, such as to find conflicts, reports nothing.
+	m.Add(w)
+	Conflicts([]Window{w, w})
+	want := map[string]int64{
+		"config_windows_duplicate/a": 1,
+		"config_windows_duplicate/b": 1,
+		"config_windows_duplicate/c": 0,
+	}
+	if diff := cmp.Diff(want, f.ints); diff != "" {
+		t.Errorf("reportDuplicates() samples mismatch (-want +got):\n%s", diff)
+	}
+}
+
 func TestMapMarshal(t *testing.T) {
 	tests, err := testData(time.Now())
 	if err != nil {
@@ -515,7 +609,7 @@ type TestReader struct {
 }
 
 func (r TestReader) PathExists(path string) (bool, error) {
-	return true, nil
+	return filepath.Base(path) != OverridesDir, nil
 }
 
 func (r TestReader) AbsPath(path string) (string, error) {
@@ -546,7 +640,11 @@ func TestWindows(t *testing.T) {
 		t.Fatalf("TestWindows(): error getting test data: %v", err)
 	}
 	m := make(Map)
-	m.Add(windows...)
+	for _, w := range windows {
+		// TestReader lists the directory itself as its only file.
+		w.Source = filepath.Join("conf/config.json", "conf/config.json")
+		m.Add(w)
+	}
 	tests := []struct {
 		desc, path, errRegex string
 		mapExpect            Map
@@ -773,6 +871,104 @@ func TestScheduleClosed(t *testing.T) {
 	}
 }
 
+func TestScheduleHelpers(t *testing.T) {
+	opens := time.Date(2026, time.March, 1, 2, 0, 0, 0, time.UTC)
+	closes := opens.Add(time.Hour)
+	s := Schedule{Name: "helpers", Opens: opens, Closes: closes, Duration: time.Hour}
+	tests := []struct {
+		desc            string
+		now             time.Time
+		contains        bool
+		untilOpen       time.Duration
+		untilClose      time.Duration
+		transition      time.Time
+		transitionState string
+	}{
+		{"before opening", opens.Add(-time.Minute), false, time.Minute, time.Hour + time.Minute, opens, StateOpen},
+		{"just before opening", opens.Add(-time.Nanosecond), false, time.Nanosecond, time.Hour + time.Nanosecond, opens, StateOpen},
+		{"at opening", opens, true, 0, time.Hour, closes, StateClosed},
+		{"while open", opens.Add(30 * time.Minute), true, 0, 30 * time.Minute, closes, StateClosed},
+		{"just before closing", closes.Add(-time.Nanosecond), true, 0, time.Nanosecond, closes, StateClosed},
+		{"at closing", closes, false, -time.Hour, 0, time.Time{}, ""},
+		{"after closing", closes.Add(time.Minute), false, -time.Hour - time.Minute, -time.Minute, time.Time{}, ""},
+	}
+	for _, tt := range tests {
+		if got := s.Contains(tt.now); got != tt.contains {
+			t.Errorf("Contains(%s) = %t, want %t", tt.desc, got, tt.contains)
+		}
+		if got := s.TimeUntilOpen(tt.now); got != tt.untilOpen {
+			t.Errorf("TimeUntilOpen(%s) = %v, want %v", tt.desc, got, tt.untilOpen)
+		}
+		if got := s.TimeUntilClose(tt.now); got != tt.untilClose {
+			t.Errorf("TimeUntilClose(%s) = %v, want %v", tt.desc, got, tt.untilClose)
+		}
+		at, state := s.NextTransition(tt.now)
+		if !at.Equal(tt.transition) || state != tt.transitionState {
+			t.Errorf("NextTransition(%s) = %v, %q; want %v, %q", tt.desc, at, state, tt.transition, tt.transitionState)
+		}
+	}
+}
+
+func TestDurationBounds(t *testing.T) {
+	defer func(d time.Duration) { MaxDuration = d }(MaxDuration)
+	MaxDuration = 24 * time.Hour
+	tests := []struct {
+		duration string
+		want     time.Duration
+		wantErr  string
+	}{
+		{"1.5h", 90 * time.Minute, ""},
+		{"24h", 24 * time.Hour, ""},
+		{"0", 0, "must be positive"},
+		{"0s", 0, "must be positive"},
+		{"-1h", 0, "must be positive"},
+		{"24h1s", 0, "exceeds the maximum of 24h0m0s"},
+	}
+	for _, tt := range tests {
+		var w Window
+		err := json.Unmarshal([]byte(fmt.Sprintf(`{"Name":"w","Format":1,"Schedule":"@daily","Duration":%q,"Labels":["a"]}`, tt.duration)), &w)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("UnmarshalJSON(Duration %s) returned error: %v", tt.duration, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("UnmarshalJSON(Duration %s) returned error %v, want %q", tt.duration, err, tt.wantErr)
+		case err == nil && w.Duration != tt.want:
+			t.Errorf("UnmarshalJSON(Duration %s) = %v, want %v", tt.duration, w.Duration, tt.want)
+		}
+	}
+	MaxDuration = 0
+	var w Window
+	if err := json.Unmarshal([]byte(`{"Name":"w","Format":1,"Schedule":"@daily","Duration":"1000h","Labels":["a"]}`), &w); err != nil {
+		t.Errorf("UnmarshalJSON() without a maximum duration returned error: %v", err)
+	}
+}
+
+func TestSortSchedules(t *testing.T) {
+	at := func(name string, opens, closes int) Schedule {
+		base := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+		return Schedule{Name: name, Opens: base.Add(time.Duration(opens) * time.Hour), Closes: base.Add(time.Duration(closes) * time.Hour)}
+	}
+	want := []Schedule{at("b", 0, 1), at("a", 1, 2), at("a", 1, 3), at("b", 1, 2), at("a", 2, 3)}
+	for i := 0; i < 10; i++ {
+		got := append([]Schedule(nil), want...)
+		rand.Shuffle(len(got), func(i, j int) { got[i], got[j] = got[j], got[i] })
+		SortSchedules(got)
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Fatalf("SortSchedules() returned unexpected order (-want +got):\n%s", diff)
+		}
+	}
+}
+
+func TestKeysSorted(t *testing.T) {
+	m := make(Map)
+	for _, l := range []string{"zeta", "alpha", "Mid"} {
+		m.Add(Window{Name: l, Labels: []string{l}})
+	}
+	if diff := cmp.Diff([]string{"alpha", "mid", "zeta"}, m.Keys()); diff != "" {
+		t.Errorf("Keys() returned unexpected labels (-want +got):\n%s", diff)
+	}
+}
+
 func TestDedupSchedules(t *testing.T) {
 	s := makeSchedules(time.Now().Local())
 	test := struct {
@@ -793,6 +989,78 @@ func TestDedupSchedules(t *testing.T) {
 	}
 }
 
+func TestMaxTaskDuration(t *testing.T) {
+	tests := []struct {
+		budget  string
+		want    time.Duration
+		wantErr string
+	}{
+		{"", 0, ""},
+		{"15m", 15 * time.Minute, ""},
+		{"1h", time.Hour, ""},
+		{"0s", 0, "must be within"},
+		{"-5m", 0, "must be within"},
+		{"1h1s", 0, "must be within"},
+		{"soon", 0, "max task duration"},
+	}
+	for _, tt := range tests {
+		var w Window
+		err := json.Unmarshal([]byte(fmt.Sprintf(`{"Name":"w","Format":1,"Schedule":"@daily","Duration":"1h","MaxTaskDuration":%q,"Labels":["a"]}`, tt.budget)), &w)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("UnmarshalJSON(MaxTaskDuration %q) returned error: %v", tt.budget, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("UnmarshalJSON(MaxTaskDuration %q) returned error %v, want %q", tt.budget, err, tt.wantErr)
+		case err == nil && (w.MaxTaskDuration != tt.want || w.Schedule.MaxTaskDuration != tt.want):
+			t.Errorf("UnmarshalJSON(MaxTaskDuration %q) = %v, schedule %v, want %v", tt.budget, w.MaxTaskDuration, w.Schedule.MaxTaskDuration, tt.want)
+		}
+	}
+
+	open := time.Date(2020, 1, 1, 0, 0, 0, 0, time.Local)
+	s := Schedule{Name: "a", Opens: open, Closes: open.Add(time.Hour), MaxTaskDuration: 20 * time.Minute}
+	s.update()
+	b, err := json.Marshal(&s)
+	if err != nil {
+		t.Fatalf("json.Marshal() returned error: %v", err)
+	}
+	if !strings.Contains(string(b), `"MaxTaskDuration":"20m0s"`) {
+		t.Errorf("json.Marshal(%+v) = %s, want MaxTaskDuration", s, b)
+	}
+	var got Schedule
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("json.Unmarshal() returned error: %v", err)
+	}
+	if got.MaxTaskDuration != s.MaxTaskDuration {
+		t.Errorf("json.Unmarshal(%s) MaxTaskDuration = %v, want %v", b, got.MaxTaskDuration, s.MaxTaskDuration)
+	}
+
+	combines := []struct {
+		desc string
+		a, b time.Duration
+		want time.Duration
+	}{
+		{"larger budget", 20 * time.Minute, 40 * time.Minute, 40 * time.Minute},
+		{"unbounded", 20 * time.Minute, 0, 0},
+	}
+	for _, tt := range combines {
+		a := Schedule{Name: "a", Opens: open, Closes: open.Add(time.Hour), MaxTaskDuration: tt.a}
+		c := Schedule{Name: "a", Opens: open.Add(30 * time.Minute), Closes: open.Add(2 * time.Hour), MaxTaskDuration: tt.b}
+		if err := a.Combine(c); err != nil {
+			t.Fatalf("Combine(%s) returned error: %v", tt.desc, err)
+		}
+		if a.MaxTaskDuration != tt.want {
+			t.Errorf("Combine(%s) MaxTaskDuration = %v, want %v", tt.desc, a.MaxTaskDuration, tt.want)
+		}
+	}
+
+	// Budgets never exceed the schedule they apply to.
+	s = Schedule{Name: "a", Opens: open, Closes: open.Add(10 * time.Minute), MaxTaskDuration: 20 * time.Minute}
+	s.update()
+	if s.MaxTaskDuration != 10*time.Minute {
+		t.Errorf("update() MaxTaskDuration = %v, want %v", s.MaxTaskDuration, 10*time.Minute)
+	}
+}
+
 func TestScheduleMarshal(t *testing.T) {
 	d, err := time.ParseDuration("1h0m0s")
 	if err != nil {
@@ -814,7 +1082,7 @@ func TestScheduleMarshal(t *testing.T) {
 			Opens:    open,
 			Closes:   closed,
 		},
-		[]byte(fmt.Sprintf(`{"Name":"should marshal","State":"closed","Opens":%q,"Closes":%q,"Duration":"1h0m0s"}`, open.Format(time.RFC3339), closed.Format(time.RFC3339))),
+		[]byte(fmt.Sprintf(`{"Name":"should marshal","State":"closed","Opens":%q,"Closes":%q,"Duration":"1h0m0s","Reason":"ended"}`, open.Format(time.RFC3339), closed.Format(time.RFC3339))),
 		false,
 	}
 
@@ -826,3 +1094,446 @@ func TestScheduleMarshal(t *testing.T) {
 		t.Errorf("TestScheduleMarshal(%q): unexpected JSON returned: got: %s; want: %s", test.desc, string(b), string(test.want))
 	}
 }
+
+func TestScheduleMarshalCurrentState(t *testing.T) {
+	now := time.Now()
+	// A schedule whose stored State has gone stale since it was computed.
+	s := Schedule{Name: "stale", State: StateClosed, Opens: now.Add(-time.Minute), Closes: now.Add(time.Hour), Duration: time.Hour + time.Minute}
+	b, err := json.Marshal(&s)
+	if err != nil {
+		t.Fatalf("json.Marshal() returned error: %v", err)
+	}
+	var got Schedule
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("json.Unmarshal() returned error: %v", err)
+	}
+	if got.State != StateOpen || got.CurrentState() != StateOpen {
+		t.Errorf("marshaled stale schedule has State %q, want %q", got.State, StateOpen)
+	}
+}
+
+func TestAggregateConservative(t *testing.T) {
+	now := time.Now()
+	m := make(Map)
+	m.Add(
+		Window{Name: "long", Labels: []string{"agg"}, Schedule: Schedule{
+			Opens: now.Add(-1 * time.Hour), Closes: now.Add(4 * time.Hour)}},
+		Window{Name: "short", Labels: []string{"agg"}, Schedule: Schedule{
+			Opens: now.Add(30 * time.Minute), Closes: now.Add(2 * time.Hour)}},
+	)
+	tests := []struct {
+		desc       string
+		a          Aggregation
+		wantCloses time.Time
+	}{
+		{"merge", AggregateMerge, now.Add(4 * time.Hour)},
+		{"conservative", AggregateConservative, now.Add(2 * time.Hour)},
+	}
+	for _, tt := range tests {
+		got := m.Aggregate("agg", tt.a)
+		if len(got) != 1 {
+			t.Fatalf("Aggregate(%s) returned %d schedules, want 1", tt.desc, len(got))
+		}
+		if !got[0].Opens.Equal(now.Add(-1 * time.Hour)) {
+			t.Errorf("Aggregate(%s) opens at %v, want %v", tt.desc, got[0].Opens, now.Add(-1*time.Hour))
+		}
+		if !got[0].Closes.Equal(tt.wantCloses) {
+			t.Errorf("Aggregate(%s) closes at %v, want %v", tt.desc, got[0].Closes, tt.wantCloses)
+		}
+		if got[0].Duration != tt.wantCloses.Sub(got[0].Opens) {
+			t.Errorf("Aggregate(%s) duration %v, want %v", tt.desc, got[0].Duration, tt.wantCloses.Sub(got[0].Opens))
+		}
+	}
+}
+
+func TestAggregateAt(t *testing.T) {
+	day := func(d, h, m int) time.Time { return time.Date(2020, time.January, d, h, m, 0, 0, time.Local) }
+	cr, err := cronParser.Parse("0 0 2 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := make(Map)
+	// The schedule computed when the window was loaded has since gone stale.
+	m.Add(Window{Name: "nightly", Labels: []string{"patch"}, Format: FormatCron, Cron: cr, Duration: time.Hour,
+		Schedule: Schedule{Opens: day(1, 2, 0), Closes: day(1, 3, 0)}})
+	tests := []struct {
+		desc          string
+		now           time.Time
+		state         string
+		opens, closes time.Time
+	}{
+		{"open", day(10, 2, 30), StateOpen, day(10, 2, 0), day(10, 3, 0)},
+		{"closed", day(10, 3, 30), StateClosed, day(11, 2, 0), day(11, 3, 0)},
+	}
+	for _, tt := range tests {
+		got := m.AggregateAt("Patch", AggregateMerge, tt.now)
+		if len(got) != 1 {
+			t.Fatalf("AggregateAt(%s) returned %d schedules, want 1", tt.desc, len(got))
+		}
+		s := got[0]
+		if s.Name != "patch" || s.State != tt.state || !s.Opens.Equal(tt.opens) || !s.Closes.Equal(tt.closes) || !s.EvaluatedAt.Equal(tt.now) {
+			t.Errorf("AggregateAt(%s) = %+v, want %s [%s, %s] evaluated at %s", tt.desc, s, tt.state, tt.opens, tt.closes, tt.now)
+		}
+		// The pinned State survives marshaling, rather than being recomputed now.
+		b, err := json.Marshal(&s)
+		if err != nil {
+			t.Fatalf("json.Marshal() returned error: %v", err)
+		}
+		var rt Schedule
+		if err := json.Unmarshal(b, &rt); err != nil {
+			t.Fatalf("json.Unmarshal() returned error: %v", err)
+		}
+		if rt.State != tt.state || !rt.EvaluatedAt.Equal(tt.now) {
+			t.Errorf("AggregateAt(%s) marshaled as %s, want State %q evaluated at %s", tt.desc, b, tt.state, tt.now)
+		}
+	}
+}
+
+func TestParseAggregation(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Aggregation
+		wantErr bool
+	}{
+		{"", AggregateMerge, false},
+		{"merge", AggregateMerge, false},
+		{"Conservative", AggregateConservative, false},
+		{"conservative, adjacent", AggregateConservative | AggregateAdjacent, false},
+		{"pessimistic", AggregateMerge, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseAggregation(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseAggregation(%q) returned error %v, want error: %t", tt.in, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("ParseAggregation(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSentinelErrors(t *testing.T) {
+	var w Window
+	err := json.Unmarshal([]byte(`{"Name": "f", "Format": 9, "Schedule": "* * * * * *", "Duration": "1h", "Labels": ["f"]}`), &w)
+	if !errors.Is(err, ErrBadFormat) {
+		t.Errorf("UnmarshalJSON(bad format) returned %v, want %v", err, ErrBadFormat)
+	}
+	var r Reader
+	if _, err := r.JSONContent("window_test.go"); !errors.Is(err, ErrNotJSON) {
+		t.Errorf("JSONContent(window_test.go) returned %v, want %v", err, ErrNotJSON)
+	}
+	s := Schedule{Name: "a", Opens: time.Now(), Closes: time.Now().Add(time.Hour)}
+	if err := s.Combine(Schedule{Name: "a", Opens: time.Now().Add(2 * time.Hour), Closes: time.Now().Add(3 * time.Hour)}); !errors.Is(err, ErrNoOverlap) {
+		t.Errorf("Combine(non-overlapping) returned %v, want %v", err, ErrNoOverlap)
+	}
+}
+
+func TestMergeSchedules(t *testing.T) {
+	now := time.Now()
+	at := func(opens, closes int) Schedule {
+		return Schedule{Name: "m", Opens: now.Add(time.Duration(opens) * time.Hour), Closes: now.Add(time.Duration(closes) * time.Hour)}
+	}
+	tests := []struct {
+		desc string
+		in   []Schedule
+		want [][2]int
+		a    Aggregation
+	}{
+		{"transitive chain", []Schedule{at(3, 5), at(0, 2), at(1, 4)}, [][2]int{{0, 5}}, AggregateMerge},
+		{"long chain", []Schedule{at(0, 2), at(1, 3), at(2, 4), at(3, 5), at(4, 6)}, [][2]int{{0, 6}}, AggregateMerge},
+		{"adjacent kept apart", []Schedule{at(0, 2), at(2, 4)}, [][2]int{{0, 2}, {2, 4}}, AggregateMerge},
+		{"shared open", []Schedule{at(0, 4), at(0, 2)}, [][2]int{{0, 4}}, AggregateMerge},
+		{"disjoint", []Schedule{at(5, 6), at(0, 1)}, [][2]int{{0, 1}, {5, 6}}, AggregateMerge},
+		{"adjacent merged", []Schedule{at(2, 4), at(0, 2), at(4, 5)}, [][2]int{{0, 5}}, AggregateAdjacent},
+	}
+	for _, tt := range tests {
+		got := mergeSchedules(tt.in, tt.a)
+		if len(got) != len(tt.want) {
+			t.Errorf("mergeSchedules(%s) returned %d schedules, want %d: %v", tt.desc, len(got), len(tt.want), got)
+			continue
+		}
+		for i, w := range tt.want {
+			want := at(w[0], w[1])
+			if !got[i].Opens.Equal(want.Opens) || !got[i].Closes.Equal(want.Closes) {
+				t.Errorf("mergeSchedules(%s)[%d] = %v, want %v", tt.desc, i, got[i], want)
+			}
+		}
+	}
+}
+
+func TestActiveHoursWindows(t *testing.T) {
+	day := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.Local)
+	start, end := day.Add(8*time.Hour), day.Add(17*time.Hour)
+	tests := []struct {
+		desc                 string
+		now                  time.Time
+		wantOpens, wantClose time.Time
+	}{
+		{"early morning", day.Add(3 * time.Hour), day.Add(-7 * time.Hour), start},
+		{"during active hours", day.Add(12 * time.Hour), end, day.Add(32 * time.Hour)},
+		{"evening", day.Add(20 * time.Hour), end, day.Add(32 * time.Hour)},
+	}
+	for _, tt := range tests {
+		got := activeHoursWindows(start, end, tt.now)
+		if len(got) != 2 {
+			t.Fatalf("activeHoursWindows(%s) returned %d windows, want 2", tt.desc, len(got))
+		}
+		if got[0].Name != ActiveHoursLabel || !got[0].Schedule.Opens.Equal(start) || !got[0].Schedule.Closes.Equal(end) {
+			t.Errorf("activeHoursWindows(%s) active hours = %v, want %v-%v", tt.desc, got[0].Schedule, start, end)
+		}
+		inv := got[1]
+		if inv.Name != OutsideActiveHoursLabel || !inv.Schedule.Opens.Equal(tt.wantOpens) || !inv.Schedule.Closes.Equal(tt.wantClose) {
+			t.Errorf("activeHoursWindows(%s) outside active hours = %v, want %v-%v", tt.desc, inv.Schedule, tt.wantOpens, tt.wantClose)
+		}
+	}
+}
+
+func TestActiveHoursWindowsDST(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	at := func(day, hour int) time.Time {
+		return time.Date(2026, time.March, day, hour, 0, 0, 0, ny)
+	}
+	tests := []struct {
+		desc                 string
+		now                  time.Time
+		wantOpens, wantClose time.Time
+		wantDuration         time.Duration
+	}{
+		// Clocks spring forward at 02:00 on March 8, 2026.
+		{"before active hours", at(8, 7), at(7, 17), at(8, 8), 14 * time.Hour},
+		{"after active hours", at(8, 20), at(8, 17), at(9, 8), 15 * time.Hour},
+	}
+	for _, tt := range tests {
+		got := activeHoursWindows(at(8, 8), at(8, 17), tt.now)
+		if len(got) != 2 {
+			t.Fatalf("activeHoursWindows(%s) returned %d windows, want 2", tt.desc, len(got))
+		}
+		if got[0].Duration != 9*time.Hour || got[0].Schedule.Duration != 9*time.Hour {
+			t.Errorf("activeHoursWindows(%s) active hours Duration = %v, want %v", tt.desc, got[0].Duration, 9*time.Hour)
+		}
+		inv := got[1].Schedule
+		if !inv.Opens.Equal(tt.wantOpens) || !inv.Closes.Equal(tt.wantClose) || inv.Duration != tt.wantDuration {
+			t.Errorf("activeHoursWindows(%s) outside active hours = %v-%v (%v), want %v-%v (%v)", tt.desc, inv.Opens, inv.Closes, inv.Duration, tt.wantOpens, tt.wantClose, tt.wantDuration)
+		}
+	}
+}
+
+func TestValidateLabel(t *testing.T) {
+	tests := []struct {
+		label   string
+		wantErr bool
+	}{
+		{"patch", false},
+		{"patch-ring_1.canary", false},
+		{"status", true},
+		{"active_hours", true},
+		{"outside_active_hours", true},
+		{"all", true},
+		{"has space", true},
+		{"slash/label", true},
+		{"_leading", true},
+		{"", true},
+		{strings.Repeat("a", 65), true},
+	}
+	for _, tt := range tests {
+		err := ValidateLabel(tt.label)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateLabel(%q) returned error %v, want error: %t", tt.label, err, tt.wantErr)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidLabel) {
+			t.Errorf("ValidateLabel(%q) returned %v, want %v", tt.label, err, ErrInvalidLabel)
+		}
+	}
+
+	var w Window
+	err := json.Unmarshal([]byte(`{"Name": "r", "Format": 1, "Schedule": "0 0 * * * *", "Duration": "1h", "Labels": ["ok", "Status"]}`), &w)
+	if !errors.Is(err, ErrInvalidLabel) {
+		t.Errorf("UnmarshalJSON(reserved label) returned %v, want %v", err, ErrInvalidLabel)
+	}
+}
+
+func TestNormalizeLabel(t *testing.T) {
+	tests := []struct {
+		in, want string
+		wantErr  bool
+	}{
+		{"OS_Patch", "os_patch", false},
+		{ActiveHoursLabel, ActiveHoursLabel, false},
+		{"", "", true},
+		{strings.Repeat("a", 65), "", true},
+		{"bad\nlabel", "", true},
+		{"../etc", "", true},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeLabel(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("NormalizeLabel(%q) = %q, %v; want %q, error %t", tt.in, got, err, tt.want, tt.wantErr)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidLabel) {
+			t.Errorf("NormalizeLabel(%q) returned %v, want %v", tt.in, err, ErrInvalidLabel)
+		}
+	}
+
+	m := make(Map)
+	m.Add(Window{Name: "mixed", Labels: []string{"Patch"}})
+	if len(m.Find("patch")) != 1 || len(m.Find("PATCH")) != 1 {
+		t.Errorf("Map.Add() stored label %v, want it found case-insensitively", m.Keys())
+	}
+}
+
+func TestComputeActivationBounds(t *testing.T) {
+	day := func(d, h, m int) time.Time { return time.Date(2020, time.January, d, h, m, 0, 0, time.Local) }
+	cr, err := cronParser.Parse("0 0 2 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		desc            string
+		now             time.Time
+		starts, expires time.Time
+		truncate        bool
+		opens, closes   time.Time
+	}{
+		{"unbounded", day(10, 12, 0), time.Time{}, time.Time{}, false, day(11, 2, 0), day(11, 3, 0)},
+		{"unbounded open", day(10, 2, 30), time.Time{}, time.Time{}, false, day(10, 2, 0), day(10, 3, 0)},
+		{"starts in future", day(10, 12, 0), day(15, 0, 0), time.Time{}, false, day(15, 2, 0), day(15, 3, 0)},
+		{"starts on activation", day(10, 12, 0), day(15, 2, 0), time.Time{}, false, day(15, 2, 0), day(15, 3, 0)},
+		{"starts during occurrence", day(10, 2, 30), day(10, 2, 10), time.Time{}, false, day(11, 2, 0), day(11, 3, 0)},
+		{"future bounds", day(10, 12, 0), day(15, 0, 0), day(16, 0, 0), false, day(15, 2, 0), day(15, 3, 0)},
+		{"expires before next", day(10, 12, 0), time.Time{}, day(10, 20, 0), false, day(10, 2, 0), day(10, 3, 0)},
+		{"expired", day(10, 12, 0), time.Time{}, day(5, 3, 0), false, day(5, 2, 0), day(5, 3, 0)},
+		{"expires on activation", day(10, 12, 0), time.Time{}, day(5, 2, 0), false, day(5, 2, 0), day(5, 3, 0)},
+		{"open past expiry", day(10, 2, 30), time.Time{}, day(10, 2, 15), false, day(10, 2, 0), day(10, 3, 0)},
+		{"truncated at expiry", day(10, 2, 30), time.Time{}, day(10, 2, 15), true, day(10, 2, 0), day(10, 2, 15)},
+		{"never activates", day(10, 12, 0), day(5, 3, 0), day(5, 4, 0), false, day(5, 4, 0), day(5, 4, 0)},
+	}
+	for _, tt := range tests {
+		w := Window{Name: tt.desc, Format: FormatCron, Cron: cr, Duration: time.Hour,
+			Starts: tt.starts, Expires: tt.expires, TruncateAtExpiry: tt.truncate}
+		w.computeActivation(tt.now)
+		if !w.Schedule.Opens.Equal(tt.opens) || !w.Schedule.Closes.Equal(tt.closes) {
+			t.Errorf("computeActivation(%s) = [%s, %s], want [%s, %s]", tt.desc,
+				w.Schedule.Opens, w.Schedule.Closes, tt.opens, tt.closes)
+		}
+	}
+}
+
+func TestOneOffWindow(t *testing.T) {
+	now := time.Now().Truncate(time.Minute)
+	tests := []struct {
+		desc          string
+		starts        time.Time
+		expires       time.Time
+		truncate      bool
+		state         string
+		opens, closes time.Time
+	}{
+		{"upcoming", now.Add(time.Hour), time.Time{}, false, "closed", now.Add(time.Hour), now.Add(3 * time.Hour)},
+		{"open", now.Add(-time.Hour), time.Time{}, false, "open", now.Add(-time.Hour), now.Add(time.Hour)},
+		{"past", now.Add(-3 * time.Hour), time.Time{}, false, "closed", now.Add(-3 * time.Hour), now.Add(-time.Hour)},
+		{"truncated", now.Add(-time.Hour), now.Add(-30 * time.Minute), true, "closed", now.Add(-time.Hour), now.Add(-30 * time.Minute)},
+	}
+	for _, tt := range tests {
+		b, err := json.Marshal(Window{Name: "one-off " + tt.desc, Starts: tt.starts, Expires: tt.expires,
+			Duration: 2 * time.Hour, TruncateAtExpiry: tt.truncate, Labels: []string{"default"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var w Window
+		if err := json.Unmarshal(b, &w); err != nil {
+			t.Fatalf("OneOff(%s): unmarshal returned error: %v", tt.desc, err)
+		}
+		if !w.OneOff() {
+			t.Errorf("OneOff(%s) = false, want true", tt.desc)
+		}
+		s := w.Schedule
+		if s.State != tt.state || !s.Opens.Equal(tt.opens) || !s.Closes.Equal(tt.closes) {
+			t.Errorf("OneOff(%s) schedule = %v, want %s [%s, %s]", tt.desc, s, tt.state, tt.opens, tt.closes)
+		}
+		if occ, _ := w.Occurrences(now.Add(-24*time.Hour), now.Add(24*time.Hour)); len(occ) != 1 {
+			t.Errorf("OneOff(%s) returned %d occurrences, want 1", tt.desc, len(occ))
+		}
+	}
+}
+
+func TestWindowTags(t *testing.T) {
+	var w Window
+	b := []byte(`{"Name": "tagged", "Format": 1, "Schedule": "0 0 2 * * *", "Duration": "1h", "Labels": ["patch"], "Tags": {"owner": "netops", "ring": "canary"}}`)
+	if err := json.Unmarshal(b, &w); err != nil {
+		t.Fatalf("unmarshal returned error: %v", err)
+	}
+	out, err := json.Marshal(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rt Window
+	if err := json.Unmarshal(out, &rt); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(w.Tags, rt.Tags); diff != "" {
+		t.Errorf("Tags did not survive a marshal round trip (-want +got):\n%s", diff)
+	}
+	if err := json.Unmarshal([]byte(`{"Name": "bad", "Format": 1, "Schedule": "0 0 2 * * *", "Duration": "1h", "Labels": ["patch"], "Tags": {"": "x"}}`), &rt); err == nil {
+		t.Errorf("unmarshal with an empty tag name returned nil error")
+	}
+
+	tests := []struct {
+		filter []string
+		want   bool
+	}{
+		{nil, true},
+		{[]string{"owner=netops"}, true},
+		{[]string{"owner"}, true},
+		{[]string{"owner=netops", "ring=canary"}, true},
+		{[]string{"owner=netops", "ring=stable"}, false},
+		{[]string{"team"}, false},
+	}
+	for _, tt := range tests {
+		f, err := ParseTagFilter(tt.filter)
+		if err != nil {
+			t.Fatalf("ParseTagFilter(%q) returned error: %v", tt.filter, err)
+		}
+		if got := w.MatchTags(f); got != tt.want {
+			t.Errorf("MatchTags(%q) = %t, want %t", tt.filter, got, tt.want)
+		}
+	}
+	if _, err := ParseTagFilter([]string{"=canary"}); err == nil {
+		t.Errorf("ParseTagFilter() with an empty tag name returned nil error")
+	}
+}
+
+func TestMixedCaseLabels(t *testing.T) {
+	r := fileReader{files: map[string]string{
+		"a.json": `{"Windows": [
+			{"Name": "nightly", "Format": 1, "Schedule": "0 0 2 * * *", "Duration": "1h", "Labels": ["OSPatch", "Reboot"]}
+		]}`,
+		"b.json": `{"Windows": [
+			{"Name": "weekly", "Format": 1, "Schedule": "0 0 3 * * SUN", "Duration": "1h", "Labels": ["ospatch"]}
+		]}`,
+	}}
+	var logBuffer bytes.Buffer
+	deck.Add(logger.Init(&logBuffer, 0))
+
+	m, err := Windows("conf", r)
+	if err != nil {
+		t.Fatalf("Windows() returned error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"ospatch", "reboot"}, m.Keys(), cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+		t.Errorf("Windows() labels returned diff (-want +got):\n%s", diff)
+	}
+	if got := len(m.Find("ospatch")); got != 2 {
+		t.Errorf("Find(ospatch) returned %d windows, want 2", got)
+	}
+	if got := len(m.Find("OSPatch")); got != 2 {
+		t.Errorf("Find(OSPatch) returned %d windows, want 2", got)
+	}
+	if !strings.Contains(logBuffer.String(), `labels "OSPatch", "ospatch" differ only in case`) {
+		t.Errorf("Windows() did not warn about case collision; log: %s", logBuffer.String())
+	}
+	if strings.Contains(logBuffer.String(), `"Reboot"`) {
+		t.Errorf("Windows() warned about a label without a collision; log: %s", logBuffer.String())
+	}
+}